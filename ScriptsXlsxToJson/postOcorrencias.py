@@ -2,7 +2,8 @@
 # -*- coding: utf-8 -*-
 """
 Script para enviar ocorrências criminais via POST para a API
-Envia cada JSON individualmente para http://localhost:8080/api/v1/reports/process-text
+Envia cada JSON individualmente para http://localhost:8080/api/v1/reports/process-text,
+ou todos de uma vez para /api/v1/reports/bulk (USE_BULK = True)
 """
 
 import json
@@ -14,6 +15,8 @@ from pathlib import Path
 API_URL = "http://localhost:8000/api/v1/reports/process-text"
 JSON_FILE = "output_json/all_crimes_consolidated.json"
 DELAY_BETWEEN_REQUESTS = 0.001  # segundos entre cada requisição (evita sobrecarregar)
+BULK_API_URL = "http://localhost:8000/api/v1/reports/bulk"
+USE_BULK = True  # envia o arquivo inteiro em uma única requisição
 
 def send_crime_report(crime_data: dict, index: int) -> bool:
     """
@@ -46,6 +49,24 @@ def send_crime_report(crime_data: dict, index: int) -> bool:
         return False
 
 
+def send_bulk(crimes: list) -> None:
+    """
+    Envia todas as ocorrências em uma única requisição para o endpoint bulk
+    e mostra as linhas rejeitadas
+    """
+    response = requests.post(BULK_API_URL, json=crimes, timeout=600)
+    if response.status_code != 200:
+        print(f"❌ ERRO {response.status_code}: {response.text[:200]}")
+        return
+
+    data = response.json()
+    for result in data["results"]:
+        if result["status"] == "rejected":
+            print(f"❌ [{result['index'] + 1}] Rejeitado: {result.get('error', '')}")
+
+    print(f"\n✅ Aceitos: {data['accepted']}  ❌ Rejeitados: {data['rejected']}  📊 Total: {data['total']}")
+
+
 def main():
     print("\n" + "="*80)
     print("ENVIO DE OCORRÊNCIAS CRIMINAIS PARA API")
@@ -72,6 +93,12 @@ def main():
         return
     
     print("\n🚀 Iniciando envio...\n")
+
+    if USE_BULK:
+        start_time = time.time()
+        send_bulk(crimes)
+        print(f"⏱️  Tempo total: {time.time() - start_time:.2f}s\n")
+        return
     
     # Contadores
     success_count = 0
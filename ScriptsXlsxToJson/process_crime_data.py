@@ -10,6 +10,8 @@ import pandas as pd
 import json
 import os
 import re
+import unicodedata
+import urllib.request
 from datetime import datetime
 from pathlib import Path
 import random
@@ -31,32 +33,15 @@ DELEGACIAS_COORDS = {
     "13 DP": {"bairro": "Cambuí", "latitude": -22.8989, "longitude": -47.0523},
 }
 
-# Crimes hediondos (peso 9)
-HEINOUS_CRIMES = [
-    "latrocínio",
-    "homicídio qualificado",
-    "homicídio praticado por grupo de extermínio",
-    "feminicídio",
-    "genocídio",
-    "estupro",
-    "estupro de vulnerável",
-    "atentado violento ao pudor",
-    "favorecimento à prostituição",
-    "exploração sexual",
-    "tráfico de pessoas",
-    "tráfico de drogas",
-    "organização criminosa",
-    "comércio ilegal de armas",
-    "extorsão qualificada",
-    "sequestro e cárcere privado",
-    "extorsão mediante sequestro",
-    "envenenamento de alimentos",
-    "epidemia com resultado morte",
-    "falsificação de medicamentos",
-    "tráfico internacional de armas",
-    "sequestro e extorsão qualificada",
-    "Homicídio Doloso"
-]
+# Taxonomia de crimes: a mesma tabela padrão que o backend grava em
+# crime_categories. Com CRIME_CATEGORIES_URL (ex.:
+# http://localhost:8080/api/v1/crime-categories) a taxonomia é lida da API,
+# com as edições feitas no banco.
+CRIME_CATEGORIES_FILE = (Path(__file__).resolve().parent.parent / "backend" / "internal"
+                         / "services" / "crime_categories_default.json")
+
+# Peso dos crimes que não casam com nenhuma subcategoria (DefaultCrimeWeight)
+DEFAULT_CRIME_WEIGHT = 3
 
 # Mapeamento de tipos de crime para nomes padronizados
 CRIME_MAPPING = {
@@ -90,19 +75,59 @@ MESES = {
 }
 
 
+def load_crime_categories() -> list:
+    """
+    Lê a taxonomia de crimes da API (CRIME_CATEGORIES_URL) ou do arquivo
+    padrão do backend
+    """
+    url = os.environ.get("CRIME_CATEGORIES_URL")
+    if url:
+        with urllib.request.urlopen(url) as response:
+            return json.load(response)
+    with open(CRIME_CATEGORIES_FILE, encoding="utf-8") as f:
+        return json.load(f)
+
+
+def normalize_text(text: str) -> str:
+    """
+    Minúsculas, sem acentos e pontuação, com um espaço entre as palavras,
+    como o NormalizeCrimeName do backend ("ROUBO - OUTROS" -> "roubo outros")
+    """
+    decomposed = unicodedata.normalize("NFD", text.lower())
+    chars = [" " if not c.isalnum() else c for c in decomposed if unicodedata.category(c) != "Mn"]
+    return " ".join("".join(chars).split())
+
+
+def build_weight_terms(categories: list) -> list:
+    """
+    Sinônimos normalizados de cada subcategoria com o seu peso, do mais longo
+    para o mais curto
+    """
+    terms = []
+    for entry in categories:
+        for synonym in [entry["subcategory"]] + list(entry.get("synonyms") or []):
+            text = normalize_text(synonym)
+            if text:
+                terms.append((text, entry["default_weight"]))
+    # O sort é estável: empates mantêm a ordem das subcategorias
+    terms.sort(key=lambda term: len(term[0]), reverse=True)
+    return terms
+
+
+WEIGHT_TERMS = build_weight_terms(load_crime_categories())
+
+
 def calculate_weight_crime(crime_type: str) -> int:
     """
-    Calcula o peso do crime baseado na lista de crimes hediondos
-    Crimes hediondos: peso 9
-    Outros crimes: peso 3
+    Calcula o peso do crime pela taxonomia, como o CrimeTaxonomy do backend:
+    vale o peso da subcategoria cujo sinônimo mais longo aparece no nome como
+    palavras inteiras; sem nenhum, DEFAULT_CRIME_WEIGHT
     """
-    crime_normalized = crime_type.strip().lower()
-    
-    for heinous_crime in HEINOUS_CRIMES:
-        if heinous_crime.lower() in crime_normalized:
-            return 9
-    
-    return 3
+    name = f" {normalize_text(crime_type)} "
+    for text, weight in WEIGHT_TERMS:
+        if f" {text} " in name:
+            return weight
+    return DEFAULT_CRIME_WEIGHT
 
 
 def extract_dp_from_filename(filename: str) -> str:
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/calendar"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/config"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/database"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/geo"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/importer"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/services"
+)
+
+const usage = `Uso: import [opções] <arquivo.xlsx|diretório>...
+     import -boundaries <arquivo.geojson|arquivo.shp|arquivo.zip> [opções]
+     import -holidays <arquivo.csv|arquivo.ics> [opções]
+     import -holiday-years <ano inicial>-<ano final> [opções]
+     import -merge-crimes [-dry-run]
+     import -backfill-dates [-dry-run]
+
+Importa as planilhas "OcorrenciaMensal(Criminal)" da SSP-SP como reports.
+A DP vem do nome do arquivo ("...-01 DP - Campinas_....xlsx") e é mapeada
+para bairro e coordenadas pela tabela de delegacias. Diretórios são lidos
+em busca de arquivos .xlsx.
+
+Com -boundaries, importa os limites (polígonos) dos bairros de um GeoJSON ou
+Shapefile em lon/lat (EPSG:4326). Bairros ainda não cadastrados são criados
+no centroide do limite.
+
+Com -holidays, importa feriados para external_holidays de um CSV (colunas
+data, nome e tipo, separadas por vírgula ou ponto e vírgula) ou de um
+calendário .ics (eventos de dia inteiro). Com -holiday-years, calcula os
+feriados nacionais, estaduais (SP) e municipais (Campinas) dos anos.
+
+Com -merge-crimes, junta os crimes cujos nomes têm a mesma chave canônica
+(sem diferenciar maiúsculas, acentos, espaços e sinônimos), movendo os reports
+para o crime mantido, e preenche a chave dos crimes antigos.
+
+Com -backfill-dates, preenche occurred_at dos reports antigos convertendo
+report_date (dd/mm/aaaa, ISO ou data e hora) no fuso DB_TIMEZONE (padrão:
+America/Sao_Paulo) e lista os reports cuja data não foi reconhecida.
+
+Opções:
+  -precincts arquivo.json  Tabela de delegacias (padrão: SSP_PRECINCTS_FILE
+                           ou a tabela embutida com as 13 DPs de Campinas)
+  -dry-run                 Apenas lê os arquivos, sem gravar no banco
+  -boundaries arquivo      Importa os limites dos bairros em vez de planilhas
+  -name-field campo        Campo com o nome do bairro nos limites (padrão:
+                           name, nome, bairro, nm_bairro...)
+  -holidays arquivo        Importa feriados (CSV ou ICS)
+  -holiday-years 2020-2030 Calcula e grava os feriados dos anos
+  -merge-crimes            Junta os crimes duplicados
+  -backfill-dates          Preenche occurred_at dos reports antigos
+`
+
+func main() {
+	precinctsFile := flag.String("precincts", "", "tabela de delegacias (JSON)")
+	dryRun := flag.Bool("dry-run", false, "apenas lê os arquivos, sem gravar no banco")
+	boundariesFile := flag.String("boundaries", "", "limites dos bairros (GeoJSON ou Shapefile)")
+	nameField := flag.String("name-field", "", "campo com o nome do bairro nos limites")
+	holidaysFile := flag.String("holidays", "", "feriados (CSV ou ICS)")
+	holidayYears := flag.String("holiday-years", "", "anos dos feriados calculados (ex.: 2020-2030)")
+	mergeCrimes := flag.Bool("merge-crimes", false, "junta os crimes duplicados")
+	backfillDates := flag.Bool("backfill-dates", false, "preenche occurred_at dos reports antigos")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	if *boundariesFile != "" {
+		importBoundaries(*boundariesFile, *nameField, *dryRun)
+		return
+	}
+	if *holidaysFile != "" || *holidayYears != "" {
+		importHolidays(*holidaysFile, *holidayYears, *dryRun)
+		return
+	}
+	if *mergeCrimes {
+		mergeDuplicateCrimes(*dryRun)
+		return
+	}
+	if *backfillDates {
+		backfillReportDates(*dryRun)
+		return
+	}
+
+	if flag.NArg() == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	files, err := collectFiles(flag.Args())
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if len(files) == 0 {
+		log.Fatal("❌ Nenhum arquivo .xlsx encontrado")
+	}
+
+	var cfg *config.Config
+	if !*dryRun {
+		cfg, err = config.Load()
+		if err != nil {
+			log.Fatalf("Erro ao carregar configuração: %v", err)
+		}
+		if *precinctsFile == "" {
+			*precinctsFile = cfg.SSPPrecinctsFile
+		}
+	}
+
+	table, err := importer.LoadConfig(*precinctsFile)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	var reqs []models.ReportRequest
+	var parsed []*importer.Result
+	for _, path := range files {
+		result, err := parseFile(path, table)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		fmt.Printf("📄 %s: %s (%s) - %d ocorrências\n",
+			result.File, result.Code, result.Precinct.Neighborhood, result.Occurrences)
+		reqs = append(reqs, result.ReportRequests()...)
+		parsed = append(parsed, result)
+	}
+	fmt.Printf("\n📊 %d planilhas, %d reports\n", len(files), len(reqs))
+
+	if *dryRun {
+		fmt.Println("✅ Dry-run: nada foi gravado")
+		return
+	}
+
+	db, err := database.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Erro ao conectar ao banco: %v", err)
+	}
+	loc, err := services.LoadReportLocation(cfg.DBTimezone)
+	if err != nil {
+		log.Fatalf("❌ DB_TIMEZONE inválido: %v", err)
+	}
+	area, err := services.GridArea(cfg.GridBBox)
+	if err != nil {
+		log.Fatalf("❌ GRID_BBOX inválido: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	svc := services.NewReportService(db, loc, area)
+	results, err := svc.ProcessReportsBulk(ctx, reqs)
+	accepted, skipped := 0, 0
+	for _, r := range results {
+		switch r.Status {
+		case services.BulkReportAccepted:
+			accepted++
+		case services.BulkReportSkipped:
+			skipped++
+		default:
+			req := reqs[r.Index]
+			fmt.Printf("  ⚠️  %s / %s / %s: %s\n", req.Name, req.CrimeName, req.ReportDate, r.Error)
+		}
+	}
+	fmt.Printf("✅ %d reports gravados, %d já importados, %d rejeitados\n", accepted, skipped, len(results)-accepted-skipped)
+	if err != nil {
+		log.Fatalf("❌ Importação interrompida após %d de %d reports: %v", len(results), len(reqs), err)
+	}
+
+	// Os meses das planilhas passam a ter exatamente as contagens delas
+	restored, removed := 0, 0
+	for _, result := range parsed {
+		reconciled, err := svc.ReconcileImportedReports(ctx, result.SourceScopes(), result.SourceCounts())
+		if err != nil {
+			log.Fatalf("❌ Erro ao reconciliar os reports de %s: %v", result.File, err)
+		}
+		restored += reconciled.Restored
+		removed += reconciled.Removed
+	}
+	fmt.Printf("🔁 %d reports restaurados e %d removidos pelas contagens das planilhas\n", restored, removed)
+}
+
+// importBoundaries lê os limites dos bairros e os grava pelo BoundaryService
+func importBoundaries(path, nameField string, dryRun bool) {
+	var boundaries []geo.Boundary
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".geojson", ".json":
+		var f *os.File
+		f, err = os.Open(path)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		boundaries, err = importer.ReadBoundariesGeoJSON(f, nameField)
+		f.Close()
+	default:
+		boundaries, err = importer.ReadBoundariesShapefile(path, nameField)
+	}
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	for _, b := range boundaries {
+		c := b.Centroid()
+		fmt.Printf("🧭 %s: %d polígonos, centroide %.5f, %.5f\n", b.Name, len(b.Geometry), c.Lat(), c.Lon())
+	}
+	fmt.Printf("\n📊 %d limites de bairros\n", len(boundaries))
+
+	if dryRun {
+		fmt.Println("✅ Dry-run: nada foi gravado")
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Erro ao carregar configuração: %v", err)
+	}
+	db, err := database.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Erro ao conectar ao banco: %v", err)
+	}
+	area, err := services.GridArea(cfg.GridBBox)
+	if err != nil {
+		log.Fatalf("❌ GRID_BBOX inválido: %v", err)
+	}
+
+	result, err := services.NewBoundaryService(db, area).ImportBoundaries(context.Background(), boundaries, filepath.Base(path))
+	if err != nil {
+		log.Fatalf("❌ Importação dos limites falhou: %v", err)
+	}
+	for _, r := range result.Rejected {
+		fmt.Printf("  ⚠️  %s: %s\n", r.Name, r.Error)
+	}
+	fmt.Printf("✅ %d limites gravados (%d bairros novos, %d atualizados), %d rejeitados\n",
+		result.Imported, result.Created, result.Updated, len(result.Rejected))
+	fmt.Println("ℹ️  Rode a knowledge base completa (não incremental) para remapear as células")
+}
+
+// importHolidays lê os feriados de um arquivo ou os calcula para os anos e
+// os grava pelo HolidayService
+func importHolidays(path, years string, dryRun bool) {
+	var holidays []calendar.Holiday
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		if strings.EqualFold(filepath.Ext(path), ".ics") {
+			holidays, err = importer.ReadHolidaysICS(f)
+		} else {
+			holidays, err = importer.ReadHolidaysCSV(f)
+		}
+		f.Close()
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+	}
+	if years != "" {
+		from, to, ok := parseYearRange(years)
+		if !ok || from < services.MinHolidayYear || to > services.MaxHolidayYear {
+			log.Fatalf("❌ -holiday-years inválido: use <ano inicial>-<ano final> (ex.: 2020-2030)")
+		}
+		holidays = append(holidays, calendar.ForYears(from, to)...)
+	}
+
+	for _, h := range holidays {
+		fmt.Printf("📅 %s %s (%s)\n", h.Key(), h.Name, h.Type)
+	}
+	fmt.Printf("\n📊 %d feriados\n", len(holidays))
+
+	if dryRun {
+		fmt.Println("✅ Dry-run: nada foi gravado")
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Erro ao carregar configuração: %v", err)
+	}
+	db, err := database.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Erro ao conectar ao banco: %v", err)
+	}
+
+	result, err := services.NewHolidayService(db).ImportHolidays(context.Background(), holidays)
+	if err != nil {
+		log.Fatalf("❌ Importação dos feriados falhou: %v", err)
+	}
+	fmt.Printf("✅ %d feriados gravados (%d novos, %d atualizados)\n", result.Imported, result.Created, result.Updated)
+	fmt.Println("ℹ️  Rode a knowledge base completa (não incremental) para recalcular as features de feriado")
+}
+
+// mergeDuplicateCrimes junta os crimes duplicados pelo CrimeService; com
+// dryRun apenas lista os grupos
+func mergeDuplicateCrimes(dryRun bool) {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Erro ao carregar configuração: %v", err)
+	}
+	db, err := database.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Erro ao conectar ao banco: %v", err)
+	}
+
+	result, err := services.NewCrimeService(db).MergeDuplicateCrimes(context.Background(), dryRun)
+	if err != nil {
+		log.Fatalf("❌ Consolidação dos crimes falhou: %v", err)
+	}
+	for _, g := range result.Groups {
+		fmt.Printf("🔗 %s (%d): %s - %d reports\n", g.CrimeName, g.CrimeID, strings.Join(g.MergedNames, ", "), g.Reports)
+	}
+	fmt.Printf("\n📊 %d crimes duplicados em %d grupos, %d reports, %d chaves preenchidas\n",
+		result.Merged, len(result.Groups), result.Reports, result.Keyed)
+
+	if dryRun {
+		fmt.Println("✅ Dry-run: nada foi gravado")
+		return
+	}
+	fmt.Println("✅ Crimes consolidados")
+}
+
+// backfillReportDates preenche occurred_at dos reports antigos pelo
+// ReportService; com dryRun apenas conta e lista as datas não reconhecidas
+func backfillReportDates(dryRun bool) {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Erro ao carregar configuração: %v", err)
+	}
+	db, err := database.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Erro ao conectar ao banco: %v", err)
+	}
+	loc, err := services.LoadReportLocation(cfg.DBTimezone)
+	if err != nil {
+		log.Fatalf("❌ DB_TIMEZONE inválido: %v", err)
+	}
+
+	area, err := services.GridArea(cfg.GridBBox)
+	if err != nil {
+		log.Fatalf("❌ GRID_BBOX inválido: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := services.NewReportService(db, loc, area).BackfillOccurredAt(ctx, dryRun)
+	if err != nil {
+		log.Fatalf("❌ Conversão das datas falhou: %v", err)
+	}
+	for _, f := range result.Failed {
+		fmt.Printf("  ⚠️  report %d: %q não reconhecida\n", f.ReportID, f.ReportDate)
+	}
+	fmt.Printf("\n📊 %d reports sem occurred_at (%s): %d convertidos, %d com data não reconhecida\n",
+		result.Scanned, loc, result.Converted, len(result.Failed))
+
+	if dryRun {
+		fmt.Println("✅ Dry-run: nada foi gravado")
+		return
+	}
+	fmt.Println("✅ Datas convertidas")
+	fmt.Println("ℹ️  Rode a knowledge base (a incremental basta) para incluir os reports convertidos")
+}
+
+// parseYearRange lê um intervalo de anos "2020-2030" (ou um único ano)
+func parseYearRange(value string) (from, to int, ok bool) {
+	first, last, found := strings.Cut(value, "-")
+	from, err := strconv.Atoi(strings.TrimSpace(first))
+	if err != nil {
+		return 0, 0, false
+	}
+	to = from
+	if found {
+		if to, err = strconv.Atoi(strings.TrimSpace(last)); err != nil {
+			return 0, 0, false
+		}
+	}
+	return from, to, from <= to
+}
+
+// collectFiles expande os diretórios em seus arquivos .xlsx
+func collectFiles(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+
+		entries, err := os.ReadDir(arg)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			// "~$" são os arquivos de bloqueio do Excel
+			if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".xlsx") &&
+				!strings.HasPrefix(entry.Name(), "~$") {
+				files = append(files, filepath.Join(arg, entry.Name()))
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func parseFile(path string, table *importer.Config) (*importer.Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return importer.ParseWorkbook(f, path, table)
+}
@@ -1,17 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 
-	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
+	"gorm.io/gorm"
 
 	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/config"
-	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/controllers"
 	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/database"
-	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
-	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/services"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/database/migrations"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/database/migrator"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/router"
 )
 
 func main() {
@@ -21,79 +21,38 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Connect to database (já está usando SQL Server via GORM)
+	// Connect to database (SQL Server, PostgreSQL ou SQLite conforme DB_DRIVER)
 	db, err := database.Connect(cfg)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	// Auto-migrate database schemas
-	if err := db.AutoMigrate(
-		
-		&models.Report{},
-		&models.Crime{},
-		&models.Neighborhood{},
-
-		// Tabelas da Knowledge Base - Curated
-		&models.CuratedIncident{},
-		&models.CuratedCell{},
-
-		// Tabelas da Knowledge Base - External
-		&models.ExternalHoliday{},
-
-		// Tabelas da Knowledge Base - Features
-		&models.FeaturesCellHourly{},
-
-		// Tabelas da Knowledge Base - Analytics
-		&models.AnalyticsQualityReport{},
-		&models.AnalyticsPipelineLog{},
-
-		// Tabela de migrations
-		&models.SchemaMigration{},
-
-		// Predict Time
-		&models.PredictCrime{},
-	); err != nil {
+	// O schema é das migrations versionadas (cmd/migrate): o servidor só
+	// confere se estão todas aplicadas, ou as aplica com DB_AUTO_MIGRATE=true
+	if err := checkMigrations(cfg, db); err != nil {
 		log.Fatalf("Migration failed: %v", err)
 	}
 
 	// Initialize services
-	reportSvc := services.NewReportService(db)
-
-	// Create controllers
-	reportCtrl := controllers.NewReportController(reportSvc)
-
-	// DSN para SQL Server no Knowledge Base Controller
-	sourceDSN := fmt.Sprintf(
-		"sqlserver://%s:%s@%s:%s?database=%s",
-		cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName,
-	)
-	targetDSN := sourceDSN // Mesmo banco para source e target
-
-	kbController := controllers.NewKnowledgeBaseController(sourceDSN, targetDSN)
-
-	// Initialize Echo
-	e := echo.New()
-	e.Use(middleware.Logger())
-	e.Use(middleware.Recover())
-	e.Use(middleware.CORS())
-
-	// Register routes
-	api := e.Group("/api/v1")
-
-	// Registrar rotas do report controller
-	reportCtrl.Register(api)
+	svcs, err := router.NewServices(cfg, db)
+	if err != nil {
+		log.Fatalf("Failed to initialize services: %v", err)
+	}
 
-	// Registrar rotas do KB controller
-	log.Println("🔧 Registrando rotas do Knowledge Base...")
-	api.POST("/knowledge-base/generate", kbController.GenerateKnowledgeBaseHandler)
-	api.GET("/knowledge-base/health", kbController.HealthCheckHandler)
-	api.GET("/knowledge-base/status", kbController.StatusHandler)
+	// Grava a taxonomia de crimes padrão enquanto crime_categories estiver vazia
+	seeded, err := svcs.Categories.SeedDefaultCategories(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to seed crime categories: %v", err)
+	}
+	if seeded > 0 {
+		log.Printf("🏷️  Taxonomia de crimes padrão gravada: %d subcategorias", seeded)
+	}
 
-	// Rota de teste
-	api.GET("/kb-test", func(c echo.Context) error {
-		return c.JSON(200, echo.Map{"message": "KB Test route works!"})
-	})
+	// Initialize Echo and register the enabled route groups
+	e, err := router.New(router.Groups(svcs), cfg.DisabledRoutes)
+	if err != nil {
+		log.Fatalf("Failed to register routes: %v", err)
+	}
 
 	// Listar todas as rotas registradas
 	log.Println("📍 Rotas registradas no Echo:")
@@ -104,4 +63,41 @@ func main() {
 	// Start server
 	log.Println("🚀 Servidor iniciando na porta :8080")
 	e.Logger.Fatal(e.Start(":8080"))
-}
\ No newline at end of file
+}
+
+// checkMigrations falha se houver migrations pendentes; com cfg.AutoMigrate
+// (desenvolvimento) aplica as pendentes antes
+func checkMigrations(cfg *config.Config, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	m, err := migrator.New(sqlDB, cfg.DBDriver, migrations.Files)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if cfg.AutoMigrate {
+		applied, err := m.Up(ctx, 0)
+		for _, mig := range applied {
+			log.Printf("⬆️  Migration %s_%s aplicada", mig.Version, mig.Name)
+		}
+		return err
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	pending := 0
+	for _, st := range statuses {
+		if !st.Applied {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%d migrations pendentes: rode go run ./backend/cmd/migrate up (ou DB_AUTO_MIGRATE=true em desenvolvimento)", pending)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Package calendar calcula os feriados nacionais, do estado de São Paulo e de
+// Campinas, usados nas features de calendário da base de conhecimento
+package calendar
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tipos de feriado gravados em external_holidays.type
+const (
+	TypeNational  = "nacional"
+	TypeState     = "estadual"
+	TypeMunicipal = "municipal"
+	// TypeImported é o tipo dos feriados importados sem tipo (ex.: de um ICS)
+	TypeImported = "importado"
+)
+
+// maxNameLength é o tamanho de external_holidays.name
+const maxNameLength = 100
+
+// Holiday é um feriado em uma data (meia-noite em UTC)
+type Holiday struct {
+	Date time.Time
+	Name string
+	Type string
+}
+
+// Key retorna a data no formato 2006-01-02, a chave usada pelas features
+func (h Holiday) Key() string {
+	return h.Date.Format("2006-01-02")
+}
+
+// Date retorna a data à meia-noite em UTC
+func Date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Easter retorna o domingo de Páscoa do ano (algoritmo de Meeus/Jones/Butcher
+// para o calendário gregoriano)
+func Easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return Date(year, time.Month(month), day)
+}
+
+// fixedHoliday é um feriado de data fixa, válido a partir de since (0: sempre)
+// e até until (0: sem fim)
+type fixedHoliday struct {
+	month        time.Month
+	day          int
+	name, kind   string
+	since, until int
+}
+
+var fixedHolidays = []fixedHoliday{
+	// Nacionais (Leis 662/1949, 6.802/1980 e 14.759/2023)
+	{time.January, 1, "Confraternização Universal", TypeNational, 0, 0},
+	{time.April, 21, "Tiradentes", TypeNational, 0, 0},
+	{time.May, 1, "Dia do Trabalho", TypeNational, 0, 0},
+	{time.September, 7, "Independência do Brasil", TypeNational, 0, 0},
+	{time.October, 12, "Nossa Senhora Aparecida", TypeNational, 1980, 0},
+	{time.November, 2, "Finados", TypeNational, 0, 0},
+	{time.November, 15, "Proclamação da República", TypeNational, 0, 0},
+	{time.November, 20, "Dia Nacional de Zumbi e da Consciência Negra", TypeNational, 2024, 0},
+	{time.December, 25, "Natal", TypeNational, 0, 0},
+
+	// Estado de São Paulo (Lei 9.497/1997)
+	{time.July, 9, "Revolução Constitucionalista", TypeState, 1997, 0},
+
+	// Campinas: a Consciência Negra era municipal até virar feriado nacional
+	{time.November, 20, "Dia da Consciência Negra", TypeMunicipal, 0, 2023},
+	{time.December, 8, "Nossa Senhora da Conceição", TypeMunicipal, 0, 0},
+}
+
+// movableHoliday é um feriado a offset dias do domingo de Páscoa
+type movableHoliday struct {
+	offset     int
+	name, kind string
+}
+
+var movableHolidays = []movableHoliday{
+	{-48, "Carnaval (segunda-feira)", TypeNational},
+	{-47, "Carnaval (terça-feira)", TypeNational},
+	{-2, "Sexta-feira Santa", TypeNational},
+	{60, "Corpus Christi", TypeNational},
+}
+
+// ForYear retorna os feriados de Campinas no ano (nacionais, estaduais e
+// municipais), em ordem de data
+func ForYear(year int) []Holiday {
+	var holidays []Holiday
+	for _, f := range fixedHolidays {
+		if (f.since == 0 || year >= f.since) && (f.until == 0 || year <= f.until) {
+			holidays = append(holidays, Holiday{Date(year, f.month, f.day), f.name, f.kind})
+		}
+	}
+	easter := Easter(year)
+	for _, m := range movableHolidays {
+		holidays = append(holidays, Holiday{easter.AddDate(0, 0, m.offset), m.name, m.kind})
+	}
+	return Merge(holidays)
+}
+
+// ForYears retorna os feriados de from a to (inclusive), em ordem de data
+func ForYears(from, to int) []Holiday {
+	var holidays []Holiday
+	for year := from; year <= to; year++ {
+		holidays = append(holidays, ForYear(year)...)
+	}
+	return holidays
+}
+
+// Merge ordena os feriados por data e junta os que caem no mesmo dia (ex.:
+// Sexta-feira Santa em 21 de abril), já que external_holidays tem um feriado
+// por data. O tipo do primeiro é mantido e os nomes são unidos por " / ".
+func Merge(holidays []Holiday) []Holiday {
+	sorted := make([]Holiday, len(holidays))
+	copy(sorted, holidays)
+	sort.SliceStable(sorted, func(a, b int) bool { return sorted[a].Date.Before(sorted[b].Date) })
+
+	var result []Holiday
+	for _, h := range sorted {
+		last := len(result) - 1
+		if last < 0 || !result[last].Date.Equal(h.Date) {
+			result = append(result, h)
+			continue
+		}
+		if !strings.Contains(result[last].Name, h.Name) {
+			name := result[last].Name + " / " + h.Name
+			if len([]rune(name)) <= maxNameLength {
+				result[last].Name = name
+			}
+		}
+	}
+	return result
+}
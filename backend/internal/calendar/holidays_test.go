@@ -0,0 +1,69 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEaster(t *testing.T) {
+	cases := map[int]string{
+		2000: "2000-04-23", 2019: "2019-04-21", 2024: "2024-03-31",
+		2025: "2025-04-20", 2026: "2026-04-05", 2038: "2038-04-25",
+	}
+	for year, want := range cases {
+		if got := Easter(year).Format("2006-01-02"); got != want {
+			t.Errorf("%d: esperava Páscoa em %s, obteve: %s", year, want, got)
+		}
+	}
+}
+
+func TestForYear(t *testing.T) {
+	byDate := make(map[string]Holiday)
+	for _, h := range ForYear(2025) {
+		byDate[h.Key()] = h
+	}
+	want := map[string]string{
+		"2025-01-01": "Confraternização Universal",
+		"2025-03-03": "Carnaval (segunda-feira)",
+		"2025-03-04": "Carnaval (terça-feira)",
+		"2025-04-18": "Sexta-feira Santa",
+		"2025-06-19": "Corpus Christi",
+		"2025-07-09": "Revolução Constitucionalista",
+		"2025-11-20": "Dia Nacional de Zumbi e da Consciência Negra",
+		"2025-12-08": "Nossa Senhora da Conceição",
+	}
+	for date, name := range want {
+		if byDate[date].Name != name {
+			t.Errorf("%s: esperava %s, obteve: %q", date, name, byDate[date].Name)
+		}
+	}
+	if len(byDate) != 15 {
+		t.Errorf("esperava 15 feriados em 2025, obteve: %d", len(byDate))
+	}
+	if h := byDate["2025-07-09"]; h.Type != TypeState {
+		t.Errorf("esperava o 9 de julho estadual, obteve: %s", h.Type)
+	}
+
+	// Até 2023 a Consciência Negra era feriado municipal
+	for _, h := range ForYear(2023) {
+		if h.Key() == "2023-11-20" && h.Type != TypeMunicipal {
+			t.Errorf("esperava a Consciência Negra municipal em 2023, obteve: %s", h.Type)
+		}
+	}
+
+	// Em 2000 a Sexta-feira Santa caiu no Tiradentes: um feriado só na data
+	holidays := ForYear(2000)
+	for i, h := range holidays {
+		if i > 0 && !holidays[i-1].Date.Before(h.Date) {
+			t.Fatalf("esperava datas únicas e em ordem, obteve: %s depois de %s", h.Key(), holidays[i-1].Key())
+		}
+		if h.Key() == "2000-04-21" && h.Name != "Tiradentes / Sexta-feira Santa" {
+			t.Errorf("esperava os nomes unidos, obteve: %s", h.Name)
+		}
+	}
+
+	if got := ForYears(2024, 2026); len(got) != len(ForYear(2024))+len(ForYear(2025))+len(ForYear(2026)) ||
+		got[0].Date != Date(2024, time.January, 1) {
+		t.Errorf("esperava os feriados de 2024 a 2026 em ordem, obteve: %d", len(got))
+	}
+}
@@ -3,11 +3,14 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
+	// DBDriver seleciona o banco: "sqlserver" (padrão), "postgres" ou "sqlite"
+	DBDriver   string
 	DBHost     string
 	DBPort     string
 	DBUser     string
@@ -15,6 +18,19 @@ type Config struct {
 	DBName     string
 	DBSSLMode  string
 	DBTimezone string
+	// SSPPrecinctsFile é a tabela de delegacias do importador de planilhas da
+	// SSP; vazio usa a tabela padrão embutida em internal/importer
+	SSPPrecinctsFile string
+	// DisabledRoutes são os grupos de rotas desligados (DISABLED_ROUTES,
+	// separados por vírgula, ex.: "import,knowledge-base")
+	DisabledRoutes []string
+	// GridBBox é a área da grade espacial (GRID_BBOX, no formato
+	// min_lon,min_lat,max_lon,max_lat); vazio usa a bounding box de Campinas
+	GridBBox string
+	// AutoMigrate aplica as migrations pendentes ao subir o servidor
+	// (DB_AUTO_MIGRATE=true, só para desenvolvimento); sem ele o servidor
+	// recusa subir com migrations pendentes
+	AutoMigrate bool
 }
 
 func Load() (*Config, error) {
@@ -25,6 +41,7 @@ func Load() (*Config, error) {
 	}
 
 	cfg := &Config{
+		DBDriver:   getEnvOrDefault("DB_DRIVER", "sqlserver"),
 		DBHost:     os.Getenv("DB_HOST"),
 		DBPort:     os.Getenv("DB_PORT"),
 		DBUser:     os.Getenv("DB_USER"),
@@ -32,19 +49,70 @@ func Load() (*Config, error) {
 		DBName:     os.Getenv("DB_NAME"),
 		DBSSLMode:  os.Getenv("DB_SSL_MODE"),
 		DBTimezone: getEnvOrDefault("DB_TIMEZONE", "America/Sao_Paulo"),
+
+		SSPPrecinctsFile: os.Getenv("SSP_PRECINCTS_FILE"),
+		DisabledRoutes:   splitList(os.Getenv("DISABLED_ROUTES")),
+		GridBBox:         os.Getenv("GRID_BBOX"),
+		AutoMigrate:      os.Getenv("DB_AUTO_MIGRATE") == "true",
 	}
 
 	fmt.Printf("Config carregada: %+v\n", cfg)
 
-	if cfg.DBHost == "" || cfg.DBPort == "" || cfg.DBUser == "" || cfg.DBName == "" {
-		return nil, fmt.Errorf("variaveis de ambiente de DB não configuradas: %+v", cfg)
+	switch cfg.DBDriver {
+	case "sqlite":
+		// SQLite só precisa do caminho do arquivo (DB_NAME)
+		if cfg.DBName == "" {
+			return nil, fmt.Errorf("variaveis de ambiente de DB não configuradas: %+v", cfg)
+		}
+	case "sqlserver", "postgres":
+		if cfg.DBHost == "" || cfg.DBPort == "" || cfg.DBUser == "" || cfg.DBName == "" {
+			return nil, fmt.Errorf("variaveis de ambiente de DB não configuradas: %+v", cfg)
+		}
+	default:
+		return nil, fmt.Errorf("DB_DRIVER inválido: %q (use sqlserver, postgres ou sqlite)", cfg.DBDriver)
 	}
 	return cfg, nil
 }
 
+// DSN monta a string de conexão do driver configurado
+func (c *Config) DSN() string {
+	switch c.DBDriver {
+	case "postgres":
+		sslMode := c.DBSSLMode
+		if sslMode == "" {
+			sslMode = "disable"
+		}
+		return fmt.Sprintf(
+			"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s TimeZone=%s",
+			c.DBHost, c.DBPort, c.DBUser, c.DBPassword, c.DBName, sslMode, c.DBTimezone,
+		)
+	case "sqlite":
+		// WAL permite leitura e escrita simultâneas (o pipeline lê reports enquanto grava).
+		// _txlock=immediate reserva a escrita no BEGIN: transações simultâneas
+		// esperam o busy_timeout em vez de falhar ao passar de leitura para escrita
+		return c.DBName + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)&_txlock=immediate"
+	default:
+		return fmt.Sprintf(
+			"sqlserver://%s:%s@%s:%s?database=%s",
+			c.DBUser, c.DBPassword, c.DBHost, c.DBPort, c.DBName,
+		)
+	}
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+// splitList separa uma lista por vírgulas, ignorando itens vazios
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
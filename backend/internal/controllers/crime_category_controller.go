@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/services"
+)
+
+// CrimeCategoryController handles the crime taxonomy (crime_categories)
+type CrimeCategoryController struct {
+	svc services.CrimeCategoryService
+}
+
+// NewCrimeCategoryController creates a new instance of CrimeCategoryController
+func NewCrimeCategoryController(svc services.CrimeCategoryService) *CrimeCategoryController {
+	return &CrimeCategoryController{svc: svc}
+}
+
+// Register registers the routes for the crime category controller
+func (ctrl *CrimeCategoryController) Register(g *echo.Group) {
+	g.GET("/crime-categories", ctrl.ListCrimeCategories)
+	g.GET("/crime-categories/classify", ctrl.ClassifyCrime)
+	g.GET("/crime-categories/:id", ctrl.GetCrimeCategoryByID)
+	g.POST("/crime-categories", ctrl.CreateCrimeCategory)
+	g.PUT("/crime-categories/:id", ctrl.UpdateCrimeCategory)
+	g.DELETE("/crime-categories/:id", ctrl.DeleteCrimeCategory)
+}
+
+// ListCrimeCategories handles listing every entry of the taxonomy
+func (ctrl *CrimeCategoryController) ListCrimeCategories(c echo.Context) error {
+	categories, err := ctrl.svc.ListCrimeCategories(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to retrieve crime categories",
+		})
+	}
+	return c.JSON(http.StatusOK, categories)
+}
+
+// ClassifyCrime handles GET /crime-categories/classify?name=: the category,
+// subcategory and weight the taxonomy gives to a crime name
+func (ctrl *CrimeCategoryController) ClassifyCrime(c echo.Context) error {
+	name := c.QueryParam("name")
+	if strings.TrimSpace(name) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Missing crime name",
+		})
+	}
+
+	taxonomy, err := ctrl.svc.Taxonomy(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to load the crime taxonomy",
+		})
+	}
+
+	entry, matched := taxonomy.Classify(name)
+	if !matched {
+		entry = models.CrimeCategory{Category: services.UncategorizedCrime, DefaultWeight: services.DefaultCrimeWeight}
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"name":        name,
+		"matched":     matched,
+		"category":    entry.Category,
+		"subcategory": entry.Subcategory,
+		"weight":      entry.DefaultWeight,
+	})
+}
+
+// GetCrimeCategoryByID handles retrieving an entry by ID
+func (ctrl *CrimeCategoryController) GetCrimeCategoryByID(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid crime category ID",
+		})
+	}
+
+	category, err := ctrl.svc.GetCrimeCategoryByID(c.Request().Context(), id)
+	if err != nil {
+		return serviceError(c, err, "Crime category not found", "Failed to retrieve crime category")
+	}
+	return c.JSON(http.StatusOK, category)
+}
+
+// CreateCrimeCategory handles the creation of a new entry
+func (ctrl *CrimeCategoryController) CreateCrimeCategory(c echo.Context) error {
+	var category models.CrimeCategory
+	if err := c.Bind(&category); err != nil {
+		return bindError(c, err)
+	}
+
+	if err := ctrl.svc.CreateCrimeCategory(c.Request().Context(), &category); err != nil {
+		return serviceError(c, err, "Crime category not found", "Failed to create crime category")
+	}
+	return c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCrimeCategory handles replacing every editable field of an entry
+func (ctrl *CrimeCategoryController) UpdateCrimeCategory(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid crime category ID",
+		})
+	}
+
+	var category models.CrimeCategory
+	if err := c.Bind(&category); err != nil {
+		return bindError(c, err)
+	}
+	category.CategoryID = id
+
+	ctx := c.Request().Context()
+	if err := ctrl.svc.UpdateCrimeCategory(ctx, &category); err != nil {
+		return serviceError(c, err, "Crime category not found", "Failed to update crime category")
+	}
+
+	updated, err := ctrl.svc.GetCrimeCategoryByID(ctx, id)
+	if err != nil {
+		return serviceError(c, err, "Crime category not found", "Failed to retrieve crime category")
+	}
+	return c.JSON(http.StatusOK, updated)
+}
+
+// DeleteCrimeCategory handles removing an entry
+func (ctrl *CrimeCategoryController) DeleteCrimeCategory(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid crime category ID",
+		})
+	}
+
+	if err := ctrl.svc.DeleteCrimeCategory(c.Request().Context(), id); err != nil {
+		return serviceError(c, err, "Crime category not found", "Failed to delete crime category")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
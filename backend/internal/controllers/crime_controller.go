@@ -1,8 +1,10 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
 	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/services"
 	"github.com/labstack/echo/v4"
 )
@@ -20,11 +22,29 @@ func NewCrimeController(svc services.CrimeService) *CrimeController {
 func (ctr *CrimeController) Register(g *echo.Group) {
 	// GET /crimes -> chama o método GetCrimes
 	g.GET("/crimes", ctr.GetCrimes)
+	g.GET("/crimes/:id", ctr.GetCrimeByID)
+	g.POST("/crimes", ctr.CreateCrime)
+	// PUT substitui todos os campos editáveis; PATCH só os enviados no corpo
+	g.PUT("/crimes/:id", ctr.ReplaceCrime)
+	g.PATCH("/crimes/:id", ctr.PatchCrime)
+	g.DELETE("/crimes/:id", ctr.DeleteCrime)
+	// Junta os crimes duplicados (mesma chave canônica do nome)
+	g.POST("/crimes/merge", ctr.MergeDuplicateCrimes)
 }
 
 
+// GetCrimes aceita os filtros, a ordenação e a paginação de parseListQuery;
+// o total vai no header X-Total-Count
 func (ctr *CrimeController) GetCrimes(c echo.Context) error {
-    crimes, err := ctr.svc.ListCrimes(c.Request().Context())
+    q, err := parseListQuery(c)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+
+    page, err := ctr.svc.ListCrimes(c.Request().Context(), q)
+    if errors.Is(err, services.ErrInvalidListQuery) {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
     if err != nil {
         return c.JSON(
             http.StatusInternalServerError,
@@ -32,6 +52,97 @@ func (ctr *CrimeController) GetCrimes(c echo.Context) error {
         )
     }
 
-    
-    return c.JSON(http.StatusOK, crimes)
+    setListHeaders(c, page)
+    return c.JSON(http.StatusOK, page.Items)
+}
+
+func (ctr *CrimeController) GetCrimeByID(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid crime ID"})
+	}
+
+	crime, err := ctr.svc.GetCrimeByID(c.Request().Context(), id)
+	if err != nil {
+		return serviceError(c, err, "Crime not found", "Failed to retrieve crime")
+	}
+	return c.JSON(http.StatusOK, crime)
+}
+
+func (ctr *CrimeController) CreateCrime(c echo.Context) error {
+	var crime models.Crime
+	if err := c.Bind(&crime); err != nil {
+		return bindError(c, err)
+	}
+
+	if err := ctr.svc.CreateCrime(c.Request().Context(), &crime); err != nil {
+		return serviceError(c, err, "Crime not found", "Failed to create crime")
+	}
+	return c.JSON(http.StatusCreated, crime)
+}
+
+func (ctr *CrimeController) ReplaceCrime(c echo.Context) error {
+	return ctr.updateCrime(c, false)
+}
+
+func (ctr *CrimeController) PatchCrime(c echo.Context) error {
+	return ctr.updateCrime(c, true)
+}
+
+func (ctr *CrimeController) updateCrime(c echo.Context, patch bool) error {
+	id, err := parseID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid crime ID"})
+	}
+
+	ctx := c.Request().Context()
+	crime := &models.Crime{}
+	if patch {
+		// O corpo é decodificado por cima do registro atual
+		if crime, err = ctr.svc.GetCrimeByID(ctx, id); err != nil {
+			return serviceError(c, err, "Crime not found", "Failed to retrieve crime")
+		}
+	}
+	if err := c.Bind(crime); err != nil {
+		return bindError(c, err)
+	}
+	crime.CrimeID = id
+
+	if err := ctr.svc.UpdateCrime(ctx, crime); err != nil {
+		return serviceError(c, err, "Crime not found", "Failed to update crime")
+	}
+
+	updated, err := ctr.svc.GetCrimeByID(ctx, id)
+	if err != nil {
+		return serviceError(c, err, "Crime not found", "Failed to retrieve crime")
+	}
+	return c.JSON(http.StatusOK, updated)
+}
+
+// DeleteCrime faz soft delete; crimes com reports retornam 409
+func (ctr *CrimeController) DeleteCrime(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid crime ID"})
+	}
+
+	if err := ctr.svc.DeleteCrime(c.Request().Context(), id); err != nil {
+		return serviceError(c, err, "Crime not found", "Failed to delete crime")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// MergeDuplicateCrimes junta os crimes com a mesma chave canônica, movendo os
+// reports para o crime mantido; dry_run=true apenas lista os grupos
+func (ctr *CrimeController) MergeDuplicateCrimes(c echo.Context) error {
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid dry_run"})
+	}
+
+	result, err := ctr.svc.MergeDuplicateCrimes(c.Request().Context(), dryRun)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to merge crimes"})
+	}
+	return c.JSON(http.StatusOK, result)
 }
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/services"
+)
+
+// validationResponse is the body of a 422 response: the reason of each
+// rejected field, keyed by its JSON name
+type validationResponse struct {
+	Error  string               `json:"error"`
+	Fields services.FieldErrors `json:"fields"`
+}
+
+// parseID reads the :id path parameter
+func parseID(c echo.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		return 0, errors.New("invalid id")
+	}
+	return uint(id), nil
+}
+
+// bindError answers a request body that could not be bound. A JSON value of
+// the wrong type becomes a field error, like the ones of the validation.
+func bindError(c echo.Context, err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return c.JSON(http.StatusUnprocessableEntity, validationResponse{
+			Error:  "Validation failed",
+			Fields: services.FieldErrors{typeErr.Field: "must be a " + typeErr.Type.String()},
+		})
+	}
+	return c.JSON(http.StatusBadRequest, map[string]string{
+		"error": "Invalid request body",
+	})
+}
+
+// serviceError answers the errors of the create, update and delete services:
+// 422 with the field errors, 404 when the record does not exist, 409 when it
+// is still referenced or would duplicate a unique key (like the coordinates
+// of a neighborhood) and 500 with the failed message otherwise.
+func serviceError(c echo.Context, err error, notFound, failed string) error {
+	var fields services.FieldErrors
+	switch {
+	case errors.As(err, &fields):
+		return c.JSON(http.StatusUnprocessableEntity, validationResponse{
+			Error:  "Validation failed",
+			Fields: fields,
+		})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": notFound,
+		})
+	case errors.Is(err, services.ErrInUse):
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "A record with the same unique fields already exists",
+		})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": failed,
+		})
+	}
+}
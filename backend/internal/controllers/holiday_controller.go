@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/calendar"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/importer"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/services"
+)
+
+// Default range of GenerateHolidays, relative to the current year
+const (
+	defaultHolidayYearsBack  = 5
+	defaultHolidayYearsAhead = 1
+)
+
+// HolidayController handles the holiday calendar (external_holidays)
+type HolidayController struct {
+	svc services.HolidayService
+}
+
+// NewHolidayController creates a new instance of HolidayController
+func NewHolidayController(svc services.HolidayService) *HolidayController {
+	return &HolidayController{svc: svc}
+}
+
+// Register registers the routes for the holiday controller
+func (ctrl *HolidayController) Register(g *echo.Group) {
+	g.GET("/holidays", ctrl.ListHolidays)
+	g.POST("/holidays/generate", ctrl.GenerateHolidays)
+	g.POST("/holidays/import", ctrl.ImportHolidays)
+}
+
+// ListHolidays handles GET /holidays, optionally filtered by year
+func (ctrl *HolidayController) ListHolidays(c echo.Context) error {
+	year := 0
+	if value := c.QueryParam("year"); value != "" {
+		var err error
+		year, err = strconv.Atoi(value)
+		if err != nil || year < 1 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid year",
+			})
+		}
+	}
+
+	holidays, err := ctrl.svc.ListHolidays(c.Request().Context(), year)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list holidays",
+		})
+	}
+	return c.JSON(http.StatusOK, holidays)
+}
+
+// GenerateHolidays handles POST /holidays/generate: computes the national,
+// São Paulo state and Campinas holidays from from_year to to_year (default:
+// five years back to next year) and saves them
+func (ctrl *HolidayController) GenerateHolidays(c echo.Context) error {
+	current := time.Now().Year()
+	from, to := current-defaultHolidayYearsBack, current+defaultHolidayYearsAhead
+	params := []struct {
+		name   string
+		target *int
+	}{{"from_year", &from}, {"to_year", &to}}
+	for _, p := range params {
+		if value := c.QueryParam(p.name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "Invalid " + p.name,
+				})
+			}
+			*p.target = n
+		}
+	}
+
+	result, err := ctrl.svc.GenerateHolidays(c.Request().Context(), from, to)
+	switch {
+	case errors.Is(err, services.ErrInvalidYearRange):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid year range: from_year must not be after to_year, between " +
+				strconv.Itoa(services.MinHolidayYear) + " and " + strconv.Itoa(services.MaxHolidayYear),
+		})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to generate holidays",
+		})
+	}
+	return c.JSON(http.StatusCreated, echo.Map{
+		"from_year": from,
+		"to_year":   to,
+		"imported":  result.Imported,
+		"created":   result.Created,
+		"updated":   result.Updated,
+	})
+}
+
+// ImportHolidays handles a multipart upload of holidays in the "file" field:
+// a CSV (date, name and optional type columns) or an iCalendar (.ics) file
+// with all-day events. dry_run=true only parses the file.
+func (ctrl *HolidayController) ImportHolidays(c echo.Context) error {
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid dry_run",
+		})
+	}
+
+	upload, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Request must be multipart/form-data with the holidays in the file field",
+		})
+	}
+	file, err := upload.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Failed to read " + upload.Filename,
+		})
+	}
+	defer file.Close()
+
+	var holidays []calendar.Holiday
+	switch strings.ToLower(filepath.Ext(upload.Filename)) {
+	case ".csv":
+		holidays, err = importer.ReadHolidaysCSV(file)
+	case ".ics":
+		holidays, err = importer.ReadHolidaysICS(file)
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Holidays must be a .csv or .ics file",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if dryRun {
+		dates := make([]string, len(holidays))
+		for i, h := range holidays {
+			dates[i] = h.Key() + " " + h.Name
+		}
+		return c.JSON(http.StatusOK, echo.Map{
+			"dry_run":  true,
+			"file":     upload.Filename,
+			"holidays": dates,
+		})
+	}
+
+	result, err := ctrl.svc.ImportHolidays(c.Request().Context(), holidays)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to import holidays",
+		})
+	}
+	return c.JSON(http.StatusCreated, echo.Map{
+		"dry_run":  false,
+		"file":     upload.Filename,
+		"imported": result.Imported,
+		"created":  result.Created,
+		"updated":  result.Updated,
+	})
+}
@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/geo"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/importer"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/services"
+)
+
+// ImportController handles the upload of SSP "OcorrenciaMensal(Criminal)"
+// spreadsheets and of neighborhood boundary files
+type ImportController struct {
+	svc        services.ReportService
+	boundaries services.BoundaryService
+	table      *importer.Config
+}
+
+// NewImportController creates a new instance of ImportController. table maps
+// each precinct (DP) to the neighborhood and coordinates of its reports.
+func NewImportController(svc services.ReportService, boundaries services.BoundaryService, table *importer.Config) *ImportController {
+	return &ImportController{svc: svc, boundaries: boundaries, table: table}
+}
+
+// Register registers the routes for the import controller
+func (ctrl *ImportController) Register(g *echo.Group) {
+	g.POST("/reports/import", ctrl.ImportSpreadsheets)
+	g.POST("/neighborhoods/boundaries/import", ctrl.ImportBoundaries)
+}
+
+// importFileSummary is the outcome of one uploaded spreadsheet
+type importFileSummary struct {
+	*importer.Result
+	Accepted int `json:"accepted"`
+	Skipped  int `json:"skipped"`
+	Rejected int `json:"rejected"`
+	// Restored and Removed are the reports brought back or deleted to match
+	// the counts of the spreadsheet
+	Restored int                         `json:"restored"`
+	Removed  int                         `json:"removed"`
+	Errors   []services.BulkReportResult `json:"errors,omitempty"`
+}
+
+// ImportSpreadsheets handles a multipart upload with one or more .xlsx files
+// in the "files" (or "file") field. Every occurrence of the spreadsheets
+// becomes a report through ReportService.ProcessReportsBulk; dry_run=true only
+// parses the files. Occurrences imported before are skipped (see
+// models.Report.SourceKey), and the imported reports of the months in the
+// spreadsheet are then reconciled with its counts. Only the rejected rows are
+// listed in the response.
+func (ctrl *ImportController) ImportSpreadsheets(c echo.Context) error {
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid dry_run",
+		})
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Request must be multipart/form-data with the spreadsheets in the files field",
+		})
+	}
+	var uploads []*multipart.FileHeader
+	uploads = append(uploads, form.File["files"]...)
+	uploads = append(uploads, form.File["file"]...)
+	if len(uploads) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "No spreadsheet uploaded",
+		})
+	}
+
+	// All files are parsed before anything is written, so a bad upload
+	// does not leave a partial import behind
+	results := make([]*importer.Result, 0, len(uploads))
+	for _, upload := range uploads {
+		file, err := upload.Open()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Failed to read " + upload.Filename,
+			})
+		}
+		result, err := importer.ParseWorkbook(file, upload.Filename, ctrl.table)
+		file.Close()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		results = append(results, result)
+	}
+
+	ctx := c.Request().Context()
+	summaries := make([]importFileSummary, 0, len(results))
+	total, accepted, skipped, rejected := 0, 0, 0, 0
+	for _, result := range results {
+		summary := importFileSummary{Result: result}
+		reqs := result.ReportRequests()
+		total += len(reqs)
+
+		if !dryRun {
+			processed, err := ctrl.svc.ProcessReportsBulk(ctx, reqs)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "Import interrupted while processing " + result.File,
+				})
+			}
+			for _, r := range processed {
+				switch r.Status {
+				case services.BulkReportAccepted:
+					summary.Accepted++
+				case services.BulkReportSkipped:
+					summary.Skipped++
+				default:
+					summary.Rejected++
+					summary.Errors = append(summary.Errors, r)
+				}
+			}
+
+			reconciled, err := ctrl.svc.ReconcileImportedReports(ctx, result.SourceScopes(), result.SourceCounts())
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "Failed to reconcile the reports of " + result.File,
+				})
+			}
+			summary.Restored, summary.Removed = reconciled.Restored, reconciled.Removed
+			accepted += summary.Accepted
+			skipped += summary.Skipped
+			rejected += summary.Rejected
+		}
+		summaries = append(summaries, summary)
+	}
+
+	status := http.StatusCreated
+	if dryRun {
+		status = http.StatusOK
+	}
+	return c.JSON(status, echo.Map{
+		"dry_run":  dryRun,
+		"files":    summaries,
+		"total":    total,
+		"accepted": accepted,
+		"skipped":  skipped,
+		"rejected": rejected,
+	})
+}
+
+// ImportBoundaries handles a multipart upload of neighborhood boundaries in the
+// "file" field: a GeoJSON FeatureCollection (.geojson or .json) or a zipped
+// Shapefile (.zip with the .shp and .dbf). The neighborhood name is read from
+// the name_field parameter or from the usual field names (name, nome,
+// bairro...). dry_run=true only parses the file.
+func (ctrl *ImportController) ImportBoundaries(c echo.Context) error {
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid dry_run",
+		})
+	}
+
+	upload, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Request must be multipart/form-data with the boundaries in the file field",
+		})
+	}
+	file, err := upload.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Failed to read " + upload.Filename,
+		})
+	}
+	defer file.Close()
+
+	nameField := c.FormValue("name_field")
+	var boundaries []geo.Boundary
+	switch strings.ToLower(filepath.Ext(upload.Filename)) {
+	case ".geojson", ".json":
+		boundaries, err = importer.ReadBoundariesGeoJSON(file, nameField)
+	case ".zip":
+		boundaries, err = importer.ReadBoundariesShapefileZip(file, upload.Size, nameField)
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Boundaries must be a .geojson, .json or zipped Shapefile (.zip)",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	names := make([]string, len(boundaries))
+	for i, b := range boundaries {
+		names[i] = b.Name
+	}
+	if dryRun {
+		return c.JSON(http.StatusOK, echo.Map{
+			"dry_run":       true,
+			"file":          upload.Filename,
+			"neighborhoods": names,
+		})
+	}
+
+	result, err := ctrl.boundaries.ImportBoundaries(c.Request().Context(), boundaries, upload.Filename)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to import boundaries",
+		})
+	}
+	return c.JSON(http.StatusCreated, echo.Map{
+		"dry_run":  false,
+		"file":     upload.Filename,
+		"imported": result.Imported,
+		"created":  result.Created,
+		"updated":  result.Updated,
+		"rejected": result.Rejected,
+	})
+}
+
+// parseDryRun reads the optional dry_run query parameter
+func parseDryRun(c echo.Context) (bool, error) {
+	value := c.QueryParam("dry_run")
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
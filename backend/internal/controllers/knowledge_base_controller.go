@@ -3,6 +3,8 @@ package controllers
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -10,19 +12,29 @@ import (
 	"time"
 	"github.com/labstack/echo/v4"
 
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
 	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/services"
 )
 
 type KnowledgeBaseController struct {
+	Dialect   services.KnowledgeBaseDialect
 	SourceDSN string
 	TargetDSN string
+	GridArea  services.BoundingBox
+	// Location é o fuso dos reports, usado no período e nas horas da base
+	Location  *time.Location
+	Jobs      *services.KnowledgeBaseJobManager
 	Logger    *log.Logger
 }
 
-func NewKnowledgeBaseController(sourceDSN, targetDSN string) *KnowledgeBaseController {
+func NewKnowledgeBaseController(dialect services.KnowledgeBaseDialect, sourceDSN, targetDSN string, gridArea services.BoundingBox, loc *time.Location) *KnowledgeBaseController {
 	return &KnowledgeBaseController{
+		Dialect:   dialect,
 		SourceDSN: sourceDSN,
 		TargetDSN: targetDSN,
+		GridArea:  gridArea,
+		Location:  loc,
+		Jobs:      services.NewKnowledgeBaseJobManager(8, 50),
 		Logger:    log.New(os.Stdout, "[KB-CTRL] ", log.LstdFlags|log.Lmsgprefix),
 	}
 }
@@ -31,18 +43,23 @@ func NewKnowledgeBaseController(sourceDSN, targetDSN string) *KnowledgeBaseContr
 // HANDLERS
 // ============================================================================
 
-// GenerateKnowledgeBaseHandler é o ponto de entrada que dispara a geração da base
+// GenerateKnowledgeBaseHandler enfileira a geração da base e retorna o ID do job.
+// O andamento pode ser acompanhado em GET /knowledge-base/jobs/:id.
 func (c *KnowledgeBaseController) GenerateKnowledgeBaseHandler(ctx echo.Context) error {
 	c.Logger.Println("📥 Recebida requisição para gerar base de conhecimento")
 
-	background := context.Background()
-
 	// Parse query parameters (opcional)
+	// cell_resolution: lado da célula quadrada em metros (100 a 20000) ou h3:5 a h3:10
 	cellResolution := 1000 // padrão
 	if res := ctx.QueryParam("cell_resolution"); res != "" {
-		if parsed, err := strconv.Atoi(res); err == nil && (parsed == 500 || parsed == 1000) {
-			cellResolution = parsed
+		parsed, err := services.ParseCellResolution(res)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, echo.Map{
+				"error":   "cell_resolution inválido",
+				"details": err.Error(),
+			})
 		}
+		cellResolution = parsed
 	}
 
 	daysBack := 1425  // padrão: 3 anos
@@ -52,43 +69,100 @@ func (c *KnowledgeBaseController) GenerateKnowledgeBaseHandler(ctx echo.Context)
 		}
 	}
 
-	c.Logger.Printf("⚙️  Parâmetros: cell_resolution=%dm, days_back=%d", cellResolution, daysBack)
+	// incremental=true processa só os reports alterados desde a última execução
+	incremental := false
+	if inc := ctx.QueryParam("incremental"); inc != "" {
+		parsed, err := strconv.ParseBool(inc)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, echo.Map{
+				"error":   "incremental inválido",
+				"details": "use true ou false",
+			})
+		}
+		incremental = parsed
+	}
+
+	// hourly_features=false desliga a fase 4 (features_cell_hourly)
+	hourlyFeatures := true
+	if hf := ctx.QueryParam("hourly_features"); hf != "" {
+		parsed, err := strconv.ParseBool(hf)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, echo.Map{
+				"error":   "hourly_features inválido",
+				"details": "use true ou false",
+			})
+		}
+		hourlyFeatures = parsed
+	}
+
+	c.Logger.Printf("⚙️  Parâmetros: cell_resolution=%s, days_back=%d, incremental=%t, hourly_features=%t",
+		services.FormatCellResolution(cellResolution), daysBack, incremental, hourlyFeatures)
+
+	endDate := time.Now().In(c.Location)
+	startDate := endDate.AddDate(0, 0, -daysBack)
+
+	params := map[string]interface{}{
+		"cell_resolution": cellResolution,
+		"days_back":       daysBack,
+		"incremental":     incremental,
+		"hourly_features": hourlyFeatures,
+		"start_date":      startDate.Format("2006-01-02"),
+		"end_date":        endDate.Format("2006-01-02"),
+	}
 
-	// Conectar no Source DB (SQL Server)
-	c.Logger.Println("🔌 Conectando ao Source Database (SQL Server)...")
-	sourceDB, err := sql.Open("sqlserver", c.SourceDSN)
+	job, err := c.Jobs.Enqueue(params, func(jobCtx context.Context, job *services.KnowledgeBaseJob) error {
+		return c.runGeneration(jobCtx, job, &services.KnowledgeBaseConfig{
+			CellResolution:     cellResolution,
+			GridArea:           &c.GridArea,
+			BatchSize:          500,
+			StartDate:          startDate,
+			EndDate:            endDate,
+			Incremental:        incremental,
+			SkipHourlyFeatures: !hourlyFeatures,
+		})
+	})
 	if err != nil {
-		c.Logger.Printf("❌ Erro ao conectar no Source DB: %v", err)
-		return ctx.JSON(http.StatusInternalServerError, echo.Map{
-			"error":   "Erro ao conectar no Source DB",
+		c.Logger.Printf("❌ Erro ao enfileirar geração: %v", err)
+		return ctx.JSON(http.StatusServiceUnavailable, echo.Map{
+			"error":   "Não foi possível enfileirar a geração da base de conhecimento",
 			"details": err.Error(),
 		})
 	}
+
+	snap := job.Snapshot()
+	return ctx.JSON(http.StatusAccepted, echo.Map{
+		"status":     snap.Status,
+		"message":    "Geração da base de conhecimento enfileirada",
+		"job_id":     snap.ID,
+		"status_url": "/api/v1/knowledge-base/jobs/" + snap.ID,
+		"params":     snap.Params,
+	})
+}
+
+// runGeneration conecta nos bancos e executa o pipeline dentro de um job
+func (c *KnowledgeBaseController) runGeneration(ctx context.Context, job *services.KnowledgeBaseJob, config *services.KnowledgeBaseConfig) error {
+	// Conectar no Source DB
+	c.Logger.Printf("🔌 Conectando ao Source Database (%s)...", c.Dialect.Name())
+	sourceDB, err := sql.Open(c.Dialect.DriverName(), c.SourceDSN)
+	if err != nil {
+		return fmt.Errorf("erro ao conectar no Source DB: %w", err)
+	}
 	defer func() {
 		if err := sourceDB.Close(); err != nil {
 			c.Logger.Printf("Erro ao fechar Source DB: %v", err)
 		}
 	}()
 
-	// Testar conexão
-	if err := sourceDB.Ping(); err != nil {
-		c.Logger.Printf("❌ Source DB não responde: %v", err)
-		return ctx.JSON(http.StatusInternalServerError, echo.Map{
-			"error":   "Source DB não está acessível",
-			"details": err.Error(),
-		})
+	if err := sourceDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("source DB não está acessível: %w", err)
 	}
 	c.Logger.Println("✅ Source DB conectado")
 
-	// Conectar no Target DB (SQL Server - mesmo banco)
-	c.Logger.Println("🔌 Conectando ao Target Database (SQL Server)...")
-	targetDB, err := sql.Open("sqlserver", c.TargetDSN)
+	// Conectar no Target DB (mesmo banco)
+	c.Logger.Printf("🔌 Conectando ao Target Database (%s)...", c.Dialect.Name())
+	targetDB, err := sql.Open(c.Dialect.DriverName(), c.TargetDSN)
 	if err != nil {
-		c.Logger.Printf("❌ Erro ao conectar no Target DB: %v", err)
-		return ctx.JSON(http.StatusInternalServerError, echo.Map{
-			"error":   "Erro ao conectar no Target DB",
-			"details": err.Error(),
-		})
+		return fmt.Errorf("erro ao conectar no Target DB: %w", err)
 	}
 	defer func() {
 		if err := targetDB.Close(); err != nil {
@@ -96,53 +170,69 @@ func (c *KnowledgeBaseController) GenerateKnowledgeBaseHandler(ctx echo.Context)
 		}
 	}()
 
-	// Testar conexão
-	if err := targetDB.Ping(); err != nil {
-		c.Logger.Printf("❌ Target DB não responde: %v", err)
-		return ctx.JSON(http.StatusInternalServerError, echo.Map{
-			"error":   "Target DB não está acessível",
-			"details": err.Error(),
-		})
+	if err := targetDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("target DB não está acessível: %w", err)
 	}
 	c.Logger.Println("✅ Target DB conectado")
 
-	// Configurar gerador
-	config := &services.KnowledgeBaseConfig{
-		SourceDB:       sourceDB,
-		TargetDB:       targetDB,
-		CellResolution: cellResolution,
-		BatchSize:      500,
-		StartDate:      time.Now().AddDate(0, 0, -daysBack),
-		EndDate:        time.Now(),
-	}
+	config.SourceDB = sourceDB
+	config.TargetDB = targetDB
+	config.Dialect = c.Dialect
+	config.Progress = job
 
 	generator := services.NewKnowledgeBaseGenerator(config)
+	job.SetExecutionID(generator.ExecutionID())
 
-	// Executar geração
 	c.Logger.Println("🚀 Iniciando geração da base de conhecimento...")
 	startTime := time.Now()
 
-	if err := generator.GenerateKnowledgeBase(background); err != nil {
-		c.Logger.Printf("❌ Erro ao gerar KB: %v", err)
-		return ctx.JSON(http.StatusInternalServerError, echo.Map{
-			"error":        "Erro na geração da base de conhecimento",
-			"details":      err.Error(),
-			"elapsed_time": time.Since(startTime).String(),
+	if err := generator.GenerateKnowledgeBase(ctx); err != nil {
+		c.Logger.Printf("❌ Erro ao gerar KB após %s: %v", time.Since(startTime), err)
+		return err
+	}
+
+	c.Logger.Printf("✅ Base de conhecimento gerada com sucesso em %s", time.Since(startTime))
+	return nil
+}
+
+// ListJobsHandler lista os jobs de geração conhecidos
+func (c *KnowledgeBaseController) ListJobsHandler(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, c.Jobs.List())
+}
+
+// GetJobHandler retorna o andamento de um job (fase atual, registros e erros)
+func (c *KnowledgeBaseController) GetJobHandler(ctx echo.Context) error {
+	job, err := c.Jobs.Get(ctx.Param("id"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, echo.Map{
+			"error": "Job não encontrado",
 		})
 	}
 
-	elapsed := time.Since(startTime)
-	c.Logger.Printf("✅ Base de conhecimento gerada com sucesso em %s", elapsed)
+	return ctx.JSON(http.StatusOK, job.Snapshot())
+}
 
-	return ctx.JSON(http.StatusOK, echo.Map{
-		"status":          "success",
-		"message":         "Base de conhecimento gerada com sucesso",
-		"elapsed_time":    elapsed.String(),
-		"cell_resolution": cellResolution,
-		"days_processed":  daysBack,
-		"start_date":      config.StartDate.Format("2006-01-02"),
-		"end_date":        config.EndDate.Format("2006-01-02"),
-	})
+// CancelJobHandler cancela um job enfileirado ou em execução
+func (c *KnowledgeBaseController) CancelJobHandler(ctx echo.Context) error {
+	job, err := c.Jobs.Cancel(ctx.Param("id"))
+	switch {
+	case errors.Is(err, services.ErrJobNotFound):
+		return ctx.JSON(http.StatusNotFound, echo.Map{
+			"error": "Job não encontrado",
+		})
+	case errors.Is(err, services.ErrJobFinished):
+		return ctx.JSON(http.StatusConflict, echo.Map{
+			"error": "Job já finalizado",
+			"job":   job.Snapshot(),
+		})
+	case err != nil:
+		return ctx.JSON(http.StatusInternalServerError, echo.Map{
+			"error":   "Erro ao cancelar job",
+			"details": err.Error(),
+		})
+	}
+
+	return ctx.JSON(http.StatusAccepted, job.Snapshot())
 }
 
 // HealthCheckHandler verifica a saúde do sistema e conectividade com DBs
@@ -155,8 +245,8 @@ func (c *KnowledgeBaseController) HealthCheckHandler(ctx echo.Context) error {
 
 	checks := health["checks"].(echo.Map)
 
-	// Check Source DB (SQL Server)
-	sourceDB, err := sql.Open("sqlserver", c.SourceDSN)
+	// Check Source DB
+	sourceDB, err := sql.Open(c.Dialect.DriverName(), c.SourceDSN)
 	if err == nil {
 		defer func() {
 			if err := sourceDB.Close(); err != nil {
@@ -166,7 +256,7 @@ func (c *KnowledgeBaseController) HealthCheckHandler(ctx echo.Context) error {
 		if err := sourceDB.Ping(); err == nil {
 			checks["source_db"] = echo.Map{
 				"status":  "ok",
-				"message": "Source database (SQL Server) is accessible",
+				"message": fmt.Sprintf("Source database (%s) is accessible", c.Dialect.Name()),
 			}
 		} else {
 			checks["source_db"] = echo.Map{
@@ -183,8 +273,8 @@ func (c *KnowledgeBaseController) HealthCheckHandler(ctx echo.Context) error {
 		health["status"] = "degraded"
 	}
 
-	// Check Target DB (SQL Server)
-	targetDB, err := sql.Open("sqlserver", c.TargetDSN)
+	// Check Target DB
+	targetDB, err := sql.Open(c.Dialect.DriverName(), c.TargetDSN)
 	if err == nil {
 		defer func() {
 			if err := targetDB.Close(); err != nil {
@@ -194,20 +284,12 @@ func (c *KnowledgeBaseController) HealthCheckHandler(ctx echo.Context) error {
 		if err := targetDB.Ping(); err == nil {
 			// Verificar se tabelas existem
 			var tableCount int
-			query := `
-				SELECT COUNT(*) 
-				FROM INFORMATION_SCHEMA.TABLES 
-				WHERE (TABLE_NAME LIKE 'curated_%' 
-					OR TABLE_NAME LIKE 'external_%'
-					OR TABLE_NAME LIKE 'features_%'
-					OR TABLE_NAME LIKE 'analytics_%')
-			`
-			err := targetDB.QueryRow(query).Scan(&tableCount)
+			err := targetDB.QueryRow(c.Dialect.KnowledgeBaseTablesQuery()).Scan(&tableCount)
 
 			if err == nil && tableCount >= 8 {
 				checks["target_db"] = echo.Map{
 					"status":  "ok",
-					"message": "Target database (SQL Server) is accessible and tables exist",
+					"message": fmt.Sprintf("Target database (%s) is accessible and tables exist", c.Dialect.Name()),
 					"tables":  tableCount,
 				}
 			} else if err == nil {
@@ -250,7 +332,7 @@ func (c *KnowledgeBaseController) HealthCheckHandler(ctx echo.Context) error {
 
 // StatusHandler retorna estatísticas da base de conhecimento
 func (c *KnowledgeBaseController) StatusHandler(ctx echo.Context) error {
-	targetDB, err := sql.Open("sqlserver", c.TargetDSN)
+	targetDB, err := sql.Open(c.Dialect.DriverName(), c.TargetDSN)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, echo.Map{
 			"error": "Não foi possível conectar ao Target DB",
@@ -293,31 +375,31 @@ func (c *KnowledgeBaseController) StatusHandler(ctx echo.Context) error {
 		stats["features"] = echo.Map{"count": featureCount}
 	}
 
-	// Última execução do pipeline
+	// Última execução do pipeline (fase mais recente registrada)
 	var lastExecution time.Time
-	var lastStatus string
-	query := `
-		SELECT TOP 1 started_at, status 
+	var lastExecutionID, lastStatus, lastPhase string
+	query := c.Dialect.Limit(`
+		SELECT execution_id, started_at, status, phase
 		FROM analytics_pipeline_logs 
-		ORDER BY started_at DESC
-	`
-	err = targetDB.QueryRow(query).Scan(&lastExecution, &lastStatus)
+		ORDER BY started_at DESC`, 1)
+	err = targetDB.QueryRow(query).Scan(&lastExecutionID, &lastExecution, &lastStatus, &lastPhase)
 	if err != nil {
 		stats["last_execution"] = echo.Map{"error": "Nenhuma execução registrada"}
 	} else {
 		stats["last_execution"] = echo.Map{
-			"timestamp": lastExecution.Format(time.RFC3339),
-			"status":    lastStatus,
+			"execution_id": lastExecutionID,
+			"timestamp":    lastExecution.Format(time.RFC3339),
+			"status":       lastStatus,
+			"phase":        lastPhase,
 		}
 	}
 
 	// Última métrica de qualidade
 	var lastReport string
-	query = `
-		SELECT TOP 1 metrics 
+	query = c.Dialect.Limit(`
+		SELECT metrics 
 		FROM analytics_quality_reports 
-		ORDER BY report_date DESC
-	`
+		ORDER BY report_date DESC`, 1)
 	err = targetDB.QueryRow(query).Scan(&lastReport)
 	if err != nil {
 		stats["quality_metrics"] = echo.Map{"error": "Nenhum relatório disponível"}
@@ -328,6 +410,71 @@ func (c *KnowledgeBaseController) StatusHandler(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, stats)
 }
 
+// ExecutionLogsHandler retorna o registro de cada fase de uma execução do pipeline
+func (c *KnowledgeBaseController) ExecutionLogsHandler(ctx echo.Context) error {
+	targetDB, err := sql.Open(c.Dialect.DriverName(), c.TargetDSN)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, echo.Map{
+			"error": "Não foi possível conectar ao Target DB",
+		})
+	}
+	defer func() {
+		if err := targetDB.Close(); err != nil {
+			c.Logger.Printf("Erro ao fechar Target DB: %v", err)
+		}
+	}()
+
+	query := c.Dialect.Rebind(`
+		SELECT id, execution_id, started_at, finished_at, status, phase,
+		       records_processed, error_message, execution_time_seconds, created_at
+		FROM analytics_pipeline_logs
+		WHERE execution_id = ?
+		ORDER BY started_at
+	`)
+	rows, err := targetDB.QueryContext(ctx.Request().Context(), query, ctx.Param("execution_id"))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, echo.Map{
+			"error":   "Erro ao consultar analytics_pipeline_logs",
+			"details": err.Error(),
+		})
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			c.Logger.Printf("Erro ao fechar rows: %v", err)
+		}
+	}()
+
+	phases := []models.AnalyticsPipelineLog{}
+	for rows.Next() {
+		var l models.AnalyticsPipelineLog
+		if err := rows.Scan(&l.ID, &l.ExecutionID, &l.StartedAt, &l.FinishedAt, &l.Status, &l.Phase,
+			&l.RecordsProcessed, &l.ErrorMessage, &l.ExecutionTimeSeconds, &l.CreatedAt); err != nil {
+			return ctx.JSON(http.StatusInternalServerError, echo.Map{
+				"error":   "Erro ao ler analytics_pipeline_logs",
+				"details": err.Error(),
+			})
+		}
+		phases = append(phases, l)
+	}
+	if err := rows.Err(); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, echo.Map{
+			"error":   "Erro ao ler analytics_pipeline_logs",
+			"details": err.Error(),
+		})
+	}
+
+	if len(phases) == 0 {
+		return ctx.JSON(http.StatusNotFound, echo.Map{
+			"error": "Execução não encontrada",
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"execution_id": ctx.Param("execution_id"),
+		"phases":       phases,
+	})
+}
+
 // ============================================================================
 // ROTAS
 // ============================================================================
@@ -342,4 +489,17 @@ func (c *KnowledgeBaseController) Register(g *echo.Group) {
 
 	// Status: estatísticas da KB
 	g.GET("/knowledge-base/status", c.StatusHandler)
+
+	// Jobs: acompanhamento e cancelamento das gerações
+	g.GET("/knowledge-base/jobs", c.ListJobsHandler)
+	g.GET("/knowledge-base/jobs/:id", c.GetJobHandler)
+	g.DELETE("/knowledge-base/jobs/:id", c.CancelJobHandler)
+
+	// Execuções: registro de cada fase em analytics_pipeline_logs
+	g.GET("/knowledge-base/executions/:execution_id", c.ExecutionLogsHandler)
+
+	// Rota de teste
+	g.GET("/kb-test", func(c echo.Context) error {
+		return c.JSON(200, echo.Map{"message": "KB Test route works!"})
+	})
 }
\ No newline at end of file
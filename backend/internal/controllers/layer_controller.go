@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/paulmach/orb/geojson"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/services"
+)
+
+// MIMEGeoJSON is the media type of the layers (RFC 7946)
+const MIMEGeoJSON = "application/geo+json"
+
+// LayerController serves the knowledge base and the predictions as GeoJSON
+type LayerController struct {
+	svc services.LayerService
+}
+
+// NewLayerController creates a new instance of LayerController
+func NewLayerController(svc services.LayerService) *LayerController {
+	return &LayerController{svc: svc}
+}
+
+// Register registers the routes for the layer controller
+func (ctrl *LayerController) Register(g *echo.Group) {
+	g.GET("/layers/cells", ctrl.GetCells)
+	g.GET("/layers/incidents", ctrl.GetIncidents)
+	g.GET("/layers/predictions", ctrl.GetPredictions)
+}
+
+// parseLayerQuery reads cell_resolution, date_from, date_to, category, bbox,
+// active and limit from the query string
+func parseLayerQuery(c echo.Context, defaultResolution int) (services.LayerQuery, error) {
+	q := services.LayerQuery{
+		CellResolution: defaultResolution,
+		Category:       c.QueryParam("category"),
+	}
+
+	var err error
+	if res := c.QueryParam("cell_resolution"); res != "" {
+		q.CellResolution, err = services.ParseCellResolution(res)
+		if err != nil {
+			return q, errors.New("Invalid cell_resolution: use 500, 1000 or h3:5 to h3:10")
+		}
+	}
+	if q.From, err = optionalTime(c, "date_from", false); err != nil {
+		return q, err
+	}
+	if q.To, err = optionalTime(c, "date_to", true); err != nil {
+		return q, err
+	}
+	if q.BBox, err = optionalBBox(c); err != nil {
+		return q, err
+	}
+	if value := c.QueryParam("active"); value != "" {
+		if q.ActiveOnly, err = strconv.ParseBool(value); err != nil {
+			return q, errors.New("Invalid active")
+		}
+	}
+	if value := c.QueryParam("limit"); value != "" {
+		q.Limit, err = strconv.Atoi(value)
+		if err != nil || q.Limit < 1 || q.Limit > services.MaxIncidentsLayerLimit {
+			return q, errors.New("Invalid limit: use 1 to " + strconv.Itoa(services.MaxIncidentsLayerLimit))
+		}
+	}
+	return q, nil
+}
+
+// writeGeoJSON answers with a FeatureCollection as application/geo+json
+func writeGeoJSON(c echo.Context, fc *geojson.FeatureCollection, err error, failed string) error {
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": failed,
+		})
+	}
+	data, err := fc.MarshalJSON()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": failed,
+		})
+	}
+	return c.Blob(http.StatusOK, MIMEGeoJSON, data)
+}
+
+// GetCells handles retrieving the grid cells of a resolution (default 500m)
+// with the number of incidents in the time window and category. active=true
+// leaves out the cells without incidents.
+func (ctrl *LayerController) GetCells(c echo.Context) error {
+	q, err := parseLayerQuery(c, defaultPredictionResolution)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	fc, err := ctrl.svc.CellsLayer(c.Request().Context(), q)
+	return writeGeoJSON(c, fc, err, "Failed to retrieve cells")
+}
+
+// GetIncidents handles retrieving the curated incidents as points. Without
+// cell_resolution the incidents of every resolution are returned.
+func (ctrl *LayerController) GetIncidents(c echo.Context) error {
+	q, err := parseLayerQuery(c, 0)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	fc, err := ctrl.svc.IncidentsLayer(c.Request().Context(), q)
+	return writeGeoJSON(c, fc, err, "Failed to retrieve incidents")
+}
+
+// GetPredictions handles retrieving the predictions of a month as risk zones.
+// level=neighborhood returns the neighborhood aggregates; min_risk_level
+// (0 to 2) leaves out the lower risk levels.
+func (ctrl *LayerController) GetPredictions(c echo.Context) error {
+	year, month, cellResolution, err := predictionParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	q := services.PredictionLayerQuery{Year: year, Month: month, CellResolution: cellResolution}
+	switch c.QueryParam("level") {
+	case "", "cell":
+	case "neighborhood":
+		q.ByNeighborhood = true
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid level: use cell or neighborhood",
+		})
+	}
+	if value := c.QueryParam("min_risk_level"); value != "" {
+		q.MinRiskLevel, err = strconv.Atoi(value)
+		if err != nil || q.MinRiskLevel < 0 || q.MinRiskLevel > 2 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid min_risk_level: use 0, 1 or 2",
+			})
+		}
+	}
+
+	fc, err := ctrl.svc.PredictionsLayer(c.Request().Context(), q)
+	return writeGeoJSON(c, fc, err, "Failed to retrieve predictions")
+}
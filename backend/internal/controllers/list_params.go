@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/services"
+)
+
+const (
+	// HeaderTotalCount carries the number of rows matching the filters
+	HeaderTotalCount = "X-Total-Count"
+	// HeaderNextCursor carries the cursor of the next page, when there is one
+	HeaderNextCursor = "X-Next-Cursor"
+)
+
+// parseListQuery reads the list query parameters shared by the list endpoints:
+//
+//	name                    case-insensitive substring of the name
+//	min_weight, max_weight  weight range (inclusive)
+//	created_from, created_to created_at range, RFC 3339 or YYYY-MM-DD (inclusive)
+//	limit, offset           offset pagination (limit defaults to 100, max 1000)
+//	cursor                  cursor pagination, the X-Next-Cursor of the previous page
+//	sort                    id, name, weight or created_at; a leading "-" sorts descending
+func parseListQuery(c echo.Context) (services.ListQuery, error) {
+	q := services.ListQuery{Name: c.QueryParam("name")}
+
+	var err error
+	if q.MinWeight, err = optionalInt(c, "min_weight"); err != nil {
+		return q, err
+	}
+	if q.MaxWeight, err = optionalInt(c, "max_weight"); err != nil {
+		return q, err
+	}
+	if q.MinWeight != nil && q.MaxWeight != nil && *q.MinWeight > *q.MaxWeight {
+		return q, errors.New("Invalid weight range: min_weight is greater than max_weight")
+	}
+
+	if q.CreatedFrom, err = optionalTime(c, "created_from", false); err != nil {
+		return q, err
+	}
+	if q.CreatedTo, err = optionalTime(c, "created_to", true); err != nil {
+		return q, err
+	}
+
+	if limit, err := optionalInt(c, "limit"); err != nil {
+		return q, err
+	} else if limit != nil {
+		if *limit < 1 || *limit > services.MaxListLimit {
+			return q, errors.New("Invalid limit: use 1 to " + strconv.Itoa(services.MaxListLimit))
+		}
+		q.Limit = *limit
+	}
+	if offset, err := optionalInt(c, "offset"); err != nil {
+		return q, err
+	} else if offset != nil {
+		if *offset < 0 {
+			return q, errors.New("Invalid offset")
+		}
+		q.Offset = *offset
+	}
+
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		after, err := strconv.ParseUint(cursor, 10, 32)
+		if err != nil {
+			return q, errors.New("Invalid cursor")
+		}
+		if q.Offset > 0 {
+			return q, errors.New("Use either offset or cursor, not both")
+		}
+		id := uint(after)
+		q.After = &id
+	}
+
+	q.Sort = c.QueryParam("sort")
+	if strings.HasPrefix(q.Sort, "-") {
+		q.Sort, q.Desc = q.Sort[1:], true
+	}
+	return q, nil
+}
+
+// setListHeaders writes the pagination headers of a list response
+func setListHeaders[T any](c echo.Context, page *services.ListPage[T]) {
+	c.Response().Header().Set(HeaderTotalCount, strconv.FormatInt(page.Total, 10))
+	if page.NextCursor != nil {
+		c.Response().Header().Set(HeaderNextCursor, strconv.FormatUint(uint64(*page.NextCursor), 10))
+	}
+}
+
+func optionalInt(c echo.Context, name string) (*int, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, errors.New("Invalid " + name)
+	}
+	return &n, nil
+}
+
+// optionalTime parses RFC 3339 or a date; a date used as the end of a range
+// covers the whole day
+func optionalTime(c echo.Context, name string, endOfDay bool) (*time.Time, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, errors.New("Invalid " + name + ": use RFC 3339 or YYYY-MM-DD")
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return &t, nil
+}
+
+// parseReportQuery reads the filters of GET /reports (see ListReports)
+func parseReportQuery(c echo.Context) (services.ReportQuery, error) {
+	q := services.ReportQuery{
+		CrimeName:        c.QueryParam("crime"),
+		NeighborhoodName: c.QueryParam("neighborhood"),
+	}
+
+	var err error
+	if q.DateFrom, err = optionalTime(c, "date_from", false); err != nil {
+		return q, err
+	}
+	if q.DateTo, err = optionalTime(c, "date_to", true); err != nil {
+		return q, err
+	}
+	if q.CrimeID, err = optionalID(c, "crime_id"); err != nil {
+		return q, err
+	}
+	if q.NeighborhoodID, err = optionalID(c, "neighborhood_id"); err != nil {
+		return q, err
+	}
+	if q.MinWeight, err = optionalInt(c, "min_weight"); err != nil {
+		return q, err
+	}
+	if q.MaxWeight, err = optionalInt(c, "max_weight"); err != nil {
+		return q, err
+	}
+
+	if q.BBox, err = optionalBBox(c); err != nil {
+		return q, err
+	}
+
+	lat, lon, radius := c.QueryParam("lat"), c.QueryParam("lon"), c.QueryParam("radius")
+	if lat != "" || lon != "" || radius != "" {
+		near := &services.GeoRadius{}
+		var errLat, errLon, errRadius error
+		near.Lat, errLat = strconv.ParseFloat(lat, 64)
+		near.Lon, errLon = strconv.ParseFloat(lon, 64)
+		near.RadiusMeters, errRadius = strconv.ParseFloat(radius, 64)
+		if errLat != nil || errLon != nil || errRadius != nil || near.RadiusMeters <= 0 {
+			return q, errors.New("Invalid radius filter: lat, lon and radius (meters) are required")
+		}
+		q.Near = near
+	}
+
+	list, err := parseListQuery(c)
+	if err != nil {
+		return q, err
+	}
+	if list.After != nil {
+		return q, errors.New("Cursor pagination is not supported for reports, use offset")
+	}
+	q.Limit, q.Offset, q.Sort, q.Desc = list.Limit, list.Offset, list.Sort, list.Desc
+	return q, nil
+}
+
+// optionalBBox reads the bbox parameter (min_lon,min_lat,max_lon,max_lat)
+func optionalBBox(c echo.Context) (*services.BoundingBox, error) {
+	bbox := c.QueryParam("bbox")
+	if bbox == "" {
+		return nil, nil
+	}
+	box, err := services.ParseBoundingBox(bbox)
+	if err != nil {
+		return nil, errors.New("Invalid bbox: use min_lon,min_lat,max_lon,max_lat")
+	}
+	return &box, nil
+}
+
+func optionalID(c echo.Context, name string) (*uint, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return nil, errors.New("Invalid " + name)
+	}
+	n := uint(id)
+	return &n, nil
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -25,21 +26,20 @@ func (ctrl *NeighborhoodController) Register(g *echo.Group) {
 	g.POST("/neighborhoods", ctrl.CreateNeighborhood)
 	g.GET("/neighborhoods/:id", ctrl.GetNeighborhoodByID)
 	g.GET("/neighborhoods", ctrl.GetAllNeighborhoods)
+	g.PUT("/neighborhoods/:id", ctrl.ReplaceNeighborhood)
+	g.PATCH("/neighborhoods/:id", ctrl.PatchNeighborhood)
+	g.DELETE("/neighborhoods/:id", ctrl.DeleteNeighborhood)
 }
 
 // CreateNeighborhood handles the creation of a new neighborhood
 func (ctrl *NeighborhoodController) CreateNeighborhood(c echo.Context) error {
 	var neighborhood models.Neighborhood
 	if err := c.Bind(&neighborhood); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request body",
-		})
+		return bindError(c, err)
 	}
 
 	if err := ctrl.svc.CreateNeighborhood(c.Request().Context(), &neighborhood); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to create neighborhood",
-		})
+		return serviceError(c, err, "Neighborhood not found", "Failed to create neighborhood")
 	}
 
 	return c.JSON(http.StatusCreated, neighborhood)
@@ -65,14 +65,85 @@ func (ctrl *NeighborhoodController) GetNeighborhoodByID(c echo.Context) error {
 	return c.JSON(http.StatusOK, neighborhood)
 }
 
-// GetAllNeighborhoods handles retrieving all neighborhoods
+// GetAllNeighborhoods handles listing neighborhoods with the filters, sorting
+// and pagination of parseListQuery. The total goes in the X-Total-Count header.
 func (ctrl *NeighborhoodController) GetAllNeighborhoods(c echo.Context) error {
-	neighborhoods, err := ctrl.svc.GetAllNeighborhoods(c.Request().Context())
+	q, err := parseListQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	page, err := ctrl.svc.GetAllNeighborhoods(c.Request().Context(), q)
+	if errors.Is(err, services.ErrInvalidListQuery) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to retrieve neighborhoods",
 		})
 	}
 
-	return c.JSON(http.StatusOK, neighborhoods)
-}
\ No newline at end of file
+	setListHeaders(c, page)
+	return c.JSON(http.StatusOK, page.Items)
+}
+
+// ReplaceNeighborhood handles replacing every editable field of a neighborhood
+func (ctrl *NeighborhoodController) ReplaceNeighborhood(c echo.Context) error {
+	return ctrl.updateNeighborhood(c, false)
+}
+
+// PatchNeighborhood handles updating only the fields sent in the body
+func (ctrl *NeighborhoodController) PatchNeighborhood(c echo.Context) error {
+	return ctrl.updateNeighborhood(c, true)
+}
+
+func (ctrl *NeighborhoodController) updateNeighborhood(c echo.Context, patch bool) error {
+	id, err := parseID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid neighborhood ID",
+		})
+	}
+
+	ctx := c.Request().Context()
+	neighborhood := &models.Neighborhood{}
+	if patch {
+		// The body is decoded over the current record
+		if neighborhood, err = ctrl.svc.GetNeighborhoodByID(ctx, id); err != nil {
+			return serviceError(c, err, "Neighborhood not found", "Failed to retrieve neighborhood")
+		}
+	}
+	if err := c.Bind(neighborhood); err != nil {
+		return bindError(c, err)
+	}
+	neighborhood.NeighborhoodID = id
+
+	if err := ctrl.svc.UpdateNeighborhood(ctx, neighborhood); err != nil {
+		return serviceError(c, err, "Neighborhood not found", "Failed to update neighborhood")
+	}
+
+	updated, err := ctrl.svc.GetNeighborhoodByID(ctx, id)
+	if err != nil {
+		return serviceError(c, err, "Neighborhood not found", "Failed to retrieve neighborhood")
+	}
+	return c.JSON(http.StatusOK, updated)
+}
+
+// DeleteNeighborhood handles soft deleting a neighborhood without reports
+func (ctrl *NeighborhoodController) DeleteNeighborhood(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid neighborhood ID",
+		})
+	}
+
+	if err := ctrl.svc.DeleteNeighborhood(c.Request().Context(), id); err != nil {
+		return serviceError(c, err, "Neighborhood not found", "Failed to delete neighborhood")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
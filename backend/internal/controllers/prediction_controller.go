@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/services"
+)
+
+// defaultPredictionResolution is the grid resolution used when the
+// cell_resolution query parameter is not informed
+const defaultPredictionResolution = 500
+
+// PredictionController handles HTTP requests related to crime predictions
+type PredictionController struct {
+	svc services.PredictionService
+}
+
+// NewPredictionController creates a new instance of PredictionController
+func NewPredictionController(svc services.PredictionService) *PredictionController {
+	return &PredictionController{svc: svc}
+}
+
+// Register registers the routes for the prediction controller
+func (ctrl *PredictionController) Register(g *echo.Group) {
+	g.GET("/predictions", ctrl.GetPredictions)
+	g.POST("/training-monthly", ctrl.TrainMonthly)
+}
+
+// predictionParams reads year, month and cell_resolution from the query string
+func predictionParams(c echo.Context) (year, month, cellResolution int, err error) {
+	year, err = strconv.Atoi(c.QueryParam("year"))
+	if err != nil || year < 1 {
+		return 0, 0, 0, errors.New("Invalid or missing year")
+	}
+	month, err = strconv.Atoi(c.QueryParam("month"))
+	if err != nil || month < 1 || month > 12 {
+		return 0, 0, 0, errors.New("Invalid or missing month (1-12)")
+	}
+
+	cellResolution = defaultPredictionResolution
+	if res := c.QueryParam("cell_resolution"); res != "" {
+		cellResolution, err = services.ParseCellResolution(res)
+		if err != nil {
+			return 0, 0, 0, errors.New("Invalid cell_resolution: use 500, 1000 or h3:5 to h3:10")
+		}
+	}
+	return year, month, cellResolution, nil
+}
+
+// GetPredictions handles retrieving the stored predictions of a month.
+// level=neighborhood returns the per-neighborhood aggregates instead of cells.
+func (ctrl *PredictionController) GetPredictions(c echo.Context) error {
+	year, month, cellResolution, err := predictionParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	level := c.QueryParam("level")
+	if level != "" && level != "cell" && level != "neighborhood" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid level: use cell or neighborhood",
+		})
+	}
+
+	predictions, err := ctrl.svc.GetPredictions(c.Request().Context(), year, month, cellResolution, level == "neighborhood")
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to retrieve predictions",
+		})
+	}
+
+	response := echo.Map{
+		"year":        year,
+		"month":       month,
+		"predictions": predictions,
+	}
+	if len(predictions) == 0 {
+		response["message"] = "No predictions for this month, train the model with POST /training-monthly"
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// TrainMonthly handles training the model and saving the predictions of a month
+func (ctrl *PredictionController) TrainMonthly(c echo.Context) error {
+	year, month, cellResolution, err := predictionParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	ctx := c.Request().Context()
+	summary, err := ctrl.svc.TrainMonthly(ctx, year, month, cellResolution)
+	if errors.Is(err, services.ErrNoTrainingData) {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"error": "Not enough data to train: generate the knowledge base first",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to train prediction model",
+		})
+	}
+
+	predictions, err := ctrl.svc.GetPredictions(ctx, year, month, cellResolution, false)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to retrieve predictions",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{
+		"year":        year,
+		"month":       month,
+		"summary":     summary,
+		"predictions": predictions,
+	})
+}
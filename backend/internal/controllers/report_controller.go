@@ -1,9 +1,17 @@
 package controllers
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 
 	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
 	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/services"
@@ -21,26 +29,148 @@ func NewReportController(svc services.ReportService) *ReportController {
 
 // Register registers the routes for the report controller
 func (ctrl *ReportController) Register(g *echo.Group) {
+	g.GET("/reports", ctrl.ListReports)
+	g.GET("/reports/:id", ctrl.GetReportByID)
 	g.POST("/reports", ctrl.CreateReport)
+	g.PUT("/reports/:id", ctrl.ReplaceReport)
+	g.PATCH("/reports/:id", ctrl.PatchReport)
+	g.DELETE("/reports/:id", ctrl.DeleteReport)
 	g.POST("/reports/process-text", ctrl.ProcessReportText)
+	g.POST("/reports/bulk", ctrl.BulkReports)
+	// Preenche occurred_at dos reports antigos a partir de report_date
+	g.POST("/reports/backfill-occurred-at", ctrl.BackfillOccurredAt)
+}
+
+// ListReports handles listing reports, newest first, with their neighborhood
+// and crime. Besides limit, offset and sort (date or id, "-" for descending),
+// it accepts:
+//
+//	date_from, date_to      occurred_at range, YYYY-MM-DD (inclusive)
+//	crime_id, crime         crime ID or case-insensitive substring of its name
+//	min_weight, max_weight  crime_weight range
+//	neighborhood_id, neighborhood
+//	bbox                    min_lon,min_lat,max_lon,max_lat
+//	lat, lon, radius        radius in meters around a point
+//
+// The total goes in the X-Total-Count header.
+func (ctrl *ReportController) ListReports(c echo.Context) error {
+	q, err := parseReportQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	page, err := ctrl.svc.ListReports(c.Request().Context(), q)
+	if errors.Is(err, services.ErrInvalidListQuery) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to retrieve reports",
+		})
+	}
+
+	setListHeaders(c, page)
+	return c.JSON(http.StatusOK, page.Items)
+}
+
+// GetReportByID handles retrieving a report by ID
+func (ctrl *ReportController) GetReportByID(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid report ID",
+		})
+	}
+
+	report, err := ctrl.svc.GetReportByID(c.Request().Context(), uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Report not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to retrieve report",
+		})
+	}
+
+	return c.JSON(http.StatusOK, report)
 }
 
 // CreateReport handles the creation of a new report
 func (ctrl *ReportController) CreateReport(c echo.Context) error {
 	var report models.Report
 	if err := c.Bind(&report); err != nil {
+		return bindError(c, err)
+	}
+
+	if err := ctrl.svc.CreateReport(c.Request().Context(), &report); err != nil {
+		return serviceError(c, err, "Report not found", "Failed to create report")
+	}
+
+	return c.JSON(http.StatusCreated, report)
+}
+
+// ReplaceReport handles replacing the neighborhood, crime and date of a report
+func (ctrl *ReportController) ReplaceReport(c echo.Context) error {
+	return ctrl.updateReport(c, false)
+}
+
+// PatchReport handles updating only the fields sent in the body
+func (ctrl *ReportController) PatchReport(c echo.Context) error {
+	return ctrl.updateReport(c, true)
+}
+
+func (ctrl *ReportController) updateReport(c echo.Context, patch bool) error {
+	id, err := parseID(c)
+	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request body",
+			"error": "Invalid report ID",
 		})
 	}
 
-	if err := ctrl.svc.CreateReport(c.Request().Context(), &report); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to create report",
+	ctx := c.Request().Context()
+	report := &models.Report{}
+	if patch {
+		// The body is decoded over the current record
+		if report, err = ctrl.svc.GetReportByID(ctx, id); err != nil {
+			return serviceError(c, err, "Report not found", "Failed to retrieve report")
+		}
+	}
+	if err := c.Bind(report); err != nil {
+		return bindError(c, err)
+	}
+	report.ReportID = id
+
+	if err := ctrl.svc.UpdateReport(ctx, report); err != nil {
+		return serviceError(c, err, "Report not found", "Failed to update report")
+	}
+
+	updated, err := ctrl.svc.GetReportByID(ctx, id)
+	if err != nil {
+		return serviceError(c, err, "Report not found", "Failed to retrieve report")
+	}
+	return c.JSON(http.StatusOK, updated)
+}
+
+// DeleteReport handles soft deleting a report. The next knowledge base run
+// removes its incident.
+func (ctrl *ReportController) DeleteReport(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid report ID",
 		})
 	}
 
-	return c.JSON(http.StatusCreated, report)
+	if err := ctrl.svc.DeleteReport(c.Request().Context(), id); err != nil {
+		return serviceError(c, err, "Report not found", "Failed to delete report")
+	}
+	return c.NoContent(http.StatusNoContent)
 }
 
 // ProcessReportText handles processing of text-based report requests
@@ -52,19 +182,166 @@ func (ctrl *ReportController) ProcessReportText(c echo.Context) error {
 		})
 	}
 
-	// Validate required fields
-	if req.Name == "" || req.Latitude == "" || req.Longitude == "" || req.CrimeName == "" {
+	// The service validates the required fields and the grid area
+	report, err := ctrl.svc.ProcessReportText(c.Request().Context(), &req)
+	if errors.Is(err, services.ErrMissingReportFields) {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Missing required fields: name, latitude, longitude, crime_name",
+			"error": err.Error(),
 		})
 	}
+	if err != nil {
+		return serviceError(c, err, "Report not found", "Failed to process report")
+	}
 
-	report, err := ctrl.svc.ProcessReportText(c.Request().Context(), &req)
+	return c.JSON(http.StatusCreated, report)
+}
+
+// BackfillOccurredAt handles POST /reports/backfill-occurred-at: parses the
+// report_date of the reports saved without occurred_at and lists the ones it
+// could not parse. dry_run=true saves nothing.
+func (ctrl *ReportController) BackfillOccurredAt(c echo.Context) error {
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid dry_run",
+		})
+	}
+
+	result, err := ctrl.svc.BackfillOccurredAt(c.Request().Context(), dryRun)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to process report",
+			"error": "Failed to backfill report dates",
 		})
 	}
+	return c.JSON(http.StatusOK, result)
+}
 
-	return c.JSON(http.StatusCreated, report)
-}
\ No newline at end of file
+// maxBulkLineSize is the longest NDJSON line accepted by BulkReports
+const maxBulkLineSize = 1024 * 1024
+
+// BulkReports handles the ingestion of many report requests at once. The body
+// is either a JSON array or NDJSON (one ReportRequest per line). Rows that
+// cannot be decoded are rejected individually; the others go through
+// ReportService.ProcessReportsBulk. The response lists one result per row.
+func (ctrl *ReportController) BulkReports(c echo.Context) error {
+	rows, err := decodeBulkReports(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if len(rows) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Request body has no reports",
+		})
+	}
+
+	results := make([]services.BulkReportResult, len(rows))
+	var reqs []models.ReportRequest
+	var indexes []int
+	for i, row := range rows {
+		if row.err != nil {
+			results[i] = services.BulkReportResult{Index: i, Status: services.BulkReportRejected, Error: row.err.Error()}
+			continue
+		}
+		reqs = append(reqs, row.req)
+		indexes = append(indexes, i)
+	}
+
+	processed, err := ctrl.svc.ProcessReportsBulk(c.Request().Context(), reqs)
+	for _, result := range processed {
+		result.Index = indexes[result.Index]
+		results[result.Index] = result
+	}
+	if err != nil {
+		// Rows after the cancellation were not processed
+		for i := len(processed); i < len(reqs); i++ {
+			results[indexes[i]] = services.BulkReportResult{Index: indexes[i], Status: services.BulkReportRejected, Error: err.Error()}
+		}
+	}
+
+	accepted := 0
+	for _, result := range results {
+		if result.Status == services.BulkReportAccepted {
+			accepted++
+		}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"total":    len(results),
+		"accepted": accepted,
+		"rejected": len(results) - accepted,
+		"results":  results,
+	})
+}
+
+// bulkRow is a decoded row of a bulk request, or the reason it was rejected
+type bulkRow struct {
+	req models.ReportRequest
+	err error
+}
+
+// decodeBulkReports reads a JSON array or an NDJSON stream. Only a malformed
+// JSON array fails the whole request; invalid NDJSON lines become rejected rows.
+func decodeBulkReports(body io.Reader) ([]bulkRow, error) {
+	reader := bufio.NewReader(body)
+
+	// Skip leading whitespace to find out the format
+	for {
+		b, err := reader.Peek(1)
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if b[0] != ' ' && b[0] != '\t' && b[0] != '\r' && b[0] != '\n' {
+			break
+		}
+		_, _ = reader.ReadByte()
+	}
+
+	var rows []bulkRow
+	decodeRow := func(raw []byte) {
+		var row bulkRow
+		if err := json.Unmarshal(raw, &row.req); err != nil {
+			row.err = fmt.Errorf("invalid report: %v", err)
+		}
+		rows = append(rows, row)
+	}
+
+	if b, _ := reader.Peek(1); b[0] == '[' {
+		decoder := json.NewDecoder(reader)
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		for decoder.More() {
+			var raw json.RawMessage
+			if err := decoder.Decode(&raw); err != nil {
+				return nil, fmt.Errorf("invalid JSON array at item %d: %v", len(rows), err)
+			}
+			decodeRow(raw)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, fmt.Errorf("invalid JSON array: %v", err)
+		}
+		return rows, nil
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxBulkLineSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		decodeRow(line)
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("NDJSON line %d is longer than %d bytes", len(rows)+1, maxBulkLineSize)
+		}
+		return nil, err
+	}
+	return rows, nil
+}
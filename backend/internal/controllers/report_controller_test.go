@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeBulkReports(t *testing.T) {
+	centro := `{"name":"Centro","latitude":-22.9056,"longitude":-47.0608,"crime_name":"Furto","report_date":"2024-01-10"}`
+	cambui := `{"name":"Cambuí","latitude":-22.8989,"longitude":-47.0523,"crime_name":"Roubo","report_date":"2024-01-11"}`
+
+	tests := []struct {
+		name string
+		body string
+		// names são os bairros esperados por linha; "" é uma linha rejeitada
+		names   []string
+		wantErr string
+	}{
+		{name: "array", body: "[" + centro + "," + cambui + "]", names: []string{"Centro", "Cambuí"}},
+		{name: "array com espaços antes", body: "\n  [" + centro + "]", names: []string{"Centro"}},
+		{name: "array vazio", body: "[]", names: nil},
+		{name: "ndjson", body: centro + "\n" + cambui + "\n", names: []string{"Centro", "Cambuí"}},
+		{name: "ndjson com linhas em branco", body: "\n" + centro + "\n\n   \n" + cambui, names: []string{"Centro", "Cambuí"}},
+		{name: "ndjson com linha inválida no meio", body: centro + "\n{\"name\": \n" + cambui, names: []string{"Centro", "", "Cambuí"}},
+		{name: "corpo vazio", body: "", names: nil},
+		{name: "corpo só com espaços", body: " \n\t", names: nil},
+		{name: "array malformado", body: "[" + centro + ",", wantErr: "invalid JSON array at item 1"},
+		{name: "array sem fechar", body: "[" + centro, wantErr: "invalid JSON array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := decodeBulkReports(strings.NewReader(tt.body))
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("esperava erro %q, obteve: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("esperava sem erro, obteve: %v", err)
+			}
+			if len(rows) != len(tt.names) {
+				t.Fatalf("esperava %d linhas, obteve: %d", len(tt.names), len(rows))
+			}
+			for i, name := range tt.names {
+				if name == "" {
+					if rows[i].err == nil || !strings.HasPrefix(rows[i].err.Error(), "invalid report: ") {
+						t.Errorf("esperava a linha %d rejeitada, obteve: %+v", i, rows[i])
+					}
+					continue
+				}
+				if rows[i].err != nil || rows[i].req.Name != name {
+					t.Errorf("esperava a linha %d do bairro %s, obteve: %+v", i, name, rows[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/services"
+)
+
+// MIMEVectorTile is the media type of the Mapbox Vector Tiles
+const MIMEVectorTile = "application/vnd.mapbox-vector-tile"
+
+// tileMaxAge is the browser cache of a tile, in seconds
+const tileMaxAge = 300
+
+// TileController serves the incidents, cells and predictions as vector tiles
+type TileController struct {
+	svc services.TileService
+}
+
+// NewTileController creates a new instance of TileController
+func NewTileController(svc services.TileService) *TileController {
+	return &TileController{svc: svc}
+}
+
+// Register registers the routes for the tile controller
+func (ctrl *TileController) Register(g *echo.Group) {
+	g.GET("/tiles/:layer/:z/:x/:y", ctrl.GetTile)
+}
+
+// parseTileQuery reads cell_resolution, date_from, date_to, category, year
+// and month from the query string
+func parseTileQuery(c echo.Context) (services.TileQuery, error) {
+	layerQuery, err := parseLayerQuery(c, defaultPredictionResolution)
+	if err != nil {
+		return services.TileQuery{}, err
+	}
+	q := services.TileQuery{
+		CellResolution: layerQuery.CellResolution,
+		From:           layerQuery.From,
+		To:             layerQuery.To,
+		Category:       layerQuery.Category,
+	}
+	if value := c.QueryParam("year"); value != "" {
+		q.Year, err = strconv.Atoi(value)
+		if err != nil || q.Year < 1 {
+			return q, errors.New("Invalid year")
+		}
+	}
+	if value := c.QueryParam("month"); value != "" {
+		q.Month, err = strconv.Atoi(value)
+		if err != nil || q.Month < 1 || q.Month > 12 {
+			return q, errors.New("Invalid month (1-12)")
+		}
+	}
+	return q, nil
+}
+
+// GetTile handles GET /tiles/{layer}/{z}/{x}/{y}.pbf for the incidents,
+// cells and predictions layers. Tiles outside the grid area are empty.
+func (ctrl *TileController) GetTile(c echo.Context) error {
+	y, ok := strings.CutSuffix(c.Param("y"), ".pbf")
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Tiles are served as {z}/{x}/{y}.pbf",
+		})
+	}
+	var coords [3]uint32
+	for i, value := range []string{c.Param("z"), c.Param("x"), y} {
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid tile coordinates",
+			})
+		}
+		coords[i] = uint32(n)
+	}
+
+	q, err := parseTileQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	data, err := ctrl.svc.Tile(c.Request().Context(), c.Param("layer"), coords[0], coords[1], coords[2], q)
+	switch {
+	case errors.Is(err, services.ErrUnknownTileLayer):
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Unknown layer: use incidents, cells or predictions",
+		})
+	case errors.Is(err, services.ErrInvalidTile):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid tile coordinates",
+		})
+	case errors.Is(err, services.ErrMissingPeriod):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "year and month are required for the predictions layer",
+		})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to render tile",
+		})
+	}
+
+	c.Response().Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(tileMaxAge))
+	return c.Blob(http.StatusOK, MIMEVectorTile, data)
+}
@@ -1,35 +1,76 @@
 package database
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
 
 	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/config"
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlserver"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
 func Connect(cfg *config.Config) (*gorm.DB, error) {
-	// Construir DSN para SQL Server
-	dsn := fmt.Sprintf(
-		"sqlserver://%s:%s@%s:%s?database=%s",
-		cfg.DBUser,
-		cfg.DBPassword,
-		cfg.DBHost,
-		cfg.DBPort,
-		cfg.DBName,
-	)
-
-	db, err := gorm.Open(sqlserver.Open(dsn), &gorm.Config{
+	// Escolher o dialector conforme DB_DRIVER (SQL Server, PostgreSQL ou SQLite)
+	var dialector gorm.Dialector
+	switch cfg.DBDriver {
+	case "postgres":
+		dialector = postgres.Open(cfg.DSN())
+	case "sqlite":
+		dialector = sqlite.Open(cfg.DSN())
+	case "sqlserver":
+		dialector = sqlserver.Open(cfg.DSN())
+	default:
+		return nil, fmt.Errorf("driver de banco não suportado: %q", cfg.DBDriver)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 		DisableForeignKeyConstraintWhenMigrating: true, // 👈 ESSENCIAL
+		// Violações de índice único viram gorm.ErrDuplicatedKey em qualquer banco
+		TranslateError: true,
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println("✅ Conectado ao SQL Server com sucesso!")
+	log.Printf("✅ Conectado ao banco (%s) com sucesso!", cfg.DBDriver)
+	return db, nil
+}
+
+// SQLDriverName retorna o nome do driver database/sql para o DB_DRIVER configurado.
+// Os drivers são registrados pelos dialectors do GORM importados neste pacote.
+func SQLDriverName(driver string) (string, error) {
+	switch driver {
+	case "postgres":
+		return "pgx", nil
+	case "sqlite":
+		return "sqlite", nil
+	case "sqlserver":
+		return "sqlserver", nil
+	default:
+		return "", fmt.Errorf("driver de banco não suportado: %q", driver)
+	}
+}
+
+// OpenSQL abre uma conexão database/sql (sem GORM), usada pelas ferramentas de linha de comando
+func OpenSQL(cfg *config.Config) (*sql.DB, error) {
+	driverName, err := SQLDriverName(cfg.DBDriver)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(driverName, cfg.DSN())
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
 	return db, nil
-}
\ No newline at end of file
+}
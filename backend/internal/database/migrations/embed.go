@@ -2,5 +2,5 @@ package migrations
 
 import "embed"
 
-//go:embed *.sql
+//go:embed *.sql sqlserver/*.sql postgres/*.sql sqlite/*.sql
 var Files embed.FS
\ No newline at end of file
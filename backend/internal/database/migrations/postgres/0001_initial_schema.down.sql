@@ -0,0 +1,17 @@
+-- ============================================================================
+-- 0001 - Remove o schema inicial (PostgreSQL)
+-- ATENÇÃO: apaga também os dados da aplicação (reports, crimes, neighborhoods)
+-- ============================================================================
+
+DROP TABLE IF EXISTS analytics_pipeline_logs;
+DROP TABLE IF EXISTS analytics_quality_reports;
+DROP TABLE IF EXISTS cell_neighborhoods;
+DROP TABLE IF EXISTS features_cell_monthly;
+DROP TABLE IF EXISTS features_cell_hourly;
+DROP TABLE IF EXISTS external_holidays;
+DROP TABLE IF EXISTS curated_cells;
+DROP TABLE IF EXISTS curated_incidents;
+DROP TABLE IF EXISTS predict_crimes;
+DROP TABLE IF EXISTS reports;
+DROP TABLE IF EXISTS neighborhoods;
+DROP TABLE IF EXISTS crimes;
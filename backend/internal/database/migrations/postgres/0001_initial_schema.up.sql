@@ -0,0 +1,187 @@
+-- ============================================================================
+-- 0001 - Schema inicial (PostgreSQL)
+-- Espelha os modelos GORM; usa IF NOT EXISTS para poder ser aplicada em bancos
+-- já criados pelo AutoMigrate do servidor.
+-- ============================================================================
+
+-- ----------------------------------------------------------------------------
+-- Tabelas da aplicação
+-- ----------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS crimes (
+    crime_id     BIGSERIAL    PRIMARY KEY,
+    crime_name   VARCHAR(255) NOT NULL,
+    crime_weight BIGINT       NOT NULL,
+    created_at   TIMESTAMPTZ,
+    updated_at   TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS neighborhoods (
+    neighborhood_id     BIGSERIAL    PRIMARY KEY,
+    name                VARCHAR(255) NOT NULL,
+    latitude            TEXT         NOT NULL,
+    longitude           TEXT         NOT NULL,
+    neighborhood_weight BIGINT       NOT NULL,
+    created_at          TIMESTAMPTZ,
+    updated_at          TIMESTAMPTZ
+);
+
+-- report_date_formated não está no modelo GORM, mas é lida pelo pipeline da KB
+CREATE TABLE IF NOT EXISTS reports (
+    report_id            BIGSERIAL    PRIMARY KEY,
+    neighborhood_id      BIGINT       NOT NULL,
+    crime_id             BIGINT       NOT NULL,
+    report_date          TEXT         NOT NULL,
+    report_date_formated TEXT,
+    created_at           TIMESTAMPTZ,
+    updated_at           TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS predict_crimes (
+    predict_crime_id BIGSERIAL    PRIMARY KEY,
+    neighborhood     VARCHAR(255) NOT NULL,
+    risk_level       BIGINT       NOT NULL
+);
+
+-- ----------------------------------------------------------------------------
+-- Knowledge Base - Curated
+-- ----------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS curated_incidents (
+    id              VARCHAR(50)   PRIMARY KEY,
+    occurred_at     TIMESTAMPTZ   NOT NULL,
+    category        VARCHAR(50)   NOT NULL,
+    severity        BIGINT        NOT NULL,
+    latitude        DECIMAL(10,8) NOT NULL,
+    longitude       DECIMAL(11,8) NOT NULL,
+    neighborhood    VARCHAR(100),
+    confidence      FLOAT,
+    source          VARCHAR(50)   DEFAULT 'legacy_reports',
+    cell_id         VARCHAR(50),
+    cell_resolution BIGINT,
+    created_at      TIMESTAMPTZ,
+    updated_at      TIMESTAMPTZ,
+    CONSTRAINT chk_curated_incidents_severity CHECK (severity >= 1 AND severity <= 10),
+    CONSTRAINT chk_curated_incidents_confidence CHECK (confidence >= 0 AND confidence <= 1)
+);
+
+CREATE INDEX IF NOT EXISTS idx_curated_incidents_occurred_at ON curated_incidents (occurred_at);
+CREATE INDEX IF NOT EXISTS idx_curated_incidents_category ON curated_incidents (category);
+CREATE INDEX IF NOT EXISTS idx_curated_incidents_severity ON curated_incidents (severity);
+CREATE INDEX IF NOT EXISTS idx_curated_incidents_cell_id ON curated_incidents (cell_id);
+CREATE INDEX IF NOT EXISTS idx_lat_lng ON curated_incidents (latitude, longitude);
+
+CREATE TABLE IF NOT EXISTS curated_cells (
+    cell_id         VARCHAR(50)   PRIMARY KEY,
+    cell_resolution BIGINT        NOT NULL,
+    city            VARCHAR(50)   DEFAULT 'Campinas',
+    center_lat      DECIMAL(10,8) NOT NULL,
+    center_lng      DECIMAL(11,8) NOT NULL,
+    bounds_json     TEXT,
+    created_at      TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_curated_cells_cell_resolution ON curated_cells (cell_resolution);
+CREATE INDEX IF NOT EXISTS idx_curated_cells_city ON curated_cells (city);
+CREATE INDEX IF NOT EXISTS idx_center ON curated_cells (center_lat, center_lng);
+
+-- ----------------------------------------------------------------------------
+-- Knowledge Base - External
+-- ----------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS external_holidays (
+    id         BIGSERIAL    PRIMARY KEY,
+    date       DATE         NOT NULL,
+    name       VARCHAR(100) NOT NULL,
+    type       VARCHAR(50),
+    city       VARCHAR(50)  DEFAULT 'Campinas',
+    created_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS unique_date_city ON external_holidays (date, city);
+CREATE INDEX IF NOT EXISTS idx_external_holidays_date ON external_holidays (date);
+CREATE INDEX IF NOT EXISTS idx_external_holidays_city ON external_holidays (city);
+
+-- ----------------------------------------------------------------------------
+-- Knowledge Base - Features
+-- ----------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS features_cell_hourly (
+    id                 BIGSERIAL    PRIMARY KEY,
+    cell_id            VARCHAR(50)  NOT NULL,
+    ts                 TIMESTAMPTZ  NOT NULL,
+    y_count            BIGINT       DEFAULT 0,
+    lag_1h             BIGINT       DEFAULT 0,
+    lag_24h            BIGINT       DEFAULT 0,
+    lag_7d             BIGINT       DEFAULT 0,
+    roll_3h_sum        BIGINT       DEFAULT 0,
+    roll_24h_sum       BIGINT       DEFAULT 0,
+    roll_7d_sum        BIGINT       DEFAULT 0,
+    roll_7d_avg        FLOAT,
+    roll_7d_std        FLOAT,
+    dow                BIGINT,
+    hour               BIGINT,
+    is_weekend         BOOLEAN,
+    is_business_hours  BOOLEAN,
+    holiday            BOOLEAN      DEFAULT false,
+    day_before_holiday BOOLEAN      DEFAULT false,
+    day_after_holiday  BOOLEAN      DEFAULT false,
+    neighbor_avg_crime FLOAT,
+    created_at         TIMESTAMPTZ,
+    updated_at         TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS unique_cell_ts ON features_cell_hourly (cell_id, ts);
+CREATE INDEX IF NOT EXISTS idx_features_cell_hourly_cell_id ON features_cell_hourly (cell_id);
+CREATE INDEX IF NOT EXISTS idx_features_cell_hourly_ts ON features_cell_hourly (ts);
+CREATE INDEX IF NOT EXISTS idx_features_cell_hourly_dow ON features_cell_hourly (dow);
+CREATE INDEX IF NOT EXISTS idx_features_cell_hourly_hour ON features_cell_hourly (hour);
+
+CREATE TABLE IF NOT EXISTS features_cell_monthly (
+    cell_id       VARCHAR(50) NOT NULL,
+    "year"        INT         NOT NULL,
+    "month"       INT         NOT NULL,
+    y_count_month INT         NOT NULL DEFAULT 0,
+    lag_1m        INT         NOT NULL DEFAULT 0,
+    lag_3m        INT         NOT NULL DEFAULT 0,
+    PRIMARY KEY (cell_id, "year", "month")
+);
+
+CREATE INDEX IF NOT EXISTS idx_features_cell_monthly_year_month ON features_cell_monthly ("year", "month");
+
+CREATE TABLE IF NOT EXISTS cell_neighborhoods (
+    cell_id      VARCHAR(50)      PRIMARY KEY,
+    neighborhood VARCHAR(100)     NOT NULL,
+    distance     DOUBLE PRECISION NULL
+);
+
+-- ----------------------------------------------------------------------------
+-- Knowledge Base - Analytics
+-- ----------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS analytics_quality_reports (
+    id          BIGSERIAL    PRIMARY KEY,
+    report_date DATE         NOT NULL,
+    metrics     TEXT         NOT NULL,
+    created_at  TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_analytics_quality_reports_report_date ON analytics_quality_reports (report_date);
+
+CREATE TABLE IF NOT EXISTS analytics_pipeline_logs (
+    id                     BIGSERIAL    PRIMARY KEY,
+    execution_id           VARCHAR(36)  NOT NULL,
+    started_at             TIMESTAMPTZ  NOT NULL,
+    finished_at            TIMESTAMPTZ,
+    status                 VARCHAR(20),
+    phase                  VARCHAR(50),
+    records_processed      BIGINT,
+    error_message          TEXT,
+    execution_time_seconds BIGINT,
+    created_at             TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pipeline_logs_execution_phase ON analytics_pipeline_logs (execution_id, phase);
+CREATE INDEX IF NOT EXISTS idx_analytics_pipeline_logs_started_at ON analytics_pipeline_logs (started_at);
+CREATE INDEX IF NOT EXISTS idx_analytics_pipeline_logs_status ON analytics_pipeline_logs (status);
@@ -0,0 +1,6 @@
+-- Marca d'água das execuções incrementais da base de conhecimento
+CREATE TABLE IF NOT EXISTS analytics_watermarks (
+    name         VARCHAR(100) PRIMARY KEY,
+    watermark_at TIMESTAMPTZ  NOT NULL,
+    updated_at   TIMESTAMPTZ
+);
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_predict_crimes_period;
+
+ALTER TABLE predict_crimes DROP COLUMN IF EXISTS created_at;
+ALTER TABLE predict_crimes DROP COLUMN IF EXISTS model_version;
+ALTER TABLE predict_crimes DROP COLUMN IF EXISTS predicted_count;
+ALTER TABLE predict_crimes DROP COLUMN IF EXISTS longitude;
+ALTER TABLE predict_crimes DROP COLUMN IF EXISTS latitude;
+ALTER TABLE predict_crimes DROP COLUMN IF EXISTS cell_id;
+ALTER TABLE predict_crimes DROP COLUMN IF EXISTS cell_resolution;
+ALTER TABLE predict_crimes DROP COLUMN IF EXISTS "month";
+ALTER TABLE predict_crimes DROP COLUMN IF EXISTS "year";
@@ -0,0 +1,12 @@
+-- Previsões mensais por célula e por bairro geradas pelo modelo do backend
+ALTER TABLE predict_crimes ADD COLUMN IF NOT EXISTS "year"          BIGINT      NOT NULL DEFAULT 0;
+ALTER TABLE predict_crimes ADD COLUMN IF NOT EXISTS "month"         BIGINT      NOT NULL DEFAULT 0;
+ALTER TABLE predict_crimes ADD COLUMN IF NOT EXISTS cell_resolution BIGINT;
+ALTER TABLE predict_crimes ADD COLUMN IF NOT EXISTS cell_id         VARCHAR(50);
+ALTER TABLE predict_crimes ADD COLUMN IF NOT EXISTS latitude        FLOAT;
+ALTER TABLE predict_crimes ADD COLUMN IF NOT EXISTS longitude       FLOAT;
+ALTER TABLE predict_crimes ADD COLUMN IF NOT EXISTS predicted_count FLOAT;
+ALTER TABLE predict_crimes ADD COLUMN IF NOT EXISTS model_version   VARCHAR(50);
+ALTER TABLE predict_crimes ADD COLUMN IF NOT EXISTS created_at      TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_predict_crimes_period ON predict_crimes ("year", "month", cell_resolution);
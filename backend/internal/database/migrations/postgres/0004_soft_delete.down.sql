@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_reports_deleted_at;
+DROP INDEX IF EXISTS idx_crimes_deleted_at;
+DROP INDEX IF EXISTS idx_neighborhoods_deleted_at;
+
+ALTER TABLE reports       DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE crimes        DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE neighborhoods DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,8 @@
+-- Soft delete de bairros, crimes e reports
+ALTER TABLE neighborhoods ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE crimes        ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE reports       ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS idx_neighborhoods_deleted_at ON neighborhoods (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_crimes_deleted_at        ON crimes (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_reports_deleted_at       ON reports (deleted_at);
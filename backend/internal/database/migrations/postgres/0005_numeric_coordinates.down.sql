@@ -0,0 +1,9 @@
+-- Volta as coordenadas para texto. Os bairros unidos pelo up continuam
+-- apagados (deleted_at), com os reports no bairro que ficou.
+ALTER TABLE neighborhoods
+    ALTER COLUMN latitude  TYPE TEXT USING COALESCE(latitude::text, ''),
+    ALTER COLUMN longitude TYPE TEXT USING COALESCE(longitude::text, '');
+
+ALTER TABLE neighborhoods
+    ALTER COLUMN latitude  SET NOT NULL,
+    ALTER COLUMN longitude SET NOT NULL;
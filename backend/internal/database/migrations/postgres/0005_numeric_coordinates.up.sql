@@ -0,0 +1,69 @@
+-- Coordenadas dos bairros passam de texto para número. Espaços e vírgula
+-- decimal são normalizados; o que não for número fica NULL. O ::text permite
+-- reaplicar sobre colunas já numéricas.
+ALTER TABLE neighborhoods
+    ALTER COLUMN latitude  DROP NOT NULL,
+    ALTER COLUMN longitude DROP NOT NULL;
+
+ALTER TABLE neighborhoods
+    ALTER COLUMN latitude TYPE DOUBLE PRECISION USING (
+        CASE WHEN REPLACE(TRIM(latitude::text), ',', '.') ~ '^[+-]?([0-9]+\.?[0-9]*|\.[0-9]+)$'
+             THEN CAST(REPLACE(TRIM(latitude::text), ',', '.') AS DOUBLE PRECISION)
+        END),
+    ALTER COLUMN longitude TYPE DOUBLE PRECISION USING (
+        CASE WHEN REPLACE(TRIM(longitude::text), ',', '.') ~ '^[+-]?([0-9]+\.?[0-9]*|\.[0-9]+)$'
+             THEN CAST(REPLACE(TRIM(longitude::text), ',', '.') AS DOUBLE PRECISION)
+        END);
+
+-- Bairros que só diferiam na escrita das coordenadas ("-22.9056" e "-22.90560")
+-- são unidos no de menor id: os reports passam para ele, os pesos são somados
+-- e os demais recebem deleted_at
+UPDATE reports
+SET neighborhood_id = (
+        SELECT MIN(k.neighborhood_id)
+        FROM neighborhoods d
+        JOIN neighborhoods k ON k.latitude = d.latitude AND k.longitude = d.longitude
+        WHERE d.neighborhood_id = reports.neighborhood_id
+          AND k.deleted_at IS NULL
+    ),
+    updated_at = CURRENT_TIMESTAMP
+WHERE neighborhood_id IN (
+    SELECT d.neighborhood_id
+    FROM neighborhoods d
+    WHERE d.deleted_at IS NULL
+      AND EXISTS (
+          SELECT 1 FROM neighborhoods k
+          WHERE k.latitude = d.latitude AND k.longitude = d.longitude
+            AND k.deleted_at IS NULL AND k.neighborhood_id < d.neighborhood_id
+      )
+);
+
+UPDATE neighborhoods
+SET neighborhood_weight = (
+        SELECT SUM(d.neighborhood_weight)
+        FROM neighborhoods d
+        WHERE d.latitude = neighborhoods.latitude AND d.longitude = neighborhoods.longitude
+          AND d.deleted_at IS NULL
+    ),
+    updated_at = CURRENT_TIMESTAMP
+WHERE deleted_at IS NULL
+  AND EXISTS (
+      SELECT 1 FROM neighborhoods d
+      WHERE d.latitude = neighborhoods.latitude AND d.longitude = neighborhoods.longitude
+        AND d.deleted_at IS NULL AND d.neighborhood_id > neighborhoods.neighborhood_id
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM neighborhoods k
+      WHERE k.latitude = neighborhoods.latitude AND k.longitude = neighborhoods.longitude
+        AND k.deleted_at IS NULL AND k.neighborhood_id < neighborhoods.neighborhood_id
+  );
+
+UPDATE neighborhoods
+SET deleted_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE deleted_at IS NULL
+  AND EXISTS (
+      SELECT 1 FROM neighborhoods k
+      WHERE k.latitude = neighborhoods.latitude AND k.longitude = neighborhoods.longitude
+        AND k.deleted_at IS NULL AND k.neighborhood_id < neighborhoods.neighborhood_id
+  );
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS neighborhood_boundaries;
@@ -0,0 +1,16 @@
+-- Limites oficiais dos bairros (GeoJSON em lon/lat), um por bairro. O retângulo
+-- envolvente permite achar os limites de uma área sem ler a geometria.
+CREATE TABLE IF NOT EXISTS neighborhood_boundaries (
+    boundary_id     BIGSERIAL        PRIMARY KEY,
+    neighborhood_id BIGINT           NOT NULL REFERENCES neighborhoods (neighborhood_id),
+    geometry        TEXT             NOT NULL,
+    min_lat         DOUBLE PRECISION NOT NULL,
+    min_lon         DOUBLE PRECISION NOT NULL,
+    max_lat         DOUBLE PRECISION NOT NULL,
+    max_lon         DOUBLE PRECISION NOT NULL,
+    source          VARCHAR(255),
+    created_at      TIMESTAMPTZ,
+    updated_at      TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_neighborhood_boundaries_neighborhood ON neighborhood_boundaries (neighborhood_id);
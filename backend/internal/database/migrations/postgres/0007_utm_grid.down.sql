@@ -0,0 +1,2 @@
+-- As células da grade em graus não são recriadas: basta gerar a base de
+-- conhecimento novamente.
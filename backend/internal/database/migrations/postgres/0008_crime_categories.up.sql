@@ -0,0 +1,14 @@
+-- Taxonomia de crimes: categoria, subcategoria, peso padrão dos crimes novos e
+-- sinônimos (array JSON) usados para classificar os nomes dos crimes. O
+-- servidor grava a taxonomia padrão quando a tabela está vazia.
+CREATE TABLE IF NOT EXISTS crime_categories (
+    category_id    BIGSERIAL    PRIMARY KEY,
+    category       VARCHAR(100) NOT NULL,
+    subcategory    VARCHAR(100) NOT NULL,
+    default_weight BIGINT       NOT NULL,
+    synonyms       TEXT,
+    created_at     TIMESTAMPTZ,
+    updated_at     TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_crime_categories_name ON crime_categories (category, subcategory);
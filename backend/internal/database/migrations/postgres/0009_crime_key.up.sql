@@ -0,0 +1,7 @@
+-- Chave canônica do nome do crime (minúsculas, sem acentos e pontuação, com os
+-- sinônimos resolvidos), única entre os crimes não apagados. Os crimes antigos
+-- ficam sem chave até rodar a consolidação de duplicados
+-- (POST /api/v1/crimes/merge ou import -merge-crimes), que preenche as chaves.
+ALTER TABLE crimes ADD COLUMN IF NOT EXISTS crime_key VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_crimes_crime_key ON crimes (crime_key) WHERE crime_key IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_reports_occurred_at;
+
+ALTER TABLE reports DROP COLUMN IF EXISTS occurred_at;
@@ -0,0 +1 @@
+-- O índice antigo não é recriado: ele é incompatível com uma linha por fase.
@@ -0,0 +1,5 @@
+-- Remove o índice único antigo apenas em analytics_pipeline_logs.execution_id,
+-- criado pelo AutoMigrate dos bancos anteriores às migrations versionadas: com
+-- uma linha por fase ele impede gravar mais de uma fase por execução. O
+-- servidor não roda mais o AutoMigrate, então a remoção passa a ser daqui.
+DROP INDEX IF EXISTS idx_analytics_pipeline_logs_execution_id;
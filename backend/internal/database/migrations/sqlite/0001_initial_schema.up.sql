@@ -0,0 +1,187 @@
+-- ============================================================================
+-- 0001 - Schema inicial (SQLite)
+-- Espelha os modelos GORM; usa IF NOT EXISTS para poder ser aplicada em bancos
+-- já criados pelo AutoMigrate do servidor.
+-- ============================================================================
+
+-- ----------------------------------------------------------------------------
+-- Tabelas da aplicação
+-- ----------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS crimes (
+    crime_id     integer   PRIMARY KEY AUTOINCREMENT,
+    crime_name   text      NOT NULL,
+    crime_weight integer   NOT NULL,
+    created_at   datetime,
+    updated_at   datetime
+);
+
+CREATE TABLE IF NOT EXISTS neighborhoods (
+    neighborhood_id     integer   PRIMARY KEY AUTOINCREMENT,
+    name                text      NOT NULL,
+    latitude            text      NOT NULL,
+    longitude           text      NOT NULL,
+    neighborhood_weight integer   NOT NULL,
+    created_at          datetime,
+    updated_at          datetime
+);
+
+-- report_date_formated não está no modelo GORM, mas é lida pelo pipeline da KB
+CREATE TABLE IF NOT EXISTS reports (
+    report_id            integer   PRIMARY KEY AUTOINCREMENT,
+    neighborhood_id      integer   NOT NULL,
+    crime_id             integer   NOT NULL,
+    report_date          text      NOT NULL,
+    report_date_formated text,
+    created_at           datetime,
+    updated_at           datetime
+);
+
+CREATE TABLE IF NOT EXISTS predict_crimes (
+    predict_crime_id integer PRIMARY KEY AUTOINCREMENT,
+    neighborhood     text    NOT NULL,
+    risk_level       integer NOT NULL
+);
+
+-- ----------------------------------------------------------------------------
+-- Knowledge Base - Curated
+-- ----------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS curated_incidents (
+    id              text          PRIMARY KEY,
+    occurred_at     datetime      NOT NULL,
+    category        text          NOT NULL,
+    severity        integer       NOT NULL,
+    latitude        decimal(10,8) NOT NULL,
+    longitude       decimal(11,8) NOT NULL,
+    neighborhood    text,
+    confidence      real,
+    source          text          DEFAULT 'legacy_reports',
+    cell_id         text,
+    cell_resolution integer,
+    created_at      datetime,
+    updated_at      datetime,
+    CONSTRAINT chk_curated_incidents_severity CHECK (severity >= 1 AND severity <= 10),
+    CONSTRAINT chk_curated_incidents_confidence CHECK (confidence >= 0 AND confidence <= 1)
+);
+
+CREATE INDEX IF NOT EXISTS idx_curated_incidents_occurred_at ON curated_incidents (occurred_at);
+CREATE INDEX IF NOT EXISTS idx_curated_incidents_category ON curated_incidents (category);
+CREATE INDEX IF NOT EXISTS idx_curated_incidents_severity ON curated_incidents (severity);
+CREATE INDEX IF NOT EXISTS idx_curated_incidents_cell_id ON curated_incidents (cell_id);
+CREATE INDEX IF NOT EXISTS idx_lat_lng ON curated_incidents (latitude, longitude);
+
+CREATE TABLE IF NOT EXISTS curated_cells (
+    cell_id         text          PRIMARY KEY,
+    cell_resolution integer       NOT NULL,
+    city            text          DEFAULT 'Campinas',
+    center_lat      decimal(10,8) NOT NULL,
+    center_lng      decimal(11,8) NOT NULL,
+    bounds_json     text,
+    created_at      datetime
+);
+
+CREATE INDEX IF NOT EXISTS idx_curated_cells_cell_resolution ON curated_cells (cell_resolution);
+CREATE INDEX IF NOT EXISTS idx_curated_cells_city ON curated_cells (city);
+CREATE INDEX IF NOT EXISTS idx_center ON curated_cells (center_lat, center_lng);
+
+-- ----------------------------------------------------------------------------
+-- Knowledge Base - External
+-- ----------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS external_holidays (
+    id         integer  PRIMARY KEY AUTOINCREMENT,
+    date       date     NOT NULL,
+    name       text     NOT NULL,
+    type       text,
+    city       text     DEFAULT 'Campinas',
+    created_at datetime
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS unique_date_city ON external_holidays (date, city);
+CREATE INDEX IF NOT EXISTS idx_external_holidays_date ON external_holidays (date);
+CREATE INDEX IF NOT EXISTS idx_external_holidays_city ON external_holidays (city);
+
+-- ----------------------------------------------------------------------------
+-- Knowledge Base - Features
+-- ----------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS features_cell_hourly (
+    id                 integer   PRIMARY KEY AUTOINCREMENT,
+    cell_id            text      NOT NULL,
+    ts                 datetime  NOT NULL,
+    y_count            integer   DEFAULT 0,
+    lag_1h             integer   DEFAULT 0,
+    lag_24h            integer   DEFAULT 0,
+    lag_7d             integer   DEFAULT 0,
+    roll_3h_sum        integer   DEFAULT 0,
+    roll_24h_sum       integer   DEFAULT 0,
+    roll_7d_sum        integer   DEFAULT 0,
+    roll_7d_avg        real,
+    roll_7d_std        real,
+    dow                integer,
+    hour               integer,
+    is_weekend         numeric,
+    is_business_hours  numeric,
+    holiday            numeric   DEFAULT false,
+    day_before_holiday numeric   DEFAULT false,
+    day_after_holiday  numeric   DEFAULT false,
+    neighbor_avg_crime real,
+    created_at         datetime,
+    updated_at         datetime
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS unique_cell_ts ON features_cell_hourly (cell_id, ts);
+CREATE INDEX IF NOT EXISTS idx_features_cell_hourly_cell_id ON features_cell_hourly (cell_id);
+CREATE INDEX IF NOT EXISTS idx_features_cell_hourly_ts ON features_cell_hourly (ts);
+CREATE INDEX IF NOT EXISTS idx_features_cell_hourly_dow ON features_cell_hourly (dow);
+CREATE INDEX IF NOT EXISTS idx_features_cell_hourly_hour ON features_cell_hourly (hour);
+
+CREATE TABLE IF NOT EXISTS features_cell_monthly (
+    cell_id       VARCHAR(50) NOT NULL,
+    "year"        INT         NOT NULL,
+    "month"       INT         NOT NULL,
+    y_count_month INT         NOT NULL DEFAULT 0,
+    lag_1m        INT         NOT NULL DEFAULT 0,
+    lag_3m        INT         NOT NULL DEFAULT 0,
+    PRIMARY KEY (cell_id, "year", "month")
+);
+
+CREATE INDEX IF NOT EXISTS idx_features_cell_monthly_year_month ON features_cell_monthly ("year", "month");
+
+CREATE TABLE IF NOT EXISTS cell_neighborhoods (
+    cell_id      VARCHAR(50)  PRIMARY KEY,
+    neighborhood VARCHAR(100) NOT NULL,
+    distance     REAL         NULL
+);
+
+-- ----------------------------------------------------------------------------
+-- Knowledge Base - Analytics
+-- ----------------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS analytics_quality_reports (
+    id          integer   PRIMARY KEY AUTOINCREMENT,
+    report_date date      NOT NULL,
+    metrics     text      NOT NULL,
+    created_at  datetime,
+    updated_at  datetime
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_analytics_quality_reports_report_date ON analytics_quality_reports (report_date);
+
+CREATE TABLE IF NOT EXISTS analytics_pipeline_logs (
+    id                     integer   PRIMARY KEY AUTOINCREMENT,
+    execution_id           text      NOT NULL,
+    started_at             datetime  NOT NULL,
+    finished_at            datetime,
+    status                 text,
+    phase                  text,
+    records_processed      integer,
+    error_message          text,
+    execution_time_seconds integer,
+    created_at             datetime
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pipeline_logs_execution_phase ON analytics_pipeline_logs (execution_id, phase);
+CREATE INDEX IF NOT EXISTS idx_analytics_pipeline_logs_started_at ON analytics_pipeline_logs (started_at);
+CREATE INDEX IF NOT EXISTS idx_analytics_pipeline_logs_status ON analytics_pipeline_logs (status);
@@ -0,0 +1,6 @@
+-- Marca d'água das execuções incrementais da base de conhecimento
+CREATE TABLE IF NOT EXISTS analytics_watermarks (
+    name         text     PRIMARY KEY,
+    watermark_at datetime NOT NULL,
+    updated_at   datetime
+);
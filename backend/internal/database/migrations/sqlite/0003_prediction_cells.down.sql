@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_predict_crimes_period;
+
+ALTER TABLE predict_crimes DROP COLUMN created_at;
+ALTER TABLE predict_crimes DROP COLUMN model_version;
+ALTER TABLE predict_crimes DROP COLUMN predicted_count;
+ALTER TABLE predict_crimes DROP COLUMN longitude;
+ALTER TABLE predict_crimes DROP COLUMN latitude;
+ALTER TABLE predict_crimes DROP COLUMN cell_id;
+ALTER TABLE predict_crimes DROP COLUMN cell_resolution;
+ALTER TABLE predict_crimes DROP COLUMN "month";
+ALTER TABLE predict_crimes DROP COLUMN "year";
@@ -0,0 +1,12 @@
+-- Previsões mensais por célula e por bairro geradas pelo modelo do backend
+ALTER TABLE predict_crimes ADD COLUMN "year"          integer NOT NULL DEFAULT 0;
+ALTER TABLE predict_crimes ADD COLUMN "month"         integer NOT NULL DEFAULT 0;
+ALTER TABLE predict_crimes ADD COLUMN cell_resolution integer;
+ALTER TABLE predict_crimes ADD COLUMN cell_id         text;
+ALTER TABLE predict_crimes ADD COLUMN latitude        real;
+ALTER TABLE predict_crimes ADD COLUMN longitude       real;
+ALTER TABLE predict_crimes ADD COLUMN predicted_count real;
+ALTER TABLE predict_crimes ADD COLUMN model_version   text;
+ALTER TABLE predict_crimes ADD COLUMN created_at      datetime;
+
+CREATE INDEX IF NOT EXISTS idx_predict_crimes_period ON predict_crimes ("year", "month", cell_resolution);
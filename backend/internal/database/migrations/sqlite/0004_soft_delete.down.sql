@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_reports_deleted_at;
+DROP INDEX IF EXISTS idx_crimes_deleted_at;
+DROP INDEX IF EXISTS idx_neighborhoods_deleted_at;
+
+ALTER TABLE reports       DROP COLUMN deleted_at;
+ALTER TABLE crimes        DROP COLUMN deleted_at;
+ALTER TABLE neighborhoods DROP COLUMN deleted_at;
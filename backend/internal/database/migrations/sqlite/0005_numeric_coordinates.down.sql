@@ -0,0 +1,13 @@
+-- Volta as coordenadas para texto. Os bairros unidos pelo up continuam
+-- apagados (deleted_at), com os reports no bairro que ficou.
+ALTER TABLE neighborhoods RENAME COLUMN latitude TO latitude_num;
+ALTER TABLE neighborhoods RENAME COLUMN longitude TO longitude_num;
+ALTER TABLE neighborhoods ADD COLUMN latitude text NOT NULL DEFAULT '';
+ALTER TABLE neighborhoods ADD COLUMN longitude text NOT NULL DEFAULT '';
+
+UPDATE neighborhoods SET
+    latitude  = COALESCE(CAST(latitude_num AS TEXT), ''),
+    longitude = COALESCE(CAST(longitude_num AS TEXT), '');
+
+ALTER TABLE neighborhoods DROP COLUMN latitude_num;
+ALTER TABLE neighborhoods DROP COLUMN longitude_num;
@@ -0,0 +1,75 @@
+-- Coordenadas dos bairros passam de texto para número. O SQLite não altera o
+-- tipo de uma coluna, então os valores são copiados para colunas novas.
+ALTER TABLE neighborhoods RENAME COLUMN latitude TO latitude_text;
+ALTER TABLE neighborhoods RENAME COLUMN longitude TO longitude_text;
+ALTER TABLE neighborhoods ADD COLUMN latitude real;
+ALTER TABLE neighborhoods ADD COLUMN longitude real;
+
+-- Normaliza espaços e vírgula decimal; o que não for número fica NULL
+UPDATE neighborhoods SET
+    latitude = CASE
+        WHEN REPLACE(TRIM(latitude_text), ',', '.') GLOB '*[0-9]*'
+         AND REPLACE(TRIM(latitude_text), ',', '.') NOT GLOB '*[^0-9.+-]*'
+        THEN CAST(REPLACE(TRIM(latitude_text), ',', '.') AS REAL)
+    END,
+    longitude = CASE
+        WHEN REPLACE(TRIM(longitude_text), ',', '.') GLOB '*[0-9]*'
+         AND REPLACE(TRIM(longitude_text), ',', '.') NOT GLOB '*[^0-9.+-]*'
+        THEN CAST(REPLACE(TRIM(longitude_text), ',', '.') AS REAL)
+    END;
+
+ALTER TABLE neighborhoods DROP COLUMN latitude_text;
+ALTER TABLE neighborhoods DROP COLUMN longitude_text;
+
+-- Bairros que só diferiam na escrita das coordenadas ("-22.9056" e "-22.90560")
+-- são unidos no de menor id: os reports passam para ele, os pesos são somados
+-- e os demais recebem deleted_at
+UPDATE reports
+SET neighborhood_id = (
+        SELECT MIN(k.neighborhood_id)
+        FROM neighborhoods d
+        JOIN neighborhoods k ON k.latitude = d.latitude AND k.longitude = d.longitude
+        WHERE d.neighborhood_id = reports.neighborhood_id
+          AND k.deleted_at IS NULL
+    ),
+    updated_at = CURRENT_TIMESTAMP
+WHERE neighborhood_id IN (
+    SELECT d.neighborhood_id
+    FROM neighborhoods d
+    WHERE d.deleted_at IS NULL
+      AND EXISTS (
+          SELECT 1 FROM neighborhoods k
+          WHERE k.latitude = d.latitude AND k.longitude = d.longitude
+            AND k.deleted_at IS NULL AND k.neighborhood_id < d.neighborhood_id
+      )
+);
+
+UPDATE neighborhoods
+SET neighborhood_weight = (
+        SELECT SUM(d.neighborhood_weight)
+        FROM neighborhoods d
+        WHERE d.latitude = neighborhoods.latitude AND d.longitude = neighborhoods.longitude
+          AND d.deleted_at IS NULL
+    ),
+    updated_at = CURRENT_TIMESTAMP
+WHERE deleted_at IS NULL
+  AND EXISTS (
+      SELECT 1 FROM neighborhoods d
+      WHERE d.latitude = neighborhoods.latitude AND d.longitude = neighborhoods.longitude
+        AND d.deleted_at IS NULL AND d.neighborhood_id > neighborhoods.neighborhood_id
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM neighborhoods k
+      WHERE k.latitude = neighborhoods.latitude AND k.longitude = neighborhoods.longitude
+        AND k.deleted_at IS NULL AND k.neighborhood_id < neighborhoods.neighborhood_id
+  );
+
+UPDATE neighborhoods
+SET deleted_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE deleted_at IS NULL
+  AND EXISTS (
+      SELECT 1 FROM neighborhoods k
+      WHERE k.latitude = neighborhoods.latitude AND k.longitude = neighborhoods.longitude
+        AND k.deleted_at IS NULL AND k.neighborhood_id < neighborhoods.neighborhood_id
+  );
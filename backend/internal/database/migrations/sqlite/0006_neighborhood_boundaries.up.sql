@@ -0,0 +1,16 @@
+-- Limites oficiais dos bairros (GeoJSON em lon/lat), um por bairro. O retângulo
+-- envolvente permite achar os limites de uma área sem ler a geometria.
+CREATE TABLE IF NOT EXISTS neighborhood_boundaries (
+    boundary_id     integer PRIMARY KEY AUTOINCREMENT,
+    neighborhood_id integer NOT NULL REFERENCES neighborhoods (neighborhood_id),
+    geometry        text    NOT NULL,
+    min_lat         real    NOT NULL,
+    min_lon         real    NOT NULL,
+    max_lat         real    NOT NULL,
+    max_lon         real    NOT NULL,
+    source          text,
+    created_at      datetime,
+    updated_at      datetime
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_neighborhood_boundaries_neighborhood ON neighborhood_boundaries (neighborhood_id);
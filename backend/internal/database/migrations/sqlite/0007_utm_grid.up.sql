@@ -0,0 +1,10 @@
+-- A grade quadrada passa a ser projetada em UTM 23S, com IDs por linha/coluna
+-- (UTM23S-<resolução>-E<coluna>-N<linha>). As células da grade antiga, em graus
+-- (CAMP-<resolução>-<n>), e os dados derivados delas são removidos; a próxima
+-- execução do pipeline gera a grade nova e reatribui os incidentes.
+DELETE FROM features_cell_hourly WHERE cell_id LIKE 'CAMP-%';
+DELETE FROM features_cell_monthly WHERE cell_id LIKE 'CAMP-%';
+DELETE FROM cell_neighborhoods WHERE cell_id LIKE 'CAMP-%';
+DELETE FROM predict_crimes WHERE cell_id LIKE 'CAMP-%';
+UPDATE curated_incidents SET cell_id = NULL, cell_resolution = NULL WHERE cell_id LIKE 'CAMP-%';
+DELETE FROM curated_cells WHERE cell_id LIKE 'CAMP-%';
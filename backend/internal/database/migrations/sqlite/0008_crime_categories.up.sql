@@ -0,0 +1,14 @@
+-- Taxonomia de crimes: categoria, subcategoria, peso padrão dos crimes novos e
+-- sinônimos (array JSON) usados para classificar os nomes dos crimes. O
+-- servidor grava a taxonomia padrão quando a tabela está vazia.
+CREATE TABLE IF NOT EXISTS crime_categories (
+    category_id    integer PRIMARY KEY AUTOINCREMENT,
+    category       text    NOT NULL,
+    subcategory    text    NOT NULL,
+    default_weight integer NOT NULL,
+    synonyms       text,
+    created_at     datetime,
+    updated_at     datetime
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_crime_categories_name ON crime_categories (category, subcategory);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_crimes_crime_key;
+
+ALTER TABLE crimes DROP COLUMN crime_key;
@@ -0,0 +1,8 @@
+-- Data e hora da ocorrência (fuso DB_TIMEZONE, padrão America/Sao_Paulo),
+-- lida de report_date na ingestão e usada pelo pipeline da KB no lugar de
+-- report_date_formated. Os reports antigos ficam com NULL até rodar o backfill
+-- (POST /api/v1/reports/backfill-occurred-at ou import -backfill-dates), que
+-- converte report_date (ou report_date_formated) e lista o que não converteu.
+ALTER TABLE reports ADD COLUMN occurred_at datetime;
+
+CREATE INDEX IF NOT EXISTS idx_reports_occurred_at ON reports (occurred_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_reports_source_key;
+
+ALTER TABLE reports DROP COLUMN source_seq;
+
+ALTER TABLE reports DROP COLUMN source_key;
@@ -0,0 +1,10 @@
+-- Origem dos reports importados das planilhas da SSP: source_key identifica a
+-- natureza e o mês ("ssp:<município>:<DP>:<aaaa-mm>:<NATUREZA>") e source_seq a
+-- posição do report entre as ocorrências dela. O par é único entre todos os
+-- reports, inclusive os apagados, para que reimportar uma planilha não
+-- duplique os reports. Os reports do chat ficam com NULL.
+ALTER TABLE reports ADD COLUMN source_key text;
+
+ALTER TABLE reports ADD COLUMN source_seq integer;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reports_source_key ON reports (source_key, source_seq) WHERE source_key IS NOT NULL;
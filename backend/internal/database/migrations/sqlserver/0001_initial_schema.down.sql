@@ -0,0 +1,17 @@
+-- ============================================================================
+-- 0001 - Remove o schema inicial (SQL Server)
+-- ATENÇÃO: apaga também os dados da aplicação (reports, crimes, neighborhoods)
+-- ============================================================================
+
+IF OBJECT_ID('analytics_pipeline_logs', 'U') IS NOT NULL DROP TABLE analytics_pipeline_logs;
+IF OBJECT_ID('analytics_quality_reports', 'U') IS NOT NULL DROP TABLE analytics_quality_reports;
+IF OBJECT_ID('cell_neighborhoods', 'U') IS NOT NULL DROP TABLE cell_neighborhoods;
+IF OBJECT_ID('features_cell_monthly', 'U') IS NOT NULL DROP TABLE features_cell_monthly;
+IF OBJECT_ID('features_cell_hourly', 'U') IS NOT NULL DROP TABLE features_cell_hourly;
+IF OBJECT_ID('external_holidays', 'U') IS NOT NULL DROP TABLE external_holidays;
+IF OBJECT_ID('curated_cells', 'U') IS NOT NULL DROP TABLE curated_cells;
+IF OBJECT_ID('curated_incidents', 'U') IS NOT NULL DROP TABLE curated_incidents;
+IF OBJECT_ID('predict_crimes', 'U') IS NOT NULL DROP TABLE predict_crimes;
+IF OBJECT_ID('reports', 'U') IS NOT NULL DROP TABLE reports;
+IF OBJECT_ID('neighborhoods', 'U') IS NOT NULL DROP TABLE neighborhoods;
+IF OBJECT_ID('crimes', 'U') IS NOT NULL DROP TABLE crimes;
@@ -0,0 +1,278 @@
+-- ============================================================================
+-- 0001 - Schema inicial (SQL Server)
+-- Espelha os modelos GORM; cada objeto só é criado se ainda não existir, para
+-- poder ser aplicada no banco da universidade já criado pelo AutoMigrate.
+-- Lotes separados por GO.
+-- ============================================================================
+
+-- ----------------------------------------------------------------------------
+-- Tabelas da aplicação
+-- ----------------------------------------------------------------------------
+
+IF OBJECT_ID('crimes', 'U') IS NULL
+BEGIN
+    CREATE TABLE crimes (
+        crime_id     BIGINT IDENTITY(1,1) PRIMARY KEY,
+        crime_name   NVARCHAR(255)        NOT NULL,
+        crime_weight BIGINT               NOT NULL,
+        created_at   DATETIMEOFFSET,
+        updated_at   DATETIMEOFFSET
+    );
+END
+GO
+
+IF OBJECT_ID('neighborhoods', 'U') IS NULL
+BEGIN
+    CREATE TABLE neighborhoods (
+        neighborhood_id     BIGINT IDENTITY(1,1) PRIMARY KEY,
+        name                NVARCHAR(255)        NOT NULL,
+        latitude            NVARCHAR(MAX)        NOT NULL,
+        longitude           NVARCHAR(MAX)        NOT NULL,
+        neighborhood_weight BIGINT               NOT NULL,
+        created_at          DATETIMEOFFSET,
+        updated_at          DATETIMEOFFSET
+    );
+END
+GO
+
+-- report_date_formated não está no modelo GORM, mas é lida pelo pipeline da KB
+IF OBJECT_ID('reports', 'U') IS NULL
+BEGIN
+    CREATE TABLE reports (
+        report_id            BIGINT IDENTITY(1,1) PRIMARY KEY,
+        neighborhood_id      BIGINT               NOT NULL,
+        crime_id             BIGINT               NOT NULL,
+        report_date          NVARCHAR(MAX)        NOT NULL,
+        report_date_formated NVARCHAR(MAX),
+        created_at           DATETIMEOFFSET,
+        updated_at           DATETIMEOFFSET
+    );
+END
+GO
+
+IF OBJECT_ID('predict_crimes', 'U') IS NULL
+BEGIN
+    CREATE TABLE predict_crimes (
+        predict_crime_id BIGINT IDENTITY(1,1) PRIMARY KEY,
+        neighborhood     NVARCHAR(255)        NOT NULL,
+        risk_level       BIGINT               NOT NULL
+    );
+END
+GO
+
+-- ----------------------------------------------------------------------------
+-- Knowledge Base - Curated
+-- ----------------------------------------------------------------------------
+
+IF OBJECT_ID('curated_incidents', 'U') IS NULL
+BEGIN
+    CREATE TABLE curated_incidents (
+        id              NVARCHAR(50)    PRIMARY KEY,
+        occurred_at     DATETIMEOFFSET  NOT NULL,
+        category        NVARCHAR(50)    NOT NULL,
+        severity        BIGINT          NOT NULL,
+        latitude        DECIMAL(10,8)   NOT NULL,
+        longitude       DECIMAL(11,8)   NOT NULL,
+        neighborhood    NVARCHAR(100),
+        confidence      FLOAT,
+        source          NVARCHAR(50)    DEFAULT 'legacy_reports',
+        cell_id         NVARCHAR(50),
+        cell_resolution BIGINT,
+        created_at      DATETIMEOFFSET,
+        updated_at      DATETIMEOFFSET,
+        CONSTRAINT chk_curated_incidents_severity CHECK (severity >= 1 AND severity <= 10),
+        CONSTRAINT chk_curated_incidents_confidence CHECK (confidence >= 0 AND confidence <= 1)
+    );
+END
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_curated_incidents_occurred_at' AND object_id = OBJECT_ID('curated_incidents'))
+    CREATE INDEX idx_curated_incidents_occurred_at ON curated_incidents (occurred_at);
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_curated_incidents_category' AND object_id = OBJECT_ID('curated_incidents'))
+    CREATE INDEX idx_curated_incidents_category ON curated_incidents (category);
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_curated_incidents_severity' AND object_id = OBJECT_ID('curated_incidents'))
+    CREATE INDEX idx_curated_incidents_severity ON curated_incidents (severity);
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_curated_incidents_cell_id' AND object_id = OBJECT_ID('curated_incidents'))
+    CREATE INDEX idx_curated_incidents_cell_id ON curated_incidents (cell_id);
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_lat_lng' AND object_id = OBJECT_ID('curated_incidents'))
+    CREATE INDEX idx_lat_lng ON curated_incidents (latitude, longitude);
+GO
+
+IF OBJECT_ID('curated_cells', 'U') IS NULL
+BEGIN
+    CREATE TABLE curated_cells (
+        cell_id         NVARCHAR(50)   PRIMARY KEY,
+        cell_resolution BIGINT         NOT NULL,
+        city            NVARCHAR(50)   DEFAULT 'Campinas',
+        center_lat      DECIMAL(10,8)  NOT NULL,
+        center_lng      DECIMAL(11,8)  NOT NULL,
+        bounds_json     NVARCHAR(MAX),
+        created_at      DATETIMEOFFSET
+    );
+END
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_curated_cells_cell_resolution' AND object_id = OBJECT_ID('curated_cells'))
+    CREATE INDEX idx_curated_cells_cell_resolution ON curated_cells (cell_resolution);
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_curated_cells_city' AND object_id = OBJECT_ID('curated_cells'))
+    CREATE INDEX idx_curated_cells_city ON curated_cells (city);
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_center' AND object_id = OBJECT_ID('curated_cells'))
+    CREATE INDEX idx_center ON curated_cells (center_lat, center_lng);
+GO
+
+-- ----------------------------------------------------------------------------
+-- Knowledge Base - External
+-- ----------------------------------------------------------------------------
+
+IF OBJECT_ID('external_holidays', 'U') IS NULL
+BEGIN
+    CREATE TABLE external_holidays (
+        id         BIGINT IDENTITY(1,1) PRIMARY KEY,
+        date       DATE                 NOT NULL,
+        name       NVARCHAR(100)        NOT NULL,
+        type       NVARCHAR(50),
+        city       NVARCHAR(50)         DEFAULT 'Campinas',
+        created_at DATETIMEOFFSET
+    );
+END
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'unique_date_city' AND object_id = OBJECT_ID('external_holidays'))
+    CREATE UNIQUE INDEX unique_date_city ON external_holidays (date, city);
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_external_holidays_date' AND object_id = OBJECT_ID('external_holidays'))
+    CREATE INDEX idx_external_holidays_date ON external_holidays (date);
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_external_holidays_city' AND object_id = OBJECT_ID('external_holidays'))
+    CREATE INDEX idx_external_holidays_city ON external_holidays (city);
+GO
+
+-- ----------------------------------------------------------------------------
+-- Knowledge Base - Features
+-- ----------------------------------------------------------------------------
+
+IF OBJECT_ID('features_cell_hourly', 'U') IS NULL
+BEGIN
+    CREATE TABLE features_cell_hourly (
+        id                 BIGINT IDENTITY(1,1) PRIMARY KEY,
+        cell_id            NVARCHAR(50)         NOT NULL,
+        ts                 DATETIMEOFFSET       NOT NULL,
+        y_count            BIGINT               DEFAULT 0,
+        lag_1h             BIGINT               DEFAULT 0,
+        lag_24h            BIGINT               DEFAULT 0,
+        lag_7d             BIGINT               DEFAULT 0,
+        roll_3h_sum        BIGINT               DEFAULT 0,
+        roll_24h_sum       BIGINT               DEFAULT 0,
+        roll_7d_sum        BIGINT               DEFAULT 0,
+        roll_7d_avg        FLOAT,
+        roll_7d_std        FLOAT,
+        dow                BIGINT,
+        hour               BIGINT,
+        is_weekend         BIT,
+        is_business_hours  BIT,
+        holiday            BIT                  DEFAULT 0,
+        day_before_holiday BIT                  DEFAULT 0,
+        day_after_holiday  BIT                  DEFAULT 0,
+        neighbor_avg_crime FLOAT,
+        created_at         DATETIMEOFFSET,
+        updated_at         DATETIMEOFFSET
+    );
+END
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'unique_cell_ts' AND object_id = OBJECT_ID('features_cell_hourly'))
+    CREATE UNIQUE INDEX unique_cell_ts ON features_cell_hourly (cell_id, ts);
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_features_cell_hourly_cell_id' AND object_id = OBJECT_ID('features_cell_hourly'))
+    CREATE INDEX idx_features_cell_hourly_cell_id ON features_cell_hourly (cell_id);
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_features_cell_hourly_ts' AND object_id = OBJECT_ID('features_cell_hourly'))
+    CREATE INDEX idx_features_cell_hourly_ts ON features_cell_hourly (ts);
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_features_cell_hourly_dow' AND object_id = OBJECT_ID('features_cell_hourly'))
+    CREATE INDEX idx_features_cell_hourly_dow ON features_cell_hourly (dow);
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_features_cell_hourly_hour' AND object_id = OBJECT_ID('features_cell_hourly'))
+    CREATE INDEX idx_features_cell_hourly_hour ON features_cell_hourly (hour);
+GO
+
+IF OBJECT_ID('features_cell_monthly', 'U') IS NULL
+BEGIN
+    CREATE TABLE features_cell_monthly (
+        cell_id       VARCHAR(50) NOT NULL,
+        [year]        INT         NOT NULL,
+        [month]       INT         NOT NULL,
+        y_count_month INT         NOT NULL DEFAULT 0,
+        lag_1m        INT         NOT NULL DEFAULT 0,
+        lag_3m        INT         NOT NULL DEFAULT 0,
+        PRIMARY KEY (cell_id, [year], [month])
+    );
+END
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_features_cell_monthly_year_month' AND object_id = OBJECT_ID('features_cell_monthly'))
+    CREATE INDEX idx_features_cell_monthly_year_month ON features_cell_monthly ([year], [month]);
+GO
+
+IF OBJECT_ID('cell_neighborhoods', 'U') IS NULL
+BEGIN
+    CREATE TABLE cell_neighborhoods (
+        cell_id      VARCHAR(50)  PRIMARY KEY,
+        neighborhood VARCHAR(100) NOT NULL,
+        distance     FLOAT        NULL
+    );
+END
+GO
+
+-- ----------------------------------------------------------------------------
+-- Knowledge Base - Analytics
+-- ----------------------------------------------------------------------------
+
+IF OBJECT_ID('analytics_quality_reports', 'U') IS NULL
+BEGIN
+    CREATE TABLE analytics_quality_reports (
+        id          BIGINT IDENTITY(1,1) PRIMARY KEY,
+        report_date DATE                 NOT NULL,
+        metrics     NVARCHAR(MAX)        NOT NULL,
+        created_at  DATETIMEOFFSET,
+        updated_at  DATETIMEOFFSET
+    );
+END
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_analytics_quality_reports_report_date' AND object_id = OBJECT_ID('analytics_quality_reports'))
+    CREATE UNIQUE INDEX idx_analytics_quality_reports_report_date ON analytics_quality_reports (report_date);
+GO
+
+IF OBJECT_ID('analytics_pipeline_logs', 'U') IS NULL
+BEGIN
+    CREATE TABLE analytics_pipeline_logs (
+        id                     BIGINT IDENTITY(1,1) PRIMARY KEY,
+        execution_id           NVARCHAR(36)         NOT NULL,
+        started_at             DATETIMEOFFSET       NOT NULL,
+        finished_at            DATETIMEOFFSET,
+        status                 NVARCHAR(20),
+        phase                  NVARCHAR(50),
+        records_processed      BIGINT,
+        error_message          NVARCHAR(MAX),
+        execution_time_seconds BIGINT,
+        created_at             DATETIMEOFFSET
+    );
+END
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_pipeline_logs_execution_phase' AND object_id = OBJECT_ID('analytics_pipeline_logs'))
+    CREATE UNIQUE INDEX idx_pipeline_logs_execution_phase ON analytics_pipeline_logs (execution_id, phase);
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_analytics_pipeline_logs_started_at' AND object_id = OBJECT_ID('analytics_pipeline_logs'))
+    CREATE INDEX idx_analytics_pipeline_logs_started_at ON analytics_pipeline_logs (started_at);
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_analytics_pipeline_logs_status' AND object_id = OBJECT_ID('analytics_pipeline_logs'))
+    CREATE INDEX idx_analytics_pipeline_logs_status ON analytics_pipeline_logs (status);
+GO
@@ -0,0 +1 @@
+IF OBJECT_ID('analytics_watermarks', 'U') IS NOT NULL DROP TABLE analytics_watermarks;
@@ -0,0 +1,10 @@
+-- Marca d'água das execuções incrementais da base de conhecimento
+IF OBJECT_ID('analytics_watermarks', 'U') IS NULL
+BEGIN
+    CREATE TABLE analytics_watermarks (
+        name         NVARCHAR(100)  NOT NULL PRIMARY KEY,
+        watermark_at DATETIMEOFFSET NOT NULL,
+        updated_at   DATETIMEOFFSET
+    );
+END
+GO
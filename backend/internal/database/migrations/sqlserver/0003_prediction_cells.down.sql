@@ -0,0 +1,19 @@
+IF EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_predict_crimes_period' AND object_id = OBJECT_ID('predict_crimes'))
+    DROP INDEX idx_predict_crimes_period ON predict_crimes;
+GO
+IF COL_LENGTH('predict_crimes', 'cell_resolution') IS NOT NULL
+    ALTER TABLE predict_crimes DROP COLUMN
+        created_at, model_version, predicted_count, longitude, latitude, cell_id, cell_resolution;
+GO
+IF COL_LENGTH('predict_crimes', 'month') IS NOT NULL
+BEGIN
+    ALTER TABLE predict_crimes DROP CONSTRAINT df_predict_crimes_month;
+    ALTER TABLE predict_crimes DROP COLUMN [month];
+END
+GO
+IF COL_LENGTH('predict_crimes', 'year') IS NOT NULL
+BEGIN
+    ALTER TABLE predict_crimes DROP CONSTRAINT df_predict_crimes_year;
+    ALTER TABLE predict_crimes DROP COLUMN [year];
+END
+GO
@@ -0,0 +1,20 @@
+-- Previsões mensais por célula e por bairro geradas pelo modelo do backend
+IF COL_LENGTH('predict_crimes', 'year') IS NULL
+    ALTER TABLE predict_crimes ADD [year] BIGINT NOT NULL CONSTRAINT df_predict_crimes_year DEFAULT 0;
+GO
+IF COL_LENGTH('predict_crimes', 'month') IS NULL
+    ALTER TABLE predict_crimes ADD [month] BIGINT NOT NULL CONSTRAINT df_predict_crimes_month DEFAULT 0;
+GO
+IF COL_LENGTH('predict_crimes', 'cell_resolution') IS NULL
+    ALTER TABLE predict_crimes ADD
+        cell_resolution BIGINT,
+        cell_id         NVARCHAR(50),
+        latitude        FLOAT,
+        longitude       FLOAT,
+        predicted_count FLOAT,
+        model_version   NVARCHAR(50),
+        created_at      DATETIMEOFFSET;
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_predict_crimes_period' AND object_id = OBJECT_ID('predict_crimes'))
+    CREATE INDEX idx_predict_crimes_period ON predict_crimes ([year], [month], cell_resolution);
+GO
@@ -0,0 +1,18 @@
+IF EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_reports_deleted_at' AND object_id = OBJECT_ID('reports'))
+    DROP INDEX idx_reports_deleted_at ON reports;
+GO
+IF COL_LENGTH('reports', 'deleted_at') IS NOT NULL
+    ALTER TABLE reports DROP COLUMN deleted_at;
+GO
+IF EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_crimes_deleted_at' AND object_id = OBJECT_ID('crimes'))
+    DROP INDEX idx_crimes_deleted_at ON crimes;
+GO
+IF COL_LENGTH('crimes', 'deleted_at') IS NOT NULL
+    ALTER TABLE crimes DROP COLUMN deleted_at;
+GO
+IF EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_neighborhoods_deleted_at' AND object_id = OBJECT_ID('neighborhoods'))
+    DROP INDEX idx_neighborhoods_deleted_at ON neighborhoods;
+GO
+IF COL_LENGTH('neighborhoods', 'deleted_at') IS NOT NULL
+    ALTER TABLE neighborhoods DROP COLUMN deleted_at;
+GO
@@ -0,0 +1,19 @@
+-- Soft delete de bairros, crimes e reports
+IF COL_LENGTH('neighborhoods', 'deleted_at') IS NULL
+    ALTER TABLE neighborhoods ADD deleted_at DATETIMEOFFSET;
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_neighborhoods_deleted_at' AND object_id = OBJECT_ID('neighborhoods'))
+    CREATE INDEX idx_neighborhoods_deleted_at ON neighborhoods (deleted_at);
+GO
+IF COL_LENGTH('crimes', 'deleted_at') IS NULL
+    ALTER TABLE crimes ADD deleted_at DATETIMEOFFSET;
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_crimes_deleted_at' AND object_id = OBJECT_ID('crimes'))
+    CREATE INDEX idx_crimes_deleted_at ON crimes (deleted_at);
+GO
+IF COL_LENGTH('reports', 'deleted_at') IS NULL
+    ALTER TABLE reports ADD deleted_at DATETIMEOFFSET;
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_reports_deleted_at' AND object_id = OBJECT_ID('reports'))
+    CREATE INDEX idx_reports_deleted_at ON reports (deleted_at);
+GO
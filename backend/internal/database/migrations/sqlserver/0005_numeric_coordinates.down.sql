@@ -0,0 +1,27 @@
+-- Volta as coordenadas para texto. Os bairros unidos pelo up continuam
+-- apagados (deleted_at), com os reports no bairro que ficou.
+IF COL_LENGTH('neighborhoods', 'latitude_text') IS NULL
+   AND (SELECT DATA_TYPE FROM INFORMATION_SCHEMA.COLUMNS
+        WHERE TABLE_NAME = 'neighborhoods' AND COLUMN_NAME = 'latitude') = 'float'
+    ALTER TABLE neighborhoods ADD latitude_text NVARCHAR(MAX) NULL, longitude_text NVARCHAR(MAX) NULL;
+GO
+IF COL_LENGTH('neighborhoods', 'latitude_text') IS NOT NULL
+    EXEC('UPDATE neighborhoods SET
+        latitude_text  = COALESCE(CONVERT(NVARCHAR(50), CAST(latitude AS DECIMAL(11,8))), ''''),
+        longitude_text = COALESCE(CONVERT(NVARCHAR(50), CAST(longitude AS DECIMAL(11,8))), '''')');
+GO
+IF COL_LENGTH('neighborhoods', 'latitude_text') IS NOT NULL
+BEGIN
+    ALTER TABLE neighborhoods DROP COLUMN latitude, longitude;
+    EXEC sp_rename 'neighborhoods.latitude_text', 'latitude', 'COLUMN';
+    EXEC sp_rename 'neighborhoods.longitude_text', 'longitude', 'COLUMN';
+END
+GO
+IF (SELECT IS_NULLABLE FROM INFORMATION_SCHEMA.COLUMNS
+    WHERE TABLE_NAME = 'neighborhoods' AND COLUMN_NAME = 'latitude') = 'YES'
+    ALTER TABLE neighborhoods ALTER COLUMN latitude NVARCHAR(MAX) NOT NULL;
+GO
+IF (SELECT IS_NULLABLE FROM INFORMATION_SCHEMA.COLUMNS
+    WHERE TABLE_NAME = 'neighborhoods' AND COLUMN_NAME = 'longitude') = 'YES'
+    ALTER TABLE neighborhoods ALTER COLUMN longitude NVARCHAR(MAX) NOT NULL;
+GO
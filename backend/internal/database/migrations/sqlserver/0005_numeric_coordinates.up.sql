@@ -0,0 +1,76 @@
+-- Coordenadas dos bairros passam de texto para número. Os valores são copiados
+-- para colunas novas, com espaços e vírgula decimal normalizados; o que não
+-- for número fica NULL (TRY_CAST). Os guards permitem reaplicar o script.
+IF COL_LENGTH('neighborhoods', 'latitude_num') IS NULL
+   AND (SELECT DATA_TYPE FROM INFORMATION_SCHEMA.COLUMNS
+        WHERE TABLE_NAME = 'neighborhoods' AND COLUMN_NAME = 'latitude') <> 'float'
+    ALTER TABLE neighborhoods ADD latitude_num FLOAT NULL, longitude_num FLOAT NULL;
+GO
+IF COL_LENGTH('neighborhoods', 'latitude_num') IS NOT NULL
+    EXEC('UPDATE neighborhoods SET
+        latitude_num  = TRY_CAST(NULLIF(REPLACE(LTRIM(RTRIM(latitude)), '','', ''.''), '''') AS FLOAT),
+        longitude_num = TRY_CAST(NULLIF(REPLACE(LTRIM(RTRIM(longitude)), '','', ''.''), '''') AS FLOAT)');
+GO
+IF COL_LENGTH('neighborhoods', 'latitude_num') IS NOT NULL
+BEGIN
+    ALTER TABLE neighborhoods DROP COLUMN latitude, longitude;
+    EXEC sp_rename 'neighborhoods.latitude_num', 'latitude', 'COLUMN';
+    EXEC sp_rename 'neighborhoods.longitude_num', 'longitude', 'COLUMN';
+END
+GO
+
+-- Bairros que só diferiam na escrita das coordenadas ("-22.9056" e "-22.90560")
+-- são unidos no de menor id: os reports passam para ele, os pesos são somados
+-- e os demais recebem deleted_at
+UPDATE reports
+SET neighborhood_id = (
+        SELECT MIN(k.neighborhood_id)
+        FROM neighborhoods d
+        JOIN neighborhoods k ON k.latitude = d.latitude AND k.longitude = d.longitude
+        WHERE d.neighborhood_id = reports.neighborhood_id
+          AND k.deleted_at IS NULL
+    ),
+    updated_at = SYSDATETIMEOFFSET()
+WHERE neighborhood_id IN (
+    SELECT d.neighborhood_id
+    FROM neighborhoods d
+    WHERE d.deleted_at IS NULL
+      AND EXISTS (
+          SELECT 1 FROM neighborhoods k
+          WHERE k.latitude = d.latitude AND k.longitude = d.longitude
+            AND k.deleted_at IS NULL AND k.neighborhood_id < d.neighborhood_id
+      )
+);
+GO
+
+UPDATE neighborhoods
+SET neighborhood_weight = (
+        SELECT SUM(d.neighborhood_weight)
+        FROM neighborhoods d
+        WHERE d.latitude = neighborhoods.latitude AND d.longitude = neighborhoods.longitude
+          AND d.deleted_at IS NULL
+    ),
+    updated_at = SYSDATETIMEOFFSET()
+WHERE deleted_at IS NULL
+  AND EXISTS (
+      SELECT 1 FROM neighborhoods d
+      WHERE d.latitude = neighborhoods.latitude AND d.longitude = neighborhoods.longitude
+        AND d.deleted_at IS NULL AND d.neighborhood_id > neighborhoods.neighborhood_id
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM neighborhoods k
+      WHERE k.latitude = neighborhoods.latitude AND k.longitude = neighborhoods.longitude
+        AND k.deleted_at IS NULL AND k.neighborhood_id < neighborhoods.neighborhood_id
+  );
+GO
+
+UPDATE neighborhoods
+SET deleted_at = SYSDATETIMEOFFSET(),
+    updated_at = SYSDATETIMEOFFSET()
+WHERE deleted_at IS NULL
+  AND EXISTS (
+      SELECT 1 FROM neighborhoods k
+      WHERE k.latitude = neighborhoods.latitude AND k.longitude = neighborhoods.longitude
+        AND k.deleted_at IS NULL AND k.neighborhood_id < neighborhoods.neighborhood_id
+  );
+GO
@@ -0,0 +1,3 @@
+IF OBJECT_ID('neighborhood_boundaries', 'U') IS NOT NULL
+    DROP TABLE neighborhood_boundaries;
+GO
@@ -0,0 +1,21 @@
+-- Limites oficiais dos bairros (GeoJSON em lon/lat), um por bairro. O retângulo
+-- envolvente permite achar os limites de uma área sem ler a geometria.
+IF OBJECT_ID('neighborhood_boundaries', 'U') IS NULL
+BEGIN
+    CREATE TABLE neighborhood_boundaries (
+        boundary_id     BIGINT IDENTITY(1,1) PRIMARY KEY,
+        neighborhood_id BIGINT               NOT NULL REFERENCES neighborhoods (neighborhood_id),
+        geometry        NVARCHAR(MAX)        NOT NULL,
+        min_lat         FLOAT                NOT NULL,
+        min_lon         FLOAT                NOT NULL,
+        max_lat         FLOAT                NOT NULL,
+        max_lon         FLOAT                NOT NULL,
+        source          NVARCHAR(255),
+        created_at      DATETIMEOFFSET,
+        updated_at      DATETIMEOFFSET
+    );
+END
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_neighborhood_boundaries_neighborhood' AND object_id = OBJECT_ID('neighborhood_boundaries'))
+    CREATE UNIQUE INDEX idx_neighborhood_boundaries_neighborhood ON neighborhood_boundaries (neighborhood_id);
+GO
@@ -0,0 +1,3 @@
+IF OBJECT_ID('crime_categories', 'U') IS NOT NULL
+    DROP TABLE crime_categories;
+GO
@@ -0,0 +1,19 @@
+-- Taxonomia de crimes: categoria, subcategoria, peso padrão dos crimes novos e
+-- sinônimos (array JSON) usados para classificar os nomes dos crimes. O
+-- servidor grava a taxonomia padrão quando a tabela está vazia.
+IF OBJECT_ID('crime_categories', 'U') IS NULL
+BEGIN
+    CREATE TABLE crime_categories (
+        category_id    BIGINT IDENTITY(1,1) PRIMARY KEY,
+        category       NVARCHAR(100)        NOT NULL,
+        subcategory    NVARCHAR(100)        NOT NULL,
+        default_weight BIGINT               NOT NULL,
+        synonyms       NVARCHAR(MAX),
+        created_at     DATETIMEOFFSET,
+        updated_at     DATETIMEOFFSET
+    );
+END
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_crime_categories_name' AND object_id = OBJECT_ID('crime_categories'))
+    CREATE UNIQUE INDEX idx_crime_categories_name ON crime_categories (category, subcategory);
+GO
@@ -0,0 +1,6 @@
+IF EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_crimes_crime_key' AND object_id = OBJECT_ID('crimes'))
+    DROP INDEX idx_crimes_crime_key ON crimes;
+GO
+IF COL_LENGTH('crimes', 'crime_key') IS NOT NULL
+    ALTER TABLE crimes DROP COLUMN crime_key;
+GO
@@ -0,0 +1,10 @@
+-- Chave canônica do nome do crime (minúsculas, sem acentos e pontuação, com os
+-- sinônimos resolvidos), única entre os crimes não apagados. Os crimes antigos
+-- ficam sem chave até rodar a consolidação de duplicados
+-- (POST /api/v1/crimes/merge ou import -merge-crimes), que preenche as chaves.
+IF COL_LENGTH('crimes', 'crime_key') IS NULL
+    ALTER TABLE crimes ADD crime_key NVARCHAR(255);
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_crimes_crime_key' AND object_id = OBJECT_ID('crimes'))
+    CREATE UNIQUE INDEX idx_crimes_crime_key ON crimes (crime_key) WHERE crime_key IS NOT NULL AND deleted_at IS NULL;
+GO
@@ -0,0 +1,3 @@
+IF EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_neighborhoods_coordinates' AND object_id = OBJECT_ID('neighborhoods'))
+    DROP INDEX idx_neighborhoods_coordinates ON neighborhoods;
+GO
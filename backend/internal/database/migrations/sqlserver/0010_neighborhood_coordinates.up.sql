@@ -0,0 +1,60 @@
+-- Índice único nas coordenadas dos bairros não apagados, para que envios
+-- simultâneos não criem o mesmo bairro duas vezes. Os duplicados já criados
+-- são unidos no de menor id, como na migration 0005: os reports passam para
+-- ele, os pesos são somados e os demais recebem deleted_at.
+UPDATE reports
+SET neighborhood_id = (
+        SELECT MIN(k.neighborhood_id)
+        FROM neighborhoods d
+        JOIN neighborhoods k ON k.latitude = d.latitude AND k.longitude = d.longitude
+        WHERE d.neighborhood_id = reports.neighborhood_id
+          AND k.deleted_at IS NULL
+    ),
+    updated_at = SYSDATETIMEOFFSET()
+WHERE neighborhood_id IN (
+    SELECT d.neighborhood_id
+    FROM neighborhoods d
+    WHERE d.deleted_at IS NULL
+      AND EXISTS (
+          SELECT 1 FROM neighborhoods k
+          WHERE k.latitude = d.latitude AND k.longitude = d.longitude
+            AND k.deleted_at IS NULL AND k.neighborhood_id < d.neighborhood_id
+      )
+);
+GO
+
+UPDATE neighborhoods
+SET neighborhood_weight = (
+        SELECT SUM(d.neighborhood_weight)
+        FROM neighborhoods d
+        WHERE d.latitude = neighborhoods.latitude AND d.longitude = neighborhoods.longitude
+          AND d.deleted_at IS NULL
+    ),
+    updated_at = SYSDATETIMEOFFSET()
+WHERE deleted_at IS NULL
+  AND EXISTS (
+      SELECT 1 FROM neighborhoods d
+      WHERE d.latitude = neighborhoods.latitude AND d.longitude = neighborhoods.longitude
+        AND d.deleted_at IS NULL AND d.neighborhood_id > neighborhoods.neighborhood_id
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM neighborhoods k
+      WHERE k.latitude = neighborhoods.latitude AND k.longitude = neighborhoods.longitude
+        AND k.deleted_at IS NULL AND k.neighborhood_id < neighborhoods.neighborhood_id
+  );
+GO
+
+UPDATE neighborhoods
+SET deleted_at = SYSDATETIMEOFFSET(),
+    updated_at = SYSDATETIMEOFFSET()
+WHERE deleted_at IS NULL
+  AND EXISTS (
+      SELECT 1 FROM neighborhoods k
+      WHERE k.latitude = neighborhoods.latitude AND k.longitude = neighborhoods.longitude
+        AND k.deleted_at IS NULL AND k.neighborhood_id < neighborhoods.neighborhood_id
+  );
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_neighborhoods_coordinates' AND object_id = OBJECT_ID('neighborhoods'))
+    CREATE UNIQUE INDEX idx_neighborhoods_coordinates ON neighborhoods (latitude, longitude) WHERE deleted_at IS NULL;
+GO
@@ -0,0 +1,6 @@
+IF EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_reports_occurred_at' AND object_id = OBJECT_ID('reports'))
+    DROP INDEX idx_reports_occurred_at ON reports;
+GO
+IF COL_LENGTH('reports', 'occurred_at') IS NOT NULL
+    ALTER TABLE reports DROP COLUMN occurred_at;
+GO
@@ -0,0 +1,11 @@
+-- Data e hora da ocorrência (fuso DB_TIMEZONE, padrão America/Sao_Paulo),
+-- lida de report_date na ingestão e usada pelo pipeline da KB no lugar de
+-- report_date_formated. Os reports antigos ficam com NULL até rodar o backfill
+-- (POST /api/v1/reports/backfill-occurred-at ou import -backfill-dates), que
+-- converte report_date (ou report_date_formated) e lista o que não converteu.
+IF COL_LENGTH('reports', 'occurred_at') IS NULL
+    ALTER TABLE reports ADD occurred_at DATETIMEOFFSET;
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_reports_occurred_at' AND object_id = OBJECT_ID('reports'))
+    CREATE INDEX idx_reports_occurred_at ON reports (occurred_at);
+GO
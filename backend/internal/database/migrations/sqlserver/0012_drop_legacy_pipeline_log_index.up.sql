@@ -0,0 +1,7 @@
+-- Remove o índice único antigo apenas em analytics_pipeline_logs.execution_id,
+-- criado pelo AutoMigrate dos bancos anteriores às migrations versionadas: com
+-- uma linha por fase ele impede gravar mais de uma fase por execução. O
+-- servidor não roda mais o AutoMigrate, então a remoção passa a ser daqui.
+IF EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_analytics_pipeline_logs_execution_id' AND object_id = OBJECT_ID('analytics_pipeline_logs'))
+    DROP INDEX idx_analytics_pipeline_logs_execution_id ON analytics_pipeline_logs;
+GO
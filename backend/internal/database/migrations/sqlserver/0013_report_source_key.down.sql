@@ -0,0 +1,9 @@
+IF EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_reports_source_key' AND object_id = OBJECT_ID('reports'))
+    DROP INDEX idx_reports_source_key ON reports;
+GO
+IF COL_LENGTH('reports', 'source_seq') IS NOT NULL
+    ALTER TABLE reports DROP COLUMN source_seq;
+GO
+IF COL_LENGTH('reports', 'source_key') IS NOT NULL
+    ALTER TABLE reports DROP COLUMN source_key;
+GO
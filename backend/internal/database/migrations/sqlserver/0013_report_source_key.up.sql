@@ -0,0 +1,14 @@
+-- Origem dos reports importados das planilhas da SSP: source_key identifica a
+-- natureza e o mês ("ssp:<município>:<DP>:<aaaa-mm>:<NATUREZA>") e source_seq a
+-- posição do report entre as ocorrências dela. O par é único entre todos os
+-- reports, inclusive os apagados, para que reimportar uma planilha não
+-- duplique os reports. Os reports do chat ficam com NULL.
+IF COL_LENGTH('reports', 'source_key') IS NULL
+    ALTER TABLE reports ADD source_key NVARCHAR(191);
+GO
+IF COL_LENGTH('reports', 'source_seq') IS NULL
+    ALTER TABLE reports ADD source_seq INT;
+GO
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_reports_source_key' AND object_id = OBJECT_ID('reports'))
+    CREATE UNIQUE INDEX idx_reports_source_key ON reports (source_key, source_seq) WHERE source_key IS NOT NULL;
+GO
@@ -0,0 +1,357 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migration é uma versão carregada de <dialeto>/NNNN_nome.up.sql (e .down.sql)
+type Migration struct {
+	Version string
+	Name    string
+	Up      string
+	// Down fica vazio quando a migration não tem arquivo .down.sql (irreversível)
+	Down string
+}
+
+// Status descreve uma migration conhecida e se ela já foi aplicada no banco
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+var (
+	// ErrIrreversible indica que a migration não tem arquivo .down.sql
+	ErrIrreversible = errors.New("migration sem arquivo .down.sql")
+	// ErrUnknownVersion indica uma versão aplicada no banco que não existe nos arquivos
+	ErrUnknownVersion = errors.New("versão aplicada não encontrada nos arquivos de migration")
+)
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([A-Za-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migrator aplica e reverte as migrations de um dialeto, registrando as versões
+// aplicadas em schema_migrations. Cada migration roda em uma transação própria
+// junto com o insert/delete da sua versão.
+type Migrator struct {
+	db         *sql.DB
+	dialect    string
+	migrations []Migration
+}
+
+// New carrega as migrations do subdiretório do dialeto em fsys (ex.: migrations.Files)
+func New(db *sql.DB, dialect string, fsys fs.FS) (*Migrator, error) {
+	name, err := NormalizeDialect(dialect)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := Load(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: name, migrations: migrations}, nil
+}
+
+// NormalizeDialect converte os aliases de DB_DRIVER no nome do diretório de migrations
+func NormalizeDialect(driver string) (string, error) {
+	switch strings.ToLower(driver) {
+	case "", "sqlserver", "mssql":
+		return "sqlserver", nil
+	case "postgres", "postgresql", "pgx":
+		return "postgres", nil
+	case "sqlite", "sqlite3":
+		return "sqlite", nil
+	default:
+		return "", fmt.Errorf("dialeto não suportado: %q", driver)
+	}
+}
+
+// Load lê e ordena (numericamente) as migrations de fsys/<dialect>
+func Load(fsys fs.FS, dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar migrations de %s: %w", dialect, err)
+	}
+
+	byVersion := make(map[string]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("nome de migration inválido: %s/%s (use NNNN_nome.up.sql)", dialect, entry.Name())
+		}
+		version, name, direction := match[1], match[2], match[3]
+
+		content, err := fs.ReadFile(fsys, path.Join(dialect, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("versão %s duplicada: %s e %s", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %s_%s sem arquivo .up.sql", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return versionNumber(migrations[i].Version) < versionNumber(migrations[j].Version)
+	})
+	for i := 1; i < len(migrations); i++ {
+		if versionNumber(migrations[i].Version) == versionNumber(migrations[i-1].Version) {
+			return nil, fmt.Errorf("versão %s duplicada: %s e %s",
+				migrations[i].Version, migrations[i-1].Name, migrations[i].Name)
+		}
+	}
+	return migrations, nil
+}
+
+func versionNumber(version string) int64 {
+	n, _ := strconv.ParseInt(version, 10, 64)
+	return n
+}
+
+// Dialect retorna o nome normalizado do dialeto
+func (m *Migrator) Dialect() string {
+	return m.dialect
+}
+
+// Migrations retorna as migrations carregadas, em ordem de versão
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Status lista todas as migrations conhecidas com a data de aplicação
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		st := Status{Migration: mig}
+		if at, ok := applied[mig.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = at
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses, nil
+}
+
+// Up aplica as migrations pendentes em ordem. steps <= 0 aplica todas.
+// Retorna as migrations aplicadas até o primeiro erro.
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, mig := range m.migrations {
+		if steps > 0 && len(done) >= steps {
+			break
+		}
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return done, err
+		}
+		if err := m.apply(ctx, mig, mig.Up, true); err != nil {
+			return done, fmt.Errorf("erro ao aplicar %s_%s: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Down reverte as últimas migrations aplicadas, da mais recente para a mais
+// antiga. steps <= 0 reverte apenas a última.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]string, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versionNumber(versions[i]) > versionNumber(versions[j])
+	})
+
+	var done []Migration
+	for _, version := range versions {
+		if len(done) >= steps {
+			break
+		}
+		mig, ok := m.find(version)
+		if !ok {
+			return done, fmt.Errorf("%w: %s", ErrUnknownVersion, version)
+		}
+		if strings.TrimSpace(mig.Down) == "" {
+			return done, fmt.Errorf("%w: %s_%s", ErrIrreversible, mig.Version, mig.Name)
+		}
+		if err := ctx.Err(); err != nil {
+			return done, err
+		}
+		if err := m.apply(ctx, mig, mig.Down, false); err != nil {
+			return done, fmt.Errorf("erro ao reverter %s_%s: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Redo reverte e reaplica a última migration aplicada
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	reverted, err := m.Down(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(reverted) == 0 {
+		return nil, nil
+	}
+
+	mig := reverted[0]
+	if err := m.apply(ctx, mig, mig.Up, true); err != nil {
+		return nil, fmt.Errorf("erro ao reaplicar %s_%s: %w", mig.Version, mig.Name, err)
+	}
+	return &mig, nil
+}
+
+func (m *Migrator) find(version string) (Migration, bool) {
+	for _, mig := range m.migrations {
+		if versionNumber(mig.Version) == versionNumber(version) {
+			return mig, true
+		}
+	}
+	return Migration{}, false
+}
+
+// apply executa o script e registra (up) ou remove (down) a versão na mesma transação
+func (m *Migrator) apply(ctx context.Context, mig Migration, script string, up bool) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for i, stmt := range SplitStatements(m.dialect, script) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("statement %d: %w", i+1, err)
+		}
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO schema_migrations (version, applied_at) VALUES ("+
+				m.placeholder(1)+", "+m.placeholder(2)+")",
+			mig.Version, time.Now())
+	} else {
+		_, err = tx.ExecContext(ctx,
+			"DELETE FROM schema_migrations WHERE version = "+m.placeholder(1),
+			mig.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao registrar versão em schema_migrations: %w", err)
+	}
+	return tx.Commit()
+}
+
+// appliedVersions garante a tabela schema_migrations e lê as versões aplicadas
+func (m *Migrator) appliedVersions(ctx context.Context) (map[string]*time.Time, error) {
+	if _, err := m.db.ExecContext(ctx, m.createTableQuery()); err != nil {
+		return nil, fmt.Errorf("erro ao criar schema_migrations: %w", err)
+	}
+
+	rows, err := m.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]*time.Time)
+	for rows.Next() {
+		var version string
+		var appliedAt sql.NullTime
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		// Normaliza a versão para o formato dos arquivos (ex.: "1" → "0001")
+		if mig, ok := m.find(version); ok {
+			version = mig.Version
+		}
+		if appliedAt.Valid {
+			at := appliedAt.Time
+			applied[version] = &at
+		} else {
+			applied[version] = nil
+		}
+	}
+	return applied, rows.Err()
+}
+
+// createTableQuery é compatível com a tabela criada pelo AutoMigrate de models.SchemaMigration
+func (m *Migrator) createTableQuery() string {
+	switch m.dialect {
+	case "postgres":
+		return `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    VARCHAR(50) PRIMARY KEY,
+			applied_at TIMESTAMPTZ NULL
+		)`
+	case "sqlite":
+		return `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    TEXT PRIMARY KEY,
+			applied_at DATETIME NULL
+		)`
+	default:
+		return `IF OBJECT_ID('schema_migrations', 'U') IS NULL
+		CREATE TABLE schema_migrations (
+			version    NVARCHAR(50) NOT NULL PRIMARY KEY,
+			applied_at DATETIMEOFFSET NULL
+		)`
+	}
+}
+
+func (m *Migrator) placeholder(n int) string {
+	switch m.dialect {
+	case "postgres":
+		return "$" + strconv.Itoa(n)
+	case "sqlite":
+		return "?" + strconv.Itoa(n)
+	default:
+		return "@p" + strconv.Itoa(n)
+	}
+}
@@ -0,0 +1,192 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/glebarez/go-sqlite"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/database/migrations"
+)
+
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "migrate.db"))
+	if err != nil {
+		t.Fatalf("não foi possivel abrir DB de teste: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&count); err != nil {
+		t.Fatalf("falha ao consultar sqlite_master: %v", err)
+	}
+	return count == 1
+}
+
+func TestMigrator_UpDownRedo(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+
+	m, err := New(db, "sqlite3", migrations.Files)
+	if err != nil {
+		t.Fatalf("esperava carregar migrations, obteve: %v", err)
+	}
+	if len(m.Migrations()) == 0 {
+		t.Fatal("esperava ao menos uma migration para sqlite")
+	}
+
+	applied, err := m.Up(ctx, 0)
+	if err != nil {
+		t.Fatalf("esperava sem erro no up, obteve: %v", err)
+	}
+	if len(applied) != len(m.Migrations()) {
+		t.Errorf("esperava %d migrations aplicadas, obteve: %d", len(m.Migrations()), len(applied))
+	}
+	if !tableExists(t, db, "curated_incidents") {
+		t.Error("esperava tabela curated_incidents após o up")
+	}
+
+	// Um segundo up não tem nada a aplicar
+	applied, err = m.Up(ctx, 0)
+	if err != nil || len(applied) != 0 {
+		t.Errorf("esperava nenhum pendente, obteve: %d (%v)", len(applied), err)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("esperava sem erro no status, obteve: %v", err)
+	}
+	for _, st := range statuses {
+		if !st.Applied || st.AppliedAt == nil {
+			t.Errorf("esperava %s_%s aplicada com data", st.Version, st.Name)
+		}
+	}
+
+	last := m.Migrations()[len(m.Migrations())-1]
+	redone, err := m.Redo(ctx)
+	if err != nil {
+		t.Fatalf("esperava sem erro no redo, obteve: %v", err)
+	}
+	if redone == nil || redone.Version != last.Version {
+		t.Errorf("esperava refazer %s, obteve: %+v", last.Version, redone)
+	}
+
+	reverted, err := m.Down(ctx, len(m.Migrations()))
+	if err != nil {
+		t.Fatalf("esperava sem erro no down, obteve: %v", err)
+	}
+	if len(reverted) != len(m.Migrations()) || reverted[0].Version != last.Version {
+		t.Errorf("esperava reverter da última para a primeira, obteve: %+v", reverted)
+	}
+	if tableExists(t, db, "curated_incidents") {
+		t.Error("esperava curated_incidents removida após o down")
+	}
+
+	var remaining int
+	db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&remaining)
+	if remaining != 0 {
+		t.Errorf("esperava schema_migrations vazia, obteve: %d", remaining)
+	}
+}
+
+func TestLoad_AllDialects(t *testing.T) {
+	var versions []string
+	for _, dialect := range []string{"sqlserver", "postgres", "sqlite"} {
+		list, err := Load(migrations.Files, dialect)
+		if err != nil {
+			t.Fatalf("esperava carregar %s, obteve: %v", dialect, err)
+		}
+		var current []string
+		for _, mig := range list {
+			current = append(current, mig.Version+"_"+mig.Name)
+			if mig.Down == "" {
+				t.Errorf("%s: migration %s_%s sem .down.sql", dialect, mig.Version, mig.Name)
+			}
+		}
+		if versions != nil && len(current) != len(versions) {
+			t.Errorf("esperava as mesmas migrations em todos os dialetos: %v x %v", versions, current)
+		}
+		versions = current
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `
+-- comentário; com ponto e vírgula
+CREATE TABLE a (name TEXT DEFAULT 'x;y');
+/* bloco; */
+CREATE FUNCTION f() RETURNS trigger AS $$ BEGIN NEW.x := 1; RETURN NEW; END; $$ LANGUAGE plpgsql;
+INSERT INTO a VALUES ('it''s; ok')
+`
+	stmts := SplitStatements("postgres", script)
+	if len(stmts) != 3 {
+		t.Fatalf("esperava 3 statements, obteve %d: %q", len(stmts), stmts)
+	}
+
+	batches := SplitStatements("sqlserver", "CREATE TABLE a (x INT);\nGO\n  go  \nCREATE INDEX i ON a (x);\nGO\n")
+	if len(batches) != 2 {
+		t.Fatalf("esperava 2 lotes, obteve %d: %q", len(batches), batches)
+	}
+}
+
+func TestMigration_NumericCoordinates(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+
+	m, err := New(db, "sqlite3", migrations.Files)
+	if err != nil {
+		t.Fatalf("esperava carregar migrations, obteve: %v", err)
+	}
+	if _, err := m.Up(ctx, 4); err != nil {
+		t.Fatalf("esperava aplicar até a 0004, obteve: %v", err)
+	}
+
+	// Mesmo bairro escrito de formas diferentes e uma coordenada inválida
+	_, err = db.Exec(`INSERT INTO neighborhoods (neighborhood_id, name, latitude, longitude, neighborhood_weight) VALUES
+        (1, 'Centro', '-22.9056', '-47.0608', 2),
+        (2, 'Centro', ' -22,90560 ', '-47.06080', 3),
+        (3, 'Sem coordenada', 'n/d', '-47.0608', 1);
+        INSERT INTO reports (neighborhood_id, crime_id, report_date) VALUES (1, 1, '2024-01-05'), (2, 1, '2024-01-06')`)
+	if err != nil {
+		t.Fatalf("falha ao inserir dados: %v", err)
+	}
+
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatalf("esperava sem erro na 0005, obteve: %v", err)
+	}
+
+	var lat float64
+	var weight int
+	if err := db.QueryRow(`SELECT latitude, neighborhood_weight FROM neighborhoods WHERE neighborhood_id = 1`).Scan(&lat, &weight); err != nil {
+		t.Fatalf("falha ao ler bairro: %v", err)
+	}
+	if lat != -22.9056 || weight != 5 {
+		t.Errorf("esperava latitude -22.9056 e peso 5, obteve: %v e %d", lat, weight)
+	}
+
+	var deleted, moved, nullLat int
+	db.QueryRow(`SELECT COUNT(*) FROM neighborhoods WHERE neighborhood_id = 2 AND deleted_at IS NOT NULL`).Scan(&deleted)
+	db.QueryRow(`SELECT COUNT(*) FROM reports WHERE neighborhood_id = 1`).Scan(&moved)
+	db.QueryRow(`SELECT COUNT(*) FROM neighborhoods WHERE neighborhood_id = 3 AND latitude IS NULL`).Scan(&nullLat)
+	if deleted != 1 || moved != 2 || nullLat != 1 {
+		t.Errorf("esperava duplicado apagado, 2 reports no bairro 1 e latitude NULL, obteve: %d, %d, %d", deleted, moved, nullLat)
+	}
+
+	if _, err := m.Down(ctx, 1); err != nil {
+		t.Fatalf("esperava sem erro no down da 0005, obteve: %v", err)
+	}
+	var text string
+	db.QueryRow(`SELECT latitude FROM neighborhoods WHERE neighborhood_id = 1`).Scan(&text)
+	if text != "-22.9056" {
+		t.Errorf("esperava latitude de volta como texto, obteve: %q", text)
+	}
+}
@@ -0,0 +1,132 @@
+package migrator
+
+import (
+	"bufio"
+	"strings"
+)
+
+// SplitStatements divide um script em statements executáveis um a um.
+//
+// No SQL Server o separador é uma linha contendo apenas GO (como no sqlcmd/SSMS),
+// já que CREATE TRIGGER/PROCEDURE precisam ser o primeiro statement do lote.
+// Nos demais dialetos o separador é ";" fora de strings, identificadores entre
+// aspas, comentários e blocos $$ do PostgreSQL.
+func SplitStatements(dialect, script string) []string {
+	if dialect == "sqlserver" {
+		return splitBatches(script)
+	}
+	return splitSemicolons(script)
+}
+
+func splitBatches(script string) []string {
+	var batches []string
+	var current strings.Builder
+
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); !isBlank(stmt) {
+			batches = append(batches, stmt)
+		}
+		current.Reset()
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(script))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.EqualFold(strings.TrimSpace(line), "GO") {
+			flush()
+			continue
+		}
+		current.WriteString(line)
+		current.WriteByte('\n')
+	}
+	flush()
+	return batches
+}
+
+func splitSemicolons(script string) []string {
+	var statements []string
+	start := 0
+
+	for i := 0; i < len(script); i++ {
+		switch c := script[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(script, i, c)
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			if end := strings.IndexByte(script[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(script)
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			if end := strings.Index(script[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(script)
+			}
+		case c == '$':
+			i = skipDollarQuoted(script, i)
+		case c == ';':
+			if stmt := strings.TrimSpace(script[start:i]); !isBlank(stmt) {
+				statements = append(statements, stmt)
+			}
+			start = i + 1
+		}
+	}
+	if start < len(script) {
+		if stmt := strings.TrimSpace(script[start:]); !isBlank(stmt) {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements
+}
+
+// skipQuoted retorna a posição da aspa que fecha o literal iniciado em i
+// (aspas duplicadas contam como escape)
+func skipQuoted(s string, i int, quote byte) int {
+	for j := i + 1; j < len(s); j++ {
+		if s[j] != quote {
+			continue
+		}
+		if j+1 < len(s) && s[j+1] == quote {
+			j++
+			continue
+		}
+		return j
+	}
+	return len(s)
+}
+
+// skipDollarQuoted pula um bloco $tag$ ... $tag$; se não for uma tag válida
+// (ex.: placeholder $1) não pula nada
+func skipDollarQuoted(s string, i int) int {
+	end := strings.IndexByte(s[i+1:], '$')
+	if end < 0 {
+		return i
+	}
+	tag := s[i : i+end+2]
+	for _, r := range tag[1 : len(tag)-1] {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return i
+		}
+	}
+	if len(tag) > 2 && tag[1] >= '0' && tag[1] <= '9' {
+		return i
+	}
+	closing := strings.Index(s[i+len(tag):], tag)
+	if closing < 0 {
+		return len(s)
+	}
+	return i + len(tag) + closing + len(tag) - 1
+}
+
+// isBlank indica se o trecho só tem espaços e comentários
+func isBlank(stmt string) bool {
+	for _, line := range strings.Split(stmt, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
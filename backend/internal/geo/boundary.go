@@ -0,0 +1,151 @@
+// Package geo guarda os limites (polígonos) dos bairros, localiza pontos e
+// células da grade dentro deles e projeta coordenadas no plano UTM
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/clip"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/planar"
+)
+
+// Boundary é o limite de um bairro, em coordenadas geográficas (lon/lat, WGS84)
+type Boundary struct {
+	Name     string
+	Geometry orb.MultiPolygon
+}
+
+// Bound retorna o retângulo que envolve o limite
+func (b Boundary) Bound() orb.Bound {
+	return b.Geometry.Bound()
+}
+
+// Centroid retorna o centroide do limite, usado como coordenada do bairro
+func (b Boundary) Centroid() orb.Point {
+	c, _ := planar.CentroidArea(b.Geometry)
+	return c
+}
+
+// MarshalGeometry grava a geometria como GeoJSON
+func MarshalGeometry(g orb.MultiPolygon) (string, error) {
+	data, err := json.Marshal(geojson.NewGeometry(g))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// UnmarshalGeometry lê uma geometria GeoJSON do tipo Polygon ou MultiPolygon
+func UnmarshalGeometry(data string) (orb.MultiPolygon, error) {
+	g, err := geojson.UnmarshalGeometry([]byte(data))
+	if err != nil {
+		return nil, err
+	}
+	return ToMultiPolygon(g.Geometry())
+}
+
+// ToMultiPolygon converte Polygon e MultiPolygon; outros tipos não são limites
+func ToMultiPolygon(g orb.Geometry) (orb.MultiPolygon, error) {
+	switch g := g.(type) {
+	case orb.Polygon:
+		return orb.MultiPolygon{g}, nil
+	case orb.MultiPolygon:
+		return g, nil
+	case nil:
+		return nil, fmt.Errorf("geometria vazia")
+	default:
+		return nil, fmt.Errorf("geometria %s não é um polígono", g.GeoJSONType())
+	}
+}
+
+// Match identifica o bairro encontrado no índice
+type Match struct {
+	ID   uint
+	Name string
+}
+
+type entry struct {
+	Match
+	geometry orb.MultiPolygon
+	bound    orb.Bound
+}
+
+// Index procura pontos e retângulos nos limites dos bairros. Cada limite é
+// descartado primeiro pelo retângulo que o envolve; Campinas tem algumas
+// centenas de bairros, então a busca linear é suficiente.
+type Index struct {
+	entries []entry
+}
+
+// NewIndex cria um índice vazio
+func NewIndex() *Index {
+	return &Index{}
+}
+
+// Add inclui o limite do bairro id
+func (x *Index) Add(id uint, name string, g orb.MultiPolygon) {
+	x.entries = append(x.entries, entry{Match: Match{ID: id, Name: name}, geometry: g, bound: g.Bound()})
+}
+
+// Len retorna o número de limites no índice
+func (x *Index) Len() int {
+	return len(x.entries)
+}
+
+// Locate retorna o bairro cujo limite contém o ponto. Se os limites se
+// sobrepõem, vale o primeiro incluído.
+func (x *Index) Locate(lat, lon float64) (Match, bool) {
+	p := orb.Point{lon, lat}
+	for _, e := range x.entries {
+		if e.bound.Contains(p) && planar.MultiPolygonContains(e.geometry, p) {
+			return e.Match, true
+		}
+	}
+	return Match{}, false
+}
+
+// LargestOverlap retorna o bairro com a maior área em comum com o retângulo,
+// ou false se nenhum limite o toca
+func (x *Index) LargestOverlap(b orb.Bound) (Match, bool) {
+	var best Match
+	bestArea := 0.0
+	for _, e := range x.entries {
+		if !e.bound.Intersects(b) {
+			continue
+		}
+		if area := OverlapArea(e.geometry, b); area > bestArea {
+			best, bestArea = e.Match, area
+		}
+	}
+	return best, bestArea > 0
+}
+
+// OverlapArea é a área (em graus²) da parte da geometria dentro do retângulo
+func OverlapArea(g orb.MultiPolygon, b orb.Bound) float64 {
+	// O clip usa a geometria recebida como rascunho
+	clipped := clip.MultiPolygon(b, g.Clone())
+	if len(clipped) == 0 {
+		return 0
+	}
+	return planar.Area(clipped)
+}
+
+// accents remove os acentos do português para comparar nomes de bairros
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// NormalizeName deixa o nome de um bairro em minúsculas, sem acentos e com
+// espaços simples, para casar "JARDIM PROENÇA" com "Jardim Proença"
+func NormalizeName(name string) string {
+	return strings.Join(strings.Fields(accents.Replace(strings.ToLower(name))), " ")
+}
@@ -0,0 +1,89 @@
+package geo
+
+import "math"
+
+// Parâmetros do elipsoide WGS84 (o SIRGAS 2000 usa o GRS80, que difere em
+// décimos de milímetro) e da projeção UTM
+const (
+	wgs84A = 6378137.0
+	wgs84F = 1 / 298.257223563
+
+	utmK0            = 0.9996
+	utmFalseEasting  = 500000.0
+	utmFalseNorthing = 10000000.0 // hemisfério sul
+)
+
+// UTMZone é um fuso UTM do hemisfério sul
+type UTMZone struct {
+	Number int
+}
+
+// UTM23S é o fuso de Campinas (meridiano central 45°W)
+var UTM23S = UTMZone{Number: 23}
+
+// Coeficientes da série de Krüger até n³, precisa ao milímetro dentro do fuso
+var (
+	utmN = wgs84F / (2 - wgs84F)
+	utmA = wgs84A / (1 + utmN) * (1 + utmN*utmN/4 + utmN*utmN*utmN*utmN/64)
+
+	utmAlpha = [3]float64{
+		utmN/2 - 2*utmN*utmN/3 + 5*utmN*utmN*utmN/16,
+		13*utmN*utmN/48 - 3*utmN*utmN*utmN/5,
+		61 * utmN * utmN * utmN / 240,
+	}
+	utmBeta = [3]float64{
+		utmN/2 - 2*utmN*utmN/3 + 37*utmN*utmN*utmN/96,
+		utmN*utmN/48 + utmN*utmN*utmN/15,
+		17 * utmN * utmN * utmN / 480,
+	}
+	utmDelta = [3]float64{
+		2*utmN - 2*utmN*utmN/3 - 2*utmN*utmN*utmN,
+		7*utmN*utmN/3 - 8*utmN*utmN*utmN/5,
+		56 * utmN * utmN * utmN / 15,
+	}
+)
+
+// centralMeridian retorna o meridiano central do fuso, em radianos
+func (z UTMZone) centralMeridian() float64 {
+	return float64(z.Number*6-183) * math.Pi / 180
+}
+
+// Forward projeta latitude/longitude (graus) em easting/northing (metros)
+func (z UTMZone) Forward(lat, lon float64) (easting, northing float64) {
+	phi := lat * math.Pi / 180
+	lambda := lon*math.Pi/180 - z.centralMeridian()
+
+	e := 2 * math.Sqrt(utmN) / (1 + utmN)
+	t := math.Sinh(math.Atanh(math.Sin(phi)) - e*math.Atanh(e*math.Sin(phi)))
+	xi := math.Atan2(t, math.Cos(lambda))
+	eta := math.Atanh(math.Sin(lambda) / math.Sqrt(1+t*t))
+
+	x, y := eta, xi
+	for j, alpha := range utmAlpha {
+		k := 2 * float64(j+1)
+		x += alpha * math.Cos(k*xi) * math.Sinh(k*eta)
+		y += alpha * math.Sin(k*xi) * math.Cosh(k*eta)
+	}
+	return utmFalseEasting + utmK0*utmA*x, utmFalseNorthing + utmK0*utmA*y
+}
+
+// Inverse converte easting/northing (metros) de volta em latitude/longitude (graus)
+func (z UTMZone) Inverse(easting, northing float64) (lat, lon float64) {
+	xi := (northing - utmFalseNorthing) / (utmK0 * utmA)
+	eta := (easting - utmFalseEasting) / (utmK0 * utmA)
+
+	xiP, etaP := xi, eta
+	for j, beta := range utmBeta {
+		k := 2 * float64(j+1)
+		xiP -= beta * math.Sin(k*xi) * math.Cosh(k*eta)
+		etaP -= beta * math.Cos(k*xi) * math.Sinh(k*eta)
+	}
+
+	chi := math.Asin(math.Sin(xiP) / math.Cosh(etaP))
+	phi := chi
+	for j, delta := range utmDelta {
+		phi += delta * math.Sin(2*float64(j+1)*chi)
+	}
+	lambda := z.centralMeridian() + math.Atan2(math.Sinh(etaP), math.Cos(xiP))
+	return phi * 180 / math.Pi, lambda * 180 / math.Pi
+}
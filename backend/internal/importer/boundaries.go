@@ -0,0 +1,291 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jonas-p/go-shp"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/planar"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/geo"
+)
+
+// BoundaryNameFields são os atributos procurados, nesta ordem, quando o campo
+// com o nome do bairro não é informado
+var BoundaryNameFields = []string{"name", "nome", "bairro", "nm_bairro", "nome_bairro", "nm_bai"}
+
+// ErrProjectedCoordinates indica um arquivo em coordenadas projetadas (ex.:
+// UTM); os limites precisam estar em lon/lat
+var ErrProjectedCoordinates = errors.New("coordenadas fora de lon/lat: converta o arquivo para EPSG:4326 (ex.: ogr2ogr -t_srs EPSG:4326)")
+
+// ReadBoundariesGeoJSON lê os limites de uma FeatureCollection (ou Feature)
+// com geometrias Polygon/MultiPolygon. O nome vem da propriedade nameField ou,
+// se vazio, da primeira de BoundaryNameFields encontrada. Features com o mesmo
+// nome são unidas em um único multipolígono.
+func ReadBoundariesGeoJSON(r io.Reader, nameField string) ([]geo.Boundary, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var probe struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("GeoJSON inválido: %w", err)
+	}
+
+	var features []*geojson.Feature
+	switch probe.Type {
+	case "FeatureCollection":
+		fc, err := geojson.UnmarshalFeatureCollection(data)
+		if err != nil {
+			return nil, fmt.Errorf("GeoJSON inválido: %w", err)
+		}
+		features = fc.Features
+	case "Feature":
+		f, err := geojson.UnmarshalFeature(data)
+		if err != nil {
+			return nil, fmt.Errorf("GeoJSON inválido: %w", err)
+		}
+		features = []*geojson.Feature{f}
+	default:
+		return nil, fmt.Errorf("GeoJSON do tipo %q: esperava FeatureCollection ou Feature", probe.Type)
+	}
+
+	var set boundarySet
+	for i, f := range features {
+		props := make(map[string]string, len(f.Properties))
+		for key, value := range f.Properties {
+			if value != nil {
+				props[key] = strings.TrimSpace(fmt.Sprint(value))
+			}
+		}
+		name, err := boundaryName(props, nameField)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+		g, err := geo.ToMultiPolygon(f.Geometry)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d (%s): %w", i, name, err)
+		}
+		set.add(name, g)
+	}
+	return set.result()
+}
+
+// ReadBoundariesShapefile lê os limites de um shapefile de polígonos: o .shp
+// (com o .dbf ao lado) ou um .zip com os dois. O nome vem do campo nameField
+// do .dbf ou, se vazio, do primeiro de BoundaryNameFields encontrado.
+func ReadBoundariesShapefile(path, nameField string) ([]geo.Boundary, error) {
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return ReadBoundariesShapefileZip(bytes.NewReader(data), int64(len(data)), nameField)
+	}
+
+	shpFile, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	dbfFile, err := os.Open(strings.TrimSuffix(path, filepath.Ext(path)) + ".dbf")
+	if err != nil {
+		shpFile.Close()
+		return nil, fmt.Errorf("shapefile sem o .dbf com os nomes: %w", err)
+	}
+	return readShapefile(shp.SequentialReaderFromExt(shpFile, dbfFile), nameField)
+}
+
+// ReadBoundariesShapefileZip lê um .zip com um único shapefile (.shp e .dbf)
+func ReadBoundariesShapefileZip(r io.ReaderAt, size int64, nameField string) ([]geo.Boundary, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("zip inválido: %w", err)
+	}
+
+	var shpEntry, dbfEntry *zip.File
+	for _, f := range z.File {
+		switch strings.ToLower(filepath.Ext(f.Name)) {
+		case ".shp":
+			if shpEntry != nil {
+				return nil, fmt.Errorf("o zip tem mais de um .shp")
+			}
+			shpEntry = f
+		case ".dbf":
+			dbfEntry = f
+		}
+	}
+	if shpEntry == nil || dbfEntry == nil {
+		return nil, fmt.Errorf("o zip precisa ter o .shp e o .dbf do shapefile")
+	}
+
+	shpFile, err := shpEntry.Open()
+	if err != nil {
+		return nil, err
+	}
+	dbfFile, err := dbfEntry.Open()
+	if err != nil {
+		shpFile.Close()
+		return nil, err
+	}
+	return readShapefile(shp.SequentialReaderFromExt(shpFile, dbfFile), nameField)
+}
+
+func readShapefile(sr shp.SequentialReader, nameField string) ([]geo.Boundary, error) {
+	defer sr.Close()
+
+	fields := sr.Fields()
+	var set boundarySet
+	for sr.Next() {
+		n, shape := sr.Shape()
+
+		props := make(map[string]string, len(fields))
+		for i, f := range fields {
+			props[f.String()] = decodeDBFText(sr.Attribute(i))
+		}
+		name, err := boundaryName(props, nameField)
+		if err != nil {
+			return nil, fmt.Errorf("registro %d: %w", n, err)
+		}
+
+		var parts []int32
+		var points []shp.Point
+		switch s := shape.(type) {
+		case *shp.Polygon:
+			parts, points = s.Parts, s.Points
+		case *shp.PolygonZ:
+			parts, points = s.Parts, s.Points
+		case *shp.PolygonM:
+			parts, points = s.Parts, s.Points
+		default:
+			return nil, fmt.Errorf("registro %d (%s): shapefile não é de polígonos", n, name)
+		}
+		set.add(name, shapefilePolygons(parts, points))
+	}
+	if err := sr.Err(); err != nil {
+		return nil, fmt.Errorf("shapefile inválido: %w", err)
+	}
+	return set.result()
+}
+
+// shapefilePolygons monta os polígonos a partir dos anéis do shapefile: anéis
+// em sentido horário são externos e os anti-horários são buracos do polígono
+// externo que os contém
+func shapefilePolygons(parts []int32, points []shp.Point) orb.MultiPolygon {
+	var mp orb.MultiPolygon
+	var holes []orb.Ring
+	for i, start := range parts {
+		end := int32(len(points))
+		if i+1 < len(parts) {
+			end = parts[i+1]
+		}
+		ring := make(orb.Ring, 0, end-start)
+		for _, p := range points[start:end] {
+			ring = append(ring, orb.Point{p.X, p.Y})
+		}
+		if len(ring) < 4 {
+			continue
+		}
+		if ring.Orientation() == orb.CW {
+			mp = append(mp, orb.Polygon{ring})
+		} else {
+			holes = append(holes, ring)
+		}
+	}
+
+	for _, hole := range holes {
+		placed := false
+		for i := range mp {
+			if planar.RingContains(mp[i][0], hole[0]) {
+				mp[i] = append(mp[i], hole)
+				placed = true
+				break
+			}
+		}
+		// Anel anti-horário fora de todos: arquivo com a orientação invertida
+		if !placed {
+			mp = append(mp, orb.Polygon{hole})
+		}
+	}
+	return mp
+}
+
+// decodeDBFText converte os atributos do .dbf, que nos arquivos das
+// prefeituras costumam estar em Latin-1, para UTF-8
+func decodeDBFText(s string) string {
+	s = strings.TrimSpace(strings.TrimRight(s, "\x00"))
+	if utf8.ValidString(s) {
+		return s
+	}
+	runes := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		runes[i] = rune(s[i])
+	}
+	return string(runes)
+}
+
+// boundaryName retorna o nome do bairro nas propriedades, comparando os nomes
+// dos campos sem diferenciar maiúsculas
+func boundaryName(props map[string]string, nameField string) (string, error) {
+	candidates := BoundaryNameFields
+	if nameField != "" {
+		candidates = []string{nameField}
+	}
+	for _, candidate := range candidates {
+		for key, value := range props {
+			if strings.EqualFold(key, candidate) && value != "" {
+				return value, nil
+			}
+		}
+	}
+	if nameField != "" {
+		return "", fmt.Errorf("sem o campo %q com o nome do bairro", nameField)
+	}
+	return "", fmt.Errorf("sem campo com o nome do bairro (%s); informe o campo", strings.Join(BoundaryNameFields, ", "))
+}
+
+// boundarySet junta os limites por nome, mantendo a ordem do arquivo
+type boundarySet struct {
+	boundaries []geo.Boundary
+	byName     map[string]int
+}
+
+func (s *boundarySet) add(name string, g orb.MultiPolygon) {
+	if s.byName == nil {
+		s.byName = make(map[string]int)
+	}
+	key := geo.NormalizeName(name)
+	if i, ok := s.byName[key]; ok {
+		s.boundaries[i].Geometry = append(s.boundaries[i].Geometry, g...)
+		return
+	}
+	s.byName[key] = len(s.boundaries)
+	s.boundaries = append(s.boundaries, geo.Boundary{Name: name, Geometry: g})
+}
+
+// result valida as coordenadas: um arquivo em UTM tem valores na casa das
+// centenas de milhares e não pode ser lido como lon/lat
+func (s *boundarySet) result() ([]geo.Boundary, error) {
+	for _, b := range s.boundaries {
+		if len(b.Geometry) == 0 {
+			return nil, fmt.Errorf("%s: limite sem polígonos", b.Name)
+		}
+		bound := b.Bound()
+		if bound.Min[0] < -180 || bound.Max[0] > 180 || bound.Min[1] < -90 || bound.Max[1] > 90 {
+			return nil, ErrProjectedCoordinates
+		}
+	}
+	return s.boundaries, nil
+}
@@ -0,0 +1,135 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+	"github.com/paulmach/orb/planar"
+)
+
+func TestReadBoundariesGeoJSON(t *testing.T) {
+	data := `{
+		"type": "FeatureCollection",
+		"features": [
+			{"type": "Feature", "properties": {"NOME": "Centro", "codigo": 1},
+			 "geometry": {"type": "Polygon", "coordinates": [[[-47.08, -22.92], [-47.04, -22.92], [-47.04, -22.89], [-47.08, -22.89], [-47.08, -22.92]]]}},
+			{"type": "Feature", "properties": {"NOME": "Barão Geraldo", "codigo": 2},
+			 "geometry": {"type": "Polygon", "coordinates": [[[-47.10, -22.84], [-47.06, -22.84], [-47.06, -22.80], [-47.10, -22.80], [-47.10, -22.84]]]}},
+			{"type": "Feature", "properties": {"NOME": "BARAO  GERALDO", "codigo": 3},
+			 "geometry": {"type": "MultiPolygon", "coordinates": [[[[-47.12, -22.84], [-47.11, -22.84], [-47.11, -22.83], [-47.12, -22.83], [-47.12, -22.84]]]]}}
+		]
+	}`
+
+	boundaries, err := ReadBoundariesGeoJSON(strings.NewReader(data), "")
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if len(boundaries) != 2 {
+		t.Fatalf("esperava 2 bairros (Barão Geraldo unido), obteve: %d", len(boundaries))
+	}
+	if boundaries[0].Name != "Centro" || len(boundaries[0].Geometry) != 1 {
+		t.Errorf("esperava o Centro com 1 polígono, obteve: %s com %d", boundaries[0].Name, len(boundaries[0].Geometry))
+	}
+	if boundaries[1].Name != "Barão Geraldo" || len(boundaries[1].Geometry) != 2 {
+		t.Errorf("esperava Barão Geraldo com 2 polígonos, obteve: %s com %d", boundaries[1].Name, len(boundaries[1].Geometry))
+	}
+
+	if _, err := ReadBoundariesGeoJSON(strings.NewReader(data), "nm_bairro"); err == nil {
+		t.Error("esperava erro para campo de nome inexistente")
+	}
+
+	projected := `{"type": "Feature", "properties": {"name": "Centro"},
+		"geometry": {"type": "Polygon", "coordinates": [[[287000, 7465000], [288000, 7465000], [288000, 7466000], [287000, 7465000]]]}}`
+	if _, err := ReadBoundariesGeoJSON(strings.NewReader(projected), ""); !errors.Is(err, ErrProjectedCoordinates) {
+		t.Errorf("esperava ErrProjectedCoordinates para UTM, obteve: %v", err)
+	}
+
+	point := `{"type": "Feature", "properties": {"name": "Centro"}, "geometry": {"type": "Point", "coordinates": [-47.06, -22.90]}}`
+	if _, err := ReadBoundariesGeoJSON(strings.NewReader(point), ""); err == nil {
+		t.Error("esperava erro para geometria que não é polígono")
+	}
+}
+
+// buildShapefileZip grava um shapefile de polígonos com o campo NM_BAIRRO e
+// retorna o .zip com o .shp e o .dbf
+func buildShapefileZip(t *testing.T, names []string, polygons [][][]shp.Point) []byte {
+	t.Helper()
+
+	base := filepath.Join(t.TempDir(), "bairros")
+	w, err := shp.Create(base+".shp", shp.POLYGON)
+	if err != nil {
+		t.Fatalf("falha ao criar shapefile: %v", err)
+	}
+	if err := w.SetFields([]shp.Field{shp.StringField("NM_BAIRRO", 50)}); err != nil {
+		t.Fatalf("falha ao criar campos: %v", err)
+	}
+	for i, parts := range polygons {
+		polygon := shp.Polygon(*shp.NewPolyLine(parts))
+		n := w.Write(&polygon)
+		if err := w.WriteAttribute(int(n), 0, names[i]); err != nil {
+			t.Fatalf("falha ao gravar atributo: %v", err)
+		}
+	}
+	w.Close()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	// O writer do go-shp grava o .dbf como "<base>dbf", sem o ponto
+	files := map[string]string{".shp": base + ".shp", ".shx": base + ".shx", ".dbf": base + "dbf"}
+	for ext, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("falha ao ler %s: %v", ext, err)
+		}
+		f, _ := zw.Create("bairros" + ext)
+		f.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("falha ao gerar zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadBoundariesShapefileZip(t *testing.T) {
+	// Anel externo em sentido horário e buraco anti-horário, como no padrão ESRI
+	outer := []shp.Point{{X: -47.08, Y: -22.92}, {X: -47.08, Y: -22.89}, {X: -47.04, Y: -22.89}, {X: -47.04, Y: -22.92}, {X: -47.08, Y: -22.92}}
+	hole := []shp.Point{{X: -47.07, Y: -22.91}, {X: -47.05, Y: -22.91}, {X: -47.05, Y: -22.90}, {X: -47.07, Y: -22.90}, {X: -47.07, Y: -22.91}}
+	other := []shp.Point{{X: -47.10, Y: -22.84}, {X: -47.10, Y: -22.80}, {X: -47.06, Y: -22.80}, {X: -47.06, Y: -22.84}, {X: -47.10, Y: -22.84}}
+
+	// "Proen\xe7a" é o nome em Latin-1, como nos arquivos das prefeituras
+	data := buildShapefileZip(t, []string{"Jardim Proen\xe7a", "Taquaral"},
+		[][][]shp.Point{{outer, hole}, {other}})
+
+	boundaries, err := ReadBoundariesShapefileZip(bytes.NewReader(data), int64(len(data)), "")
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if len(boundaries) != 2 {
+		t.Fatalf("esperava 2 bairros, obteve: %d", len(boundaries))
+	}
+	if boundaries[0].Name != "Jardim Proença" {
+		t.Errorf("esperava o nome convertido para UTF-8, obteve: %q", boundaries[0].Name)
+	}
+	if len(boundaries[0].Geometry) != 1 || len(boundaries[0].Geometry[0]) != 2 {
+		t.Fatalf("esperava 1 polígono com 1 buraco, obteve: %v", boundaries[0].Geometry)
+	}
+	if planar.MultiPolygonContains(boundaries[0].Geometry, [2]float64{-47.06, -22.905}) {
+		t.Error("esperava o ponto do buraco fora do limite")
+	}
+	if !planar.MultiPolygonContains(boundaries[0].Geometry, [2]float64{-47.075, -22.915}) {
+		t.Error("esperava o ponto entre o anel externo e o buraco dentro do limite")
+	}
+	if boundaries[1].Name != "Taquaral" {
+		t.Errorf("esperava Taquaral, obteve: %q", boundaries[1].Name)
+	}
+
+	if _, err := ReadBoundariesShapefileZip(bytes.NewReader([]byte("não é zip")), 9, ""); err == nil {
+		t.Error("esperava erro para arquivo que não é zip")
+	}
+}
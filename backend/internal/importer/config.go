@@ -0,0 +1,85 @@
+package importer
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//go:embed ssp_default.json
+var defaultConfigJSON []byte
+
+// Precinct é a delegacia (DP) de uma planilha e o bairro/coordenadas usados
+// nos reports gerados a partir dela
+type Precinct struct {
+	Neighborhood string  `json:"neighborhood"`
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+}
+
+// Config é a tabela configurável do importador: delegacias e nomes
+// padronizados dos crimes. Os pesos vêm da taxonomia de crimes do banco.
+type Config struct {
+	// Precincts mapeia o código da DP ("01 DP") para bairro e coordenadas
+	Precincts map[string]Precinct `json:"precincts"`
+	// CrimeNames mapeia a natureza da planilha (sem o "(n)" das notas) para o nome do crime
+	CrimeNames map[string]string `json:"crime_names"`
+}
+
+// DefaultConfig retorna a tabela padrão com as 13 DPs de Campinas
+func DefaultConfig() (*Config, error) {
+	return parseConfig(defaultConfigJSON)
+}
+
+// LoadConfig lê a tabela de um arquivo JSON no mesmo formato de
+// ssp_default.json; com path vazio retorna a tabela padrão
+func LoadConfig(path string) (*Config, error) {
+	if path == "" {
+		return DefaultConfig()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler tabela de delegacias: %w", err)
+	}
+	return parseConfig(data)
+}
+
+func parseConfig(data []byte) (*Config, error) {
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("tabela de delegacias inválida: %w", err)
+	}
+	if len(cfg.Precincts) == 0 {
+		return nil, fmt.Errorf("tabela de delegacias sem nenhuma DP")
+	}
+	// As chaves são comparadas já normalizadas
+	names := make(map[string]string, len(cfg.CrimeNames))
+	for raw, name := range cfg.CrimeNames {
+		names[normalizeNature(raw)] = name
+	}
+	cfg.CrimeNames = names
+
+	return &cfg, nil
+}
+
+// CrimeName retorna o nome padronizado de uma natureza da planilha; naturezas
+// fora da tabela viram "Primeira Letra Maiúscula"
+func (c *Config) CrimeName(nature string) string {
+	if name, ok := c.CrimeNames[normalizeNature(nature)]; ok {
+		return name
+	}
+	return titleCase(strings.TrimSpace(notePattern.ReplaceAllString(nature, "")))
+}
+
+func titleCase(s string) string {
+	words := strings.Fields(strings.ToLower(s))
+	for i, w := range words {
+		r := []rune(w)
+		r[0] = []rune(strings.ToUpper(string(r[0])))[0]
+		words[i] = string(r)
+	}
+	return strings.Join(words, " ")
+}
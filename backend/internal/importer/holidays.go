@@ -0,0 +1,201 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/calendar"
+)
+
+// holidayDateLayouts são os formatos de data aceitos no CSV de feriados
+var holidayDateLayouts = []string{"2006-01-02", "02/01/2006"}
+
+// Colunas reconhecidas no cabeçalho do CSV de feriados
+var (
+	holidayDateColumns = []string{"date", "data"}
+	holidayNameColumns = []string{"name", "nome", "feriado", "descricao"}
+	holidayTypeColumns = []string{"type", "tipo"}
+)
+
+// ErrNoHolidays indica um arquivo sem nenhum feriado
+var ErrNoHolidays = errors.New("nenhum feriado encontrado no arquivo")
+
+// ReadHolidaysCSV lê feriados de um CSV separado por vírgula ou ponto e
+// vírgula, com as colunas data, nome e tipo (opcional). O cabeçalho é
+// opcional: sem ele, as colunas são lidas nessa ordem. A data pode estar em
+// AAAA-MM-DD ou DD/MM/AAAA; sem tipo, o feriado é gravado como importado.
+func ReadHolidaysCSV(r io.Reader) ([]calendar.Holiday, error) {
+	br := bufio.NewReader(r)
+	first, err := br.Peek(4096)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	reader := csv.NewReader(br)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if line, _, _ := strings.Cut(string(first), "\n"); strings.Count(line, ";") > strings.Count(line, ",") {
+		reader.Comma = ';'
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV inválido: %w", err)
+	}
+
+	dateCol, nameCol, typeCol := 0, 1, 2
+	start := 0
+	if len(records) > 0 {
+		header := records[0]
+		header[0] = strings.TrimPrefix(header[0], "\ufeff") // BOM do Excel
+		if _, err := parseHolidayDate(header[0]); err != nil {
+			dateCol = findColumn(header, holidayDateColumns)
+			nameCol = findColumn(header, holidayNameColumns)
+			typeCol = findColumn(header, holidayTypeColumns)
+			if dateCol < 0 || nameCol < 0 {
+				return nil, errors.New("CSV sem as colunas de data e nome (ex.: data;nome;tipo)")
+			}
+			start = 1
+		}
+	}
+
+	var holidays []calendar.Holiday
+	for i := start; i < len(records); i++ {
+		record := records[i]
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if dateCol >= len(record) || nameCol >= len(record) {
+			return nil, fmt.Errorf("linha %d: esperava data e nome", i+1)
+		}
+		date, err := parseHolidayDate(record[dateCol])
+		if err != nil {
+			return nil, fmt.Errorf("linha %d: %w", i+1, err)
+		}
+		name := strings.TrimSpace(record[nameCol])
+		if name == "" {
+			return nil, fmt.Errorf("linha %d: feriado sem nome", i+1)
+		}
+		kind := calendar.TypeImported
+		if typeCol >= 0 && typeCol < len(record) && strings.TrimSpace(record[typeCol]) != "" {
+			kind = strings.ToLower(strings.TrimSpace(record[typeCol]))
+		}
+		holidays = append(holidays, calendar.Holiday{Date: date, Name: name, Type: kind})
+	}
+	if len(holidays) == 0 {
+		return nil, ErrNoHolidays
+	}
+	return calendar.Merge(holidays), nil
+}
+
+// ReadHolidaysICS lê os eventos de dia inteiro (DTSTART;VALUE=DATE) de um
+// calendário iCalendar, como os exportados pelo Google Agenda. Um evento de
+// vários dias vira um feriado por dia; eventos com horário são ignorados.
+func ReadHolidaysICS(r io.Reader) ([]calendar.Holiday, error) {
+	lines, err := unfoldICS(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var holidays []calendar.Holiday
+	var inEvent, timed bool
+	var start, end time.Time
+	var summary string
+	for i, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name, params, _ := strings.Cut(strings.ToUpper(name), ";")
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			inEvent, timed = true, false
+			start, end, summary = time.Time{}, time.Time{}, ""
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			inEvent = false
+			if timed || start.IsZero() || summary == "" {
+				continue
+			}
+			if end.IsZero() || !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			// DTEND de um evento de dia inteiro é exclusivo
+			for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+				holidays = append(holidays, calendar.Holiday{Date: day, Name: summary, Type: calendar.TypeImported})
+			}
+		case !inEvent:
+			// Propriedades do próprio calendário (VERSION, PRODID...)
+		case name == "DTSTART" || name == "DTEND":
+			// Só eventos de dia inteiro: "20250101", sem a hora
+			if len(value) != 8 && !strings.Contains(params, "VALUE=DATE") {
+				timed = true
+				continue
+			}
+			date, err := time.Parse("20060102", value)
+			if err != nil {
+				return nil, fmt.Errorf("linha %d: data inválida %q", i+1, value)
+			}
+			if name == "DTSTART" {
+				start = date
+			} else {
+				end = date
+			}
+		case name == "SUMMARY":
+			summary = unescapeICS(value)
+		}
+	}
+	if len(holidays) == 0 {
+		return nil, ErrNoHolidays
+	}
+	return calendar.Merge(holidays), nil
+}
+
+// unfoldICS lê as linhas do calendário, juntando as continuações (linhas que
+// começam com espaço ou tab)
+func unfoldICS(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// unescapeICS desfaz os escapes de um valor de texto do iCalendar
+func unescapeICS(value string) string {
+	return strings.TrimSpace(strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\N`, " ", `\\`, `\`).Replace(value))
+}
+
+func parseHolidayDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range holidayDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("data inválida %q: use AAAA-MM-DD ou DD/MM/AAAA", value)
+}
+
+// findColumn retorna o índice da primeira coluna do cabeçalho com um dos nomes
+// (sem diferenciar maiúsculas e acentos), ou -1
+func findColumn(header []string, names []string) int {
+	for i, column := range header {
+		folded := foldName(column)
+		for _, name := range names {
+			if folded == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,85 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/calendar"
+)
+
+func TestReadHolidaysCSV(t *testing.T) {
+	data := "\ufeffData;Nome;Tipo\n" +
+		"25/01/2025;Aniversário de São Paulo;estadual\n" +
+		"2025-07-14;Fundação de Campinas;\n" +
+		"\n"
+	holidays, err := ReadHolidaysCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if len(holidays) != 2 {
+		t.Fatalf("esperava 2 feriados, obteve: %d", len(holidays))
+	}
+	if holidays[0].Key() != "2025-01-25" || holidays[0].Type != "estadual" {
+		t.Errorf("esperava 2025-01-25 estadual, obteve: %+v", holidays[0])
+	}
+	if holidays[1].Name != "Fundação de Campinas" || holidays[1].Type != calendar.TypeImported {
+		t.Errorf("esperava a Fundação de Campinas importada, obteve: %+v", holidays[1])
+	}
+
+	// Sem cabeçalho, as colunas são data, nome e tipo
+	holidays, err = ReadHolidaysCSV(strings.NewReader("2025-12-24,Véspera de Natal,facultativo\n"))
+	if err != nil || len(holidays) != 1 || holidays[0].Type != "facultativo" {
+		t.Errorf("esperava 1 feriado facultativo, obteve: %+v (%v)", holidays, err)
+	}
+
+	for _, bad := range []string{"data;valor\n2025-01-01;x\n", "2025-13-01;Mês inválido\n", "2025-01-01;\n"} {
+		if _, err := ReadHolidaysCSV(strings.NewReader(bad)); err == nil {
+			t.Errorf("esperava erro para %q", bad)
+		}
+	}
+	if _, err := ReadHolidaysCSV(strings.NewReader("data;nome\n")); !errors.Is(err, ErrNoHolidays) {
+		t.Errorf("esperava ErrNoHolidays, obteve: %v", err)
+	}
+}
+
+func TestReadHolidaysICS(t *testing.T) {
+	data := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20250303",
+		"DTEND;VALUE=DATE:20250305",
+		"SUMMARY:Carnaval",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20250714",
+		"SUMMARY:Fundação de Campinas\\, ponto",
+		"  facultativo",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20250715T120000Z",
+		"DTEND:20250715T130000Z",
+		"SUMMARY:Reunião",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	holidays, err := ReadHolidaysICS(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if len(holidays) != 3 {
+		t.Fatalf("esperava 3 feriados (Carnaval em 2 dias), obteve: %+v", holidays)
+	}
+	if holidays[0].Key() != "2025-03-03" || holidays[1].Key() != "2025-03-04" || holidays[1].Name != "Carnaval" {
+		t.Errorf("esperava o Carnaval em 03 e 04/03, obteve: %+v", holidays[:2])
+	}
+	if holidays[2].Name != "Fundação de Campinas, ponto facultativo" {
+		t.Errorf("esperava o SUMMARY desdobrado, obteve: %q", holidays[2].Name)
+	}
+
+	if _, err := ReadHolidaysICS(strings.NewReader("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n")); !errors.Is(err, ErrNoHolidays) {
+		t.Errorf("esperava ErrNoHolidays, obteve: %v", err)
+	}
+}
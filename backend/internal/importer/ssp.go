@@ -0,0 +1,293 @@
+// Package importer lê as planilhas "OcorrenciaMensal(Criminal)" da SSP-SP e
+// as transforma em reports
+package importer
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+// ReportDateLayout é o formato de report_date gerado pelo importador (dd/mm/aaaa)
+const ReportDateLayout = "02/01/2006"
+
+var (
+	// precinctPattern extrai o número da DP do nome do arquivo
+	// ("OcorrenciaMensal(Criminal)-01 DP - Campinas_....xlsx")
+	precinctPattern = regexp.MustCompile(`(\d{2})\s*DP`)
+	// municipalityPattern extrai o município que segue a DP no nome do arquivo
+	municipalityPattern = regexp.MustCompile(`\d{2}\s*DP\s*-?\s*([^_.]+)`)
+	// notePattern remove as notas de rodapé das naturezas ("FURTO - OUTROS (3)")
+	notePattern = regexp.MustCompile(`\s*\(\d+\)`)
+
+	accentReplacer = strings.NewReplacer(
+		"á", "a", "à", "a", "â", "a", "ã", "a", "é", "e", "ê", "e", "í", "i",
+		"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ü", "u", "ç", "c",
+	)
+
+	monthsByName = map[string]int{
+		"janeiro": 1, "fevereiro": 2, "marco": 3, "abril": 4, "maio": 5, "junho": 6,
+		"julho": 7, "agosto": 8, "setembro": 9, "outubro": 10, "novembro": 11, "dezembro": 12,
+	}
+)
+
+// Row é a contagem de uma natureza em um mês de uma delegacia
+type Row struct {
+	Year      int    `json:"year"`
+	Month     int    `json:"month"`
+	Nature    string `json:"nature"`
+	CrimeName string `json:"crime_name"`
+	Count     int    `json:"count"`
+}
+
+// Result é o conteúdo de uma planilha já mapeado para a delegacia
+type Result struct {
+	File         string   `json:"file"`
+	Code         string   `json:"precinct"`
+	Municipality string   `json:"municipality"`
+	Precinct     Precinct `json:"-"`
+	Rows         []Row    `json:"-"`
+	// Periods são os meses publicados ("2025-01"), com ou sem ocorrências
+	Periods []string `json:"-"`
+	// Occurrences é a soma das contagens, ou seja, quantos reports a planilha gera
+	Occurrences int `json:"occurrences"`
+}
+
+// PrecinctCode extrai o código da DP ("01 DP") do nome do arquivo
+func PrecinctCode(filename string) (string, error) {
+	match := precinctPattern.FindStringSubmatch(filepath.Base(filename))
+	if match == nil {
+		return "", fmt.Errorf("não foi possível identificar a DP no nome do arquivo %q", filename)
+	}
+	return match[1] + " DP", nil
+}
+
+// ParseWorkbook lê uma planilha da SSP. Cada aba é um ano e cada linha uma
+// natureza com as contagens de janeiro a dezembro; meses ainda não publicados
+// ("...") e as linhas de totais e de número de vítimas são ignorados.
+func ParseWorkbook(r io.Reader, filename string, cfg *Config) (*Result, error) {
+	code, err := PrecinctCode(filename)
+	if err != nil {
+		return nil, err
+	}
+	precinct, ok := cfg.Precincts[code]
+	if !ok {
+		return nil, fmt.Errorf("DP %q não está na tabela de delegacias", code)
+	}
+
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir planilha %q: %w", filename, err)
+	}
+	defer f.Close()
+
+	result := &Result{
+		File: filepath.Base(filename), Code: code, Precinct: precinct,
+		Municipality: Municipality(filename),
+	}
+	for _, sheet := range f.GetSheetList() {
+		year, err := strconv.Atoi(strings.TrimSpace(sheet))
+		if err != nil {
+			continue // abas que não são de um ano
+		}
+
+		rows, err := f.GetRows(sheet)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler aba %q de %q: %w", sheet, filename, err)
+		}
+		sheetRows, months := parseSheet(rows, year, cfg)
+		result.Rows = append(result.Rows, sheetRows...)
+		for _, month := range months {
+			result.Periods = append(result.Periods, fmt.Sprintf("%04d-%02d", year, month))
+		}
+	}
+
+	for _, row := range result.Rows {
+		result.Occurrences += row.Count
+	}
+	return result, nil
+}
+
+// monthColumn é uma coluna de mês do cabeçalho
+type monthColumn struct {
+	col   int
+	month int
+}
+
+// parseSheet lê as linhas de uma aba a partir do cabeçalho "Natureza", na
+// ordem da planilha (linhas e, em cada linha, colunas de mês), e retorna
+// também os meses publicados, em que alguma célula tem uma contagem
+func parseSheet(rows [][]string, year int, cfg *Config) ([]Row, []int) {
+	header := -1
+	var months []monthColumn
+	for i, row := range rows {
+		if len(row) > 0 && foldName(row[0]) == "natureza" {
+			header = i
+			for col, name := range row {
+				if month, ok := monthsByName[foldName(name)]; ok {
+					months = append(months, monthColumn{col: col, month: month})
+				}
+			}
+			break
+		}
+	}
+	if header < 0 {
+		return nil, nil
+	}
+
+	var result []Row
+	published := make(map[int]bool)
+	for _, row := range rows[header+1:] {
+		if len(row) == 0 || skipNature(row[0]) {
+			continue
+		}
+
+		crimeName := cfg.CrimeName(row[0])
+		for _, m := range months {
+			if m.col >= len(row) {
+				continue
+			}
+			count, ok := parseCount(row[m.col])
+			if !ok {
+				continue
+			}
+			if strings.TrimSpace(row[m.col]) != "" {
+				published[m.month] = true
+			}
+			if count == 0 {
+				continue
+			}
+			result = append(result, Row{
+				Year:      year,
+				Month:     m.month,
+				Nature:    strings.TrimSpace(row[0]),
+				CrimeName: crimeName,
+				Count:     count,
+			})
+		}
+	}
+
+	var periods []int
+	for _, m := range months {
+		if published[m.month] {
+			periods = append(periods, m.month)
+		}
+	}
+	return result, periods
+}
+
+// skipNature indica linhas que não são ocorrências (totais e vítimas)
+func skipNature(nature string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(nature))
+	return upper == "" || strings.Contains(upper, "VÍTIMAS") || strings.Contains(upper, "VITIMAS") ||
+		strings.HasPrefix(upper, "TOTAL DE")
+}
+
+// parseCount lê uma célula de contagem; "..." (mês não publicado) e valores
+// não numéricos retornam ok=false. O ponto é separador de milhar.
+func parseCount(value string) (int, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "-" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(strings.ReplaceAll(value, ".", ""))
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// ReportRequests gera um report por ocorrência. Os dias são distribuídos de
+// forma uniforme e determinística dentro do mês, para que importar a mesma
+// planilha duas vezes gere as mesmas datas. Cada report leva a SourceKey da
+// sua natureza e mês e a posição (SourceSeq) entre as ocorrências dela, com
+// as quais a ingestão ignora os já importados. O peso dos crimes novos vem da
+// taxonomia (crime_categories) na ingestão.
+func (r *Result) ReportRequests() []models.ReportRequest {
+	reqs := make([]models.ReportRequest, 0, r.Occurrences)
+	lat := models.Coordinate(r.Precinct.Latitude)
+	lng := models.Coordinate(r.Precinct.Longitude)
+
+	// Naturezas que só diferem nas notas somam na mesma chave
+	seq := make(map[string]int)
+	for _, row := range r.Rows {
+		first := time.Date(row.Year, time.Month(row.Month), 1, 0, 0, 0, 0, time.UTC)
+		days := first.AddDate(0, 1, -1).Day()
+		key := r.SourceKey(row)
+		for i := 0; i < row.Count; i++ {
+			day := int((float64(i) + 0.5) * float64(days) / float64(row.Count))
+			reqs = append(reqs, models.ReportRequest{
+				Name:       r.Precinct.Neighborhood,
+				Latitude:   lat,
+				Longitude:  lng,
+				CrimeName:  row.CrimeName,
+				ReportDate: first.AddDate(0, 0, day).Format(ReportDateLayout),
+				SourceKey:  key,
+				SourceSeq:  seq[key],
+			})
+			seq[key]++
+		}
+	}
+	return reqs
+}
+
+// SourceKey identifica as ocorrências de uma natureza num mês da planilha
+// de uma DP: "ssp:campinas:01 DP:2024-03:FURTO - OUTROS". A chave não
+// depende da posição da linha, então linhas incluídas ou removidas numa nova
+// versão da planilha não mudam as chaves das demais.
+func (r *Result) SourceKey(row Row) string {
+	return fmt.Sprintf("%s%s", r.sourcePrefix(fmt.Sprintf("%04d-%02d", row.Year, row.Month)), normalizeNature(row.Nature))
+}
+
+// sourcePrefix é o início das SourceKey de um mês ("2024-03") da planilha
+func (r *Result) sourcePrefix(period string) string {
+	return fmt.Sprintf("ssp:%s:%s:%s:", foldName(r.Municipality), r.Code, period)
+}
+
+// SourceScopes são os prefixos das SourceKey dos meses publicados na
+// planilha, para que a reconciliação remova também as naturezas que sumiram
+func (r *Result) SourceScopes() []string {
+	scopes := make([]string, len(r.Periods))
+	for i, period := range r.Periods {
+		scopes[i] = r.sourcePrefix(period)
+	}
+	return scopes
+}
+
+// SourceCounts é o número de ocorrências por SourceKey
+func (r *Result) SourceCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, row := range r.Rows {
+		counts[r.SourceKey(row)] += row.Count
+	}
+	return counts
+}
+
+// Municipality extrai o município do nome do arquivo ("...-01 DP - Campinas_...");
+// vazio quando o nome não tem o município
+func Municipality(filename string) string {
+	match := municipalityPattern.FindStringSubmatch(filepath.Base(filename))
+	if match == nil {
+		return ""
+	}
+	return strings.TrimSpace(match[1])
+}
+
+// normalizeNature deixa a natureza no formato das chaves de crime_names
+func normalizeNature(nature string) string {
+	nature = notePattern.ReplaceAllString(nature, "")
+	return strings.ToUpper(strings.Join(strings.Fields(nature), " "))
+}
+
+// foldName compara nomes de cabeçalho sem acentos e sem diferenciar maiúsculas
+func foldName(s string) string {
+	return accentReplacer.Replace(strings.ToLower(strings.TrimSpace(s)))
+}
@@ -0,0 +1,178 @@
+package importer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// buildWorkbook monta uma planilha no layout da SSP com uma aba por ano
+func buildWorkbook(t *testing.T, sheets map[string][][]any) *bytes.Buffer {
+	t.Helper()
+
+	f := excelize.NewFile()
+	defer f.Close()
+	for name, rows := range sheets {
+		if _, err := f.NewSheet(name); err != nil {
+			t.Fatalf("falha ao criar aba: %v", err)
+		}
+		for i, row := range rows {
+			cell, _ := excelize.CoordinatesToCellName(1, i+1)
+			if err := f.SetSheetRow(name, cell, &row); err != nil {
+				t.Fatalf("falha ao escrever linha: %v", err)
+			}
+		}
+	}
+	f.DeleteSheet("Sheet1")
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("falha ao gerar planilha: %v", err)
+	}
+	return buf
+}
+
+func TestParseWorkbook(t *testing.T) {
+	cfg, err := DefaultConfig()
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+
+	header := []any{"Natureza", "Janeiro", "Fevereiro", "Março", "Abril", "Maio", "Junho", "Julho",
+		"Agosto", "Setembro", "Outubro", "Novembro", "Dezembro", "Total"}
+	buf := buildWorkbook(t, map[string][][]any{
+		"2025": {
+			header,
+			{"HOMICÍDIO DOLOSO (2)", "1", "...", "...", "...", "...", "...", "...", "...", "...", "...", "...", "...", "1"},
+			{"Nº DE VÍTIMAS EM HOMICÍDIO DOLOSO (3)", "1", "...", "...", "...", "...", "...", "...", "...", "...", "...", "...", "...", "1"},
+			{"FURTO - OUTROS", "3", "0", "...", "...", "...", "...", "...", "...", "...", "...", "...", "...", "3"},
+			{"TOTAL DE ESTUPRO (4)", "2", "...", "...", "...", "...", "...", "...", "...", "...", "...", "...", "...", "2"},
+			{"PICHAÇÃO", "0", "1", "...", "...", "...", "...", "...", "...", "...", "...", "...", "...", "1"},
+		},
+		"Notas": {{"Fonte: SSP"}},
+	})
+
+	result, err := ParseWorkbook(buf, "OcorrenciaMensal(Criminal)-13 DP Campinas_20251125_230904.xlsx", cfg)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if result.Code != "13 DP" || result.Precinct.Neighborhood != "Cambuí" {
+		t.Errorf("esperava 13 DP no Cambuí, obteve: %s %+v", result.Code, result.Precinct)
+	}
+	if result.Occurrences != 5 || len(result.Rows) != 3 {
+		t.Fatalf("esperava 5 ocorrências em 3 linhas, obteve: %d em %+v", result.Occurrences, result.Rows)
+	}
+
+	reqs := result.ReportRequests()
+	if len(reqs) != 5 {
+		t.Fatalf("esperava 5 reports, obteve: %d", len(reqs))
+	}
+
+	// As linhas seguem a ordem da planilha e cada ocorrência tem a chave da
+	// sua natureza e mês, com a posição entre as ocorrências dela
+	if first := result.Rows[0]; first.Month != 1 || first.Nature != "HOMICÍDIO DOLOSO (2)" {
+		t.Errorf("esperava o homicídio de janeiro primeiro, obteve: %+v", first)
+	}
+	if last := result.Rows[2]; last.Month != 2 || last.Nature != "PICHAÇÃO" {
+		t.Errorf("esperava a pichação de fevereiro por último, obteve: %+v", last)
+	}
+	if reqs[3].SourceKey != "ssp:campinas:13 DP:2025-01:FURTO - OUTROS" || reqs[3].SourceSeq != 2 {
+		t.Errorf("esperava a terceira ocorrência do furto de janeiro, obteve: %s #%d", reqs[3].SourceKey, reqs[3].SourceSeq)
+	}
+	if scopes := result.SourceScopes(); len(scopes) != 2 || scopes[1] != "ssp:campinas:13 DP:2025-02:" {
+		t.Errorf("esperava janeiro e fevereiro publicados, obteve: %v", scopes)
+	}
+
+	byCrime := make(map[string][]string)
+	for _, req := range reqs {
+		if req.Name != "Cambuí" || req.Latitude != -22.8989 || req.Longitude != -47.0523 {
+			t.Errorf("esperava bairro e coordenadas da 13 DP, obteve: %+v", req)
+		}
+		byCrime[req.CrimeName] = append(byCrime[req.CrimeName], req.ReportDate)
+		// O peso vem da taxonomia de crimes na ingestão
+		if req.CrimeWeight != 0 {
+			t.Errorf("esperava o peso vazio, obteve: %d", req.CrimeWeight)
+		}
+	}
+	if len(byCrime["Homicídio Doloso"]) == 0 {
+		t.Errorf("esperava o homicídio doloso com o nome padronizado, obteve: %v", byCrime)
+	}
+	// Natureza fora da tabela mantém o nome em "Primeira Letra Maiúscula"
+	if dates := byCrime["Pichação"]; len(dates) != 1 || dates[0] != "15/02/2025" {
+		t.Errorf("esperava uma pichação no meio de fevereiro, obteve: %v", dates)
+	}
+	// As três ocorrências de janeiro são espalhadas pelo mês
+	want := []string{"06/01/2025", "16/01/2025", "26/01/2025"}
+	got := byCrime["Furto"]
+	if len(got) != len(want) {
+		t.Fatalf("esperava %v, obteve: %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("esperava %v, obteve: %v", want, got)
+			break
+		}
+	}
+}
+
+func TestParseWorkbook_UnknownPrecinct(t *testing.T) {
+	cfg, _ := DefaultConfig()
+	buf := buildWorkbook(t, map[string][][]any{"2025": {{"Natureza", "Janeiro"}}})
+
+	if _, err := ParseWorkbook(buf, "OcorrenciaMensal(Criminal)-99 DP - Campinas.xlsx", cfg); err == nil {
+		t.Error("esperava erro para DP fora da tabela")
+	}
+	if _, err := PrecinctCode("planilha.xlsx"); err == nil {
+		t.Error("esperava erro para arquivo sem DP no nome")
+	}
+}
+
+// TestParseWorkbook_RowInserted reimporta a planilha com uma linha nova no
+// meio e uma contagem menor: as chaves das demais linhas não mudam
+func TestParseWorkbook_RowInserted(t *testing.T) {
+	cfg, _ := DefaultConfig()
+	header := []any{"Natureza", "Janeiro", "Fevereiro"}
+	file := "OcorrenciaMensal(Criminal)-01 DP - Campinas_20251125_225146.xlsx"
+
+	before, err := ParseWorkbook(buildWorkbook(t, map[string][][]any{
+		"2025": {
+			header,
+			{"HOMICÍDIO DOLOSO (2)", "1", "0"},
+			{"FURTO - OUTROS", "3", "2"},
+			{"PICHAÇÃO", "0", "1"},
+		},
+	}), file, cfg)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	after, err := ParseWorkbook(buildWorkbook(t, map[string][][]any{
+		"2025": {
+			header,
+			{"HOMICÍDIO DOLOSO (3)", "1", "0"},
+			{"ROUBO - OUTROS", "4", "0"},
+			{"FURTO - OUTROS", "3", "1"},
+			{"PICHAÇÃO", "0", "1"},
+		},
+	}), file, cfg)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+
+	old, counts := before.SourceCounts(), after.SourceCounts()
+	if len(old) != 4 || len(counts) != 5 {
+		t.Fatalf("esperava 4 e 5 chaves, obteve: %v e %v", old, counts)
+	}
+	for key, n := range old {
+		want := n
+		if key == "ssp:campinas:01 DP:2025-02:FURTO - OUTROS" {
+			want = 1
+		}
+		if counts[key] != want {
+			t.Errorf("esperava %s com %d ocorrências, obteve: %d", key, want, counts[key])
+		}
+	}
+	if counts["ssp:campinas:01 DP:2025-01:ROUBO - OUTROS"] != 4 {
+		t.Errorf("esperava a linha nova com chave própria, obteve: %v", counts)
+	}
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Coordinate is a latitude or longitude in decimal degrees. In JSON it is
+// written as a number and read from a number or a string, with a comma or a
+// dot as the decimal separator ("-22.9056", "-22,9056" or -22.9056).
+type Coordinate float64
+
+// ParseCoordinate parses a coordinate written as text
+func ParseCoordinate(value string) (Coordinate, error) {
+	v, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(value), ",", "."), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid coordinate: %q", value)
+	}
+	return Coordinate(v), nil
+}
+
+// Float64 returns the coordinate as a float64
+func (c Coordinate) Float64() float64 {
+	return float64(c)
+}
+
+// UnmarshalJSON accepts a number or a numeric string; null and "" leave the
+// coordinate unset (zero)
+func (c *Coordinate) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	text := string(data)
+	if data[0] == '"' {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		if strings.TrimSpace(text) == "" {
+			*c = 0
+			return nil
+		}
+	}
+
+	v, err := ParseCoordinate(text)
+	if err != nil {
+		// Same error encoding/json returns for a value of the wrong type
+		return &json.UnmarshalTypeError{Value: "string " + strconv.Quote(text), Type: reflect.TypeOf(float64(0))}
+	}
+	*c = v
+	return nil
+}
+
+// Scan reads a coordinate from a numeric column. NULL (coordinates that could
+// not be converted by the 0005 migration) is read as zero.
+func (c *Coordinate) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*c = 0
+	case float64:
+		*c = Coordinate(v)
+	case float32:
+		*c = Coordinate(v)
+	case int64:
+		*c = Coordinate(v)
+	case []byte:
+		return c.scanText(string(v))
+	case string:
+		return c.scanText(v)
+	default:
+		return fmt.Errorf("unsupported coordinate type %T", value)
+	}
+	return nil
+}
+
+func (c *Coordinate) scanText(value string) error {
+	v, err := ParseCoordinate(value)
+	if err != nil {
+		return err
+	}
+	*c = v
+	return nil
+}
+
+// Value writes the coordinate as a float
+func (c Coordinate) Value() (driver.Value, error) {
+	return float64(c), nil
+}
@@ -1,12 +1,51 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
 
 // Crime represents the type of crime and its weight
 type Crime struct {
-	CrimeID     uint      `json:"crime_id" gorm:"primaryKey;column:crime_id"`
-	CrimeName   string    `json:"crime_name" gorm:"column:crime_name;size:255;not null"`
-	CrimeWeight int       `json:"crime_weight" gorm:"column:crime_weight;not null"`
-	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	CrimeID     uint   `json:"crime_id" gorm:"primaryKey;column:crime_id"`
+	CrimeName   string `json:"crime_name" gorm:"column:crime_name;size:255;not null"`
+	CrimeWeight int    `json:"crime_weight" gorm:"column:crime_weight;not null"`
+	// CrimeKey is the canonical key of CrimeName, unique among the crimes not
+	// deleted. The services set it from the crime taxonomy (the subcategory
+	// of the crime's synonym); crimes saved before it existed have no key
+	// until their duplicates are merged.
+	CrimeKey  string         `json:"crime_key" gorm:"column:crime_key;size:255;uniqueIndex:idx_crimes_crime_key,where:crime_key IS NOT NULL AND deleted_at IS NULL"`
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// BeforeSave fills a missing CrimeKey with the normalized CrimeName, for the
+// crimes saved without going through the taxonomy
+func (c *Crime) BeforeSave(tx *gorm.DB) error {
+	if c.CrimeKey == "" && c.CrimeName != "" {
+		c.CrimeKey = NormalizeCrimeName(c.CrimeName)
+	}
+	return nil
+}
+
+// NormalizeCrimeName lowercases the name and removes accents and punctuation,
+// leaving single spaces between the words ("ROUBO - OUTROS" -> "roubo outros")
+func NormalizeCrimeName(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(name)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Accents, split from their letters by NFD
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
 }
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// CrimeCategory is an entry of the crime taxonomy: a subcategory of crimes
+// (e.g. "Roubo" in "Patrimonial"), the weight given to new crimes of it and
+// the synonyms that match crime names to it
+type CrimeCategory struct {
+	CategoryID    uint       `json:"category_id" gorm:"primaryKey;column:category_id"`
+	Category      string     `json:"category" gorm:"column:category;size:100;not null;uniqueIndex:idx_crime_categories_name"`
+	Subcategory   string     `json:"subcategory" gorm:"column:subcategory;size:100;not null;uniqueIndex:idx_crime_categories_name"`
+	DefaultWeight int        `json:"default_weight" gorm:"column:default_weight;not null"`
+	Synonyms      StringList `json:"synonyms" gorm:"column:synonyms"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (CrimeCategory) TableName() string {
+	return "crime_categories"
+}
+
+// StringList is a list of strings stored as a JSON array in a text column
+type StringList []string
+
+// GormDataType stores the list in the string (text) type of each database
+func (StringList) GormDataType() string {
+	return "string"
+}
+
+// Scan reads the JSON array; NULL and "" are read as an empty list
+func (l *StringList) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported string list type %T", value)
+	}
+	if len(data) == 0 {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
+
+// Value writes the list as a JSON array
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		l = StringList{}
+	}
+	data, err := json.Marshal([]string(l))
+	return string(data), err
+}
@@ -36,8 +36,8 @@ type CuratedCell struct {
 	CenterLat      float64   `json:"center_lat" gorm:"column:center_lat;type:decimal(10,8);not null;index:idx_center"`
 	CenterLng      float64   `json:"center_lng" gorm:"column:center_lng;type:decimal(11,8);not null;index:idx_center"`
 
-	// Guardar JSON como string em nvarchar(max) no SQL Server
-	BoundsJSON *string `json:"bounds_json" gorm:"column:bounds_json;type:nvarchar(max)"`
+	// Guardar JSON como string sem tamanho: nvarchar(max) no SQL Server, text no PostgreSQL/SQLite
+	BoundsJSON *string `json:"bounds_json" gorm:"column:bounds_json"`
 
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 }
@@ -121,8 +121,8 @@ type AnalyticsQualityReport struct {
 	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement;column:id"`
 	ReportDate time.Time `json:"report_date" gorm:"column:report_date;type:date;uniqueIndex;not null"`
 
-	// Trocar type:json por nvarchar(max)
-	Metrics string `json:"metrics" gorm:"column:metrics;type:nvarchar(max);not null"`
+	// String sem tamanho: nvarchar(max) no SQL Server, text no PostgreSQL/SQLite
+	Metrics string `json:"metrics" gorm:"column:metrics;not null"`
 
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
@@ -131,19 +131,19 @@ func (AnalyticsQualityReport) TableName() string {
 	return "analytics_quality_reports"
 }
 
-// AnalyticsPipelineLog representa um log de execução do pipeline
-// AnalyticsPipelineLog representa um log de execução do pipeline
+// AnalyticsPipelineLog representa a execução de uma fase do pipeline.
+// Cada execução (execution_id) tem uma linha por fase.
 type AnalyticsPipelineLog struct {
 	ID                   uint       `json:"id" gorm:"primaryKey;autoIncrement;column:id"`
-	ExecutionID          string     `json:"execution_id" gorm:"column:execution_id;size:36;uniqueIndex;not null"`
+	ExecutionID          string     `json:"execution_id" gorm:"column:execution_id;size:36;uniqueIndex:idx_pipeline_logs_execution_phase;not null"`
 	StartedAt            time.Time  `json:"started_at" gorm:"column:started_at;not null;index"`
 	FinishedAt           *time.Time `json:"finished_at" gorm:"column:finished_at"`
 	Status               string     `json:"status" gorm:"column:status;size:20;index"`
-	Phase                string     `json:"phase" gorm:"column:phase;size:50"`
+	Phase                string     `json:"phase" gorm:"column:phase;size:50;uniqueIndex:idx_pipeline_logs_execution_phase"`
 	RecordsProcessed     *int       `json:"records_processed" gorm:"column:records_processed"`
 
-	// text -> nvarchar(max)
-	ErrorMessage *string `json:"error_message" gorm:"column:error_message;type:nvarchar(max)"`
+	// String sem tamanho: nvarchar(max) no SQL Server, text no PostgreSQL/SQLite
+	ErrorMessage *string `json:"error_message" gorm:"column:error_message"`
 
 	ExecutionTimeSeconds *int      `json:"execution_time_seconds" gorm:"column:execution_time_seconds"`
 	CreatedAt            time.Time `json:"created_at" gorm:"autoCreateTime"`
@@ -153,6 +153,18 @@ func (AnalyticsPipelineLog) TableName() string {
 	return "analytics_pipeline_logs"
 }
 
+// AnalyticsWatermark guarda até onde um processamento incremental já leu
+// (ex.: o maior reports.updated_at processado pelo pipeline da KB)
+type AnalyticsWatermark struct {
+	Name        string    `json:"name" gorm:"primaryKey;column:name;size:100"`
+	WatermarkAt time.Time `json:"watermark_at" gorm:"column:watermark_at;not null"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (AnalyticsWatermark) TableName() string {
+	return "analytics_watermarks"
+}
+
 // ============================================================================
 // MIGRATIONS TABLE
 // ============================================================================
@@ -1,13 +1,18 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Neighborhood struct {
-	NeighborhoodID     uint      `json:"neighborhood_id" gorm:"primaryKey;column:neighborhood_id"`
-	Name               string    `json:"name" gorm:"column:name;size:255;not null"`
-	Latitude           string    `json:"latitude" gorm:"column:latitude;not null"`
-	Longitude          string    `json:"longitude" gorm:"column:longitude;not null"`
-	NeighborhoodWeight int       `json:"neighborhood_weight" gorm:"column:neighborhood_weight;not null"`
-	CreatedAt          time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt          time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	NeighborhoodID     uint           `json:"neighborhood_id" gorm:"primaryKey;column:neighborhood_id"`
+	Name               string         `json:"name" gorm:"column:name;size:255;not null"`
+	Latitude           Coordinate     `json:"latitude" gorm:"column:latitude;type:float;uniqueIndex:idx_neighborhoods_coordinates,where:deleted_at IS NULL"`
+	Longitude          Coordinate     `json:"longitude" gorm:"column:longitude;type:float;uniqueIndex:idx_neighborhoods_coordinates,where:deleted_at IS NULL"`
+	NeighborhoodWeight int            `json:"neighborhood_weight" gorm:"column:neighborhood_weight;not null"`
+	CreatedAt          time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt          time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt          gorm.DeletedAt `json:"-" gorm:"index"`
 }
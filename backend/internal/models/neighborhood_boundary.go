@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// NeighborhoodBoundary is the official border of a neighborhood. The geometry
+// is a GeoJSON Polygon or MultiPolygon in lon/lat (WGS84); the bounding box
+// columns let the boundaries of an area be found without parsing it.
+type NeighborhoodBoundary struct {
+	BoundaryID     uint      `json:"boundary_id" gorm:"primaryKey;column:boundary_id"`
+	NeighborhoodID uint      `json:"neighborhood_id" gorm:"column:neighborhood_id;not null;uniqueIndex:idx_neighborhood_boundaries_neighborhood"`
+	Geometry       string    `json:"-" gorm:"column:geometry;not null"`
+	MinLat         float64   `json:"min_lat" gorm:"column:min_lat;type:float;not null"`
+	MinLon         float64   `json:"min_lon" gorm:"column:min_lon;type:float;not null"`
+	MaxLat         float64   `json:"max_lat" gorm:"column:max_lat;type:float;not null"`
+	MaxLon         float64   `json:"max_lon" gorm:"column:max_lon;type:float;not null"`
+	Source         string    `json:"source" gorm:"column:source;size:255"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	Neighborhood Neighborhood `json:"neighborhood" gorm:"foreignKey:NeighborhoodID;references:NeighborhoodID"`
+}
@@ -1,7 +1,21 @@
 package models
 
+import "time"
+
+// PredictCrime guarda a previsão de risco de um mês, por célula da grade
+// (CellID preenchido) ou agregada por bairro (CellID nulo)
 type PredictCrime struct {
 	PredictCrimeID     uint      `json:"predict_crime_id" gorm:"primaryKey;column:predict_crime_id"`
 	Neighborhood     string      `json:"neighborhood" gorm:"column:neighborhood;size:255;not null"`
 	Risk_Level int       `json:"risk_level" gorm:"column:risk_level;not null"`
-}
\ No newline at end of file
+
+	Year           int       `json:"year" gorm:"column:year;not null;default:0;index:idx_predict_crimes_period"`
+	Month          int       `json:"month" gorm:"column:month;not null;default:0;index:idx_predict_crimes_period"`
+	CellResolution *int      `json:"cell_resolution,omitempty" gorm:"column:cell_resolution;index:idx_predict_crimes_period"`
+	CellID         *string   `json:"cell_id,omitempty" gorm:"column:cell_id;size:50"`
+	Latitude       float64   `json:"lat" gorm:"column:latitude;type:float"`
+	Longitude      float64   `json:"long" gorm:"column:longitude;type:float"`
+	PredictedCount float64   `json:"predicted_count" gorm:"column:predicted_count;type:float"`
+	ModelVersion   string    `json:"model_version" gorm:"column:model_version;size:50"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -1,14 +1,28 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Report struct {
 	ReportID       uint         `json:"report_id" gorm:"primaryKey;column:report_id"`
 	NeighborhoodID uint         `json:"neighborhood_id" gorm:"column:neighborhood_id;not null"`
 	CrimeID        uint         `json:"crime_id" gorm:"column:crime_id;not null"`
 	ReportDate     string       `json:"report_date" gorm:"column:report_date;not null"`
+	// OccurredAt is ReportDate parsed in the timezone of the reports
+	// (DB_TIMEZONE); nil while a legacy date was not converted by the backfill
+	OccurredAt     *time.Time   `json:"occurred_at" gorm:"column:occurred_at;index:idx_reports_occurred_at"`
+	// SourceKey identifies the nature and month of an imported SSP sheet the
+	// report came from, and SourceSeq its position among the occurrences of
+	// that key, so importing the sheet again does not duplicate it; both are
+	// nil for the reports sent through the chat
+	SourceKey      *string      `json:"-" gorm:"column:source_key;size:191;uniqueIndex:idx_reports_source_key,where:source_key IS NOT NULL"`
+	SourceSeq      *int         `json:"-" gorm:"column:source_seq;uniqueIndex:idx_reports_source_key"`
 	CreatedAt      time.Time    `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt      time.Time    `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
 
 	Neighborhood   Neighborhood `json:"neighborhood" gorm:"foreignKey:NeighborhoodID;references:NeighborhoodID"`
 	Crime          Crime        `json:"crime" gorm:"foreignKey:CrimeID;references:CrimeID"`
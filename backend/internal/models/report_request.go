@@ -2,10 +2,14 @@ package models
 
 // JSON from front-end
 type ReportRequest struct {
-	Name        string `json:"name"`
-	Latitude    string `json:"latitude"`
-	Longitude   string `json:"longitude"`
-	CrimeName   string `json:"crime_name"`
-	ReportDate  string `json:"report_date"`
-	CrimeWeight int    `json:"crime_weight"`
-}
\ No newline at end of file
+	Name        string     `json:"name"`
+	Latitude    Coordinate `json:"latitude"`
+	Longitude   Coordinate `json:"longitude"`
+	CrimeName   string     `json:"crime_name"`
+	ReportDate  string     `json:"report_date"`
+	CrimeWeight int        `json:"crime_weight"`
+	// SourceKey and SourceSeq are set by the SSP importer (see
+	// Report.SourceKey); they are not read from the JSON
+	SourceKey string `json:"-"`
+	SourceSeq int    `json:"-"`
+}
@@ -0,0 +1,179 @@
+// Package router monta o servidor HTTP: cria os serviços a partir da
+// configuração e registra as rotas de cada controller em /api/v1
+package router
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"gorm.io/gorm"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/config"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/controllers"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/importer"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/services"
+)
+
+// APIPrefix é o grupo em que todas as rotas são registradas
+const APIPrefix = "/api/v1"
+
+// Nomes dos grupos de rotas, usados em DISABLED_ROUTES
+const (
+	GroupReports       = "reports"
+	GroupImport        = "import"
+	GroupCrimes        = "crimes"
+	GroupCategories    = "crime-categories"
+	GroupNeighborhoods = "neighborhoods"
+	GroupPredictions   = "predictions"
+	GroupLayers        = "layers"
+	GroupTiles         = "tiles"
+	GroupHolidays      = "holidays"
+	GroupKnowledgeBase = "knowledge-base"
+)
+
+// Registrar é implementado por todo controller que expõe rotas
+type Registrar interface {
+	Register(g *echo.Group)
+}
+
+// Group é um grupo de rotas que pode ser desligado pela configuração
+type Group struct {
+	Name       string
+	Controller Registrar
+}
+
+// Services é o container com as dependências dos controllers
+type Services struct {
+	Reports       services.ReportService
+	Crimes        services.CrimeService
+	Categories    services.CrimeCategoryService
+	Neighborhoods services.NeighborhoodService
+	Boundaries    services.BoundaryService
+	Predictions   services.PredictionService
+	Layers        services.LayerService
+	Tiles         services.TileService
+	Holidays      services.HolidayService
+
+	// Tabela de delegacias do importador de planilhas da SSP
+	Precincts *importer.Config
+
+	// Dialeto e DSNs do pipeline da Knowledge Base
+	KBDialect services.KnowledgeBaseDialect
+	SourceDSN string
+	TargetDSN string
+
+	// Área coberta pela grade espacial
+	GridArea services.BoundingBox
+
+	// Fuso das datas dos reports (DB_TIMEZONE)
+	ReportLocation *time.Location
+}
+
+// NewServices cria os serviços a partir da configuração e da conexão
+func NewServices(cfg *config.Config, db *gorm.DB) (*Services, error) {
+	precincts, err := importer.LoadConfig(cfg.SSPPrecinctsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	kbDialect, err := services.NewKnowledgeBaseDialect(cfg.DBDriver)
+	if err != nil {
+		return nil, err
+	}
+	dsn := cfg.DSN()
+
+	gridArea, err := services.GridArea(cfg.GridBBox)
+	if err != nil {
+		return nil, fmt.Errorf("GRID_BBOX inválido: %w", err)
+	}
+
+	reportLocation, err := services.LoadReportLocation(cfg.DBTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("DB_TIMEZONE inválido: %w", err)
+	}
+
+	predictions := services.NewPredictionService(db)
+
+	return &Services{
+		Reports:       services.NewReportService(db, reportLocation, gridArea),
+		Crimes:        services.NewCrimeService(db),
+		Categories:    services.NewCrimeCategoryService(db),
+		Neighborhoods: services.NewNeighborhoodService(db, gridArea),
+		Boundaries:    services.NewBoundaryService(db, gridArea),
+		Predictions:   predictions,
+		Layers:        services.NewLayerService(db, predictions),
+		Tiles:         services.NewTileService(db, predictions, gridArea, services.DefaultTileCacheSize, services.DefaultTileCacheTTL),
+		Holidays:      services.NewHolidayService(db),
+		Precincts:     precincts,
+		KBDialect:     kbDialect,
+		SourceDSN:     dsn,
+		TargetDSN:     dsn, // Mesmo banco para source e target
+		GridArea:      gridArea,
+
+		ReportLocation: reportLocation,
+	}, nil
+}
+
+// Groups retorna os grupos de rotas da API, na ordem de registro
+func Groups(s *Services) []Group {
+	return []Group{
+		{GroupReports, controllers.NewReportController(s.Reports)},
+		{GroupImport, controllers.NewImportController(s.Reports, s.Boundaries, s.Precincts)},
+		{GroupCrimes, controllers.NewCrimeController(s.Crimes)},
+		{GroupCategories, controllers.NewCrimeCategoryController(s.Categories)},
+		{GroupNeighborhoods, controllers.NewNeighborhoodController(s.Neighborhoods)},
+		{GroupPredictions, controllers.NewPredictionController(s.Predictions)},
+		{GroupLayers, controllers.NewLayerController(s.Layers)},
+		{GroupTiles, controllers.NewTileController(s.Tiles)},
+		{GroupHolidays, controllers.NewHolidayController(s.Holidays)},
+		{GroupKnowledgeBase, controllers.NewKnowledgeBaseController(s.KBDialect, s.SourceDSN, s.TargetDSN, s.GridArea, s.ReportLocation)},
+	}
+}
+
+// New cria o Echo com os middlewares e registra os grupos que não estão em
+// disabled. Um nome desconhecido em disabled é erro, para que um erro de
+// digitação não deixe uma rota ligada sem aviso.
+func New(groups []Group, disabled []string) (*echo.Echo, error) {
+	off := make(map[string]bool, len(disabled))
+	for _, name := range disabled {
+		off[strings.TrimSpace(name)] = true
+	}
+	for name := range off {
+		if !hasGroup(groups, name) {
+			return nil, fmt.Errorf("grupo de rotas desconhecido em DISABLED_ROUTES: %q", name)
+		}
+	}
+
+	e := echo.New()
+	e.Use(middleware.Logger())
+	e.Use(middleware.Recover())
+	// Os headers de paginação precisam ser expostos para o front ler
+	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		ExposeHeaders: []string{controllers.HeaderTotalCount, controllers.HeaderNextCursor},
+	}))
+
+	api := e.Group(APIPrefix)
+	for _, group := range groups {
+		if off[group.Name] {
+			log.Printf("⏭️  Rotas de %s desligadas", group.Name)
+			continue
+		}
+		log.Printf("🔧 Registrando rotas de %s...", group.Name)
+		group.Controller.Register(api)
+	}
+
+	return e, nil
+}
+
+func hasGroup(groups []Group, name string) bool {
+	for _, group := range groups {
+		if group.Name == name {
+			return true
+		}
+	}
+	return false
+}
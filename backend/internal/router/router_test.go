@@ -0,0 +1,77 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/config"
+)
+
+// routeSet lista as rotas registradas como "MÉTODO caminho"
+func routeSet(e *echo.Echo) map[string]bool {
+	routes := make(map[string]bool)
+	for _, r := range e.Routes() {
+		routes[r.Method+" "+r.Path] = true
+	}
+	return routes
+}
+
+func TestNew_RegistersEnabledGroups(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("não foi possível abrir DB de teste: %v", err)
+	}
+	svcs, err := NewServices(&config.Config{DBDriver: "sqlite", DBName: ":memory:"}, db)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+
+	e, err := New(Groups(svcs), nil)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	routes := routeSet(e)
+	for _, route := range []string{
+		"GET /api/v1/reports",
+		"POST /api/v1/reports/import",
+		"POST /api/v1/reports/backfill-occurred-at",
+		"POST /api/v1/neighborhoods/boundaries/import",
+		"GET /api/v1/crimes",
+		"POST /api/v1/crimes/merge",
+		"GET /api/v1/crime-categories/classify",
+		"PUT /api/v1/crime-categories/:id",
+		"GET /api/v1/neighborhoods/:id",
+		"GET /api/v1/predictions",
+		"GET /api/v1/layers/cells",
+		"GET /api/v1/layers/predictions",
+		"GET /api/v1/tiles/:layer/:z/:x/:y",
+		"POST /api/v1/holidays/generate",
+		"POST /api/v1/holidays/import",
+		"POST /api/v1/knowledge-base/generate",
+		"GET /api/v1/knowledge-base/jobs/:id",
+		"GET /api/v1/kb-test",
+	} {
+		if !routes[route] {
+			t.Errorf("esperava a rota %s registrada", route)
+		}
+	}
+
+	e, err = New(Groups(svcs), []string{GroupKnowledgeBase, " " + GroupImport})
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	routes = routeSet(e)
+	if routes["POST /api/v1/knowledge-base/generate"] || routes["POST /api/v1/reports/import"] {
+		t.Error("esperava as rotas da knowledge base e de importação desligadas")
+	}
+	if !routes["GET /api/v1/reports"] {
+		t.Error("esperava as demais rotas registradas")
+	}
+
+	if _, err := New(Groups(svcs), []string{"relatorios"}); err == nil {
+		t.Error("esperava erro para grupo desconhecido")
+	}
+}
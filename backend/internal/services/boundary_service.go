@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/geo"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+// BoundaryService defines business operations related to neighborhood boundaries
+type BoundaryService interface {
+	// ImportBoundaries saves the boundaries read from a GeoJSON or Shapefile.
+	// Each boundary is matched to a live neighborhood by name (ignoring case
+	// and accents); unknown names become new neighborhoods at the centroid.
+	ImportBoundaries(ctx context.Context, boundaries []geo.Boundary, source string) (*BoundaryImportResult, error)
+}
+
+// BoundaryImportResult summarizes an import of boundaries
+type BoundaryImportResult struct {
+	Imported int                    `json:"imported"`
+	Created  int                    `json:"created"`
+	Updated  int                    `json:"updated"`
+	Rejected []BoundaryImportReject `json:"rejected,omitempty"`
+}
+
+// BoundaryImportReject is a boundary left out of the import
+type BoundaryImportReject struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// boundaryService is the concrete implementation of BoundaryService
+type boundaryService struct {
+	db   *gorm.DB
+	area BoundingBox
+}
+
+// NewBoundaryService creates a new instance of BoundaryService that creates
+// neighborhoods only for the boundaries centered inside area (the grid area)
+func NewBoundaryService(db *gorm.DB, area BoundingBox) BoundaryService {
+	return &boundaryService{db: db, area: area}
+}
+
+// ImportBoundaries saves all the boundaries in a single transaction. The
+// knowledge base only uses them after a full (non incremental) run.
+func (s *boundaryService) ImportBoundaries(ctx context.Context, boundaries []geo.Boundary, source string) (*BoundaryImportResult, error) {
+	result := &BoundaryImportResult{}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var neighborhoods []models.Neighborhood
+		if err := tx.Find(&neighborhoods).Error; err != nil {
+			return err
+		}
+		byName := make(map[string]uint, len(neighborhoods))
+		for _, n := range neighborhoods {
+			key := geo.NormalizeName(n.Name)
+			if _, ok := byName[key]; !ok {
+				byName[key] = n.NeighborhoodID
+			}
+		}
+
+		for _, b := range boundaries {
+			key := geo.NormalizeName(b.Name)
+			id, ok := byName[key]
+			if !ok {
+				centroid := b.Centroid()
+				n := &models.Neighborhood{
+					Name:      b.Name,
+					Latitude:  models.Coordinate(centroid.Lat()),
+					Longitude: models.Coordinate(centroid.Lon()),
+				}
+				// The centroid outside the grid area means a boundary
+				// outside the city
+				if err := ValidateNeighborhood(n, s.area); err != nil {
+					result.Rejected = append(result.Rejected, BoundaryImportReject{Name: b.Name, Error: err.Error()})
+					continue
+				}
+				if err := tx.Create(n).Error; err != nil {
+					return err
+				}
+				id = n.NeighborhoodID
+				byName[key] = id
+				result.Created++
+			}
+
+			updated, err := saveBoundary(tx, id, b, source)
+			if err != nil {
+				return fmt.Errorf("%s: %w", b.Name, err)
+			}
+			if updated {
+				result.Updated++
+			}
+			result.Imported++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// saveBoundary inserts or replaces the boundary of a neighborhood, reporting
+// whether it already had one
+func saveBoundary(tx *gorm.DB, neighborhoodID uint, b geo.Boundary, source string) (bool, error) {
+	geometry, err := geo.MarshalGeometry(b.Geometry)
+	if err != nil {
+		return false, err
+	}
+	bound := b.Bound()
+	record := models.NeighborhoodBoundary{
+		NeighborhoodID: neighborhoodID,
+		Geometry:       geometry,
+		MinLat:         bound.Min.Lat(),
+		MinLon:         bound.Min.Lon(),
+		MaxLat:         bound.Max.Lat(),
+		MaxLon:         bound.Max.Lon(),
+		Source:         source,
+	}
+
+	var existing models.NeighborhoodBoundary
+	err = tx.Where("neighborhood_id = ?", neighborhoodID).Take(&existing).Error
+	switch {
+	case err == nil:
+		record.BoundaryID = existing.BoundaryID
+		return true, updateColumns(tx, &record, "geometry", "min_lat", "min_lon", "max_lat", "max_lon", "source")
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return false, tx.Create(&record).Error
+	default:
+		return false, err
+	}
+}
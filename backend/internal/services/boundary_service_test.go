@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/paulmach/orb"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/geo"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+// square cria um limite quadrado com os cantos informados (lat/lon)
+func square(name string, minLat, minLon, maxLat, maxLon float64) geo.Boundary {
+	return geo.Boundary{Name: name, Geometry: orb.MultiPolygon{{{
+		{minLon, minLat}, {maxLon, minLat}, {maxLon, maxLat}, {minLon, maxLat}, {minLon, minLat},
+	}}}}
+}
+
+func TestImportBoundaries(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	centro := models.Neighborhood{Name: "Centro", Latitude: -22.9056, Longitude: -47.0608, NeighborhoodWeight: 3}
+	if err := gdb.Create(&centro).Error; err != nil {
+		t.Fatalf("falha ao inserir bairro: %v", err)
+	}
+
+	svc := NewBoundaryService(gdb, DefaultGridArea)
+	boundaries := []geo.Boundary{
+		square("CENTRO", -22.92, -47.08, -22.89, -47.04),
+		square("Jardim Proença", -22.93, -47.06, -22.91, -47.04),
+		square("Longe", -23.60, -46.70, -23.50, -46.60), // fora de Campinas
+	}
+	result, err := svc.ImportBoundaries(ctx, boundaries, "limites.geojson")
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if result.Imported != 2 || result.Created != 1 || result.Updated != 0 {
+		t.Errorf("esperava 2 importados, 1 criado e 0 atualizados, obteve: %+v", result)
+	}
+	if len(result.Rejected) != 1 || result.Rejected[0].Name != "Longe" {
+		t.Errorf("esperava o limite Longe rejeitado, obteve: %+v", result.Rejected)
+	}
+
+	var boundary models.NeighborhoodBoundary
+	if err := gdb.Where("neighborhood_id = ?", centro.NeighborhoodID).First(&boundary).Error; err != nil {
+		t.Fatalf("esperava o limite do Centro no bairro existente, obteve: %v", err)
+	}
+	if boundary.MinLat != -22.92 || boundary.MaxLon != -47.04 || boundary.Source != "limites.geojson" {
+		t.Errorf("limite gravado incorreto: %+v", boundary)
+	}
+	g, err := geo.UnmarshalGeometry(boundary.Geometry)
+	if err != nil || len(g) != 1 {
+		t.Errorf("esperava a geometria em GeoJSON, obteve: %v (%v)", g, err)
+	}
+
+	var proenca models.Neighborhood
+	if err := gdb.Where("name = ?", "Jardim Proença").First(&proenca).Error; err != nil {
+		t.Fatalf("esperava o bairro Jardim Proença criado, obteve: %v", err)
+	}
+	if lat := proenca.Latitude.Float64(); lat < -22.9201 || lat > -22.9199 {
+		t.Errorf("esperava o bairro no centroide (-22.92), obteve: %v", lat)
+	}
+
+	// Reimportar substitui os limites sem criar bairros
+	result, err = svc.ImportBoundaries(ctx, boundaries[:2], "limites-2024.geojson")
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if result.Imported != 2 || result.Created != 0 || result.Updated != 2 {
+		t.Errorf("esperava 2 limites atualizados, obteve: %+v", result)
+	}
+	var count int64
+	gdb.Model(&models.NeighborhoodBoundary{}).Count(&count)
+	if count != 2 {
+		t.Errorf("esperava 2 limites, obteve: %d", count)
+	}
+}
+
+// TestGenerateKnowledgeBase_Boundaries verifica que, com limites importados,
+// incidentes e células ficam no bairro do polígono e não no ponto mais próximo
+func TestGenerateKnowledgeBase_Boundaries(t *testing.T) {
+	gdb, db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	centro := models.Neighborhood{Name: "Centro", Latitude: -22.9056, Longitude: -47.0608, NeighborhoodWeight: 3}
+	taquaral := models.Neighborhood{Name: "Taquaral", Latitude: -22.8758, Longitude: -47.0533, NeighborhoodWeight: 1}
+	for _, n := range []*models.Neighborhood{&centro, &taquaral} {
+		if err := gdb.Create(n).Error; err != nil {
+			t.Fatalf("falha ao inserir bairro: %v", err)
+		}
+	}
+	furto := models.Crime{CrimeName: "Furto", CrimeWeight: 3}
+	if err := gdb.Create(&furto).Error; err != nil {
+		t.Fatalf("falha ao inserir crime: %v", err)
+	}
+	date := "2024-02-10 10:00:00"
+	if err := gdb.Exec(
+		`INSERT INTO reports (neighborhood_id, crime_id, report_date, occurred_at, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		centro.NeighborhoodID, furto.CrimeID, date, occurredAt(t, date), time.Now(), time.Now(),
+	).Error; err != nil {
+		t.Fatalf("falha ao inserir report: %v", err)
+	}
+
+	// O limite do Taquaral cobre também o ponto do Centro
+	if _, err := NewBoundaryService(gdb, DefaultGridArea).ImportBoundaries(ctx, []geo.Boundary{
+		square("Taquaral", -22.92, -47.08, -22.86, -47.04),
+	}, "teste"); err != nil {
+		t.Fatalf("falha ao importar limites: %v", err)
+	}
+
+	dialect, _ := NewKnowledgeBaseDialect("sqlite")
+	generator := NewKnowledgeBaseGenerator(&KnowledgeBaseConfig{
+		SourceDB:           db,
+		TargetDB:           db,
+		Dialect:            dialect,
+		CellResolution:     1000,
+		StartDate:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
+		EndDate:            time.Date(2024, 3, 31, 0, 0, 0, 0, time.Local),
+		SkipHourlyFeatures: true,
+	})
+	if err := generator.GenerateKnowledgeBase(ctx); err != nil {
+		t.Fatalf("esperava sem erro no pipeline, obteve: %v", err)
+	}
+
+	var neighborhood string
+	db.QueryRow(`SELECT neighborhood FROM curated_incidents`).Scan(&neighborhood)
+	if neighborhood != "Taquaral" {
+		t.Errorf("esperava o incidente no bairro do polígono (Taquaral), obteve: %q", neighborhood)
+	}
+
+	grid := newSpatialGrid(1000, DefaultGridArea)
+	cellID, _ := grid.locate(-22.9056, -47.0608)
+	var distance sql.NullFloat64
+	db.QueryRow(`SELECT neighborhood, distance FROM cell_neighborhoods WHERE cell_id = ?`, cellID).Scan(&neighborhood, &distance)
+	if neighborhood != "Taquaral" || !distance.Valid || distance.Float64 != 0 {
+		t.Errorf("esperava a célula do Centro no Taquaral com distância 0, obteve: %q (%v)", neighborhood, distance)
+	}
+
+	// Células da borda, com o centro fora do polígono, ficam pela área em comum
+	var overlap int
+	db.QueryRow(`SELECT COUNT(*) FROM cell_neighborhoods WHERE neighborhood = 'Taquaral' AND distance IS NULL`).Scan(&overlap)
+	if overlap == 0 {
+		t.Error("esperava células da borda mapeadas pela área em comum")
+	}
+
+	// Longe do polígono continua valendo o ponto mais próximo
+	farID, _ := grid.locate(-23.05, -47.25)
+	db.QueryRow(`SELECT neighborhood, distance FROM cell_neighborhoods WHERE cell_id = ?`, farID).Scan(&neighborhood, &distance)
+	if !distance.Valid || distance.Float64 == 0 {
+		t.Errorf("esperava a célula distante mapeada pelo ponto mais próximo, obteve: %q (%v)", neighborhood, distance)
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+// CrimeCategoryService defines business operations related to the crime
+// taxonomy (crime_categories) used by the ingestion and the knowledge base
+type CrimeCategoryService interface {
+	// ListCrimeCategories returns every entry ordered by category and subcategory
+	ListCrimeCategories(ctx context.Context) ([]models.CrimeCategory, error)
+	GetCrimeCategoryByID(ctx context.Context, id uint) (*models.CrimeCategory, error)
+	// CreateCrimeCategory and UpdateCrimeCategory validate the entry and
+	// reject a category and subcategory already in the taxonomy
+	CreateCrimeCategory(ctx context.Context, c *models.CrimeCategory) error
+	UpdateCrimeCategory(ctx context.Context, c *models.CrimeCategory) error
+	DeleteCrimeCategory(ctx context.Context, id uint) error
+	// Taxonomy returns the taxonomy of the saved entries, or the default one
+	// while the table is empty
+	Taxonomy(ctx context.Context) (*CrimeTaxonomy, error)
+	// SeedDefaultCategories saves DefaultCrimeCategories when the table is
+	// empty and returns how many entries were saved
+	SeedDefaultCategories(ctx context.Context) (int, error)
+}
+
+// crimeCategoryService is the concrete implementation of CrimeCategoryService
+type crimeCategoryService struct {
+	db *gorm.DB
+}
+
+// NewCrimeCategoryService creates a new instance of CrimeCategoryService
+func NewCrimeCategoryService(db *gorm.DB) CrimeCategoryService {
+	return &crimeCategoryService{db: db}
+}
+
+// ListCrimeCategories retrieves every entry of the taxonomy
+func (s *crimeCategoryService) ListCrimeCategories(ctx context.Context) ([]models.CrimeCategory, error) {
+	var categories []models.CrimeCategory
+	err := s.db.WithContext(ctx).Order("category").Order("subcategory").Find(&categories).Error
+	return categories, err
+}
+
+// GetCrimeCategoryByID retrieves an entry by its ID
+func (s *crimeCategoryService) GetCrimeCategoryByID(ctx context.Context, id uint) (*models.CrimeCategory, error) {
+	var category models.CrimeCategory
+	if err := s.db.WithContext(ctx).First(&category, id).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// CreateCrimeCategory validates and creates a new entry
+func (s *crimeCategoryService) CreateCrimeCategory(ctx context.Context, c *models.CrimeCategory) error {
+	db := s.db.WithContext(ctx)
+	if err := validateCrimeCategoryName(db, c); err != nil {
+		return err
+	}
+	return db.Create(c).Error
+}
+
+// UpdateCrimeCategory validates and saves the editable fields of an entry
+func (s *crimeCategoryService) UpdateCrimeCategory(ctx context.Context, c *models.CrimeCategory) error {
+	db := s.db.WithContext(ctx)
+	if err := validateCrimeCategoryName(db, c); err != nil {
+		return err
+	}
+	return updateColumns(db, c, "category", "subcategory", "default_weight", "synonyms")
+}
+
+// DeleteCrimeCategory removes an entry. Crimes keep their weight; the
+// knowledge base reclassifies their incidents in the next full run.
+func (s *crimeCategoryService) DeleteCrimeCategory(ctx context.Context, id uint) error {
+	result := s.db.WithContext(ctx).Delete(&models.CrimeCategory{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Taxonomy loads the taxonomy of the saved entries
+func (s *crimeCategoryService) Taxonomy(ctx context.Context) (*CrimeTaxonomy, error) {
+	return loadCrimeTaxonomy(s.db.WithContext(ctx))
+}
+
+// SeedDefaultCategories saves the default taxonomy in an empty table
+func (s *crimeCategoryService) SeedDefaultCategories(ctx context.Context) (int, error) {
+	seeded := 0
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.CrimeCategory{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		categories := DefaultCrimeCategories()
+		if err := tx.Create(&categories).Error; err != nil {
+			return err
+		}
+		seeded = len(categories)
+		return nil
+	})
+	return seeded, err
+}
+
+// loadCrimeTaxonomy builds the taxonomy of crime_categories, falling back to
+// DefaultCrimeCategories while the table is empty
+func loadCrimeTaxonomy(db *gorm.DB) (*CrimeTaxonomy, error) {
+	var categories []models.CrimeCategory
+	if err := db.Order("category_id").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	if len(categories) == 0 {
+		categories = DefaultCrimeCategories()
+	}
+	return NewCrimeTaxonomy(categories), nil
+}
+
+// validateCrimeCategoryName runs ValidateCrimeCategory and checks that no
+// other entry has the same category and subcategory
+func validateCrimeCategoryName(db *gorm.DB, c *models.CrimeCategory) error {
+	if err := ValidateCrimeCategory(c); err != nil {
+		return err
+	}
+
+	var existing models.CrimeCategory
+	err := db.Where("category = ? AND subcategory = ? AND category_id <> ?", c.Category, c.Subcategory, c.CategoryID).
+		First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return FieldErrors{"subcategory": "already exists in category " + c.Category}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestCrimeTaxonomy_Classify(t *testing.T) {
+	taxonomy := NewCrimeTaxonomy(DefaultCrimeCategories())
+
+	for name, want := range map[string]string{
+		"HOMICIDIO DOLOSO":       "Homicídio",
+		"Roubo - Outros":         "Roubo",
+		"roubo de carga":         "Roubo",
+		"Furto de Veículo":       "Furto",
+		"Tentativa de homicídio": "Tentativa de Homicídio",
+		"Homicídio Culposo":      "Homicídio Culposo",
+		"Estupro de Vulnerável":  "Estupro",
+		"Lesão Corporal Culposa por Acidente de Trânsito": "Acidente de Trânsito",
+		"Homicídio Culposo por Acidente de Trânsito":      "Acidente de Trânsito",
+	} {
+		entry, ok := taxonomy.Classify(name)
+		if !ok || entry.Subcategory != want {
+			t.Errorf("%q: esperava %s, obteve: %+v (%v)", name, want, entry, ok)
+		}
+	}
+
+	// Apenas palavras inteiras: "danos" e "furtos" não são "dano" e "furto"
+	for _, name := range []string{"Danos morais", "Pichações", "Ameaça", ""} {
+		if entry, ok := taxonomy.Classify(name); ok {
+			t.Errorf("%q: esperava sem categoria, obteve: %+v", name, entry)
+		}
+	}
+	if got := taxonomy.Category("Ameaça"); got != UncategorizedCrime {
+		t.Errorf("esperava %s, obteve: %s", UncategorizedCrime, got)
+	}
+	if got := taxonomy.Category("Latrocínio"); got != "Hediondo" {
+		t.Errorf("esperava Hediondo, obteve: %s", got)
+	}
+
+	if w := taxonomy.Weight("Latrocínio", 2); w != 9 {
+		t.Errorf("esperava o peso da taxonomia (9), obteve: %d", w)
+	}
+	if w := taxonomy.Weight("Ameaça", 4); w != 4 {
+		t.Errorf("esperava o peso informado (4), obteve: %d", w)
+	}
+	if w := taxonomy.Weight("Ameaça", 0); w != DefaultCrimeWeight {
+		t.Errorf("esperava o peso padrão (%d), obteve: %d", DefaultCrimeWeight, w)
+	}
+}
+
+func TestCrimeCategoryService(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	svc := NewCrimeCategoryService(gdb)
+	ctx := context.Background()
+
+	seeded, err := svc.SeedDefaultCategories(ctx)
+	if err != nil || seeded != len(DefaultCrimeCategories()) {
+		t.Fatalf("esperava %d subcategorias gravadas, obteve: %d (%v)", len(DefaultCrimeCategories()), seeded, err)
+	}
+	if seeded, err := svc.SeedDefaultCategories(ctx); err != nil || seeded != 0 {
+		t.Errorf("esperava não gravar de novo com a tabela preenchida, obteve: %d (%v)", seeded, err)
+	}
+
+	ameaca := &models.CrimeCategory{Category: "Pessoa", Subcategory: "Ameaça", DefaultWeight: 4,
+		Synonyms: models.StringList{"ameaça", "intimidação"}}
+	if err := svc.CreateCrimeCategory(ctx, ameaca); err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+
+	var fields FieldErrors
+	duplicate := &models.CrimeCategory{Category: "Pessoa", Subcategory: "Ameaça", DefaultWeight: 2}
+	if err := svc.CreateCrimeCategory(ctx, duplicate); !errors.As(err, &fields) || fields["subcategory"] == "" {
+		t.Errorf("esperava subcategoria duplicada rejeitada, obteve: %v", err)
+	}
+	invalid := &models.CrimeCategory{Category: " ", Subcategory: "Injúria", DefaultWeight: 11, Synonyms: models.StringList{"-"}}
+	if err := svc.CreateCrimeCategory(ctx, invalid); !errors.As(err, &fields) || len(fields) != 3 {
+		t.Errorf("esperava erros em category, default_weight e synonyms, obteve: %v", err)
+	}
+
+	ameaca.DefaultWeight = 6
+	if err := svc.UpdateCrimeCategory(ctx, ameaca); err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	taxonomy, err := svc.Taxonomy(ctx)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if w := taxonomy.Weight("Intimidação", 0); w != 6 {
+		t.Errorf("esperava o peso editado (6), obteve: %d", w)
+	}
+
+	if err := svc.DeleteCrimeCategory(ctx, ameaca.CategoryID); err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if err := svc.DeleteCrimeCategory(ctx, ameaca.CategoryID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("esperava registro não encontrado, obteve: %v", err)
+	}
+	if err := svc.UpdateCrimeCategory(ctx, ameaca); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("esperava registro não encontrado, obteve: %v", err)
+	}
+}
+
+// TestFindOrCreateCrime_TaxonomyWeight verifica que os crimes novos recebem o
+// peso da taxonomia, e o informado apenas fora dela
+func TestFindOrCreateCrime_TaxonomyWeight(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	svc := NewReportService(gdb, saoPaulo, DefaultGridArea)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name         string
+		weight, want int
+	}{
+		{"ESTUPRO", 3, 9},
+		{"Furto de Celular", 8, 3},
+		{"Ameaça", 4, 4},
+		{"Perturbação do Sossego", 0, DefaultCrimeWeight},
+	} {
+		id, err := svc.FindOrCreateCrime(ctx, &models.Crime{CrimeName: tc.name, CrimeWeight: tc.weight})
+		if err != nil {
+			t.Fatalf("esperava sem erro, obteve: %v", err)
+		}
+		var crime models.Crime
+		gdb.First(&crime, id)
+		if crime.CrimeWeight != tc.want {
+			t.Errorf("%s: esperava peso %d, obteve: %d", tc.name, tc.want, crime.CrimeWeight)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+// CrimeMergeResult summarizes a MergeDuplicateCrimes run
+type CrimeMergeResult struct {
+	DryRun bool              `json:"dry_run"`
+	Groups []CrimeMergeGroup `json:"groups"`
+	// Merged is the number of crimes soft deleted into another one
+	Merged int `json:"merged"`
+	// Reports is the number of reports moved to the kept crimes
+	Reports int64 `json:"reports"`
+	// Keyed is the number of crimes whose crime_key was filled or fixed
+	Keyed int `json:"keyed"`
+}
+
+// CrimeMergeGroup is a set of crimes with the same key merged into CrimeID
+type CrimeMergeGroup struct {
+	CrimeKey    string   `json:"crime_key"`
+	CrimeID     uint     `json:"crime_id"`
+	CrimeName   string   `json:"crime_name"`
+	MergedIDs   []uint   `json:"merged_ids"`
+	MergedNames []string `json:"merged_names"`
+	Reports     int64    `json:"reports"`
+}
+
+// MergeDuplicateCrimes groups the crimes by CrimeTaxonomy.Key and keeps one
+// crime per key: the one that already has the key or, otherwise, the oldest.
+// Keys that no longer match the name of their crime are cleared first.
+// The reports of the others, deleted ones included, are moved to it with a
+// new updated_at, so the next incremental knowledge base run sees them, and
+// the others are soft deleted. The kept crime keeps its name and weight.
+// With dryRun nothing is written.
+func (s *crimeService) MergeDuplicateCrimes(ctx context.Context, dryRun bool) (*CrimeMergeResult, error) {
+	result := &CrimeMergeResult{DryRun: dryRun, Groups: []CrimeMergeGroup{}}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var crimes []models.Crime
+		if err := tx.Order("crime_id").Find(&crimes).Error; err != nil {
+			return err
+		}
+		taxonomy, err := loadCrimeTaxonomy(tx)
+		if err != nil {
+			return err
+		}
+
+		var keys []string
+		byKey := make(map[string][]models.Crime)
+		for _, c := range crimes {
+			key := taxonomy.Key(c.CrimeName)
+			if _, ok := byKey[key]; !ok {
+				keys = append(keys, key)
+			}
+			byKey[key] = append(byKey[key], c)
+		}
+
+		// A live crime may hold the key of another group (a renamed crime, a
+		// synonym added later). Those keys are cleared in one statement before
+		// any key is assigned, or the partial unique index would refuse the new
+		// key of a group processed before the group of the stale crime.
+		var stale []uint
+		for _, c := range crimes {
+			if c.CrimeKey != "" && c.CrimeKey != taxonomy.Key(c.CrimeName) {
+				stale = append(stale, c.CrimeID)
+			}
+		}
+		if !dryRun && len(stale) > 0 {
+			if err := tx.Model(&models.Crime{}).Where("crime_id IN ?", stale).
+				Update("crime_key", nil).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		for _, key := range keys {
+			group := byKey[key]
+			kept := group[0]
+			for _, c := range group {
+				if c.CrimeKey == key {
+					kept = c
+					break
+				}
+			}
+
+			if len(group) > 1 {
+				merge := CrimeMergeGroup{CrimeKey: key, CrimeID: kept.CrimeID, CrimeName: kept.CrimeName}
+				for _, c := range group {
+					if c.CrimeID != kept.CrimeID {
+						merge.MergedIDs = append(merge.MergedIDs, c.CrimeID)
+						merge.MergedNames = append(merge.MergedNames, c.CrimeName)
+					}
+				}
+
+				reports := tx.Unscoped().Model(&models.Report{}).Where("crime_id IN ?", merge.MergedIDs)
+				if dryRun {
+					if err := reports.Count(&merge.Reports).Error; err != nil {
+						return err
+					}
+				} else {
+					moved := reports.Updates(map[string]any{"crime_id": kept.CrimeID, "updated_at": now})
+					if moved.Error != nil {
+						return moved.Error
+					}
+					merge.Reports = moved.RowsAffected
+
+					if err := tx.Model(&models.Crime{}).Where("crime_id IN ?", merge.MergedIDs).
+						Updates(map[string]any{"deleted_at": now, "updated_at": now}).Error; err != nil {
+						return err
+					}
+				}
+
+				result.Groups = append(result.Groups, merge)
+				result.Merged += len(merge.MergedIDs)
+				result.Reports += merge.Reports
+			}
+
+			if kept.CrimeKey != key {
+				if !dryRun {
+					if err := tx.Model(&models.Crime{}).Where("crime_id = ?", kept.CrimeID).
+						Update("crime_key", key).Error; err != nil {
+						return err
+					}
+				}
+				result.Keyed++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+func TestCrimeTaxonomyKey(t *testing.T) {
+	taxonomy := NewCrimeTaxonomy(DefaultCrimeCategories())
+	for name, want := range map[string]string{
+		"Homicídio Doloso":       "homicidio",
+		"HOMICIDIO  DOLOSO":      "homicidio",
+		" homicídio-doloso ":     "homicidio",
+		"Homici\u0301dio Doloso": "homicidio", // i + acento combinado (NFD)
+		"Feminicídio":            "homicidio",
+		"Lesão Corporal Dolosa":  "lesao corporal",
+		"ROUBO - OUTROS":         "roubo",
+		"Assalto":                "roubo",
+		"Furto de Celular":       "furto de celular",
+	} {
+		if got := taxonomy.Key(name); got != want {
+			t.Errorf("%q: esperava %q, obteve: %q", name, want, got)
+		}
+	}
+}
+
+// TestMergeDuplicateCrimes junta crimes gravados antes de crime_key e verifica
+// que os reports, inclusive os apagados, passam para o crime mantido
+func TestMergeDuplicateCrimes(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	// Crimes antigos, sem chave
+	for _, c := range []struct {
+		name   string
+		weight int
+	}{
+		{"Homicídio Doloso", 9}, {"HOMICIDIO  DOLOSO", 3}, {"Furto", 3}, {"Roubo - Outros", 5}, {"Roubo", 5},
+	} {
+		if err := gdb.Exec(`INSERT INTO crimes (crime_name, crime_weight, created_at, updated_at)
+			VALUES (?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`, c.name, c.weight).Error; err != nil {
+			t.Fatalf("falha ao inserir crime: %v", err)
+		}
+	}
+	neighborhood := models.Neighborhood{Name: "Centro", Latitude: -22.9056, Longitude: -47.0608}
+	gdb.Create(&neighborhood)
+	for _, crimeID := range []uint{1, 2, 2, 4} {
+		gdb.Create(&models.Report{NeighborhoodID: neighborhood.NeighborhoodID, CrimeID: crimeID, ReportDate: "2024-01-10"})
+	}
+	gdb.Delete(&models.Report{}, 3)
+
+	svc := NewCrimeService(gdb)
+	dry, err := svc.MergeDuplicateCrimes(ctx, true)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if dry.Merged != 2 || dry.Reports != 2 || dry.Keyed != 3 || len(dry.Groups) != 2 {
+		t.Fatalf("esperava 2 crimes, 2 reports e 3 chaves, obteve: %+v", dry)
+	}
+	var keyed int64
+	gdb.Model(&models.Crime{}).Where("crime_key IS NOT NULL").Count(&keyed)
+	if keyed != 0 {
+		t.Errorf("esperava o dry-run sem gravar, obteve: %d chaves", keyed)
+	}
+
+	result, err := svc.MergeDuplicateCrimes(ctx, false)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if g := result.Groups[0]; g.CrimeID != 1 || len(g.MergedIDs) != 1 || g.MergedIDs[0] != 2 || g.Reports != 2 {
+		t.Errorf("esperava o crime 2 junto ao 1 com 2 reports, obteve: %+v", g)
+	}
+	if g := result.Groups[1]; g.CrimeID != 4 || g.CrimeKey != "roubo" {
+		t.Errorf("esperava o roubo mantido no crime 4, obteve: %+v", g)
+	}
+
+	var moved int64
+	gdb.Unscoped().Model(&models.Report{}).Where("crime_id = ?", 1).Count(&moved)
+	if moved != 3 {
+		t.Errorf("esperava 3 reports no crime 1, obteve: %d", moved)
+	}
+	var crimes []models.Crime
+	gdb.Order("crime_id").Find(&crimes)
+	if len(crimes) != 3 || crimes[0].CrimeKey != "homicidio" || crimes[0].CrimeWeight != 9 {
+		t.Errorf("esperava 3 crimes com chave, obteve: %+v", crimes)
+	}
+
+	again, err := svc.MergeDuplicateCrimes(ctx, false)
+	if err != nil || again.Merged != 0 || again.Keyed != 0 {
+		t.Errorf("esperava nada a consolidar, obteve: %+v (%v)", again, err)
+	}
+
+	// Depois da consolidação as variações do nome caem no mesmo crime
+	id, err := NewReportService(gdb, saoPaulo, DefaultGridArea).FindOrCreateCrime(ctx, &models.Crime{CrimeName: "homicidio doloso", CrimeWeight: 3})
+	if err != nil || id != 1 {
+		t.Errorf("esperava o crime 1, obteve: %d (%v)", id, err)
+	}
+	var fields FieldErrors
+	err = svc.CreateCrime(ctx, &models.Crime{CrimeName: "Homicídio-Doloso", CrimeWeight: 9})
+	if !errors.As(err, &fields) || fields["crime_name"] == "" {
+		t.Errorf("esperava erro em crime_name, obteve: %v", err)
+	}
+	furto := &models.Crime{CrimeID: 3, CrimeName: "FURTO DE CELULAR", CrimeWeight: 3}
+	if err := svc.UpdateCrime(ctx, furto); err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if updated, _ := svc.GetCrimeByID(ctx, 3); updated.CrimeKey != "furto de celular" {
+		t.Errorf("esperava a chave do nome novo, obteve: %q", updated.CrimeKey)
+	}
+	if err := gdb.Create(&models.Crime{CrimeName: "ROUBO", CrimeWeight: 5}).Error; err == nil {
+		t.Error("esperava o índice único recusar a chave repetida")
+	}
+}
+
+// TestMergeDuplicateCrimes_StaleKey corrige a chave antiga de um crime que
+// pertence ao grupo de outro crime processado antes dele
+func TestMergeDuplicateCrimes_StaleKey(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	// O roubo (crime 1) ainda não tem chave e o furto (crime 2) ficou com a
+	// chave "roubo" de antes de uma renomeação
+	roubo := models.Crime{CrimeName: "Roubo", CrimeWeight: 5}
+	furto := models.Crime{CrimeName: "Furto", CrimeWeight: 3}
+	for _, c := range []*models.Crime{&roubo, &furto} {
+		if err := gdb.Create(c).Error; err != nil {
+			t.Fatalf("falha ao inserir crime: %v", err)
+		}
+	}
+	gdb.Exec(`UPDATE crimes SET crime_key = NULL WHERE crime_id = ?`, roubo.CrimeID)
+	gdb.Exec(`UPDATE crimes SET crime_key = 'roubo' WHERE crime_id = ?`, furto.CrimeID)
+
+	result, err := NewCrimeService(gdb).MergeDuplicateCrimes(ctx, false)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if result.Merged != 0 || result.Keyed != 2 {
+		t.Errorf("esperava 2 chaves corrigidas sem consolidar crimes, obteve: %+v", result)
+	}
+
+	var crimes []models.Crime
+	gdb.Order("crime_id").Find(&crimes)
+	if len(crimes) != 2 || crimes[0].CrimeKey != "roubo" || crimes[1].CrimeKey != "furto" {
+		t.Errorf("esperava as chaves roubo e furto, obteve: %+v", crimes)
+	}
+}
@@ -2,6 +2,8 @@ package services
 
 import (
     "context"
+    "errors"
+    "fmt"
 
     "github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
     "gorm.io/gorm"
@@ -11,9 +13,19 @@ import (
 // relacionadas à entidade Crime no sistema.
 // Aqui declaramos os métodos que qualquer implementação deve oferecer.
 type CrimeService interface {
-    // ListCrimes retorna todos os registros de crimes
-    // e um erro caso algo falhe na recuperação no banco.
-    ListCrimes(ctx context.Context) ([]models.Crime, error)
+    // ListCrimes retorna uma página dos crimes que atendem aos filtros,
+    // com o total de registros, e um erro caso algo falhe no banco.
+    ListCrimes(ctx context.Context, q ListQuery) (*ListPage[models.Crime], error)
+    GetCrimeByID(ctx context.Context, id uint) (*models.Crime, error)
+    // CreateCrime e UpdateCrime validam nome e peso antes de gravar, e
+    // rejeitam um nome com a mesma chave (CrimeTaxonomy.Key) de outro crime
+    CreateCrime(ctx context.Context, c *models.Crime) error
+    UpdateCrime(ctx context.Context, c *models.Crime) error
+    // DeleteCrime faz soft delete; falha com ErrInUse enquanto houver reports do crime
+    DeleteCrime(ctx context.Context, id uint) error
+    // MergeDuplicateCrimes junta os crimes com a mesma chave em um só,
+    // movendo os reports, e preenche a chave dos crimes antigos
+    MergeDuplicateCrimes(ctx context.Context, dryRun bool) (*CrimeMergeResult, error)
 }
 
 type crimeService struct {
@@ -25,14 +37,73 @@ func NewCrimeService(db *gorm.DB) CrimeService {
 }
 
 
-func (s *crimeService) ListCrimes(ctx context.Context) ([]models.Crime, error) {
-    var crimes []models.Crime
+// crimeColumns são as colunas de Crime usadas pelos filtros e ordenação
+var crimeColumns = listColumns{id: "crime_id", name: "crime_name", weight: "crime_weight", createdAt: "created_at"}
 
-    // Execute consult with 0 filter: SELECT * FROM "Crimes"
-    if err := s.db.WithContext(ctx).Find(&crimes).Error; err != nil {
+func (s *crimeService) ListCrimes(ctx context.Context, q ListQuery) (*ListPage[models.Crime], error) {
+    // SELECT * FROM crimes WHERE <filtros> ORDER BY <sort> com limite
+    return listPage(s.db.WithContext(ctx), &models.Crime{}, q, crimeColumns,
+        func(c models.Crime) uint { return c.CrimeID })
+}
+
+func (s *crimeService) GetCrimeByID(ctx context.Context, id uint) (*models.Crime, error) {
+    var crime models.Crime
+    if err := s.db.WithContext(ctx).First(&crime, id).Error; err != nil {
         return nil, err
     }
+    return &crime, nil
+}
+
+func (s *crimeService) CreateCrime(ctx context.Context, c *models.Crime) error {
+    db := s.db.WithContext(ctx)
+    if err := validateCrimeKey(db, c); err != nil {
+        return err
+    }
+    return db.Create(c).Error
+}
+
+func (s *crimeService) UpdateCrime(ctx context.Context, c *models.Crime) error {
+    db := s.db.WithContext(ctx)
+    if err := validateCrimeKey(db, c); err != nil {
+        return err
+    }
+    // crime_key foi recalculada a partir do nome por validateCrimeKey
+    return updateColumns(db, c, "crime_name", "crime_weight", "crime_key")
+}
 
-    // Return slice and nil 
-    return crimes, nil
-}
\ No newline at end of file
+// validateCrimeKey roda ValidateCrime, preenche a chave do crime pela
+// taxonomia e verifica que nenhum outro crime tem a mesma chave, o que o
+// índice único também recusaria
+func validateCrimeKey(db *gorm.DB, c *models.Crime) error {
+    if err := ValidateCrime(c); err != nil {
+        return err
+    }
+    taxonomy, err := loadCrimeTaxonomy(db)
+    if err != nil {
+        return err
+    }
+    c.CrimeKey = taxonomy.Key(c.CrimeName)
+
+    var existing models.Crime
+    err = db.Where("crime_key = ? AND crime_id <> ?", c.CrimeKey, c.CrimeID).First(&existing).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    return FieldErrors{"crime_name": fmt.Sprintf("same as crime %d (%s)", existing.CrimeID, existing.CrimeName)}
+}
+
+func (s *crimeService) DeleteCrime(ctx context.Context, id uint) error {
+    return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        var reports int64
+        if err := tx.Model(&models.Report{}).Where("crime_id = ?", id).Count(&reports).Error; err != nil {
+            return err
+        }
+        if reports > 0 {
+            return fmt.Errorf("%w: crime has %d reports", ErrInUse, reports)
+        }
+        return softDelete(tx, &models.Crime{}, "crime_id", id)
+    })
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+const (
+	// UncategorizedCrime is the category of crimes that match no entry of the
+	// taxonomy
+	UncategorizedCrime = "Comum"
+	// DefaultCrimeWeight is the weight of new crimes that match no entry of
+	// the taxonomy and have no valid weight of their own
+	DefaultCrimeWeight = 3
+)
+
+// defaultCrimeCategoriesJSON is the default taxonomy. The same file is read
+// by ScriptsXlsxToJson/process_crime_data.py, so both weight crimes alike.
+//
+//go:embed crime_categories_default.json
+var defaultCrimeCategoriesJSON []byte
+
+// DefaultCrimeCategories is the taxonomy saved in crime_categories when the
+// table is empty, read from crime_categories_default.json. The heinous crimes
+// (Lei 8.072/1990) weigh 9, like the old list of the SSP importer.
+func DefaultCrimeCategories() []models.CrimeCategory {
+	var categories []models.CrimeCategory
+	if err := json.Unmarshal(defaultCrimeCategoriesJSON, &categories); err != nil {
+		// The file is embedded in the binary and parsed by the tests
+		panic(fmt.Sprintf("crime_categories_default.json: %v", err))
+	}
+	return categories
+}
+
+// CrimeTaxonomy classifies crime names into the entries of crime_categories.
+// A name matches an entry when it contains one of its synonyms, or its
+// subcategory, as whole words, ignoring case, accents and punctuation. The
+// longest matching synonym wins, so "Roubo de Carga" can be told apart from
+// "Roubo" and "Lesão Corporal Culposa por Acidente de Trânsito" from
+// "Lesão Corporal".
+type CrimeTaxonomy struct {
+	terms []taxonomyTerm
+	// keys maps the normalized subcategories and synonyms to the key of
+	// their entry
+	keys map[string]string
+}
+
+// taxonomyTerm is a normalized synonym of an entry
+type taxonomyTerm struct {
+	text  string
+	entry models.CrimeCategory
+}
+
+// NewCrimeTaxonomy builds the taxonomy of the categories
+func NewCrimeTaxonomy(categories []models.CrimeCategory) *CrimeTaxonomy {
+	t := &CrimeTaxonomy{keys: make(map[string]string)}
+	for _, c := range categories {
+		key := models.NormalizeCrimeName(c.Subcategory)
+		for _, synonym := range append([]string{c.Subcategory}, c.Synonyms...) {
+			if text := models.NormalizeCrimeName(synonym); text != "" {
+				t.terms = append(t.terms, taxonomyTerm{text, c})
+				// A synonym repeated in another entry keeps the first one
+				if _, ok := t.keys[text]; !ok {
+					t.keys[text] = key
+				}
+			}
+		}
+	}
+	// Longest first; ties keep the order of the categories
+	sort.SliceStable(t.terms, func(a, b int) bool { return len(t.terms[a].text) > len(t.terms[b].text) })
+	return t
+}
+
+// Classify returns the entry of the crime name; false when no entry matches
+func (t *CrimeTaxonomy) Classify(crimeName string) (models.CrimeCategory, bool) {
+	name := " " + models.NormalizeCrimeName(crimeName) + " "
+	for _, term := range t.terms {
+		if strings.Contains(name, " "+term.text+" ") {
+			return term.entry, true
+		}
+	}
+	return models.CrimeCategory{}, false
+}
+
+// Key returns the merge key of a crime name (crimes.crime_key): the
+// normalized subcategory of the entry that has the whole name as its
+// subcategory or one of its synonyms, or the normalized name itself. Unlike
+// Classify, a name that only contains a synonym keeps its own key.
+// "Homicídio Doloso", "HOMICIDIO  DOLOSO" and "Feminicídio" share the key
+// "homicidio"; "Furto de Celular" has the key "furto de celular".
+func (t *CrimeTaxonomy) Key(crimeName string) string {
+	name := models.NormalizeCrimeName(crimeName)
+	if key, ok := t.keys[name]; ok {
+		return key
+	}
+	return name
+}
+
+// Category returns the category of the crime name, or UncategorizedCrime
+func (t *CrimeTaxonomy) Category(crimeName string) string {
+	if entry, ok := t.Classify(crimeName); ok {
+		return entry.Category
+	}
+	return UncategorizedCrime
+}
+
+// Weight returns the weight of a new crime: the default weight of its entry
+// or, when it matches none, fallback if it is a valid weight and
+// DefaultCrimeWeight otherwise
+func (t *CrimeTaxonomy) Weight(crimeName string, fallback int) int {
+	if entry, ok := t.Classify(crimeName); ok {
+		return entry.DefaultWeight
+	}
+	if fallback >= MinCrimeWeight && fallback <= MaxCrimeWeight {
+		return fallback
+	}
+	return DefaultCrimeWeight
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/calendar"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+// HolidayCity is the city of the holidays saved in external_holidays
+const HolidayCity = "Campinas"
+
+// Limits of the years accepted by GenerateHolidays
+const (
+	MinHolidayYear = 1900
+	MaxHolidayYear = 2200
+)
+
+// ErrInvalidYearRange is returned for a year range outside MinHolidayYear
+// and MaxHolidayYear or with from after to
+var ErrInvalidYearRange = errors.New("invalid year range")
+
+// HolidayService defines business operations related to the holiday calendar
+// used by the calendar features of the knowledge base
+type HolidayService interface {
+	// GenerateHolidays computes and saves the national, São Paulo state and
+	// Campinas holidays from fromYear to toYear (inclusive)
+	GenerateHolidays(ctx context.Context, fromYear, toYear int) (*HolidayImportResult, error)
+	// ImportHolidays saves holidays read from a CSV or ICS file. A holiday on
+	// a date already in the calendar replaces its name and type.
+	ImportHolidays(ctx context.Context, holidays []calendar.Holiday) (*HolidayImportResult, error)
+	// ListHolidays returns the holidays of a year (every year when year is 0),
+	// ordered by date
+	ListHolidays(ctx context.Context, year int) ([]models.ExternalHoliday, error)
+}
+
+// HolidayImportResult summarizes a generation or import of holidays
+type HolidayImportResult struct {
+	Imported int `json:"imported"`
+	Created  int `json:"created"`
+	Updated  int `json:"updated"`
+}
+
+// holidayService is the concrete implementation of HolidayService
+type holidayService struct {
+	db *gorm.DB
+}
+
+// NewHolidayService creates a new instance of HolidayService
+func NewHolidayService(db *gorm.DB) HolidayService {
+	return &holidayService{db: db}
+}
+
+// GenerateHolidays saves the holidays computed by the calendar package
+func (s *holidayService) GenerateHolidays(ctx context.Context, fromYear, toYear int) (*HolidayImportResult, error) {
+	if fromYear < MinHolidayYear || toYear > MaxHolidayYear || fromYear > toYear {
+		return nil, ErrInvalidYearRange
+	}
+	return s.ImportHolidays(ctx, calendar.ForYears(fromYear, toYear))
+}
+
+// ImportHolidays upserts the holidays by date in a single transaction
+func (s *holidayService) ImportHolidays(ctx context.Context, holidays []calendar.Holiday) (*HolidayImportResult, error) {
+	result := &HolidayImportResult{}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []models.ExternalHoliday
+		if err := tx.Where("city = ?", HolidayCity).Find(&existing).Error; err != nil {
+			return err
+		}
+		byDate := make(map[string]models.ExternalHoliday, len(existing))
+		for _, h := range existing {
+			byDate[h.Date.Format("2006-01-02")] = h
+		}
+
+		for _, h := range calendar.Merge(holidays) {
+			record, ok := byDate[h.Key()]
+			switch {
+			case !ok:
+				record = models.ExternalHoliday{Date: h.Date, Name: h.Name, Type: h.Type, City: HolidayCity}
+				if err := tx.Create(&record).Error; err != nil {
+					return err
+				}
+				byDate[h.Key()] = record
+				result.Created++
+			case record.Name != h.Name || record.Type != h.Type:
+				record.Name, record.Type = h.Name, h.Type
+				if err := updateColumns(tx, &record, "name", "type"); err != nil {
+					return err
+				}
+				result.Updated++
+			}
+			result.Imported++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListHolidays returns the holidays of the city
+func (s *holidayService) ListHolidays(ctx context.Context, year int) ([]models.ExternalHoliday, error) {
+	db := s.db.WithContext(ctx).Where("city = ?", HolidayCity)
+	if year != 0 {
+		db = db.Where("date >= ? AND date < ?", calendar.Date(year, 1, 1), calendar.Date(year+1, 1, 1))
+	}
+	var holidays []models.ExternalHoliday
+	if err := db.Order("date").Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/calendar"
+)
+
+func TestHolidayService(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	ctx := context.Background()
+	svc := NewHolidayService(gdb)
+
+	result, err := svc.GenerateHolidays(ctx, 2024, 2025)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	want := len(calendar.ForYears(2024, 2025))
+	if result.Created != want || result.Updated != 0 {
+		t.Errorf("esperava %d feriados novos, obteve: %+v", want, result)
+	}
+
+	// Gerar de novo não duplica; o import troca o nome de uma data existente
+	if result, err = svc.GenerateHolidays(ctx, 2025, 2025); err != nil || result.Created != 0 || result.Updated != 0 {
+		t.Errorf("esperava nenhuma mudança, obteve: %+v (%v)", result, err)
+	}
+	result, err = svc.ImportHolidays(ctx, []calendar.Holiday{
+		{Date: calendar.Date(2025, time.December, 8), Name: "Imaculada Conceição", Type: calendar.TypeMunicipal},
+		{Date: calendar.Date(2025, time.July, 14), Name: "Fundação de Campinas", Type: calendar.TypeImported},
+	})
+	if err != nil || result.Imported != 2 || result.Created != 1 || result.Updated != 1 {
+		t.Errorf("esperava 1 novo e 1 atualizado, obteve: %+v (%v)", result, err)
+	}
+
+	holidays, err := svc.ListHolidays(ctx, 2025)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if len(holidays) != len(calendar.ForYear(2025))+1 {
+		t.Errorf("esperava %d feriados em 2025, obteve: %d", len(calendar.ForYear(2025))+1, len(holidays))
+	}
+	for i, h := range holidays {
+		if h.Date.Year() != 2025 || h.City != HolidayCity || (i > 0 && h.Date.Before(holidays[i-1].Date)) {
+			t.Errorf("feriado fora de 2025 ou fora de ordem: %+v", h)
+		}
+		if h.Date.Format("2006-01-02") == "2025-12-08" && h.Name != "Imaculada Conceição" {
+			t.Errorf("esperava o nome atualizado, obteve: %s", h.Name)
+		}
+	}
+
+	if _, err := svc.GenerateHolidays(ctx, 2026, 2025); !errors.Is(err, ErrInvalidYearRange) {
+		t.Errorf("esperava ErrInvalidYearRange, obteve: %v", err)
+	}
+}
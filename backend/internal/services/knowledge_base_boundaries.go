@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/geo"
+)
+
+// ============================================================================
+// LIMITES DOS BAIRROS
+// ============================================================================
+//
+// Com limites importados em neighborhood_boundaries, os bairros deixam de ser
+// só o ponto mais próximo:
+//   - o incidente fica no bairro cujo polígono contém as suas coordenadas;
+//   - a célula fica no bairro que contém o seu centro (distance = 0) ou, se o
+//     centro não cai em nenhum, no de maior área em comum (distance NULL);
+//   - células sem nenhum polígono mantêm o bairro do ponto mais próximo.
+//
+// Na execução incremental a grade reaproveitada mantém o mapeamento atual,
+// então depois de importar limites é preciso uma execução completa.
+
+// loadBoundaries lê os limites dos bairros ativos. Sem a tabela (banco ainda
+// não migrado) o pipeline segue com o mapeamento pelo ponto mais próximo.
+func (kg *KnowledgeBaseGenerator) loadBoundaries(ctx context.Context, db *sql.DB) {
+	rows, err := db.QueryContext(ctx, `
+        SELECT b.neighborhood_id, n.name, b.geometry
+        FROM neighborhood_boundaries b
+        JOIN neighborhoods n ON n.neighborhood_id = b.neighborhood_id
+        WHERE n.deleted_at IS NULL
+        ORDER BY b.neighborhood_id`)
+	if err != nil {
+		kg.logger.Printf("⚠️  Limites dos bairros indisponíveis, usando o ponto mais próximo: %v", err)
+		kg.progress.Warning(fmt.Errorf("limites dos bairros: %v", err))
+		return
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			kg.logger.Printf("⚠️  Erro ao fechar rows: %v", err)
+		}
+	}()
+
+	index := geo.NewIndex()
+	for rows.Next() {
+		var id uint
+		var name, geometry string
+		if err := rows.Scan(&id, &name, &geometry); err != nil {
+			kg.logger.Printf("⚠️  Erro ao escanear limite: %v", err)
+			continue
+		}
+		g, err := geo.UnmarshalGeometry(geometry)
+		if err != nil {
+			kg.logger.Printf("⚠️  Limite inválido do bairro %s: %v", name, err)
+			kg.progress.Warning(fmt.Errorf("limite do bairro %s: %v", name, err))
+			continue
+		}
+		index.Add(id, name, g)
+	}
+	if err := rows.Err(); err != nil {
+		kg.logger.Printf("⚠️  Erro ao ler limites dos bairros: %v", err)
+		kg.progress.Warning(fmt.Errorf("limites dos bairros: %v", err))
+		return
+	}
+
+	if index.Len() > 0 {
+		kg.boundaries = index
+		kg.logger.Printf("🧭 %d limites de bairros carregados", index.Len())
+	}
+}
+
+// incidentNeighborhood retorna o bairro do incidente: o do polígono que contém
+// as coordenadas ou, sem limites, o bairro do report
+func (kg *KnowledgeBaseGenerator) incidentNeighborhood(report Report, lat, lon float64) string {
+	if kg.boundaries != nil {
+		if m, ok := kg.boundaries.Locate(lat, lon); ok {
+			return m.Name
+		}
+	}
+	return report.Neighborhood.Name
+}
+
+// cellBoundary é o bairro de uma célula pelos limites
+type cellBoundary struct {
+	cellID       string
+	neighborhood string
+	distance     sql.NullFloat64
+}
+
+// mapCellsToBoundaries sobrescreve em cell_neighborhoods o bairro das células
+// da grade que tocam algum limite, em uma única transação para que o
+// mapeamento nunca fique pela metade. Retorna quantas células foram remapeadas.
+func (kg *KnowledgeBaseGenerator) mapCellsToBoundaries(ctx context.Context, db *sql.DB) (int, error) {
+	grid, err := kg.cellGrid()
+	if err != nil {
+		return 0, err
+	}
+
+	var cells []cellBoundary
+	err = grid.each(func(c gridCell) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if m, ok := kg.boundaries.Locate(c.lat, c.lon); ok {
+			cells = append(cells, cellBoundary{c.id, m.Name, sql.NullFloat64{Valid: true}})
+			return nil
+		}
+		// Na grade H3, a área em comum é a do retângulo que envolve o hexágono
+		if m, ok := kg.boundaries.LargestOverlap(c.polygon.Bound()); ok {
+			cells = append(cells, cellBoundary{cellID: c.id, neighborhood: m.Name})
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	const paramsPerRow = 3
+	chunkSize := kg.dialect.MaxParams() / paramsPerRow
+	if chunkSize > 500 {
+		chunkSize = 500
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for start := 0; start < len(cells); start += chunkSize {
+		end := start + chunkSize
+		if end > len(cells) {
+			end = len(cells)
+		}
+		chunk := cells[start:end]
+
+		ids := make([]interface{}, len(chunk))
+		values := make([]string, len(chunk))
+		args := make([]interface{}, 0, len(chunk)*paramsPerRow)
+		for k, c := range chunk {
+			ids[k] = c.cellID
+			values[k] = "(?, ?, ?)"
+			args = append(args, c.cellID, c.neighborhood, c.distance)
+		}
+
+		deleteQuery := kg.dialect.Rebind(fmt.Sprintf(`DELETE FROM cell_neighborhoods WHERE cell_id IN (%s)`, placeholders(len(chunk))))
+		if _, err := tx.ExecContext(ctx, deleteQuery, ids...); err != nil {
+			return 0, err
+		}
+		insertQuery := kg.dialect.Rebind(`INSERT INTO cell_neighborhoods (cell_id, neighborhood, distance) VALUES ` + strings.Join(values, ", "))
+		if _, err := tx.ExecContext(ctx, insertQuery, args...); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	kg.logger.Printf("🧭 %d células mapeadas pelos limites dos bairros", len(cells))
+	return len(cells), nil
+}
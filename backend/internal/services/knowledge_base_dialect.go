@@ -0,0 +1,100 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KnowledgeBaseDialect isola o SQL específico de cada banco usado pelo pipeline
+// da base de conhecimento. As queries portáveis ficam no gerador e usam "?" como
+// placeholder, convertido por Rebind; as queries que dependem de recursos do banco
+// (MERGE, CROSS APPLY, LATERAL, séries de datas...) vêm prontas do dialeto, já com
+// os placeholders nativos.
+type KnowledgeBaseDialect interface {
+	// Name identifica o dialeto: "sqlserver", "postgres" ou "sqlite"
+	Name() string
+	// DriverName é o nome do driver registrado em database/sql
+	DriverName() string
+	// Rebind converte os placeholders "?" de uma query para o formato do banco.
+	// A query não pode conter "?" dentro de literais.
+	Rebind(query string) string
+	// MaxParams é o número máximo de parâmetros aceitos em um statement
+	MaxParams() int
+	// MaxWriters é o número máximo de conexões gravando ao mesmo tempo
+	// (0 = sem limite); limita os workers da fase 4
+	MaxWriters() int
+	// Limit restringe a query (terminada em ORDER BY) às primeiras n linhas
+	Limit(query string, n int) string
+	// IsDuplicateKey indica se o erro é violação de chave primária/única
+	IsDuplicateKey(err error) bool
+	// Instant envolve uma coluna ou placeholder de data/hora para que compare
+	// como instante, independente do fuso em que o valor foi gravado
+	Instant(value string) string
+
+	// HistoricalReportsQuery lê os reports com occurred_at no período alterados
+	// depois da marca d'água. Args: início, fim (instantes, inclusivos),
+	// updated_at mínimo (exclusivo; nil lê todos)
+	HistoricalReportsQuery() string
+	// UpsertIncidentsQuery insere ou atualiza incidentes em curated_incidents.
+	// values é a lista "(?, ...), (?, ...)" das colunas (id, occurred_at, category,
+	// severity, latitude, longitude, neighborhood, confidence, source); a query
+	// resultante passa por Rebind. Incidentes atualizados perdem a célula atribuída.
+	UpsertIncidentsQuery(values string) string
+	// InsertCellQuery insere uma célula ignorando as já existentes.
+	// Args: cell_id, cell_resolution, city, center_lat, center_lng, bounds_json
+	InsertCellQuery() string
+	// AssignCellsQuery grava a célula de vários incidentes em um único UPDATE.
+	// values é a lista "(?, ?), (?, ?)" de pares (id, cell_id); a query resultante
+	// passa por Rebind. Args: cell_resolution seguido dos pares.
+	AssignCellsQuery(values string) string
+	// CellNeighborhoodsStatements recriam o mapeamento célula → bairro mais próximo
+	CellNeighborhoodsStatements() []string
+	// MonthlyFeaturesTableStatements criam features_cell_monthly se necessário
+	MonthlyFeaturesTableStatements() []string
+	// MonthlyFeaturesQuery faz upsert das features mensais. Args: início, fim,
+	// cell_resolution, cell_id ("" para todas as células) e o primeiro mês gravado
+	// (os meses anteriores a ele só entram no cálculo dos lags)
+	MonthlyFeaturesQuery() string
+	// KnowledgeBaseTablesQuery conta as tabelas curated_/external_/features_/analytics_
+	KnowledgeBaseTablesQuery() string
+}
+
+// NewKnowledgeBaseDialect retorna o dialeto para o driver configurado (DB_DRIVER)
+func NewKnowledgeBaseDialect(driver string) (KnowledgeBaseDialect, error) {
+	switch strings.ToLower(driver) {
+	case "", "sqlserver", "mssql":
+		return sqlServerDialect{}, nil
+	case "postgres", "postgresql", "pgx":
+		return postgresDialect{}, nil
+	case "sqlite", "sqlite3":
+		return sqliteDialect{}, nil
+	default:
+		return nil, fmt.Errorf("dialeto não suportado: %q", driver)
+	}
+}
+
+// rebindNumbered troca cada "?" por prefix + número sequencial (ex.: @p1, $1)
+func rebindNumbered(query, prefix string) string {
+	var b strings.Builder
+	b.Grow(len(query) + 16)
+
+	n := 0
+	for i := 0; i < len(query); i++ {
+		if query[i] != '?' {
+			b.WriteByte(query[i])
+			continue
+		}
+		n++
+		b.WriteString(prefix)
+		b.WriteString(strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+// knowledgeBaseTablesFilter é o filtro comum às queries de contagem de tabelas
+const knowledgeBaseTablesFilter = `
+	(TABLE_NAME LIKE 'curated_%'
+		OR TABLE_NAME LIKE 'external_%'
+		OR TABLE_NAME LIKE 'features_%'
+		OR TABLE_NAME LIKE 'analytics_%')`
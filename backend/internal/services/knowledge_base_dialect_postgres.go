@@ -0,0 +1,187 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// postgresDialect roda o pipeline em PostgreSQL/PostGIS, com as mesmas tabelas
+// planas (curated_incidents, curated_cells...) criadas pelas migrations
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string       { return "postgres" }
+func (postgresDialect) DriverName() string { return "pgx" }
+func (postgresDialect) MaxParams() int     { return 65535 }
+func (postgresDialect) MaxWriters() int    { return 0 }
+
+func (postgresDialect) Instant(value string) string { return value }
+
+func (postgresDialect) Rebind(query string) string {
+	return rebindNumbered(query, "$")
+}
+
+func (postgresDialect) Limit(query string, n int) string {
+	return fmt.Sprintf("%s LIMIT %d", query, n)
+}
+
+// IsDuplicateKey reconhece unique_violation (SQLSTATE 23505)
+func (postgresDialect) IsDuplicateKey(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func (postgresDialect) HistoricalReportsQuery() string {
+	return `
+    SELECT r.report_id, r.neighborhood_id, r.crime_id, r.occurred_at, r.created_at, r.updated_at,
+        n.name AS neighborhood_name, n.latitude, n.longitude, n.neighborhood_weight,
+        c.crime_name, c.crime_weight,
+        CASE WHEN r.deleted_at IS NULL THEN 0 ELSE 1 END AS deleted
+    FROM reports r
+    JOIN neighborhoods n ON r.neighborhood_id = n.neighborhood_id
+    JOIN crimes c ON r.crime_id = c.crime_id
+    WHERE r.occurred_at BETWEEN $1 AND $2
+      AND (CAST($3 AS timestamptz) IS NULL OR r.updated_at >= $3)
+    ORDER BY r.occurred_at
+`
+}
+
+func (postgresDialect) UpsertIncidentsQuery(values string) string {
+	return `
+        INSERT INTO curated_incidents
+        (id, occurred_at, category, severity, latitude, longitude, neighborhood, confidence, source)
+        VALUES ` + values + `
+        ON CONFLICT (id) DO UPDATE SET
+            occurred_at     = EXCLUDED.occurred_at,
+            category        = EXCLUDED.category,
+            severity        = EXCLUDED.severity,
+            latitude        = EXCLUDED.latitude,
+            longitude       = EXCLUDED.longitude,
+            neighborhood    = EXCLUDED.neighborhood,
+            confidence      = EXCLUDED.confidence,
+            source          = EXCLUDED.source,
+            cell_id         = NULL,
+            cell_resolution = NULL
+    `
+}
+
+func (postgresDialect) InsertCellQuery() string {
+	return `
+		INSERT INTO curated_cells (cell_id, cell_resolution, city, center_lat, center_lng, bounds_json)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (cell_id) DO NOTHING
+	`
+}
+
+func (postgresDialect) AssignCellsQuery(values string) string {
+	return `
+        UPDATE curated_incidents AS ci
+        SET cell_id = v.cell_id, cell_resolution = ?
+        FROM (VALUES ` + values + `) AS v (id, cell_id)
+        WHERE ci.id = v.id
+    `
+}
+
+func (postgresDialect) CellNeighborhoodsStatements() []string {
+	return []string{
+		`CREATE TABLE IF NOT EXISTS cell_neighborhoods (
+            cell_id      VARCHAR(50) PRIMARY KEY,
+            neighborhood VARCHAR(100) NOT NULL,
+            distance     DOUBLE PRECISION NULL
+        )`,
+		`TRUNCATE TABLE cell_neighborhoods`,
+		`INSERT INTO cell_neighborhoods (cell_id, neighborhood, distance)
+        SELECT c.cell_id, nearest.name, nearest.distance
+        FROM curated_cells c
+        CROSS JOIN LATERAL (
+            SELECT
+                n.name,
+                SQRT(
+                    POWER(n.latitude  - c.center_lat, 2) +
+                    POWER(n.longitude - c.center_lng, 2)
+                ) AS distance
+            FROM neighborhoods n
+            WHERE n.latitude IS NOT NULL
+              AND n.longitude IS NOT NULL
+              AND n.deleted_at IS NULL
+            ORDER BY distance
+            LIMIT 1
+        ) AS nearest`,
+	}
+}
+
+func (postgresDialect) MonthlyFeaturesTableStatements() []string {
+	return []string{
+		`CREATE TABLE IF NOT EXISTS features_cell_monthly (
+            cell_id         VARCHAR(50) NOT NULL,
+            "year"          INT         NOT NULL,
+            "month"         INT         NOT NULL,
+            y_count_month   INT         NOT NULL DEFAULT 0,
+            lag_1m          INT         NOT NULL DEFAULT 0,
+            lag_3m          INT         NOT NULL DEFAULT 0,
+            PRIMARY KEY (cell_id, "year", "month")
+        )`,
+		`CREATE INDEX IF NOT EXISTS idx_features_cell_monthly_year_month
+            ON features_cell_monthly("year", "month")`,
+	}
+}
+
+func (postgresDialect) MonthlyFeaturesQuery() string {
+	return `
+    WITH month_series AS (
+        SELECT generate_series(
+            date_trunc('month', CAST($1 AS timestamp)),
+            date_trunc('month', CAST($2 AS timestamp)),
+            interval '1 month'
+        ) AS month_date
+    ),
+    cells AS (
+        SELECT cell_id FROM curated_cells
+        WHERE cell_resolution = $3
+          AND ($4 = '' OR cell_id = $4)
+    ),
+    incidents_by_month AS (
+        SELECT cell_id, date_trunc('month', occurred_at) AS month_date, COUNT(*) AS y_count_month
+        FROM curated_incidents
+        WHERE cell_id IS NOT NULL
+          AND ($4 = '' OR cell_id = $4)
+        GROUP BY cell_id, date_trunc('month', occurred_at)
+    ),
+    aggregated AS (
+        SELECT c.cell_id, ms.month_date, COALESCE(ibm.y_count_month, 0) AS y_count_month
+        FROM cells c
+        CROSS JOIN month_series ms
+        LEFT JOIN incidents_by_month ibm
+            ON ibm.cell_id = c.cell_id
+           AND ibm.month_date = ms.month_date
+    ),
+    with_lags AS (
+        SELECT
+            cell_id,
+            month_date,
+            CAST(EXTRACT(YEAR FROM month_date) AS INT)  AS "year",
+            CAST(EXTRACT(MONTH FROM month_date) AS INT) AS "month",
+            y_count_month,
+            -- lag_1m: mês anterior
+            COALESCE(LAG(y_count_month) OVER w, 0) AS lag_1m,
+            -- lag_3m: soma dos últimos 3 meses (não incluindo o mês atual)
+            COALESCE(SUM(y_count_month) OVER (w ROWS BETWEEN 3 PRECEDING AND 1 PRECEDING), 0) AS lag_3m
+        FROM aggregated
+        WINDOW w AS (PARTITION BY cell_id ORDER BY month_date)
+    )
+    INSERT INTO features_cell_monthly (cell_id, "year", "month", y_count_month, lag_1m, lag_3m)
+    SELECT cell_id, "year", "month", y_count_month, lag_1m, lag_3m
+    FROM with_lags
+    WHERE month_date >= date_trunc('month', CAST($5 AS timestamp))
+    ON CONFLICT (cell_id, "year", "month") DO UPDATE SET
+        y_count_month = EXCLUDED.y_count_month,
+        lag_1m        = EXCLUDED.lag_1m,
+        lag_3m        = EXCLUDED.lag_3m
+    `
+}
+
+func (postgresDialect) KnowledgeBaseTablesQuery() string {
+	return `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND` +
+		knowledgeBaseTablesFilter
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/glebarez/go-sqlite"
+)
+
+// sqliteDialect permite rodar o pipeline inteiro localmente e nos testes.
+// Datas são gravadas pelo driver como texto "YYYY-MM-DD HH:MM:SS[.fff]-03:00",
+// então ano/mês são extraídos com substr para preservar o horário local gravado
+// (as funções de data do SQLite converteriam para UTC).
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string       { return "sqlite" }
+func (sqliteDialect) DriverName() string { return "sqlite" }
+func (sqliteDialect) MaxParams() int     { return 32766 }
+
+// MaxWriters é 1: o SQLite aceita um único escritor por vez
+func (sqliteDialect) MaxWriters() int { return 1 }
+
+// Instant converte com datetime() o texto gravado com o offset do fuso para UTC
+func (sqliteDialect) Instant(value string) string {
+	return "datetime(" + value + ")"
+}
+
+func (sqliteDialect) Rebind(query string) string {
+	return rebindNumbered(query, "?")
+}
+
+func (sqliteDialect) Limit(query string, n int) string {
+	return fmt.Sprintf("%s LIMIT %d", query, n)
+}
+
+// IsDuplicateKey reconhece SQLITE_CONSTRAINT_PRIMARYKEY (1555) e SQLITE_CONSTRAINT_UNIQUE (2067)
+func (sqliteDialect) IsDuplicateKey(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code() == 1555 || sqliteErr.Code() == 2067 ||
+		strings.Contains(sqliteErr.Error(), "UNIQUE constraint failed")
+}
+
+func (sqliteDialect) HistoricalReportsQuery() string {
+	return `
+    SELECT r.report_id, r.neighborhood_id, r.crime_id, r.occurred_at, r.created_at, r.updated_at,
+        n.name AS neighborhood_name, n.latitude, n.longitude, n.neighborhood_weight,
+        c.crime_name, c.crime_weight,
+        CASE WHEN r.deleted_at IS NULL THEN 0 ELSE 1 END AS deleted
+    FROM reports r
+    JOIN neighborhoods n ON r.neighborhood_id = n.neighborhood_id
+    JOIN crimes c ON r.crime_id = c.crime_id
+    WHERE datetime(r.occurred_at) BETWEEN datetime(?1) AND datetime(?2)
+      AND (?3 IS NULL OR r.updated_at >= ?3)
+    ORDER BY datetime(r.occurred_at)
+`
+}
+
+func (sqliteDialect) UpsertIncidentsQuery(values string) string {
+	return `
+        INSERT INTO curated_incidents
+        (id, occurred_at, category, severity, latitude, longitude, neighborhood, confidence, source)
+        VALUES ` + values + `
+        ON CONFLICT (id) DO UPDATE SET
+            occurred_at     = excluded.occurred_at,
+            category        = excluded.category,
+            severity        = excluded.severity,
+            latitude        = excluded.latitude,
+            longitude       = excluded.longitude,
+            neighborhood    = excluded.neighborhood,
+            confidence      = excluded.confidence,
+            source          = excluded.source,
+            cell_id         = NULL,
+            cell_resolution = NULL
+    `
+}
+
+func (sqliteDialect) InsertCellQuery() string {
+	return `
+		INSERT INTO curated_cells (cell_id, cell_resolution, city, center_lat, center_lng, bounds_json)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+		ON CONFLICT (cell_id) DO NOTHING
+	`
+}
+
+func (sqliteDialect) AssignCellsQuery(values string) string {
+	return `
+        UPDATE curated_incidents
+        SET cell_id = v.column2, cell_resolution = ?
+        FROM (VALUES ` + values + `) AS v
+        WHERE curated_incidents.id = v.column1
+    `
+}
+
+func (sqliteDialect) CellNeighborhoodsStatements() []string {
+	return []string{
+		`CREATE TABLE IF NOT EXISTS cell_neighborhoods (
+            cell_id      VARCHAR(50) PRIMARY KEY,
+            neighborhood VARCHAR(100) NOT NULL,
+            distance     REAL NULL
+        )`,
+		`DELETE FROM cell_neighborhoods`,
+		`INSERT INTO cell_neighborhoods (cell_id, neighborhood, distance)
+        SELECT cell_id, name, SQRT(distance2)
+        FROM (
+            SELECT
+                c.cell_id,
+                n.name,
+                (n.latitude  - c.center_lat) *
+                (n.latitude  - c.center_lat) +
+                (n.longitude - c.center_lng) *
+                (n.longitude - c.center_lng) AS distance2,
+                ROW_NUMBER() OVER (PARTITION BY c.cell_id ORDER BY
+                    (n.latitude  - c.center_lat) *
+                    (n.latitude  - c.center_lat) +
+                    (n.longitude - c.center_lng) *
+                    (n.longitude - c.center_lng)
+                ) AS rn
+            FROM curated_cells c
+            CROSS JOIN neighborhoods n
+            WHERE n.latitude IS NOT NULL
+              AND n.longitude IS NOT NULL
+              AND n.deleted_at IS NULL
+        )
+        WHERE rn = 1`,
+	}
+}
+
+func (sqliteDialect) MonthlyFeaturesTableStatements() []string {
+	return []string{
+		`CREATE TABLE IF NOT EXISTS features_cell_monthly (
+            cell_id         VARCHAR(50) NOT NULL,
+            year            INT         NOT NULL,
+            month           INT         NOT NULL,
+            y_count_month   INT         NOT NULL DEFAULT 0,
+            lag_1m          INT         NOT NULL DEFAULT 0,
+            lag_3m          INT         NOT NULL DEFAULT 0,
+            PRIMARY KEY (cell_id, year, month)
+        )`,
+		`CREATE INDEX IF NOT EXISTS idx_features_cell_monthly_year_month
+            ON features_cell_monthly(year, month)`,
+	}
+}
+
+func (sqliteDialect) MonthlyFeaturesQuery() string {
+	return `
+    WITH RECURSIVE month_series(month_date) AS (
+        SELECT substr(?1, 1, 7) || '-01'
+        UNION ALL
+        SELECT date(month_date, '+1 month')
+        FROM month_series
+        WHERE date(month_date, '+1 month') <= substr(?2, 1, 7) || '-01'
+    ),
+    cells AS (
+        SELECT cell_id FROM curated_cells
+        WHERE cell_resolution = ?3
+          AND (?4 = '' OR cell_id = ?4)
+    ),
+    incidents_by_month AS (
+        SELECT cell_id, substr(occurred_at, 1, 7) || '-01' AS month_date, COUNT(*) AS y_count_month
+        FROM curated_incidents
+        WHERE cell_id IS NOT NULL
+          AND (?4 = '' OR cell_id = ?4)
+        GROUP BY cell_id, substr(occurred_at, 1, 7)
+    ),
+    aggregated AS (
+        SELECT c.cell_id, ms.month_date, COALESCE(ibm.y_count_month, 0) AS y_count_month
+        FROM cells c
+        CROSS JOIN month_series ms
+        LEFT JOIN incidents_by_month ibm
+            ON ibm.cell_id = c.cell_id
+           AND ibm.month_date = ms.month_date
+    ),
+    with_lags AS (
+        SELECT
+            cell_id,
+            month_date,
+            CAST(substr(month_date, 1, 4) AS INTEGER) AS year,
+            CAST(substr(month_date, 6, 2) AS INTEGER) AS month,
+            y_count_month,
+            -- lag_1m: mês anterior
+            COALESCE(LAG(y_count_month) OVER w, 0) AS lag_1m,
+            -- lag_3m: soma dos últimos 3 meses (não incluindo o mês atual)
+            COALESCE(SUM(y_count_month) OVER (w ROWS BETWEEN 3 PRECEDING AND 1 PRECEDING), 0) AS lag_3m
+        FROM aggregated
+        WINDOW w AS (PARTITION BY cell_id ORDER BY month_date)
+    )
+    INSERT INTO features_cell_monthly (cell_id, year, month, y_count_month, lag_1m, lag_3m)
+    SELECT cell_id, year, month, y_count_month, lag_1m, lag_3m
+    FROM with_lags
+    WHERE month_date >= substr(?5, 1, 7) || '-01'
+    ON CONFLICT (cell_id, year, month) DO UPDATE SET
+        y_count_month = excluded.y_count_month,
+        lag_1m        = excluded.lag_1m,
+        lag_3m        = excluded.lag_3m
+    `
+}
+
+func (sqliteDialect) KnowledgeBaseTablesQuery() string {
+	return `SELECT COUNT(*) FROM (SELECT name AS TABLE_NAME FROM sqlite_master WHERE type = 'table') WHERE` +
+		knowledgeBaseTablesFilter
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	mssql "github.com/microsoft/go-mssqldb"
+)
+
+// sqlServerDialect é o dialeto original do pipeline (banco da universidade)
+type sqlServerDialect struct{}
+
+func (sqlServerDialect) Name() string       { return "sqlserver" }
+func (sqlServerDialect) DriverName() string { return "sqlserver" }
+func (sqlServerDialect) MaxParams() int     { return 2100 }
+func (sqlServerDialect) MaxWriters() int    { return 0 }
+
+func (sqlServerDialect) Instant(value string) string { return value }
+
+func (sqlServerDialect) Rebind(query string) string {
+	return rebindNumbered(query, "@p")
+}
+
+func (sqlServerDialect) Limit(query string, n int) string {
+	return fmt.Sprintf("%s OFFSET 0 ROWS FETCH NEXT %d ROWS ONLY", query, n)
+}
+
+// IsDuplicateKey reconhece os erros 2627 (PRIMARY KEY/UNIQUE constraint) e 2601 (unique index)
+func (sqlServerDialect) IsDuplicateKey(err error) bool {
+	var mssqlErr mssql.Error
+	if errors.As(err, &mssqlErr) {
+		return mssqlErr.Number == 2627 || mssqlErr.Number == 2601
+	}
+	return false
+}
+
+func (sqlServerDialect) HistoricalReportsQuery() string {
+	return `
+    SELECT r.report_id, r.neighborhood_id, r.crime_id, r.occurred_at, r.created_at, r.updated_at,
+        n.name as neighborhood_name, n.latitude, n.longitude, n.neighborhood_weight,
+        c.crime_name, c.crime_weight,
+        CASE WHEN r.deleted_at IS NULL THEN 0 ELSE 1 END as deleted
+    FROM reports r
+    JOIN neighborhoods n ON r.neighborhood_id = n.neighborhood_id
+    JOIN crimes c ON r.crime_id = c.crime_id
+    WHERE r.occurred_at BETWEEN @p1 AND @p2
+      AND (@p3 IS NULL OR r.updated_at >= @p3)
+    ORDER BY r.occurred_at
+`
+}
+
+func (sqlServerDialect) UpsertIncidentsQuery(values string) string {
+	return `
+        MERGE curated_incidents AS target
+        USING (VALUES ` + values + `) AS source
+            (id, occurred_at, category, severity, latitude, longitude, neighborhood, confidence, source)
+            ON target.id = source.id
+        WHEN MATCHED THEN
+            UPDATE SET
+                occurred_at     = source.occurred_at,
+                category        = source.category,
+                severity        = source.severity,
+                latitude        = source.latitude,
+                longitude       = source.longitude,
+                neighborhood    = source.neighborhood,
+                confidence      = source.confidence,
+                source          = source.source,
+                cell_id         = NULL,
+                cell_resolution = NULL
+        WHEN NOT MATCHED BY TARGET THEN
+            INSERT (id, occurred_at, category, severity, latitude, longitude, neighborhood, confidence, source)
+            VALUES (source.id, source.occurred_at, source.category, source.severity, source.latitude,
+                    source.longitude, source.neighborhood, source.confidence, source.source);
+    `
+}
+
+func (sqlServerDialect) InsertCellQuery() string {
+	return `
+		IF NOT EXISTS (SELECT 1 FROM curated_cells WHERE cell_id = @p1)
+		BEGIN
+			INSERT INTO curated_cells
+			(cell_id, cell_resolution, city, center_lat, center_lng, bounds_json)
+			VALUES (@p1, @p2, @p3, @p4, @p5, @p6)
+		END
+	`
+}
+
+func (sqlServerDialect) AssignCellsQuery(values string) string {
+	return `
+        UPDATE ci
+        SET cell_id = v.cell_id, cell_resolution = ?
+        FROM curated_incidents ci
+        JOIN (VALUES ` + values + `) AS v (id, cell_id) ON ci.id = v.id
+    `
+}
+
+func (sqlServerDialect) CellNeighborhoodsStatements() []string {
+	return []string{`
+        IF OBJECT_ID('cell_neighborhoods', 'U') IS NULL
+        BEGIN
+            CREATE TABLE cell_neighborhoods (
+                cell_id      VARCHAR(50) PRIMARY KEY,
+                neighborhood VARCHAR(100) NOT NULL,
+                distance     FLOAT NULL
+            );
+        END;
+
+        TRUNCATE TABLE cell_neighborhoods;
+
+        INSERT INTO cell_neighborhoods (cell_id, neighborhood, distance)
+        SELECT
+            c.cell_id,
+            nearest.name AS neighborhood,
+            nearest.distance
+        FROM curated_cells c
+        CROSS APPLY (
+            SELECT TOP 1
+                n.name,
+                SQRT(
+                    POWER(n.latitude  - c.center_lat, 2) +
+                    POWER(n.longitude - c.center_lng, 2)
+                ) AS distance
+            FROM neighborhoods n
+            WHERE n.latitude IS NOT NULL
+              AND n.longitude IS NOT NULL
+              AND n.deleted_at IS NULL
+            ORDER BY
+                POWER(n.latitude  - c.center_lat, 2) +
+                POWER(n.longitude - c.center_lng, 2)
+        ) AS nearest;
+    `}
+}
+
+func (sqlServerDialect) MonthlyFeaturesTableStatements() []string {
+	return []string{`
+    IF OBJECT_ID('features_cell_monthly', 'U') IS NULL
+    BEGIN
+        CREATE TABLE features_cell_monthly (
+            cell_id         VARCHAR(50) NOT NULL,
+            [year]          INT         NOT NULL,
+            [month]         INT         NOT NULL,
+            y_count_month   INT         NOT NULL DEFAULT 0,
+            lag_1m          INT         NOT NULL DEFAULT 0,
+            lag_3m          INT         NOT NULL DEFAULT 0,
+            PRIMARY KEY (cell_id, [year], [month])
+        );
+
+        CREATE INDEX idx_features_cell_monthly_year_month
+            ON features_cell_monthly([year], [month]);
+    END
+    `}
+}
+
+func (sqlServerDialect) MonthlyFeaturesQuery() string {
+	return `
+    ;WITH Months AS (
+        SELECT
+            DATEFROMPARTS(YEAR(@p1), MONTH(@p1), 1) AS month_start,
+            DATEFROMPARTS(YEAR(@p2), MONTH(@p2), 1) AS month_end
+    ),
+    MonthSeries AS (
+        SELECT month_start AS month_date
+        FROM Months
+        UNION ALL
+        SELECT DATEADD(month, 1, month_date)
+        FROM MonthSeries
+        CROSS JOIN Months
+        WHERE DATEADD(month, 1, month_date) <= (SELECT month_end FROM Months)
+    ),
+    Cells AS (
+        SELECT cell_id
+        FROM curated_cells
+        WHERE cell_resolution = @p3
+          AND (@p4 = '' OR cell_id = @p4)
+    ),
+    CellMonths AS (
+        SELECT
+            c.cell_id,
+            YEAR(ms.month_date)  AS [year],
+            MONTH(ms.month_date) AS [month]
+        FROM Cells c
+        CROSS JOIN MonthSeries ms
+    ),
+    IncidentsByMonth AS (
+        SELECT
+            ci.cell_id,
+            YEAR(ci.occurred_at)  AS [year],
+            MONTH(ci.occurred_at) AS [month],
+            COUNT(*) AS y_count_month
+        FROM curated_incidents ci
+        WHERE ci.cell_id IS NOT NULL
+          AND (@p4 = '' OR ci.cell_id = @p4)
+        GROUP BY ci.cell_id, YEAR(ci.occurred_at), MONTH(ci.occurred_at)
+    ),
+    Aggregated AS (
+        SELECT
+            cm.cell_id,
+            cm.[year],
+            cm.[month],
+            ISNULL(ibm.y_count_month, 0) AS y_count_month
+        FROM CellMonths cm
+        LEFT JOIN IncidentsByMonth ibm
+            ON ibm.cell_id = cm.cell_id
+           AND ibm.[year]  = cm.[year]
+           AND ibm.[month] = cm.[month]
+    ),
+    WithLags AS (
+        SELECT
+            a1.cell_id,
+            a1.[year],
+            a1.[month],
+            a1.y_count_month,
+
+            -- lag_1m: mês anterior
+            ISNULL((
+                SELECT TOP 1 a0.y_count_month
+                FROM Aggregated a0
+                WHERE a0.cell_id = a1.cell_id
+                  AND DATEADD(month, 1, DATEFROMPARTS(a0.[year], a0.[month], 1))
+                    = DATEFROMPARTS(a1.[year], a1.[month], 1)
+            ), 0) AS lag_1m,
+
+            -- lag_3m: soma dos últimos 3 meses (não incluindo o mês atual)
+            ISNULL((
+                SELECT SUM(a0.y_count_month)
+                FROM Aggregated a0
+                WHERE a0.cell_id = a1.cell_id
+                  AND DATEFROMPARTS(a0.[year], a0.[month], 1)
+                    >= DATEADD(month, -3, DATEFROMPARTS(a1.[year], a1.[month], 1))
+                  AND DATEFROMPARTS(a0.[year], a0.[month], 1)
+                    < DATEFROMPARTS(a1.[year], a1.[month], 1)
+            ), 0) AS lag_3m
+        FROM Aggregated a1
+    )
+    MERGE features_cell_monthly AS target
+    USING (
+        SELECT * FROM WithLags
+        WHERE DATEFROMPARTS([year], [month], 1) >= DATEFROMPARTS(YEAR(@p5), MONTH(@p5), 1)
+    ) AS source
+        ON target.cell_id = source.cell_id
+       AND target.[year]  = source.[year]
+       AND target.[month] = source.[month]
+    WHEN MATCHED THEN
+        UPDATE SET
+            y_count_month = source.y_count_month,
+            lag_1m        = source.lag_1m,
+            lag_3m        = source.lag_3m
+    WHEN NOT MATCHED BY TARGET THEN
+        INSERT (cell_id, [year], [month], y_count_month, lag_1m, lag_3m)
+        VALUES (source.cell_id, source.[year], source.[month],
+                source.y_count_month, source.lag_1m, source.lag_3m)
+    OPTION (MAXRECURSION 0);
+    `
+}
+
+func (sqlServerDialect) KnowledgeBaseTablesQuery() string {
+	return `SELECT COUNT(*) FROM INFORMATION_SCHEMA.TABLES WHERE` + knowledgeBaseTablesFilter
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
-	"strconv"
 	"strings"
 	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/geo"
 )
 
 type Crime struct {
@@ -19,13 +23,13 @@ type Crime struct {
 }
 
 type Neighborhood struct {
-	NeighborhoodID     uint      `json:"neighborhood_id"`
-	Name               string    `json:"name"`
-	Latitude           string    `json:"latitude"`
-	Longitude          string    `json:"longitude"`
-	NeighborhoodWeight int       `json:"neighborhood_weight"`
-	CreatedAt          time.Time `json:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at"`
+	NeighborhoodID     uint            `json:"neighborhood_id"`
+	Name               string          `json:"name"`
+	Latitude           sql.NullFloat64 `json:"latitude"`
+	Longitude          sql.NullFloat64 `json:"longitude"`
+	NeighborhoodWeight int             `json:"neighborhood_weight"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
 }
 
 type Report struct {
@@ -35,9 +39,11 @@ type Report struct {
 	CrimeID        uint         `json:"crime_id"`
 	Crime          Crime        `json:"crime"`
 	ReportDate     string       `json:"report_date"`
-	ReportDateFormated string	`json:"report_date_formated"`
+	OccurredAt     sql.NullTime `json:"occurred_at"`
 	CreatedAt      time.Time    `json:"created_at"`
 	UpdatedAt      time.Time    `json:"updated_at"`
+	// Deleted indica um report apagado (soft delete): o incidente dele é removido
+	Deleted        bool         `json:"deleted"`
 }
 
 type KnowledgeBaseConfig struct {
@@ -47,19 +53,218 @@ type KnowledgeBaseConfig struct {
 	BatchSize      int
 	StartDate      time.Time
 	EndDate        time.Time
+
+	// Dialect define o SQL do banco usado (padrão: SQL Server)
+	Dialect KnowledgeBaseDialect
+
+	// Progress recebe o andamento de cada fase (opcional)
+	Progress KnowledgeBaseProgress
+
+	// GridArea é a área coberta pela grade e aceita para os incidentes
+	// (padrão: DefaultGridArea, a bounding box de Campinas)
+	GridArea *BoundingBox
+
+	// Incremental processa apenas os reports alterados desde a última execução
+	// (marca d'água em analytics_watermarks), reaproveita a grade existente e
+	// recalcula as features mensais só das células/meses afetados
+	Incremental bool
+
+	// SkipHourlyFeatures desliga a fase 4 (features_cell_hourly), ligada por padrão
+	SkipHourlyFeatures bool
+	// Workers é o número de dias processados em paralelo na fase 4 (padrão: 4)
+	Workers int
 }
 
+// Nomes das fases do pipeline, usados no acompanhamento de jobs
+const (
+	PhaseMigrateHistoricalData   = "migrate_historical_data"
+	PhaseGenerateSpatialGrid     = "generate_spatial_grid"
+	PhaseMapCellsToNeighborhoods = "map_cells_to_neighborhoods"
+	PhaseAssignCellsToIncidents  = "assign_cells_to_incidents"
+	PhaseGenerateMonthlyFeatures = "generate_monthly_features"
+	PhaseGenerateHourlyFeatures  = "generate_hourly_features"
+	PhaseValidateDataQuality     = "validate_data_quality"
+)
+
+// KnowledgeBaseProgress é notificado pelo gerador durante a execução do pipeline.
+// As chamadas acontecem na goroutine que executa GenerateKnowledgeBase.
+type KnowledgeBaseProgress interface {
+	// PhaseStarted indica que uma nova fase começou
+	PhaseStarted(phase string)
+	// RowsProcessed soma n registros processados à fase atual
+	RowsProcessed(n int)
+	// Warning registra um erro não fatal (ex.: batch ignorado)
+	Warning(err error)
+	// PhaseFinished indica o fim da fase, com o total de registros e o erro (se houver)
+	PhaseFinished(phase string, records int, err error)
+}
+
+// noopProgress é usado quando nenhum KnowledgeBaseProgress é configurado
+type noopProgress struct{}
+
+func (noopProgress) PhaseStarted(string)              {}
+func (noopProgress) RowsProcessed(int)                {}
+func (noopProgress) Warning(error)                    {}
+func (noopProgress) PhaseFinished(string, int, error) {}
+
 type KnowledgeBaseGenerator struct {
 	config      *KnowledgeBaseConfig
+	dialect     KnowledgeBaseDialect
 	logger      *log.Logger
 	executionID string
+	progress    KnowledgeBaseProgress
+
+	// Estado da execução incremental
+	watermark    *time.Time             // marca d'água lida no início (nil = execução completa)
+	newWatermark time.Time              // início desta execução menos watermarkSafetyMargin
+	gridReused   bool                   // a grade já existia e não foi regerada
+	upsertFailed bool                   // algum batch falhou: a marca d'água não avança
+	affected     map[string]*monthRange // células e meses com incidentes alterados
+
+	// Limites dos bairros (nil = mapeamento pelo ponto mais próximo)
+	boundaries *geo.Index
+
+	// Grade da resolução configurada, criada em cellGrid()
+	grid cellGrid
+
+	// Taxonomia de crime_categories, usada na categoria dos incidentes
+	taxonomy *CrimeTaxonomy
 }
 
+// monthRange guarda o primeiro e o último mês afetados de uma célula
+type monthRange struct {
+	first, last time.Time
+}
+
+// maxIncrementalCells limita o recálculo mensal célula a célula; acima disso
+// é mais barato recalcular todas as células de uma vez
+const maxIncrementalCells = 200
+
+// watermarkSafetyMargin recua a marca d'água gravada em relação ao início da
+// execução, para reler os reports de transações que gravaram updated_at antes
+// do início mas só fizeram commit depois da leitura. Reler um report é seguro:
+// o upsert em curated_incidents é idempotente.
+const watermarkSafetyMargin = 5 * time.Minute
+
 func NewKnowledgeBaseGenerator(config *KnowledgeBaseConfig) *KnowledgeBaseGenerator {
+	var progress KnowledgeBaseProgress = noopProgress{}
+	if config.Progress != nil {
+		progress = config.Progress
+	}
+
+	var dialect KnowledgeBaseDialect = sqlServerDialect{}
+	if config.Dialect != nil {
+		dialect = config.Dialect
+	}
+
 	return &KnowledgeBaseGenerator{
 		config:      config,
+		dialect:     dialect,
 		logger:      log.New(os.Stdout, "[KB-GEN] ", log.LstdFlags|log.Lmsgprefix),
-		executionID: fmt.Sprintf("exec_%d", time.Now().Unix()),
+		executionID: uuid.NewString(),
+		progress:    progress,
+		affected:    make(map[string]*monthRange),
+	}
+}
+
+// gridArea retorna a área da grade configurada
+func (kg *KnowledgeBaseGenerator) gridArea() BoundingBox {
+	if kg.config.GridArea != nil {
+		return *kg.config.GridArea
+	}
+	return DefaultGridArea
+}
+
+// cellGrid retorna a grade (quadrada ou H3) de config.CellResolution
+func (kg *KnowledgeBaseGenerator) cellGrid() (cellGrid, error) {
+	if kg.grid == nil {
+		grid, err := newCellGrid(kg.config.CellResolution, kg.gridArea())
+		if err != nil {
+			return nil, err
+		}
+		kg.grid = grid
+	}
+	return kg.grid, nil
+}
+
+// ExecutionID retorna o identificador desta execução do pipeline
+func (kg *KnowledgeBaseGenerator) ExecutionID() string {
+	return kg.executionID
+}
+
+// runPhase executa uma fase do pipeline notificando o progresso e registrando
+// a execução em analytics_pipeline_logs. Se o contexto for cancelado, a fase não é iniciada.
+func (kg *KnowledgeBaseGenerator) runPhase(ctx context.Context, phase string, fn func() (int, error)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	startedAt := time.Now()
+	kg.progress.PhaseStarted(phase)
+	kg.startPhaseLog(ctx, phase, startedAt)
+
+	records, err := fn()
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	kg.finishPhaseLog(ctx, phase, startedAt, records, err)
+	kg.progress.PhaseFinished(phase, records, err)
+
+	return err
+}
+
+// logDB retorna o banco onde ficam as tabelas analytics_*
+func (kg *KnowledgeBaseGenerator) logDB() *sql.DB {
+	if kg.config.TargetDB != nil {
+		return kg.config.TargetDB
+	}
+	return kg.config.SourceDB
+}
+
+// startPhaseLog insere o registro da fase com status "running".
+// Falhas ao gravar o log não interrompem o pipeline.
+func (kg *KnowledgeBaseGenerator) startPhaseLog(ctx context.Context, phase string, startedAt time.Time) {
+	query := kg.dialect.Rebind(`
+		INSERT INTO analytics_pipeline_logs (execution_id, started_at, status, phase, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if _, err := kg.logDB().ExecContext(ctx, query,
+		kg.executionID, startedAt, JobStatusRunning, phase, time.Now(),
+	); err != nil {
+		kg.logger.Printf("⚠️  Erro ao registrar início da fase %s: %v", phase, err)
+	}
+}
+
+// finishPhaseLog atualiza o registro da fase com o resultado da execução.
+// Usa um contexto sem cancelamento para que fases canceladas também sejam registradas.
+func (kg *KnowledgeBaseGenerator) finishPhaseLog(ctx context.Context, phase string, startedAt time.Time, records int, phaseErr error) {
+	logCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+
+	status := JobStatusSucceeded
+	var errorMessage *string
+	if phaseErr != nil {
+		status = JobStatusFailed
+		if errors.Is(phaseErr, context.Canceled) {
+			status = JobStatusCancelled
+		}
+		msg := phaseErr.Error()
+		errorMessage = &msg
+	}
+
+	finishedAt := time.Now()
+	query := kg.dialect.Rebind(`
+		UPDATE analytics_pipeline_logs
+		SET finished_at = ?, status = ?, records_processed = ?,
+		    error_message = ?, execution_time_seconds = ?
+		WHERE execution_id = ? AND phase = ?
+	`)
+	if _, err := kg.logDB().ExecContext(logCtx, query,
+		finishedAt, status, records, errorMessage, int(finishedAt.Sub(startedAt).Seconds()),
+		kg.executionID, phase,
+	); err != nil {
+		kg.logger.Printf("⚠️  Erro ao registrar fim da fase %s: %v", phase, err)
 	}
 }
 
@@ -69,56 +274,97 @@ func NewKnowledgeBaseGenerator(config *KnowledgeBaseConfig) *KnowledgeBaseGenera
 
 func (kg *KnowledgeBaseGenerator) GenerateKnowledgeBase(ctx context.Context) error {
 	kg.logger.Println("🚀 Iniciando geração da base de conhecimento...")
-	kg.logger.Printf("📋 Execution ID: %s", kg.executionID)
+	kg.logger.Printf("📋 Execution ID: %s (dialeto %s)", kg.executionID, kg.dialect.Name())
 	kg.logger.Printf("📅 Período: %s até %s",
 		kg.config.StartDate.Format("2006-01-02"),
 		kg.config.EndDate.Format("2006-01-02"))
 
 	startTime := time.Now()
+	kg.newWatermark = startTime.Add(-watermarkSafetyMargin)
 	db := kg.config.SourceDB // Usar apenas um banco
 
+	if _, err := kg.cellGrid(); err != nil {
+		return fmt.Errorf("❌ grade %s: %v", FormatCellResolution(kg.config.CellResolution), err)
+	}
+
+	if kg.config.Incremental {
+		if err := kg.loadWatermark(ctx); err != nil {
+			return fmt.Errorf("❌ erro ao ler marca d'água: %v", err)
+		}
+		if kg.watermark != nil {
+			kg.logger.Printf("🔁 Modo incremental: reports alterados desde %s", kg.watermark.Format(time.RFC3339))
+		} else {
+			kg.logger.Println("🔁 Modo incremental sem marca d'água: processando o período completo")
+		}
+	}
+
+	kg.loadBoundaries(ctx, db)
+	kg.loadTaxonomy(ctx, db)
+
 	// Fase 1: Migrar dados históricos
 	kg.logger.Println("📊 Fase 1: Migrando dados históricos...")
-	if err := kg.migrateHistoricalData(ctx, db); err != nil {
+	if err := kg.runPhase(ctx, PhaseMigrateHistoricalData, func() (int, error) {
+		return kg.migrateHistoricalData(ctx, db)
+	}); err != nil {
 		return fmt.Errorf("❌ erro na migração: %v", err)
 	}
 
 	// Fase 2: Gerar grade espacial
 	kg.logger.Println("🗺️  Fase 2: Gerando grade espacial...")
-	if err := kg.generateSpatialGrid(ctx, db); err != nil {
+	if err := kg.runPhase(ctx, PhaseGenerateSpatialGrid, func() (int, error) {
+		return kg.generateSpatialGrid(ctx, db)
+	}); err != nil {
 		return fmt.Errorf("❌ erro na grade espacial: %v", err)
 	}
 
 	// Fase 2.5: Mapeamento célula → bairro
 	kg.logger.Println("🏷️ Fase 2.5: Gerando mapeamento célula → bairro...")
-	if err := kg.mapCellsToNeighborhoods(ctx, db); err != nil {
+	if err := kg.runPhase(ctx, PhaseMapCellsToNeighborhoods, func() (int, error) {
+		return kg.mapCellsToNeighborhoods(ctx, db)
+	}); err != nil {
 		return fmt.Errorf("erro no mapeamento de células para bairros: %v", err)
 	}
 
 	// Fase 3: Atribuir células aos incidentes
 	kg.logger.Println("🎯 Fase 3: Atribuindo células aos incidentes...")
-	if err := kg.assignCellsToIncidents(ctx, db); err != nil {
+	if err := kg.runPhase(ctx, PhaseAssignCellsToIncidents, func() (int, error) {
+		return kg.assignCellsToIncidents(ctx, db)
+	}); err != nil {
 		return fmt.Errorf("❌ erro na atribuição de células: %v", err)
 	}
 
 	// Fase 3.5: Gerar features mensais
 	kg.logger.Println("📅 Fase 3.5: Gerando features mensais...")
-	if err := kg.generateMonthlyFeatures(ctx, db); err != nil {
+	if err := kg.runPhase(ctx, PhaseGenerateMonthlyFeatures, func() (int, error) {
+		return kg.generateMonthlyFeatures(ctx, db)
+	}); err != nil {
 		return fmt.Errorf("erro na geração de features mensais: %v", err)
 	}
 
 	// Fase 4: Gerar features temporais (horárias)
-	////kg.logger.Println("⚙️  Fase 4: Gerando features temporais...")
-	////if err := kg.generateTemporalFeatures(ctx, db); err != nil {
-	////	return fmt.Errorf("❌ erro na geração de features: %v", err)
-	////}
+	if kg.config.SkipHourlyFeatures {
+		kg.logger.Println("⏭️  Fase 4: Features temporais desativadas (SkipHourlyFeatures)")
+	} else {
+		kg.logger.Println("⚙️  Fase 4: Gerando features temporais...")
+		if err := kg.runPhase(ctx, PhaseGenerateHourlyFeatures, func() (int, error) {
+			return kg.generateTemporalFeatures(ctx, db)
+		}); err != nil {
+			return fmt.Errorf("❌ erro na geração de features: %v", err)
+		}
+	}
 
 	// Fase 5: Validar qualidade
 	kg.logger.Println("✓ Fase 5: Validando qualidade dos dados...")
-	if err := kg.validateDataQuality(ctx, db); err != nil {
+	if err := kg.runPhase(ctx, PhaseValidateDataQuality, func() (int, error) {
+		return kg.validateDataQuality(ctx, db)
+	}); err != nil {
 		return fmt.Errorf("❌ erro na validação: %v", err)
 	}
 
+	if err := kg.saveWatermark(ctx); err != nil {
+		return fmt.Errorf("❌ erro ao gravar marca d'água: %v", err)
+	}
+
 	executionTime := time.Since(startTime)
 	kg.logger.Printf("✅ Base de conhecimento gerada com sucesso em %s!", executionTime)
 
@@ -129,24 +375,17 @@ func (kg *KnowledgeBaseGenerator) GenerateKnowledgeBase(ctx context.Context) err
 // FASE 1: MIGRAÇÃO DE DADOS HISTÓRICOS (COM BATCH INSERT)
 // ============================================================================
 
-func (kg *KnowledgeBaseGenerator) migrateHistoricalData(ctx context.Context, db *sql.DB) error {
-	query := `
-    SELECT r.report_id, r.neighborhood_id, r.crime_id, r.report_date_formated, r.created_at, r.updated_at,
-        n.name as neighborhood_name, n.latitude, n.longitude, n.neighborhood_weight,
-        c.crime_name, c.crime_weight
-    FROM reports r
-    JOIN neighborhoods n ON r.neighborhood_id = n.neighborhood_id
-    JOIN crimes c ON r.crime_id = c.crime_id
-    WHERE TRY_CONVERT(date, r.report_date_formated, 103) BETWEEN @start AND @end
-    ORDER BY TRY_CONVERT(date, r.report_date_formated, 103)
-`
-
-	rows, err := db.QueryContext(ctx, query,
-    sql.Named("start", kg.config.StartDate),
-    sql.Named("end", kg.config.EndDate),
-		)
+func (kg *KnowledgeBaseGenerator) migrateHistoricalData(ctx context.Context, db *sql.DB) (int, error) {
+	query := kg.dialect.HistoricalReportsQuery()
+
+	var updatedAfter interface{}
+	if kg.watermark != nil {
+		updatedAfter = *kg.watermark
+	}
+
+	rows, err := db.QueryContext(ctx, query, kg.config.StartDate, kg.config.EndDate, updatedAfter)
 	if err != nil {
-		return fmt.Errorf("erro na query: %v", err)
+		return 0, fmt.Errorf("erro na query: %v", err)
 	}
 	defer func() {
 		if err := rows.Close(); err != nil {
@@ -167,13 +406,14 @@ func (kg *KnowledgeBaseGenerator) migrateHistoricalData(ctx context.Context, db
 		var report Report
 		var crime Crime
 		var neighborhood Neighborhood
+		var deleted int
 
 		err := rows.Scan(
 			&report.ReportID, &report.NeighborhoodID, &report.CrimeID,
-			&report.ReportDateFormated, &report.CreatedAt, &report.UpdatedAt,
+			&report.OccurredAt, &report.CreatedAt, &report.UpdatedAt,
 			&neighborhood.Name, &neighborhood.Latitude, &neighborhood.Longitude,
 			&neighborhood.NeighborhoodWeight,
-			&crime.CrimeName, &crime.CrimeWeight,
+			&crime.CrimeName, &crime.CrimeWeight, &deleted,
 		)
 		if err != nil {
 			kg.logger.Printf("⚠️  Erro ao escanear linha: %v", err)
@@ -183,25 +423,49 @@ func (kg *KnowledgeBaseGenerator) migrateHistoricalData(ctx context.Context, db
 
 		report.Neighborhood = neighborhood
 		report.Crime = crime
+		report.Deleted = deleted != 0
 		batch = append(batch, report)
 
 		if len(batch) >= batchSize {
+			if err := ctx.Err(); err != nil {
+				return processed, err
+			}
+
 			ok, fail := kg.insertIncidentsBatch(ctx, db, batch)
 			processed += ok
 			skipped += fail
+			kg.progress.RowsProcessed(ok)
 			batch = batch[:0]
 			kg.logger.Printf("  ➜ Processados %d registros (batch)...", processed)
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return processed, fmt.Errorf("erro ao ler reports: %v", err)
+	}
 
 	if len(batch) > 0 {
 		ok, fail := kg.insertIncidentsBatch(ctx, db, batch)
 		processed += ok
 		skipped += fail
+		kg.progress.RowsProcessed(ok)
 	}
 
 	kg.logger.Printf("✅ Migração concluída: %d incidentes processados, %d ignorados", processed, skipped)
-	return nil
+	kg.warnMissingOccurredAt(ctx, db)
+	return processed, nil
+}
+
+// warnMissingOccurredAt avisa sobre os reports que ficam fora da base por
+// ainda não terem occurred_at (datas antigas não convertidas pelo backfill)
+func (kg *KnowledgeBaseGenerator) warnMissingOccurredAt(ctx context.Context, db *sql.DB) {
+	var missing int
+	err := db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM reports WHERE occurred_at IS NULL AND deleted_at IS NULL`).Scan(&missing)
+	if err != nil || missing == 0 {
+		return
+	}
+	kg.logger.Printf("⚠️  %d reports sem occurred_at foram ignorados: rode o backfill das datas", missing)
+	kg.progress.Warning(fmt.Errorf("%d reports sem occurred_at ignorados (POST /api/v1/reports/backfill-occurred-at)", missing))
 }
 
 func (kg *KnowledgeBaseGenerator) insertIncidentsBatch(ctx context.Context, db *sql.DB, reports []Report) (processed int, skipped int) {
@@ -209,26 +473,34 @@ func (kg *KnowledgeBaseGenerator) insertIncidentsBatch(ctx context.Context, db *
 		return 0, 0
 	}
 
-	const maxParams = 2100
 	const paramsPerRow = 9
-	maxRowsPerInsert := maxParams / paramsPerRow // 233
+	maxRowsPerInsert := kg.dialect.MaxParams() / paramsPerRow // 233 no SQL Server
+	if maxRowsPerInsert > 1000 {
+		maxRowsPerInsert = 1000
+	}
 
 	valueStrings := []string{}
 	valueArgs := []interface{}{}
-	paramIndex := 1
+	batchIDs := []string{}
+	var invalidIDs, deletedIDs []string
 
 	flushBatch := func() {
 		if len(valueStrings) == 0 {
 			return
 		}
-		query := fmt.Sprintf(`
-            INSERT INTO curated_incidents 
-            (id, occurred_at, category, severity, latitude, longitude, neighborhood, confidence, source)
-            VALUES %s
-        `, strings.Join(valueStrings, ","))
+
+		// Na execução incremental, as células antigas dos incidentes alterados
+		// também precisam ter as features recalculadas
+		if kg.watermark != nil {
+			kg.trackPreviousCells(ctx, db, batchIDs)
+		}
+
+		query := kg.dialect.Rebind(kg.dialect.UpsertIncidentsQuery(strings.Join(valueStrings, ",")))
 
 		if _, err := db.ExecContext(ctx, query, valueArgs...); err != nil {
-			kg.logger.Printf("⚠️  Erro no batch insert de incidents: %v", err)
+			kg.logger.Printf("⚠️  Erro no batch upsert de incidents: %v", err)
+			kg.progress.Warning(fmt.Errorf("batch upsert de incidents (%d linhas): %v", len(valueStrings), err))
+			kg.upsertFailed = true
 			// se der erro nesse sub-batch, considera todos eles como ignorados
 			skipped += len(valueStrings)
 		} else {
@@ -238,54 +510,45 @@ func (kg *KnowledgeBaseGenerator) insertIncidentsBatch(ctx context.Context, db *
 		// reset do batch
 		valueStrings = valueStrings[:0]
 		valueArgs = valueArgs[:0]
-		paramIndex = 1
+		batchIDs = batchIDs[:0]
 	}
 
 	for _, report := range reports {
-		lat, err := strconv.ParseFloat(strings.ReplaceAll(report.Neighborhood.Latitude, ",", "."), 64)
-		if err != nil {
-			kg.logger.Printf("SKIP lat inválido: neighborhood_id=%d, lat=%q, err=%v",
-				report.NeighborhoodID, report.Neighborhood.Latitude, err)
-			skipped++
+		if report.Deleted {
+			deletedIDs = append(deletedIDs, fmt.Sprintf("rpt_%d", report.ReportID))
 			continue
 		}
 
-		lon, err := strconv.ParseFloat(strings.ReplaceAll(report.Neighborhood.Longitude, ",", "."), 64)
-		if err != nil {
-			kg.logger.Printf("SKIP lon inválido: neighborhood_id=%d, lon=%q, err=%v",
-				report.NeighborhoodID, report.Neighborhood.Longitude, err)
+		// Coordenadas que a migration 0005 não conseguiu converter ficam NULL
+		if !report.Neighborhood.Latitude.Valid || !report.Neighborhood.Longitude.Valid {
+			kg.logger.Printf("SKIP coordenadas ausentes: neighborhood_id=%d", report.NeighborhoodID)
 			skipped++
+			invalidIDs = append(invalidIDs, fmt.Sprintf("rpt_%d", report.ReportID))
 			continue
 		}
+		lat := report.Neighborhood.Latitude.Float64
+		lon := report.Neighborhood.Longitude.Float64
 
-		if lat < -23.1 || lat > -22.7 || lon < -47.3 || lon > -46.8 {
+		if !kg.gridArea().Contains(lat, lon) {
 			kg.logger.Printf("SKIP fora da bounding box: neighborhood_id=%d, lat=%f, lon=%f",
 				report.NeighborhoodID, lat, lon)
 			skipped++
+			invalidIDs = append(invalidIDs, fmt.Sprintf("rpt_%d", report.ReportID))
 			continue
 		}
 
-		reportTime, err := time.Parse("2006-01-02", report.ReportDateFormated)
-				if t, e := time.Parse(time.RFC3339, report.ReportDateFormated); e == nil {
-			reportTime = t
-		} else if t, e := time.Parse("2006-01-02 15:04:05", report.ReportDateFormated); e == nil {
-			// 2) Tenta "YYYY-MM-DD HH:MM:SS"
-			reportTime = t
-		} else if t, e := time.Parse("2006-01-02", report.ReportDateFormated); e == nil {
-			// 3) Tenta só data "YYYY-MM-DD"
-			reportTime = t
-		} else {
-			kg.logger.Printf("SKIP data inválida: report_id=%d, report_date=%q, err=%v",
-				report.ReportID, report.ReportDateFormated, e)
+		// occurred_at NULL: report antigo ainda não convertido pelo backfill
+		if !report.OccurredAt.Valid {
+			kg.logger.Printf("SKIP data ausente: report_id=%d", report.ReportID)
 			skipped++
+			invalidIDs = append(invalidIDs, fmt.Sprintf("rpt_%d", report.ReportID))
 			continue
 		}
+		// No fuso de StartDate, o mesmo das features horárias e dos feriados
+		reportTime := report.OccurredAt.Time.In(kg.config.StartDate.Location())
 
-    	// ... resto igual ...
-
-
-		category := kg.mapCrimeCategory(report.Crime.CrimeName)
-		severity := report.Crime.CrimeWeight
+		category := kg.taxonomy.Category(report.Crime.CrimeName)
+		severity := kg.crimeSeverity(report.Crime)
 		confidence := kg.calculateConfidence(report)
 		incidentID := fmt.Sprintf("rpt_%d", report.ReportID)
 
@@ -294,11 +557,8 @@ func (kg *KnowledgeBaseGenerator) insertIncidentsBatch(ctx context.Context, db *
 			flushBatch()
 		}
 
-		valueStrings = append(valueStrings, fmt.Sprintf(
-			"(@p%d, @p%d, @p%d, @p%d, @p%d, @p%d, @p%d, @p%d, @p%d)",
-			paramIndex, paramIndex+1, paramIndex+2, paramIndex+3,
-			paramIndex+4, paramIndex+5, paramIndex+6, paramIndex+7, paramIndex+8,
-		))
+		valueStrings = append(valueStrings, "(?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		batchIDs = append(batchIDs, incidentID)
 
 		valueArgs = append(valueArgs,
 			incidentID,
@@ -307,183 +567,292 @@ func (kg *KnowledgeBaseGenerator) insertIncidentsBatch(ctx context.Context, db *
 			severity,
 			lat,
 			lon,
-			report.Neighborhood.Name,
+			kg.incidentNeighborhood(report, lat, lon),
 			confidence,
 			"legacy_reports",
 		)
-
-		paramIndex += paramsPerRow
 	}
 
 	// flush final
 	flushBatch()
 
+	// Um report alterado que deixou de ser válido não pode continuar como incidente
+	if kg.watermark != nil && len(invalidIDs) > 0 {
+		kg.removeIncidents(ctx, db, invalidIDs)
+	}
+
+	// Reports apagados saem da base em qualquer modo de execução
+	if len(deletedIDs) > 0 {
+		kg.removeIncidents(ctx, db, deletedIDs)
+	}
+
 	return processed, skipped
 }
 
+// trackPreviousCells marca como afetados a célula e o mês atuais dos incidentes
+// que serão sobrescritos pelo upsert
+func (kg *KnowledgeBaseGenerator) trackPreviousCells(ctx context.Context, db *sql.DB, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+
+	query := kg.dialect.Rebind(fmt.Sprintf(
+		`SELECT cell_id, occurred_at FROM curated_incidents WHERE cell_id IS NOT NULL AND id IN (%s)`,
+		placeholders(len(ids)),
+	))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		kg.logger.Printf("⚠️  Erro ao ler células anteriores dos incidentes: %v", err)
+		kg.progress.Warning(fmt.Errorf("células anteriores dos incidentes: %v", err))
+		return
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			kg.logger.Printf("⚠️  Erro ao fechar rows: %v", err)
+		}
+	}()
+
+	for rows.Next() {
+		var cellID string
+		var occurredAt time.Time
+		if err := rows.Scan(&cellID, &occurredAt); err != nil {
+			continue
+		}
+		kg.markAffected(cellID, occurredAt)
+	}
+}
+
+// removeIncidents apaga incidentes cujos reports ficaram inválidos ou foram apagados
+func (kg *KnowledgeBaseGenerator) removeIncidents(ctx context.Context, db *sql.DB, ids []string) {
+	const chunkSize = 500
+
+	for len(ids) > 0 {
+		chunk := ids
+		if len(chunk) > chunkSize {
+			chunk = ids[:chunkSize]
+		}
+		ids = ids[len(chunk):]
+
+		kg.trackPreviousCells(ctx, db, chunk)
+
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+		query := kg.dialect.Rebind(fmt.Sprintf(`DELETE FROM curated_incidents WHERE id IN (%s)`, placeholders(len(chunk))))
+		if result, err := db.ExecContext(ctx, query, args...); err != nil {
+			kg.logger.Printf("⚠️  Erro ao remover incidentes inválidos: %v", err)
+			kg.progress.Warning(fmt.Errorf("remoção de incidentes inválidos: %v", err))
+		} else if n, _ := result.RowsAffected(); n > 0 {
+			kg.logger.Printf("🗑️  %d incidentes removidos (reports inválidos ou apagados)", n)
+		}
+	}
+}
+
+// markAffected registra o mês de um incidente alterado em uma célula
+func (kg *KnowledgeBaseGenerator) markAffected(cellID string, occurredAt time.Time) {
+	month := time.Date(occurredAt.Year(), occurredAt.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	r, ok := kg.affected[cellID]
+	if !ok {
+		kg.affected[cellID] = &monthRange{first: month, last: month}
+		return
+	}
+	if month.Before(r.first) {
+		r.first = month
+	}
+	if month.After(r.last) {
+		r.last = month
+	}
+}
+
+// placeholders retorna "?, ?, ..." com n posições
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
 // ============================================================================
 // FASE 2: GRADE ESPACIAL
 // ============================================================================
 
-func (kg *KnowledgeBaseGenerator) generateSpatialGrid(ctx context.Context, db *sql.DB) error {
-	minLon, minLat := -47.3, -23.1
-	maxLon, maxLat := -46.8, -22.7
+func (kg *KnowledgeBaseGenerator) generateSpatialGrid(ctx context.Context, db *sql.DB) (int, error) {
+	if kg.config.Incremental {
+		var existing int
+		query := kg.dialect.Rebind(`SELECT COUNT(*) FROM curated_cells WHERE cell_resolution = ?`)
+		if err := db.QueryRowContext(ctx, query, kg.config.CellResolution).Scan(&existing); err != nil {
+			return 0, err
+		}
+		if existing > 0 {
+			kg.gridReused = true
+			kg.logger.Printf("♻️  Grade %s já existe (%d células), reaproveitando", FormatCellResolution(kg.config.CellResolution), existing)
+			return 0, nil
+		}
+	}
 
-	cellSizeDegrees := float64(kg.config.CellResolution) / 111000.0
+	grid, err := kg.cellGrid()
+	if err != nil {
+		return 0, err
+	}
 
-	cellID := 0
 	processed := 0
+	insertQuery := kg.dialect.InsertCellQuery()
 
-	for lon := minLon; lon < maxLon; lon += cellSizeDegrees {
-		for lat := minLat; lat < maxLat; lat += cellSizeDegrees {
-			cellID++
-
-			centerLat := lat + cellSizeDegrees/2
-			centerLon := lon + cellSizeDegrees/2
-
-			cellIDStr := fmt.Sprintf("CAMP-%d-%d", kg.config.CellResolution, cellID)
-
-			insertQuery := `
-				IF NOT EXISTS (SELECT 1 FROM curated_cells WHERE cell_id = @p1)
-				BEGIN
-					INSERT INTO curated_cells 
-					(cell_id, cell_resolution, city, center_lat, center_lng)
-					VALUES (@p2, @p3, @p4, @p5, @p6)
-				END
-			`
-
-			_, err := db.ExecContext(ctx, insertQuery,
-				cellIDStr,
-				cellIDStr,
-				kg.config.CellResolution,
-				"Campinas",
-				centerLat,
-				centerLon,
-			)
-
-			if err != nil {
-				return err
-			}
+	err = grid.each(func(c gridCell) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
-			processed++
+		_, err := db.ExecContext(ctx, insertQuery,
+			c.id,
+			kg.config.CellResolution,
+			"Campinas",
+			c.lat,
+			c.lon,
+			polygonJSON(c.polygon),
+		)
+		if err != nil {
+			return err
 		}
+
+		processed++
+		kg.progress.RowsProcessed(1)
+		return nil
+	})
+	if err != nil {
+		return processed, err
 	}
 
 	kg.logger.Printf("✅ Grade espacial gerada: %d células", processed)
-	return nil
+	return processed, nil
 }
 
 // ============================================================================
 // FASE 2.5: MAPEAMENTO CÉLULA → BAIRRO
 // ============================================================================
 
-func (kg *KnowledgeBaseGenerator) mapCellsToNeighborhoods(ctx context.Context, db *sql.DB) error {
-	query := `
-        IF OBJECT_ID('cell_neighborhoods', 'U') IS NULL
-        BEGIN
-            CREATE TABLE cell_neighborhoods (
-                cell_id      VARCHAR(50) PRIMARY KEY,
-                neighborhood VARCHAR(100) NOT NULL,
-                distance     FLOAT NULL
-            );
-        END;
-
-        TRUNCATE TABLE cell_neighborhoods;
-
-        INSERT INTO cell_neighborhoods (cell_id, neighborhood, distance)
-        SELECT
-            c.cell_id,
-            nearest.name AS neighborhood,
-            nearest.distance
-        FROM curated_cells c
-        CROSS APPLY (
-            SELECT TOP 1
-                n.name,
-                SQRT(
-                    POWER(n.latitude  - c.center_lat, 2) +
-                    POWER(n.longitude - c.center_lng, 2)
-                ) AS distance
-            FROM neighborhoods n
-            WHERE n.latitude IS NOT NULL 
-              AND n.longitude IS NOT NULL
-            ORDER BY 
-                POWER(n.latitude  - c.center_lat, 2) +
-                POWER(n.longitude - c.center_lng, 2)
-        ) AS nearest;
-    `
-	_, err := db.ExecContext(ctx, query)
-	return err
+func (kg *KnowledgeBaseGenerator) mapCellsToNeighborhoods(ctx context.Context, db *sql.DB) (int, error) {
+	if kg.gridReused {
+		kg.logger.Println("♻️  Grade reaproveitada, mantendo o mapeamento célula → bairro atual")
+		return 0, nil
+	}
+
+	for _, stmt := range kg.dialect.CellNeighborhoodsStatements() {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return 0, err
+		}
+	}
+
+	if kg.boundaries != nil {
+		if _, err := kg.mapCellsToBoundaries(ctx, db); err != nil {
+			return 0, fmt.Errorf("mapeamento pelos limites dos bairros: %v", err)
+		}
+	}
+
+	var mapped int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM cell_neighborhoods`).Scan(&mapped); err != nil {
+		return 0, err
+	}
+	kg.progress.RowsProcessed(mapped)
+
+	return mapped, nil
 }
 
 // ============================================================================
 // FASE 3: ATRIBUIR CÉLULAS AOS INCIDENTES
 // ============================================================================
 
-func (kg *KnowledgeBaseGenerator) assignCellsToIncidents(ctx context.Context, db *sql.DB) error {
-	cellsQuery := `SELECT cell_id, center_lat, center_lng FROM curated_cells WHERE cell_resolution = @p1`
-	cellRows, err := db.QueryContext(ctx, cellsQuery, kg.config.CellResolution)
+func (kg *KnowledgeBaseGenerator) assignCellsToIncidents(ctx context.Context, db *sql.DB) (int, error) {
+	grid, err := kg.cellGrid()
 	if err != nil {
-		return err
-	}
-	defer func() {
-		if err := cellRows.Close(); err != nil {
-			kg.logger.Printf("⚠️  Erro ao fechar cellRows: %v", err)
-		}
-	}()
-
-	type Cell struct {
-		ID        string
-		CenterLat float64
-		CenterLng float64
+		return 0, err
 	}
 
-	var cells []Cell
-	for cellRows.Next() {
-		var c Cell
-		if err := cellRows.Scan(&c.ID, &c.CenterLat, &c.CenterLng); err != nil {
-			continue
-		}
-		cells = append(cells, c)
+	type assignment struct {
+		incidentID string
+		cellID     string
 	}
 
-	incidentsQuery := `SELECT id, latitude, longitude FROM curated_incidents WHERE cell_id IS NULL`
-	incidentRows, err := db.QueryContext(ctx, incidentsQuery)
+	// A célula é calculada direto da latitude/longitude; os incidentes pendentes
+	// são lidos antes dos UPDATEs para não manter o cursor aberto durante a escrita
+	incidentRows, err := db.QueryContext(ctx,
+		`SELECT id, latitude, longitude, occurred_at FROM curated_incidents WHERE cell_id IS NULL`)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	defer func() {
-		if err := incidentRows.Close(); err != nil {
-			kg.logger.Printf("⚠️  Erro ao fechar incidentRows: %v", err)
-		}
-	}()
-
-	updated := 0
-	cellSizeDegrees := float64(kg.config.CellResolution) / 111000.0
 
+	var pending []assignment
+	outside := 0
 	for incidentRows.Next() {
 		var incidentID string
 		var lat, lon float64
+		var occurredAt time.Time
+
+		if err := incidentRows.Scan(&incidentID, &lat, &lon, &occurredAt); err != nil {
+			continue
+		}
 
-		if err := incidentRows.Scan(&incidentID, &lat, &lon); err != nil {
+		cellID, ok := grid.locate(lat, lon)
+		if !ok {
+			outside++
 			continue
 		}
+		pending = append(pending, assignment{incidentID: incidentID, cellID: cellID})
+		kg.markAffected(cellID, occurredAt)
+	}
+	err = incidentRows.Err()
+	if closeErr := incidentRows.Close(); closeErr != nil {
+		kg.logger.Printf("⚠️  Erro ao fechar incidentRows: %v", closeErr)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("erro ao ler incidentes sem célula: %w", err)
+	}
 
-		for _, cell := range cells {
-			if lat >= cell.CenterLat-cellSizeDegrees/2 && lat < cell.CenterLat+cellSizeDegrees/2 &&
-				lon >= cell.CenterLng-cellSizeDegrees/2 && lon < cell.CenterLng+cellSizeDegrees/2 {
+	// UPDATEs em lote: cell_resolution + (id, cell_id) por incidente
+	const paramsPerRow = 2
+	rowsPerUpdate := (kg.dialect.MaxParams() - 1) / paramsPerRow
+	if rowsPerUpdate > 1000 {
+		rowsPerUpdate = 1000
+	}
 
-				updateQuery := `UPDATE curated_incidents SET cell_id = @p1, cell_resolution = @p2 WHERE id = @p3`
-				_, err := db.ExecContext(ctx, updateQuery, cell.ID, kg.config.CellResolution, incidentID)
-				if err == nil {
-					updated++
-				}
-				break
-			}
+	updated := 0
+	for len(pending) > 0 {
+		if err := ctx.Err(); err != nil {
+			return updated, err
+		}
+
+		chunk := pending
+		if len(chunk) > rowsPerUpdate {
+			chunk = pending[:rowsPerUpdate]
+		}
+		pending = pending[len(chunk):]
+
+		valueStrings := make([]string, 0, len(chunk))
+		valueArgs := make([]interface{}, 0, 1+len(chunk)*paramsPerRow)
+		valueArgs = append(valueArgs, kg.config.CellResolution)
+		for _, a := range chunk {
+			valueStrings = append(valueStrings, "(?, ?)")
+			valueArgs = append(valueArgs, a.incidentID, a.cellID)
+		}
+
+		query := kg.dialect.Rebind(kg.dialect.AssignCellsQuery(strings.Join(valueStrings, ",")))
+		if _, err := db.ExecContext(ctx, query, valueArgs...); err != nil {
+			return updated, fmt.Errorf("erro ao atribuir células (%d incidentes): %w", len(chunk), err)
 		}
+
+		updated += len(chunk)
+		kg.progress.RowsProcessed(len(chunk))
 	}
 
+	if outside > 0 {
+		kg.logger.Printf("ℹ️  %d incidentes fora da grade ficaram sem célula", outside)
+	}
 	kg.logger.Printf("✅ Atribuídas células a %d incidentes", updated)
-	return nil
+	return updated, nil
 }
 
 // ============================================================================
@@ -491,140 +860,46 @@ func (kg *KnowledgeBaseGenerator) assignCellsToIncidents(ctx context.Context, db
 // ============================================================================
 
 func (kg *KnowledgeBaseGenerator) ensureMonthlyFeaturesTable(ctx context.Context, db *sql.DB) error {
-	query := `
-    IF OBJECT_ID('features_cell_monthly', 'U') IS NULL
-    BEGIN
-        CREATE TABLE features_cell_monthly (
-            cell_id         VARCHAR(50) NOT NULL,
-            [year]          INT         NOT NULL,
-            [month]         INT         NOT NULL,
-            y_count_month   INT         NOT NULL DEFAULT 0,
-            lag_1m          INT         NOT NULL DEFAULT 0,
-            lag_3m          INT         NOT NULL DEFAULT 0,
-            PRIMARY KEY (cell_id, [year], [month])
-        );
-
-        CREATE INDEX idx_features_cell_monthly_year_month 
-            ON features_cell_monthly([year], [month]);
-    END
-    `
-	_, err := db.ExecContext(ctx, query)
-	if err != nil {
-		return fmt.Errorf("erro ao criar tabela features_cell_monthly: %w", err)
+	for _, stmt := range kg.dialect.MonthlyFeaturesTableStatements() {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("erro ao criar tabela features_cell_monthly: %w", err)
+		}
 	}
 	return nil
 }
 
-func (kg *KnowledgeBaseGenerator) generateMonthlyFeatures(ctx context.Context, db *sql.DB) error {
+func (kg *KnowledgeBaseGenerator) generateMonthlyFeatures(ctx context.Context, db *sql.DB) (int, error) {
 	kg.logger.Println("📅 Gerando features mensais por célula...")
 
 	// Garantir que a tabela existe
 	if err := kg.ensureMonthlyFeaturesTable(ctx, db); err != nil {
-		return err
+		return 0, err
+	}
+
+	query := kg.dialect.MonthlyFeaturesQuery()
+
+	if kg.watermark != nil {
+		switch {
+		case len(kg.affected) == 0:
+			kg.logger.Println("✅ Nenhuma célula afetada, features mensais mantidas")
+			return 0, nil
+		case len(kg.affected) <= maxIncrementalCells:
+			return kg.generateMonthlyFeaturesForCells(ctx, db, query)
+		default:
+			kg.logger.Printf("ℹ️  %d células afetadas, recalculando todas", len(kg.affected))
+		}
 	}
 
-	query := `
-    ;WITH Months AS (
-        SELECT 
-            DATEFROMPARTS(YEAR(@start), MONTH(@start), 1) AS month_start,
-            DATEFROMPARTS(YEAR(@end), MONTH(@end), 1)     AS month_end
-    ),
-    MonthSeries AS (
-        SELECT month_start AS month_date
-        FROM Months
-        UNION ALL
-        SELECT DATEADD(month, 1, month_date)
-        FROM MonthSeries
-        CROSS JOIN Months
-        WHERE DATEADD(month, 1, month_date) <= (SELECT month_end FROM Months)
-    ),
-    Cells AS (
-        SELECT cell_id
-        FROM curated_cells
-        WHERE cell_resolution = @cellRes
-    ),
-    CellMonths AS (
-        SELECT 
-            c.cell_id,
-            YEAR(ms.month_date)  AS [year],
-            MONTH(ms.month_date) AS [month]
-        FROM Cells c
-        CROSS JOIN MonthSeries ms
-    ),
-    IncidentsByMonth AS (
-        SELECT
-            ci.cell_id,
-            YEAR(ci.occurred_at)  AS [year],
-            MONTH(ci.occurred_at) AS [month],
-            COUNT(*) AS y_count_month
-        FROM curated_incidents ci
-        WHERE ci.cell_id IS NOT NULL
-        GROUP BY ci.cell_id, YEAR(ci.occurred_at), MONTH(ci.occurred_at)
-    ),
-    Aggregated AS (
-        SELECT
-            cm.cell_id,
-            cm.[year],
-            cm.[month],
-            ISNULL(ibm.y_count_month, 0) AS y_count_month
-        FROM CellMonths cm
-        LEFT JOIN IncidentsByMonth ibm
-            ON ibm.cell_id = cm.cell_id
-           AND ibm.[year]  = cm.[year]
-           AND ibm.[month] = cm.[month]
-    ),
-    WithLags AS (
-        SELECT
-            a1.cell_id,
-            a1.[year],
-            a1.[month],
-            a1.y_count_month,
-
-            -- lag_1m: mês anterior
-            ISNULL((
-                SELECT TOP 1 a0.y_count_month
-                FROM Aggregated a0
-                WHERE a0.cell_id = a1.cell_id
-                  AND DATEADD(month, 1, DATEFROMPARTS(a0.[year], a0.[month], 1)) 
-                    = DATEFROMPARTS(a1.[year], a1.[month], 1)
-            ), 0) AS lag_1m,
-
-            -- lag_3m: soma dos últimos 3 meses (não incluindo o mês atual)
-            ISNULL((
-                SELECT SUM(a0.y_count_month)
-                FROM Aggregated a0
-                WHERE a0.cell_id = a1.cell_id
-                  AND DATEFROMPARTS(a0.[year], a0.[month], 1) 
-                    >= DATEADD(month, -3, DATEFROMPARTS(a1.[year], a1.[month], 1))
-                  AND DATEFROMPARTS(a0.[year], a0.[month], 1) 
-                    < DATEFROMPARTS(a1.[year], a1.[month], 1)
-            ), 0) AS lag_3m
-        FROM Aggregated a1
-    )
-    MERGE features_cell_monthly AS target
-    USING WithLags AS source
-        ON target.cell_id = source.cell_id
-       AND target.[year]  = source.[year]
-       AND target.[month] = source.[month]
-    WHEN MATCHED THEN
-        UPDATE SET
-            y_count_month = source.y_count_month,
-            lag_1m        = source.lag_1m,
-            lag_3m        = source.lag_3m
-    WHEN NOT MATCHED BY TARGET THEN
-        INSERT (cell_id, [year], [month], y_count_month, lag_1m, lag_3m)
-        VALUES (source.cell_id, source.[year], source.[month],
-                source.y_count_month, source.lag_1m, source.lag_3m)
-    OPTION (MAXRECURSION 0);
-    `
-
-	_, err := db.ExecContext(ctx, query,
-		sql.Named("start", kg.config.StartDate),
-		sql.Named("end", kg.config.EndDate),
-		sql.Named("cellRes", kg.config.CellResolution),
-	)
+	result, err := db.ExecContext(ctx, query,
+		kg.config.StartDate, kg.config.EndDate, kg.config.CellResolution, "", kg.config.StartDate)
 	if err != nil {
-		return fmt.Errorf("erro ao gerar features mensais: %w", err)
+		return 0, fmt.Errorf("erro ao gerar features mensais: %w", err)
+	}
+
+	affected := 0
+	if n, err := result.RowsAffected(); err == nil {
+		affected = int(n)
+		kg.progress.RowsProcessed(affected)
 	}
 
 	var count int
@@ -634,25 +909,110 @@ func (kg *KnowledgeBaseGenerator) generateMonthlyFeatures(ctx context.Context, d
 		kg.logger.Println("✅ Features mensais geradas com sucesso")
 	}
 
-	return nil
+	return affected, nil
+}
+
+// generateMonthlyFeaturesForCells recalcula apenas as células afetadas, do
+// primeiro mês alterado até 3 meses depois do último (alcance do lag_3m).
+// O cálculo começa até 3 meses antes para que os lags dos meses gravados
+// sejam os mesmos de uma execução completa.
+func (kg *KnowledgeBaseGenerator) generateMonthlyFeaturesForCells(ctx context.Context, db *sql.DB, query string) (int, error) {
+	start := kg.config.StartDate
+	startMonth := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	total := 0
+	for cellID, r := range kg.affected {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		writeFrom := r.first
+		if writeFrom.Before(startMonth) {
+			writeFrom = startMonth
+		}
+		from := writeFrom.AddDate(0, -3, 0)
+		if from.Before(startMonth) {
+			from = startMonth
+		}
+		to := r.last.AddDate(0, 3, 0)
+		if to.After(kg.config.EndDate) {
+			to = kg.config.EndDate
+		}
+		if writeFrom.After(to) {
+			continue
+		}
+
+		result, err := db.ExecContext(ctx, query, from, to, kg.config.CellResolution, cellID, writeFrom)
+		if err != nil {
+			return total, fmt.Errorf("erro ao gerar features mensais da célula %s: %w", cellID, err)
+		}
+		if n, err := result.RowsAffected(); err == nil {
+			total += int(n)
+			kg.progress.RowsProcessed(int(n))
+		}
+	}
+
+	kg.logger.Printf("✅ Features mensais recalculadas para %d células afetadas (%d registros)", len(kg.affected), total)
+	return total, nil
 }
 
 // ============================================================================
-// FUNÇÕES AUXILIARES (CATEGORIA, CONFIANÇA)
+// MARCA D'ÁGUA (EXECUÇÃO INCREMENTAL)
 // ============================================================================
 
-func (kg *KnowledgeBaseGenerator) mapCrimeCategory(crimeName string) string {
-	crimeName = strings.ToLower(crimeName)
-	hediondos := []string{"homicidio", "homicídio", "latrocinio", "latrocínio", "estupro", "sequestro", "trafico", "tráfico"}
+// watermarkName identifica a marca d'água por resolução, já que a atribuição
+// de células e as features dependem dela
+func (kg *KnowledgeBaseGenerator) watermarkName() string {
+	return "knowledge_base:reports.updated_at:" + FormatCellResolution(kg.config.CellResolution)
+}
+
+func (kg *KnowledgeBaseGenerator) loadWatermark(ctx context.Context) error {
+	query := kg.dialect.Rebind(`SELECT watermark_at FROM analytics_watermarks WHERE name = ?`)
 
-	for _, h := range hediondos {
-		if strings.Contains(crimeName, h) {
-			return "Hediondo"
-		}
+	var watermark time.Time
+	err := kg.logDB().QueryRowContext(ctx, query, kg.watermarkName()).Scan(&watermark)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
 	}
-	return "Comum"
+	if err != nil {
+		return err
+	}
+
+	kg.watermark = &watermark
+	return nil
 }
 
+// saveWatermark avança a marca d'água para o início desta execução menos
+// watermarkSafetyMargin. O maior updated_at lido não serve: reports com o
+// mesmo updated_at, ou de transações com commit atrasado, ficariam de fora.
+func (kg *KnowledgeBaseGenerator) saveWatermark(ctx context.Context) error {
+	if kg.watermark != nil && !kg.newWatermark.After(*kg.watermark) {
+		return nil
+	}
+	if kg.upsertFailed {
+		kg.logger.Println("⚠️  Houve batches com erro: marca d'água mantida para reprocessar na próxima execução")
+		return nil
+	}
+
+	now := time.Now()
+	updateQuery := kg.dialect.Rebind(`UPDATE analytics_watermarks SET watermark_at = ?, updated_at = ? WHERE name = ?`)
+	result, err := kg.logDB().ExecContext(ctx, updateQuery, kg.newWatermark, now, kg.watermarkName())
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n > 0 {
+		return nil
+	}
+
+	insertQuery := kg.dialect.Rebind(`INSERT INTO analytics_watermarks (name, watermark_at, updated_at) VALUES (?, ?, ?)`)
+	_, err = kg.logDB().ExecContext(ctx, insertQuery, kg.watermarkName(), kg.newWatermark, now)
+	return err
+}
+
+// ============================================================================
+// FUNÇÕES AUXILIARES (CONFIANÇA)
+// ============================================================================
+
 func (kg *KnowledgeBaseGenerator) calculateConfidence(report Report) float64 {
 	confidence := 0.5
 
@@ -681,167 +1041,11 @@ func (kg *KnowledgeBaseGenerator) calculateConfidence(report Report) float64 {
 	return confidence
 }
 
-// ============================================================================
-// FASE 4: FEATURES TEMPORAIS (HORÁRIAS)
-// ============================================================================
-
-func (kg *KnowledgeBaseGenerator) generateFeaturesForRange(ctx context.Context, db *sql.DB, rangeStart, rangeEnd time.Time) error {
-	query := `
-	;WITH Hours AS (
-		-- Gera todas as horas entre @start e @end
-		SELECT DATEADD(hour, n, @start) AS ts
-		FROM (
-			SELECT TOP (DATEDIFF(hour, @start, @end))
-				ROW_NUMBER() OVER (ORDER BY (SELECT NULL)) - 1 AS n
-			FROM sys.all_objects
-		) AS t
-	),
-	Cells AS (
-		SELECT cell_id, center_lat, center_lng
-		FROM curated_cells
-		WHERE cell_resolution = @cellRes
-	),
-	CellHours AS (
-		-- Produto cartesiano de células x horas
-		SELECT c.cell_id, h.ts
-		FROM Cells c
-		CROSS JOIN Hours h
-	),
-	BaseIncidents AS (
-		-- Incidentes no range estendido para calcular lags
-		SELECT 
-			cell_id,
-			occurred_at
-		FROM curated_incidents
-		WHERE occurred_at >= DATEADD(day, -7, @start)
-		  AND occurred_at < @end
-	),
-	Aggregated AS (
-		SELECT
-			ch.cell_id,
-			ch.ts,
-
-			-- y_count: ocorrências na própria hora
-			SUM(CASE 
-					WHEN bi.occurred_at >= ch.ts
-					 AND bi.occurred_at < DATEADD(hour, 1, ch.ts)
-					THEN 1 ELSE 0 END
-			) AS y_count,
-
-			-- lag_1h: ocorrências na hora anterior
-			SUM(CASE 
-					WHEN bi.occurred_at >= DATEADD(hour, -1, ch.ts)
-					 AND bi.occurred_at < ch.ts
-					THEN 1 ELSE 0 END
-			) AS lag_1h,
-
-			-- lag_24h: últimas 24h
-			SUM(CASE 
-					WHEN bi.occurred_at >= DATEADD(hour, -24, ch.ts)
-					 AND bi.occurred_at < ch.ts
-					THEN 1 ELSE 0 END
-			) AS lag_24h,
-
-			-- lag_7d: últimos 7 dias
-			SUM(CASE 
-					WHEN bi.occurred_at >= DATEADD(day, -7, ch.ts)
-					 AND bi.occurred_at < ch.ts
-					THEN 1 ELSE 0 END
-			) AS lag_7d
-		FROM CellHours ch
-		LEFT JOIN BaseIncidents bi
-			ON bi.cell_id = ch.cell_id
-		   AND bi.occurred_at >= DATEADD(day, -7, ch.ts)
-		   AND bi.occurred_at < DATEADD(hour, 1, ch.ts)
-		GROUP BY ch.cell_id, ch.ts
-	),
-	WithCalendar AS (
-		SELECT
-			a.cell_id,
-			a.ts,
-			a.y_count,
-			a.lag_1h,
-			a.lag_24h,
-			a.lag_7d,
-			DATEPART(dw, a.ts) - 1 AS dow, -- 0=domingo
-			DATEPART(hour, a.ts) AS hour,
-			CASE WHEN eh.date IS NULL THEN 0 ELSE 1 END AS holiday,
-			CASE WHEN DATEPART(dw, a.ts) IN (1, 7) THEN 1 ELSE 0 END AS is_weekend,
-			CASE WHEN DATEPART(hour, a.ts) BETWEEN 8 AND 18 THEN 1 ELSE 0 END AS is_business_hours
-		FROM Aggregated a
-		LEFT JOIN external_holidays eh
-			ON eh.date = CAST(a.ts AS date)
-	)
-	-- INSERT ou UPDATE em features_cell_hourly em batch
-	MERGE features_cell_hourly AS target
-	USING WithCalendar AS source
-		ON target.cell_id = source.cell_id
-	   AND target.ts = source.ts
-	WHEN MATCHED THEN
-		UPDATE SET
-			y_count = source.y_count,
-			lag_1h = source.lag_1h,
-			lag_24h = source.lag_24h,
-			lag_7d = source.lag_7d,
-			dow = source.dow,
-			hour = source.hour,
-			holiday = source.holiday,
-			is_weekend = source.is_weekend,
-			is_business_hours = source.is_business_hours
-	WHEN NOT MATCHED BY TARGET THEN
-		INSERT (cell_id, ts, y_count, lag_1h, lag_24h, lag_7d, dow, hour, holiday, is_weekend, is_business_hours)
-		VALUES (source.cell_id, source.ts, source.y_count, source.lag_1h, source.lag_24h, source.lag_7d,
-				source.dow, source.hour, source.holiday, source.is_weekend, source.is_business_hours);
-	`
-
-	_, err := db.ExecContext(ctx, query,
-		sql.Named("start", rangeStart),
-		sql.Named("end", rangeEnd),
-		sql.Named("cellRes", kg.config.CellResolution),
-	)
-	if err != nil {
-		return fmt.Errorf("erro ao gerar features para range %s - %s: %w",
-			rangeStart.Format(time.RFC3339), rangeEnd.Format(time.RFC3339), err)
-	}
-
-	return nil
-}
-
-func (kg *KnowledgeBaseGenerator) generateTemporalFeatures(ctx context.Context, db *sql.DB) error {
-	start := kg.config.StartDate
-	end := kg.config.EndDate
-
-	// Processar em blocos de 1 dia
-	day := start
-	daysProcessed := 0
-
-	for day.Before(end) {
-		dayEnd := day.Add(24 * time.Hour)
-		if dayEnd.After(end) {
-			dayEnd = end
-		}
-
-		startBlock := time.Now()
-		if err := kg.generateFeaturesForRange(ctx, db, day, dayEnd); err != nil {
-			kg.logger.Printf("⚠️  Erro ao processar dia %s: %v", day.Format("2006-01-02"), err)
-		}
-
-		daysProcessed++
-		kg.logger.Printf("  ➜ Dia %s processado em %s (total dias: %d)",
-			day.Format("2006-01-02"), time.Since(startBlock), daysProcessed)
-
-		day = dayEnd
-	}
-
-	kg.logger.Printf("✅ Features temporais geradas para %d dias", daysProcessed)
-	return nil
-}
-
 // ============================================================================
 // FASE 5: VALIDAÇÃO DE QUALIDADE
 // ============================================================================
 
-func (kg *KnowledgeBaseGenerator) validateDataQuality(ctx context.Context, db *sql.DB) error {
+func (kg *KnowledgeBaseGenerator) validateDataQuality(ctx context.Context, db *sql.DB) (int, error) {
 	metrics := make(map[string]interface{})
 
 	var incidentsCount int
@@ -864,32 +1068,34 @@ func (kg *KnowledgeBaseGenerator) validateDataQuality(ctx context.Context, db *s
 
 	metricsJSON, _ := json.Marshal(metrics)
 
-	insertQuery := `
-		INSERT INTO analytics_quality_reports (report_date, metrics)
-		VALUES (CAST(GETDATE() AS DATE), @p1)
-	`
-	_, err := db.ExecContext(ctx, insertQuery, string(metricsJSON))
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	insertQuery := kg.dialect.Rebind(`
+		INSERT INTO analytics_quality_reports (report_date, metrics, created_at, updated_at)
+		VALUES (?, ?, ?, ?)
+	`)
+	_, err := db.ExecContext(ctx, insertQuery, today, string(metricsJSON), now, now)
 
 	if err != nil {
-		// 2627 = chave duplicada
-		if strings.Contains(err.Error(), "2627") {
+		if kg.dialect.IsDuplicateKey(err) {
 			kg.logger.Printf("ℹ️  Registro de qualidade já existe para hoje, atualizando em vez de inserir...")
 
-			updateQuery := `
+			updateQuery := kg.dialect.Rebind(`
 				UPDATE analytics_quality_reports 
-				SET metrics = @p1
-				WHERE report_date = CAST(GETDATE() AS DATE)
-			`
-			if _, errUpdate := db.ExecContext(ctx, updateQuery, string(metricsJSON)); errUpdate != nil {
+				SET metrics = ?, updated_at = ?
+				WHERE report_date = ?
+			`)
+			if _, errUpdate := db.ExecContext(ctx, updateQuery, string(metricsJSON), now, today); errUpdate != nil {
 				kg.logger.Printf("⚠️  Erro ao atualizar analytics_quality_reports: %v", errUpdate)
-				return errUpdate
+				return 0, errUpdate
 			}
 			// sucesso na atualização → não é erro crítico
-			return nil
+			return 1, nil
 		}
 
 		// outros erros (não de chave duplicada) devem ser propagados
-		return err
+		return 0, err
 	}
 
 	kg.logger.Printf("📊 Métricas de qualidade:")
@@ -897,5 +1103,5 @@ func (kg *KnowledgeBaseGenerator) validateDataQuality(ctx context.Context, db *s
 	kg.logger.Printf("   • Total de células: %d", cellsCount)
 	kg.logger.Printf("   • Total de features: %d", featuresCount)
 
-	return nil
+	return 1, nil
 }
\ No newline at end of file
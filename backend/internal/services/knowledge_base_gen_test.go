@@ -0,0 +1,384 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+// setupSQLiteDB cria um banco SQLite em arquivo temporário com todas as tabelas
+// da aplicação e retorna as conexões GORM e database/sql para o mesmo arquivo
+func setupSQLiteDB(t *testing.T) (*gorm.DB, *sql.DB) {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "radar.db") +
+		"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=synchronous(OFF)&_txlock=immediate"
+
+	gdb, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+		TranslateError:                           true,
+	})
+	if err != nil {
+		t.Fatalf("não foi possivel abrir DB de teste: %v", err)
+	}
+	if err := gdb.AutoMigrate(
+		&models.Report{}, &models.Crime{}, &models.Neighborhood{}, &models.NeighborhoodBoundary{}, &models.CrimeCategory{},
+		&models.CuratedIncident{}, &models.CuratedCell{}, &models.ExternalHoliday{},
+		&models.FeaturesCellHourly{}, &models.AnalyticsQualityReport{}, &models.AnalyticsPipelineLog{},
+		&models.AnalyticsWatermark{}, &models.PredictCrime{},
+	); err != nil {
+		t.Fatalf("falha na migração dos modelos: %v", err)
+	}
+
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		t.Fatalf("falha ao obter *sql.DB: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	return gdb, sqlDB
+}
+
+// occurredAt converte a data de um report de teste como na ingestão
+func occurredAt(t *testing.T, date string) time.Time {
+	t.Helper()
+	at, err := ParseReportDate(date, saoPaulo)
+	if err != nil {
+		t.Fatalf("data de teste inválida: %v", err)
+	}
+	return at
+}
+
+// TestGenerateKnowledgeBase_SQLite executa o pipeline completo no dialeto SQLite
+func TestGenerateKnowledgeBase_SQLite(t *testing.T) {
+	gdb, db := setupSQLiteDB(t)
+
+	centro := models.Neighborhood{Name: "Centro", Latitude: -22.9056, Longitude: -47.0608, NeighborhoodWeight: 3}
+	taquaral := models.Neighborhood{Name: "Taquaral", Latitude: -22.8758, Longitude: -47.0533, NeighborhoodWeight: 1}
+	fora := models.Neighborhood{Name: "Fora", Latitude: -23.5505, Longitude: -46.6333, NeighborhoodWeight: 1}
+	for _, n := range []*models.Neighborhood{&centro, &taquaral, &fora} {
+		if err := gdb.Create(n).Error; err != nil {
+			t.Fatalf("falha ao inserir bairro: %v", err)
+		}
+	}
+	furto := models.Crime{CrimeName: "Furto", CrimeWeight: 3}
+	homicidio := models.Crime{CrimeName: "Homicídio Doloso", CrimeWeight: 10}
+	for _, c := range []*models.Crime{&furto, &homicidio} {
+		if err := gdb.Create(c).Error; err != nil {
+			t.Fatalf("falha ao inserir crime: %v", err)
+		}
+	}
+
+	reports := []struct {
+		neighborhood, crime uint
+		date                string
+	}{
+		{centro.NeighborhoodID, furto.CrimeID, "2024-01-10 10:00:00"},
+		{centro.NeighborhoodID, homicidio.CrimeID, "2024-02-15 22:00:00"},
+		{taquaral.NeighborhoodID, furto.CrimeID, "2024-02-20 08:30:00"},
+		{fora.NeighborhoodID, furto.CrimeID, "2024-02-21 08:30:00"},   // fora da bounding box
+		{centro.NeighborhoodID, furto.CrimeID, "2023-06-01 12:00:00"}, // fora do período
+	}
+	for _, r := range reports {
+		if err := gdb.Exec(
+			`INSERT INTO reports (neighborhood_id, crime_id, report_date, occurred_at, created_at, updated_at)
+			 VALUES (?, ?, ?, ?, ?, ?)`,
+			r.neighborhood, r.crime, r.date, occurredAt(t, r.date), time.Now(), time.Now(),
+		).Error; err != nil {
+			t.Fatalf("falha ao inserir report: %v", err)
+		}
+	}
+
+	dialect, err := NewKnowledgeBaseDialect("sqlite")
+	if err != nil {
+		t.Fatalf("esperava dialeto sqlite, obteve: %v", err)
+	}
+
+	generator := NewKnowledgeBaseGenerator(&KnowledgeBaseConfig{
+		SourceDB:       db,
+		TargetDB:       db,
+		Dialect:        dialect,
+		CellResolution: 1000,
+		BatchSize:      2,
+		StartDate:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
+		EndDate:        time.Date(2024, 3, 31, 0, 0, 0, 0, time.Local),
+	})
+	if err := generator.GenerateKnowledgeBase(context.Background()); err != nil {
+		t.Fatalf("esperava sem erro no pipeline, obteve: %v", err)
+	}
+
+	var incidents, assigned int
+	db.QueryRow(`SELECT COUNT(*) FROM curated_incidents`).Scan(&incidents)
+	db.QueryRow(`SELECT COUNT(*) FROM curated_incidents WHERE cell_id IS NOT NULL`).Scan(&assigned)
+	if incidents != 3 || assigned != 3 {
+		t.Errorf("esperava 3 incidentes com célula, obteve: %d incidentes, %d com célula", incidents, assigned)
+	}
+
+	var category string
+	db.QueryRow(`SELECT category FROM curated_incidents WHERE severity = 10`).Scan(&category)
+	if category != "Hediondo" {
+		t.Errorf("esperava categoria Hediondo, obteve: %q", category)
+	}
+
+	var febCount int
+	db.QueryRow(`SELECT COALESCE(SUM(y_count_month), 0) FROM features_cell_monthly WHERE year = 2024 AND month = 2`).Scan(&febCount)
+	if febCount != 2 {
+		t.Errorf("esperava 2 incidentes em fev/2024, obteve: %d", febCount)
+	}
+
+	var lag int
+	db.QueryRow(`
+		SELECT f.lag_1m FROM features_cell_monthly f
+		JOIN curated_incidents ci ON ci.cell_id = f.cell_id
+		WHERE ci.severity = 10 AND f.year = 2024 AND f.month = 2`).Scan(&lag)
+	if lag != 1 {
+		t.Errorf("esperava lag_1m = 1 na célula do Centro, obteve: %d", lag)
+	}
+
+	var phases, succeeded int
+	db.QueryRow(`SELECT COUNT(*) FROM analytics_pipeline_logs WHERE execution_id = ?`, generator.ExecutionID()).Scan(&phases)
+	db.QueryRow(`SELECT COUNT(*) FROM analytics_pipeline_logs WHERE execution_id = ? AND status = ?`,
+		generator.ExecutionID(), JobStatusSucceeded).Scan(&succeeded)
+	if phases != 7 || succeeded != 7 {
+		t.Errorf("esperava 7 fases registradas com sucesso, obteve: %d fases, %d com sucesso", phases, succeeded)
+	}
+
+	// Uma segunda execução no mesmo dia atualiza o relatório de qualidade
+	if err := NewKnowledgeBaseGenerator(generator.config).GenerateKnowledgeBase(context.Background()); err != nil {
+		t.Fatalf("esperava sem erro na segunda execução, obteve: %v", err)
+	}
+	var reportsCount int
+	db.QueryRow(`SELECT COUNT(*) FROM analytics_quality_reports`).Scan(&reportsCount)
+	if reportsCount != 1 {
+		t.Errorf("esperava 1 relatório de qualidade, obteve: %d", reportsCount)
+	}
+}
+
+// TestGenerateKnowledgeBase_PhaseLogs verifica que cada fase executada tem
+// uma linha em analytics_pipeline_logs e que, quando uma fase falha, ela é
+// gravada com o erro e as fases seguintes não são registradas
+func TestGenerateKnowledgeBase_PhaseLogs(t *testing.T) {
+	gdb, db := setupSQLiteDB(t)
+
+	centro := models.Neighborhood{Name: "Centro", Latitude: -22.9056, Longitude: -47.0608, NeighborhoodWeight: 3}
+	furto := models.Crime{CrimeName: "Furto", CrimeWeight: 3}
+	for _, v := range []interface{}{&centro, &furto} {
+		if err := gdb.Create(v).Error; err != nil {
+			t.Fatalf("falha ao inserir dados base: %v", err)
+		}
+	}
+	at := occurredAt(t, "2024-01-10 10:00:00")
+	if err := gdb.Create(&models.Report{
+		NeighborhoodID: centro.NeighborhoodID, CrimeID: furto.CrimeID, ReportDate: "2024-01-10 10:00:00", OccurredAt: &at,
+	}).Error; err != nil {
+		t.Fatalf("falha ao inserir report: %v", err)
+	}
+
+	// Sem curated_cells a fase 2 (grade espacial) falha
+	if err := gdb.Migrator().DropTable(&models.CuratedCell{}); err != nil {
+		t.Fatalf("falha ao apagar curated_cells: %v", err)
+	}
+
+	dialect, _ := NewKnowledgeBaseDialect("sqlite")
+	generator := NewKnowledgeBaseGenerator(&KnowledgeBaseConfig{
+		SourceDB:       db,
+		TargetDB:       db,
+		Dialect:        dialect,
+		CellResolution: 1000,
+		StartDate:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
+		EndDate:        time.Date(2024, 3, 31, 0, 0, 0, 0, time.Local),
+	})
+	if err := generator.GenerateKnowledgeBase(context.Background()); err == nil {
+		t.Fatal("esperava erro na grade espacial sem curated_cells")
+	}
+
+	var logs []models.AnalyticsPipelineLog
+	if err := gdb.Where("execution_id = ?", generator.ExecutionID()).Order("id").Find(&logs).Error; err != nil {
+		t.Fatalf("falha ao ler analytics_pipeline_logs: %v", err)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("esperava 2 fases registradas, obteve: %+v", logs)
+	}
+
+	migrate, grid := logs[0], logs[1]
+	if migrate.Phase != PhaseMigrateHistoricalData || migrate.Status != JobStatusSucceeded ||
+		migrate.FinishedAt == nil || migrate.RecordsProcessed == nil || *migrate.RecordsProcessed != 1 ||
+		migrate.ErrorMessage != nil {
+		t.Errorf("esperava a migração concluída com 1 registro, obteve: %+v", migrate)
+	}
+	if grid.Phase != PhaseGenerateSpatialGrid || grid.Status != JobStatusFailed ||
+		grid.FinishedAt == nil || grid.ErrorMessage == nil || *grid.ErrorMessage == "" {
+		t.Errorf("esperava a grade espacial com status %s e erro, obteve: %+v", JobStatusFailed, grid)
+	}
+}
+
+// TestGenerateKnowledgeBase_Incremental verifica que a segunda execução só
+// processa os reports alterados e recalcula apenas as células afetadas
+func TestGenerateKnowledgeBase_Incremental(t *testing.T) {
+	gdb, db := setupSQLiteDB(t)
+
+	centro := models.Neighborhood{Name: "Centro", Latitude: -22.9056, Longitude: -47.0608, NeighborhoodWeight: 3}
+	taquaral := models.Neighborhood{Name: "Taquaral", Latitude: -22.8758, Longitude: -47.0533, NeighborhoodWeight: 1}
+	furto := models.Crime{CrimeName: "Furto", CrimeWeight: 3}
+	roubo := models.Crime{CrimeName: "Roubo", CrimeWeight: 5}
+	for _, v := range []interface{}{&centro, &taquaral, &furto, &roubo} {
+		if err := gdb.Create(v).Error; err != nil {
+			t.Fatalf("falha ao inserir dados base: %v", err)
+		}
+	}
+
+	insertReport := func(neighborhood, crime uint, date string, updatedAt time.Time) uint {
+		t.Helper()
+		at := occurredAt(t, date)
+		r := models.Report{NeighborhoodID: neighborhood, CrimeID: crime, ReportDate: date, OccurredAt: &at}
+		if err := gdb.Create(&r).Error; err != nil {
+			t.Fatalf("falha ao inserir report: %v", err)
+		}
+		gdb.Exec(`UPDATE reports SET updated_at = ? WHERE report_id = ?`, updatedAt, r.ReportID)
+		return r.ReportID
+	}
+
+	base := time.Now().Add(-time.Hour)
+	first := insertReport(centro.NeighborhoodID, furto.CrimeID, "2024-01-10 10:00:00", base)
+	insertReport(taquaral.NeighborhoodID, furto.CrimeID, "2024-02-20 08:30:00", base)
+
+	dialect, _ := NewKnowledgeBaseDialect("sqlite")
+	config := &KnowledgeBaseConfig{
+		SourceDB:       db,
+		TargetDB:       db,
+		Dialect:        dialect,
+		CellResolution: 1000,
+		BatchSize:      100,
+		StartDate:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
+		EndDate:        time.Date(2024, 6, 30, 0, 0, 0, 0, time.Local),
+		Incremental:    true,
+	}
+	if err := NewKnowledgeBaseGenerator(config).GenerateKnowledgeBase(context.Background()); err != nil {
+		t.Fatalf("esperava sem erro na primeira execução, obteve: %v", err)
+	}
+
+	var watermarks int
+	db.QueryRow(`SELECT COUNT(*) FROM analytics_watermarks`).Scan(&watermarks)
+	if watermarks != 1 {
+		t.Fatalf("esperava marca d'água gravada, obteve: %d", watermarks)
+	}
+
+	// O report de janeiro muda para o Taquaral e um novo report entra em março
+	later := time.Now()
+	gdb.Exec(`UPDATE reports SET neighborhood_id = ?, crime_id = ?, updated_at = ? WHERE report_id = ?`,
+		taquaral.NeighborhoodID, roubo.CrimeID, later, first)
+	march := insertReport(centro.NeighborhoodID, furto.CrimeID, "2024-03-05 19:00:00", later)
+
+	second := NewKnowledgeBaseGenerator(config)
+	if err := second.GenerateKnowledgeBase(context.Background()); err != nil {
+		t.Fatalf("esperava sem erro na execução incremental, obteve: %v", err)
+	}
+
+	var migrated, gridCells int
+	db.QueryRow(`SELECT records_processed FROM analytics_pipeline_logs WHERE execution_id = ? AND phase = ?`,
+		second.ExecutionID(), PhaseMigrateHistoricalData).Scan(&migrated)
+	db.QueryRow(`SELECT records_processed FROM analytics_pipeline_logs WHERE execution_id = ? AND phase = ?`,
+		second.ExecutionID(), PhaseGenerateSpatialGrid).Scan(&gridCells)
+	if migrated != 2 || gridCells != 0 {
+		t.Errorf("esperava 2 reports processados e grade reaproveitada, obteve: %d reports, %d células", migrated, gridCells)
+	}
+
+	var incidents, unassigned int
+	db.QueryRow(`SELECT COUNT(*) FROM curated_incidents`).Scan(&incidents)
+	db.QueryRow(`SELECT COUNT(*) FROM curated_incidents WHERE cell_id IS NULL`).Scan(&unassigned)
+	if incidents != 3 || unassigned != 0 {
+		t.Errorf("esperava 3 incidentes com célula, obteve: %d incidentes, %d sem célula", incidents, unassigned)
+	}
+
+	// Janeiro: o incidente saiu do Centro e foi para o Taquaral
+	var centroJan, taquaralJan, taquaralFebLag, centroMar int
+	cellOf := `(SELECT cell_id FROM curated_incidents WHERE neighborhood = ? LIMIT 1)`
+	db.QueryRow(`SELECT COALESCE(SUM(y_count_month), 0) FROM features_cell_monthly
+		WHERE year = 2024 AND month = 1 AND cell_id = (SELECT cell_id FROM curated_incidents WHERE id = 'rpt_3')`).Scan(&centroJan)
+	db.QueryRow(`SELECT y_count_month FROM features_cell_monthly WHERE year = 2024 AND month = 1 AND cell_id = `+cellOf, "Taquaral").Scan(&taquaralJan)
+	db.QueryRow(`SELECT lag_1m FROM features_cell_monthly WHERE year = 2024 AND month = 2 AND cell_id = `+cellOf, "Taquaral").Scan(&taquaralFebLag)
+	db.QueryRow(`SELECT y_count_month FROM features_cell_monthly WHERE year = 2024 AND month = 3 AND cell_id = `+cellOf, "Centro").Scan(&centroMar)
+	if centroJan != 0 || taquaralJan != 1 || taquaralFebLag != 1 || centroMar != 1 {
+		t.Errorf("features mensais incorretas: centro jan=%d, taquaral jan=%d, taquaral fev lag_1m=%d, centro mar=%d",
+			centroJan, taquaralJan, taquaralFebLag, centroMar)
+	}
+
+	// O report de março é apagado (soft delete): o incidente e a contagem somem
+	if err := NewReportService(gdb, saoPaulo, DefaultGridArea).DeleteReport(context.Background(), march); err != nil {
+		t.Fatalf("esperava sem erro ao apagar report, obteve: %v", err)
+	}
+	if err := NewKnowledgeBaseGenerator(config).GenerateKnowledgeBase(context.Background()); err != nil {
+		t.Fatalf("esperava sem erro na execução após apagar, obteve: %v", err)
+	}
+	db.QueryRow(`SELECT COUNT(*) FROM curated_incidents`).Scan(&incidents)
+	db.QueryRow(`SELECT COALESCE(SUM(y_count_month), 0) FROM features_cell_monthly WHERE year = 2024 AND month = 3`).Scan(&centroMar)
+	if incidents != 2 || centroMar != 0 {
+		t.Errorf("esperava o incidente de março removido, obteve: %d incidentes, %d em março", incidents, centroMar)
+	}
+}
+
+// TestGenerateKnowledgeBase_IncrementalLateCommit verifica que a execução
+// incremental lê um report cujo updated_at é anterior ao fim da execução
+// anterior, como o de uma transação com commit atrasado
+func TestGenerateKnowledgeBase_IncrementalLateCommit(t *testing.T) {
+	gdb, db := setupSQLiteDB(t)
+
+	centro := models.Neighborhood{Name: "Centro", Latitude: -22.9056, Longitude: -47.0608, NeighborhoodWeight: 3}
+	furto := models.Crime{CrimeName: "Furto", CrimeWeight: 3}
+	for _, v := range []interface{}{&centro, &furto} {
+		if err := gdb.Create(v).Error; err != nil {
+			t.Fatalf("falha ao inserir dados base: %v", err)
+		}
+	}
+
+	insertReport := func(date string, updatedAt time.Time) {
+		t.Helper()
+		at := occurredAt(t, date)
+		r := models.Report{NeighborhoodID: centro.NeighborhoodID, CrimeID: furto.CrimeID, ReportDate: date, OccurredAt: &at}
+		if err := gdb.Create(&r).Error; err != nil {
+			t.Fatalf("falha ao inserir report: %v", err)
+		}
+		gdb.Exec(`UPDATE reports SET updated_at = ? WHERE report_id = ?`, updatedAt, r.ReportID)
+	}
+
+	updatedAt := time.Now().Add(-time.Minute)
+	insertReport("2024-01-10 10:00:00", updatedAt)
+
+	dialect, _ := NewKnowledgeBaseDialect("sqlite")
+	config := &KnowledgeBaseConfig{
+		SourceDB:           db,
+		TargetDB:           db,
+		Dialect:            dialect,
+		CellResolution:     1000,
+		BatchSize:          100,
+		StartDate:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
+		EndDate:            time.Date(2024, 6, 30, 0, 0, 0, 0, time.Local),
+		Incremental:        true,
+		SkipHourlyFeatures: true,
+	}
+	if err := NewKnowledgeBaseGenerator(config).GenerateKnowledgeBase(context.Background()); err != nil {
+		t.Fatalf("esperava sem erro na primeira execução, obteve: %v", err)
+	}
+
+	// Report com o mesmo updated_at do já processado, visível só agora
+	insertReport("2024-01-11 10:00:00", updatedAt)
+
+	if err := NewKnowledgeBaseGenerator(config).GenerateKnowledgeBase(context.Background()); err != nil {
+		t.Fatalf("esperava sem erro na execução incremental, obteve: %v", err)
+	}
+
+	var incidents int
+	db.QueryRow(`SELECT COUNT(*) FROM curated_incidents`).Scan(&incidents)
+	if incidents != 2 {
+		t.Errorf("esperava os 2 reports em curated_incidents, obteve: %d", incidents)
+	}
+}
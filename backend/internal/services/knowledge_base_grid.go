@@ -0,0 +1,295 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/geo"
+)
+
+// Bounding box de Campinas, a área padrão da grade e a usada na validação dos bairros
+const (
+	campinasMinLat = -23.1
+	campinasMaxLat = -22.7
+	campinasMinLon = -47.3
+	campinasMaxLon = -46.8
+)
+
+// DefaultGridArea é a área coberta pela grade quando GRID_BBOX não é configurado
+var DefaultGridArea = BoundingBox{
+	MinLat: campinasMinLat, MinLon: campinasMinLon,
+	MaxLat: campinasMaxLat, MaxLon: campinasMaxLon,
+}
+
+// GridArea lê a área da grade de GRID_BBOX (min_lon,min_lat,max_lon,max_lat);
+// vazio retorna DefaultGridArea
+func GridArea(value string) (BoundingBox, error) {
+	if value == "" {
+		return DefaultGridArea, nil
+	}
+	return ParseBoundingBox(value)
+}
+
+// metersPerDegree é a aproximação usada para converter distâncias em graus
+const metersPerDegree = 111000.0
+
+// metersToDegrees converte metros em graus de longitude na latitude mais
+// afastada do equador da área, o que cobre também os graus de latitude
+func metersToDegrees(meters float64, area BoundingBox) float64 {
+	lat := math.Max(math.Abs(area.MinLat), math.Abs(area.MaxLat))
+	return meters / (metersPerDegree * math.Cos(lat*math.Pi/180))
+}
+
+// Resoluções H3 aceitas pelo pipeline. Em cell_resolution, valores até
+// h3MaxIndexResolution são resoluções H3; acima disso, o lado da célula quadrada
+// em metros. Abaixo de 5 uma célula cobre quase a cidade inteira e acima de 10
+// a grade passa de 150 mil células.
+const (
+	H3MinResolution = 5
+	H3MaxResolution = 10
+
+	h3MaxIndexResolution = 15
+)
+
+// Limites do lado da célula da grade quadrada, em metros. Em 100m a grade de
+// Campinas tem ~250 mil células.
+const (
+	MinSquareResolution = 100
+	MaxSquareResolution = 20000
+)
+
+// ErrInvalidCellResolution indica uma resolução que não corresponde a nenhuma grade
+var ErrInvalidCellResolution = errors.New("resolução de célula inválida: use 100 a 20000 (metros) ou h3:5 a h3:10")
+
+// gridCell é uma célula da grade com o centro e o contorno (lon/lat)
+type gridCell struct {
+	id       string
+	lat, lon float64
+	polygon  orb.Polygon
+}
+
+// cellGrid é um esquema de células sobre Campinas: a grade quadrada
+// (spatialGrid) ou a grade hexagonal H3 (h3Grid). O pipeline, as camadas e as
+// previsões usam só esta interface, então os dois esquemas geram curated_cells,
+// a atribuição dos incidentes e as features da mesma forma.
+type cellGrid interface {
+	// each percorre as células da grade em uma ordem estável, parando no primeiro erro
+	each(fn func(c gridCell) error) error
+	// locate retorna a célula que contém o ponto; false fora da grade
+	locate(lat, lon float64) (string, bool)
+	// neighbors retorna os IDs das células vizinhas que existem na grade
+	neighbors(cellID string) []string
+	// margin é a distância (em graus) que uma célula pode avançar além do seu centro
+	margin() float64
+}
+
+// newCellGrid cria a grade de uma cell_resolution sobre a área
+func newCellGrid(resolution int, area BoundingBox) (cellGrid, error) {
+	if isH3Resolution(resolution) {
+		if resolution < H3MinResolution || resolution > H3MaxResolution {
+			return nil, ErrInvalidCellResolution
+		}
+		return newH3Grid(resolution, area)
+	}
+	if resolution < MinSquareResolution || resolution > MaxSquareResolution {
+		return nil, ErrInvalidCellResolution
+	}
+	return newSpatialGrid(resolution, area), nil
+}
+
+// isH3Resolution indica se a cell_resolution é de uma grade H3
+func isH3Resolution(resolution int) bool {
+	return resolution >= 0 && resolution <= h3MaxIndexResolution
+}
+
+// cellIDPrefix é o prefixo dos IDs das células de uma resolução, usado nos
+// filtros por LIKE: UTM23S-<metros>- na grade quadrada e H3-<resolução>- na H3
+func cellIDPrefix(resolution int) string {
+	if isH3Resolution(resolution) {
+		return fmt.Sprintf("H3-%d-", resolution)
+	}
+	return fmt.Sprintf("UTM%dS-%d-", gridZone.Number, resolution)
+}
+
+// ParseCellResolution lê a resolução da grade no formato dos parâmetros da
+// API: o lado da célula em metros ("250", "2000") para a grade quadrada e
+// "h3:8" para a grade H3. Retorna o valor gravado em cell_resolution.
+func ParseCellResolution(value string) (int, error) {
+	if res, ok := strings.CutPrefix(strings.ToLower(value), "h3:"); ok {
+		n, err := strconv.Atoi(res)
+		if err != nil || n < H3MinResolution || n > H3MaxResolution {
+			return 0, ErrInvalidCellResolution
+		}
+		return n, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < MinSquareResolution || n > MaxSquareResolution {
+		return 0, ErrInvalidCellResolution
+	}
+	return n, nil
+}
+
+// FormatCellResolution descreve a cell_resolution para logs e respostas
+// ("1000m" ou "h3:8")
+func FormatCellResolution(resolution int) string {
+	if isH3Resolution(resolution) {
+		return fmt.Sprintf("h3:%d", resolution)
+	}
+	return fmt.Sprintf("%dm", resolution)
+}
+
+// polygonJSON serializa o contorno de uma célula como um Polygon GeoJSON, o
+// formato gravado em curated_cells.bounds_json
+func polygonJSON(p orb.Polygon) string {
+	data, _ := json.Marshal(geojson.NewGeometry(p))
+	return string(data)
+}
+
+// gridZone é o fuso UTM em que a grade quadrada é projetada
+var gridZone = geo.UTM23S
+
+// spatialGrid é a grade de células quadradas de resolution metros no plano UTM
+// 23S. As células seguem a malha fixa do fuso: a coluna é easting/resolução e
+// a linha northing/resolução, então o ID UTM23S-<resolução>-E<coluna>-N<linha>
+// depende só da posição da célula, não da área nem da ordem de geração. A
+// grade tem as colunas e linhas que cobrem a área configurada.
+type spatialGrid struct {
+	resolution     int
+	area           BoundingBox
+	minCol, maxCol int
+	minRow, maxRow int
+}
+
+// gridEdgeSamples é o número de pontos por borda usados para envolver a área
+// em UTM: as bordas em lat/lon viram curvas no plano projetado
+const gridEdgeSamples = 16
+
+func newSpatialGrid(resolution int, area BoundingBox) spatialGrid {
+	minE, minN := math.Inf(1), math.Inf(1)
+	maxE, maxN := math.Inf(-1), math.Inf(-1)
+	for k := 0; k <= gridEdgeSamples; k++ {
+		f := float64(k) / gridEdgeSamples
+		lat := area.MinLat + f*(area.MaxLat-area.MinLat)
+		lon := area.MinLon + f*(area.MaxLon-area.MinLon)
+		for _, p := range [][2]float64{{area.MinLat, lon}, {area.MaxLat, lon}, {lat, area.MinLon}, {lat, area.MaxLon}} {
+			e, n := gridZone.Forward(p[0], p[1])
+			minE, maxE = math.Min(minE, e), math.Max(maxE, e)
+			minN, maxN = math.Min(minN, n), math.Max(maxN, n)
+		}
+	}
+
+	res := float64(resolution)
+	return spatialGrid{
+		resolution: resolution,
+		area:       area,
+		minCol:     int(math.Floor(minE / res)),
+		maxCol:     int(math.Floor(maxE / res)),
+		minRow:     int(math.Floor(minN / res)),
+		maxRow:     int(math.Floor(maxN / res)),
+	}
+}
+
+// size retorna o total de células da grade
+func (g spatialGrid) size() int {
+	return (g.maxCol - g.minCol + 1) * (g.maxRow - g.minRow + 1)
+}
+
+// contains indica se a coluna e a linha estão na grade
+func (g spatialGrid) contains(col, row int) bool {
+	return col >= g.minCol && col <= g.maxCol && row >= g.minRow && row <= g.maxRow
+}
+
+// cellID retorna o ID da célula na coluna e linha
+func (g spatialGrid) cellID(col, row int) string {
+	return fmt.Sprintf("%sE%d-N%d", cellIDPrefix(g.resolution), col, row)
+}
+
+// center retorna o centro da célula na coluna e linha
+func (g spatialGrid) center(col, row int) (lat, lon float64) {
+	res := float64(g.resolution)
+	return gridZone.Inverse((float64(col)+0.5)*res, (float64(row)+0.5)*res)
+}
+
+// bounds retorna o quadrado da célula (lon/lat, sentido anti-horário)
+func (g spatialGrid) bounds(col, row int) orb.Polygon {
+	res := float64(g.resolution)
+	minE, minN := float64(col)*res, float64(row)*res
+	ring := make(orb.Ring, 0, 5)
+	for _, c := range [][2]float64{{minE, minN}, {minE + res, minN}, {minE + res, minN + res}, {minE, minN + res}, {minE, minN}} {
+		lat, lon := gridZone.Inverse(c[0], c[1])
+		ring = append(ring, orb.Point{lon, lat})
+	}
+	return orb.Polygon{ring}
+}
+
+// each percorre as células coluna a coluna
+func (g spatialGrid) each(fn func(c gridCell) error) error {
+	for col := g.minCol; col <= g.maxCol; col++ {
+		for row := g.minRow; row <= g.maxRow; row++ {
+			lat, lon := g.center(col, row)
+			if err := fn(gridCell{g.cellID(col, row), lat, lon, g.bounds(col, row)}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// locate calcula diretamente a célula que contém o ponto, sem percorrer a grade.
+// Retorna false se o ponto estiver fora da área.
+func (g spatialGrid) locate(lat, lon float64) (string, bool) {
+	if !g.area.Contains(lat, lon) {
+		return "", false
+	}
+	e, n := gridZone.Forward(lat, lon)
+	col := int(math.Floor(e / float64(g.resolution)))
+	row := int(math.Floor(n / float64(g.resolution)))
+	if !g.contains(col, row) {
+		return "", false
+	}
+	return g.cellID(col, row), true
+}
+
+// indices converte o ID de uma célula desta grade de volta em coluna e linha
+func (g spatialGrid) indices(cellID string) (col, row int, ok bool) {
+	rest, ok := strings.CutPrefix(cellID, cellIDPrefix(g.resolution))
+	if !ok {
+		return 0, 0, false
+	}
+	if _, err := fmt.Sscanf(rest, "E%d-N%d", &col, &row); err != nil ||
+		g.cellID(col, row) != cellID || !g.contains(col, row) {
+		return 0, 0, false
+	}
+	return col, row, true
+}
+
+// neighbors retorna os IDs das até 8 células vizinhas que existem na grade
+func (g spatialGrid) neighbors(cellID string) []string {
+	col, row, ok := g.indices(cellID)
+	if !ok {
+		return nil
+	}
+
+	result := make([]string, 0, 8)
+	for dc := -1; dc <= 1; dc++ {
+		for dr := -1; dr <= 1; dr++ {
+			if (dc == 0 && dr == 0) || !g.contains(col+dc, row+dr) {
+				continue
+			}
+			result = append(result, g.cellID(col+dc, row+dr))
+		}
+	}
+	return result
+}
+
+// margin é a meia diagonal da célula, em graus
+func (g spatialGrid) margin() float64 {
+	return metersToDegrees(float64(g.resolution)*math.Sqrt2/2, g.area)
+}
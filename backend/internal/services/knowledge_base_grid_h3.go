@@ -0,0 +1,135 @@
+//go:build cgo
+
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/paulmach/orb"
+	h3 "github.com/uber/h3-go/v4"
+)
+
+// h3Grid é a grade de hexágonos H3 de uma resolução sobre a área. Pertencem
+// à grade as células cujo centro cai na área expandida por margin(), o que
+// garante que todo ponto da área tem a sua célula na grade. O ID da célula é
+// H3-<resolução>-<índice H3>.
+type h3Grid struct {
+	resolution int
+	area       BoundingBox
+	box        orb.Bound // área onde ficam os centros das células da grade
+	marginDeg  float64
+}
+
+func newH3Grid(resolution int, area BoundingBox) (cellGrid, error) {
+	// O raio de um hexágono é o comprimento da aresta; a margem de duas arestas
+	// cobre a variação de tamanho entre as células da mesma resolução
+	margin := metersToDegrees(2*h3.HexagonEdgeLengthAvgM(resolution), area)
+	bound := orb.Bound{Min: orb.Point{area.MinLon, area.MinLat}, Max: orb.Point{area.MaxLon, area.MaxLat}}
+	return h3Grid{
+		resolution: resolution,
+		area:       area,
+		box:        bound.Pad(margin),
+		marginDeg:  margin,
+	}, nil
+}
+
+func (g h3Grid) cellID(c h3.Cell) string {
+	return fmt.Sprintf("H3-%d-%s", g.resolution, c.String())
+}
+
+// cell converte o ID de volta no índice H3, conferindo a resolução
+func (g h3Grid) cell(cellID string) (h3.Cell, bool) {
+	index, ok := strings.CutPrefix(cellID, cellIDPrefix(g.resolution))
+	if !ok {
+		return 0, false
+	}
+	c := h3.Cell(h3.IndexFromString(index))
+	if !c.IsValid() || c.Resolution() != g.resolution {
+		return 0, false
+	}
+	return c, true
+}
+
+// contains indica se a célula pertence à grade
+func (g h3Grid) contains(c h3.Cell) bool {
+	center := c.LatLng()
+	return g.box.Contains(orb.Point{center.Lng, center.Lat})
+}
+
+// each percorre as células em ordem de ID. O polígono enviado ao H3 é a área
+// da grade com mais uma margem, já que ele só devolve células com o centro
+// dentro do polígono e as suas arestas são arcos de círculo máximo.
+func (g h3Grid) each(fn func(c gridCell) error) error {
+	area := g.box.Pad(g.marginDeg)
+	loop := h3.GeoLoop{
+		{Lat: area.Min.Lat(), Lng: area.Min.Lon()},
+		{Lat: area.Min.Lat(), Lng: area.Max.Lon()},
+		{Lat: area.Max.Lat(), Lng: area.Max.Lon()},
+		{Lat: area.Max.Lat(), Lng: area.Min.Lon()},
+	}
+
+	var ids []string
+	cells := make(map[string]h3.Cell)
+	for _, c := range h3.PolygonToCells(h3.GeoPolygon{GeoLoop: loop}, g.resolution) {
+		if g.contains(c) {
+			id := g.cellID(c)
+			ids = append(ids, id)
+			cells[id] = c
+		}
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		c := cells[id]
+		center := c.LatLng()
+		if err := fn(gridCell{id, center.Lat, center.Lng, h3Polygon(c)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// locate retorna o hexágono que contém o ponto; false fora da área
+func (g h3Grid) locate(lat, lon float64) (string, bool) {
+	if !g.area.Contains(lat, lon) {
+		return "", false
+	}
+	c := h3.LatLngToCell(h3.NewLatLng(lat, lon), g.resolution)
+	if !g.contains(c) {
+		return "", false
+	}
+	return g.cellID(c), true
+}
+
+// neighbors retorna os até 6 hexágonos vizinhos que existem na grade
+func (g h3Grid) neighbors(cellID string) []string {
+	c, ok := g.cell(cellID)
+	if !ok {
+		return nil
+	}
+
+	result := make([]string, 0, 6)
+	for _, n := range c.GridDisk(1) {
+		if n != c && g.contains(n) {
+			result = append(result, g.cellID(n))
+		}
+	}
+	return result
+}
+
+func (g h3Grid) margin() float64 {
+	return g.marginDeg
+}
+
+// h3Polygon converte o contorno da célula em um anel fechado (lon/lat)
+func h3Polygon(c h3.Cell) orb.Polygon {
+	boundary := c.Boundary()
+	ring := make(orb.Ring, 0, len(boundary)+1)
+	for _, v := range boundary {
+		ring = append(ring, orb.Point{v.Lng, v.Lat})
+	}
+	ring = append(ring, ring[0])
+	return orb.Polygon{ring}
+}
@@ -0,0 +1,11 @@
+//go:build !cgo
+
+package services
+
+import "errors"
+
+// O pacote do H3 é uma ligação em C: sem cgo (como na imagem Docker, compilada
+// com CGO_ENABLED=0) só a grade quadrada está disponível
+func newH3Grid(int, BoundingBox) (cellGrid, error) {
+	return nil, errors.New("grade H3 indisponível: o servidor foi compilado sem cgo")
+}
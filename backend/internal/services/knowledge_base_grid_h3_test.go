@@ -0,0 +1,148 @@
+//go:build cgo
+
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+func TestH3Grid(t *testing.T) {
+	grid, err := newCellGrid(8, DefaultGridArea)
+	if err != nil {
+		t.Fatalf("esperava grade H3, obteve: %v", err)
+	}
+
+	ids := make(map[string]bool)
+	polygons := make(map[string]orb.Polygon)
+	err = grid.each(func(c gridCell) error {
+		if ids[c.id] {
+			t.Fatalf("célula repetida: %s", c.id)
+		}
+		ids[c.id] = true
+		polygons[c.id] = c.polygon
+		if !strings.HasPrefix(c.id, "H3-8-") || len(c.polygon[0]) != 7 {
+			t.Fatalf("esperava hexágono H3-8-*, obteve: %s com %d vértices", c.id, len(c.polygon[0]))
+		}
+		if got, ok := grid.locate(c.lat, c.lon); DefaultGridArea.Contains(c.lat, c.lon) && (!ok || got != c.id) {
+			t.Fatalf("centro de %s localizado em %q (ok=%t)", c.id, got, ok)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if len(ids) < 2000 || len(ids) > 5000 {
+		t.Errorf("esperava uns 3 mil hexágonos na resolução 8, obteve: %d", len(ids))
+	}
+
+	// Todo ponto da bounding box, inclusive os cantos, cai numa célula da grade
+	for _, p := range []orb.Point{
+		{campinasMinLon, campinasMinLat}, {campinasMaxLon, campinasMaxLat},
+		{campinasMinLon, campinasMaxLat}, {-47.0608, -22.9056},
+	} {
+		id, ok := grid.locate(p.Lat(), p.Lon())
+		if !ok || !ids[id] {
+			t.Errorf("esperava %v numa célula da grade, obteve: %q (ok=%t)", p, id, ok)
+			continue
+		}
+		if !planar.PolygonContains(polygons[id], p) {
+			t.Errorf("esperava o hexágono %s em volta de %v", id, p)
+		}
+	}
+	if id, ok := grid.locate(-23.5505, -46.6333); ok {
+		t.Errorf("esperava São Paulo fora da grade, obteve: %q", id)
+	}
+
+	// No centro da cidade todo hexágono tem 6 vizinhos, e a vizinhança é simétrica
+	center, _ := grid.locate(-22.9056, -47.0608)
+	neighbors := grid.neighbors(center)
+	if len(neighbors) != 6 {
+		t.Fatalf("esperava 6 vizinhos, obteve: %d", len(neighbors))
+	}
+	for _, n := range neighbors {
+		if !ids[n] || !strings.Contains(strings.Join(grid.neighbors(n), ","), center) {
+			t.Errorf("esperava %s vizinho de %s e na grade", n, center)
+		}
+	}
+	if grid.neighbors("CAMP-1000-1") != nil {
+		t.Errorf("esperava sem vizinhos para um ID de outra grade")
+	}
+}
+
+// TestGenerateKnowledgeBase_H3 executa o pipeline com a grade H3 e confere
+// que células, atribuição e features seguem o mesmo caminho da grade quadrada
+func TestGenerateKnowledgeBase_H3(t *testing.T) {
+	gdb, db := setupSQLiteDB(t)
+
+	centro := models.Neighborhood{Name: "Centro", Latitude: -22.9056, Longitude: -47.0608, NeighborhoodWeight: 3}
+	furto := models.Crime{CrimeName: "Furto", CrimeWeight: 3}
+	if err := gdb.Create(&centro).Error; err != nil {
+		t.Fatalf("falha ao inserir bairro: %v", err)
+	}
+	if err := gdb.Create(&furto).Error; err != nil {
+		t.Fatalf("falha ao inserir crime: %v", err)
+	}
+	for _, date := range []string{"2024-01-10 10:00:00", "2024-02-15 22:00:00"} {
+		if err := gdb.Exec(
+			`INSERT INTO reports (neighborhood_id, crime_id, report_date, occurred_at, created_at, updated_at)
+			 VALUES (?, ?, ?, ?, ?, ?)`,
+			centro.NeighborhoodID, furto.CrimeID, date, occurredAt(t, date), time.Now(), time.Now(),
+		).Error; err != nil {
+			t.Fatalf("falha ao inserir report: %v", err)
+		}
+	}
+
+	dialect, _ := NewKnowledgeBaseDialect("sqlite")
+	config := &KnowledgeBaseConfig{
+		SourceDB:           db,
+		TargetDB:           db,
+		Dialect:            dialect,
+		CellResolution:     7,
+		BatchSize:          50,
+		StartDate:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
+		EndDate:            time.Date(2024, 3, 31, 0, 0, 0, 0, time.Local),
+		SkipHourlyFeatures: true,
+	}
+	if err := NewKnowledgeBaseGenerator(config).GenerateKnowledgeBase(context.Background()); err != nil {
+		t.Fatalf("esperava sem erro no pipeline, obteve: %v", err)
+	}
+
+	grid, _ := newCellGrid(7, DefaultGridArea)
+	total := 0
+	grid.each(func(gridCell) error { total++; return nil })
+
+	var cells, hexagons int
+	db.QueryRow(`SELECT COUNT(*) FROM curated_cells WHERE cell_resolution = 7`).Scan(&cells)
+	db.QueryRow(`SELECT COUNT(*) FROM curated_cells WHERE cell_resolution = 7 AND bounds_json LIKE '%Polygon%'`).Scan(&hexagons)
+	if cells != total || hexagons != total {
+		t.Errorf("esperava %d hexágonos com contorno, obteve: %d (%d com contorno)", total, cells, hexagons)
+	}
+
+	want, _ := grid.locate(-22.9056, -47.0608)
+	var cellID string
+	var resolution int
+	db.QueryRow(`SELECT cell_id, cell_resolution FROM curated_incidents LIMIT 1`).Scan(&cellID, &resolution)
+	if cellID != want || resolution != 7 {
+		t.Errorf("esperava incidente em %s (h3:7), obteve: %s (%d)", want, cellID, resolution)
+	}
+
+	var febCount int
+	db.QueryRow(`SELECT y_count_month FROM features_cell_monthly WHERE cell_id = ? AND year = 2024 AND month = 2`, want).Scan(&febCount)
+	if febCount != 1 {
+		t.Errorf("esperava 1 incidente em fev/2024 no hexágono, obteve: %d", febCount)
+	}
+
+	var neighborhood string
+	db.QueryRow(`SELECT neighborhood FROM cell_neighborhoods WHERE cell_id = ?`, want).Scan(&neighborhood)
+	if neighborhood != "Centro" {
+		t.Errorf("esperava o hexágono mapeado para o Centro, obteve: %q", neighborhood)
+	}
+}
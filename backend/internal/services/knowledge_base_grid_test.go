@@ -0,0 +1,106 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/geo"
+)
+
+func TestSpatialGrid_UTM(t *testing.T) {
+	grid := newSpatialGrid(1000, DefaultGridArea)
+
+	// Centro de Campinas fica em E 288627, N 7465450 no fuso 23S
+	id, ok := grid.locate(-22.9056, -47.0608)
+	if !ok || id != "UTM23S-1000-E288-N7465" {
+		t.Fatalf("esperava UTM23S-1000-E288-N7465, obteve: %q (ok=%t)", id, ok)
+	}
+
+	// As células são quadrados de 1km no plano UTM
+	col, row, ok := grid.indices(id)
+	if !ok || col != 288 || row != 7465 {
+		t.Fatalf("esperava coluna 288 e linha 7465, obteve: %d, %d (ok=%t)", col, row, ok)
+	}
+	polygon := grid.bounds(col, row)
+	for k := 0; k < 4; k++ {
+		a, b := polygon[0][k], polygon[0][k+1]
+		ea, na := geo.UTM23S.Forward(a.Lat(), a.Lon())
+		eb, nb := geo.UTM23S.Forward(b.Lat(), b.Lon())
+		if side := math.Hypot(eb-ea, nb-na); math.Abs(side-1000) > 0.01 {
+			t.Errorf("esperava lado de 1000m, obteve: %.3f", side)
+		}
+	}
+	lat, lon := grid.center(col, row)
+	if !planar.PolygonContains(polygon, orb.Point{lon, lat}) {
+		t.Errorf("esperava o centro (%f, %f) dentro da célula", lat, lon)
+	}
+
+	// Todo ponto da área, inclusive os cantos, tem a sua célula na grade
+	cells := make(map[string]bool)
+	grid.each(func(c gridCell) error { cells[c.id] = true; return nil })
+	if len(cells) != grid.size() {
+		t.Errorf("esperava %d células, obteve: %d", grid.size(), len(cells))
+	}
+	for _, p := range [][2]float64{{campinasMinLat, campinasMinLon}, {campinasMaxLat, campinasMaxLon}, {campinasMinLat, campinasMaxLon}, {campinasMaxLat, campinasMinLon}} {
+		if id, ok := grid.locate(p[0], p[1]); !ok || !cells[id] {
+			t.Errorf("esperava o canto %v numa célula da grade, obteve: %q", p, id)
+		}
+	}
+	for _, p := range [][2]float64{{-23.2, -47.0}, {-22.9, -47.4}, {-22.0, -47.0}} {
+		if id, ok := grid.locate(p[0], p[1]); ok {
+			t.Errorf("esperava ponto %v fora da grade, obteve: %q", p, id)
+		}
+	}
+
+	if n := grid.neighbors(id); len(n) != 8 || n[0] != "UTM23S-1000-E287-N7464" {
+		t.Errorf("esperava 8 vizinhos a partir de E287-N7464, obteve: %v", n)
+	}
+	for _, bad := range []string{"CAMP-1000-1", "UTM23S-1000-E288-N1", "UTM23S-1000-E0288-N7465", "UTM23S-500-E288-N7465"} {
+		if _, _, ok := grid.indices(bad); ok {
+			t.Errorf("esperava %s fora da grade", bad)
+		}
+	}
+}
+
+func TestSpatialGrid_Resolutions(t *testing.T) {
+	// O ID depende só da posição: a mesma célula em duas áreas tem o mesmo ID
+	small := BoundingBox{MinLat: -22.95, MinLon: -47.1, MaxLat: -22.85, MaxLon: -47.0}
+	for _, resolution := range []int{250, 2000} {
+		grid, err := newCellGrid(resolution, DefaultGridArea)
+		if err != nil {
+			t.Fatalf("%dm: esperava grade, obteve: %v", resolution, err)
+		}
+		inner := newSpatialGrid(resolution, small)
+		a, _ := grid.locate(-22.9056, -47.0608)
+		b, _ := inner.locate(-22.9056, -47.0608)
+		if a == "" || a != b {
+			t.Errorf("%dm: esperava o mesmo ID nas duas áreas, obteve: %q e %q", resolution, a, b)
+		}
+		if inner.size() >= grid.(spatialGrid).size() {
+			t.Errorf("%dm: esperava menos células na área menor", resolution)
+		}
+	}
+}
+
+func TestParseCellResolution(t *testing.T) {
+	cases := map[string]int{"500": 500, "1000": 1000, "250": 250, "2000": 2000, "h3:8": 8, "H3:5": 5}
+	for value, want := range cases {
+		if got, err := ParseCellResolution(value); err != nil || got != want {
+			t.Errorf("%s: esperava %d, obteve: %d (%v)", value, want, got, err)
+		}
+	}
+	for _, value := range []string{"", "50", "8", "25000", "h3:4", "h3:11", "h3:", "abc"} {
+		if _, err := ParseCellResolution(value); err == nil {
+			t.Errorf("%s: esperava erro de resolução inválida", value)
+		}
+	}
+	if FormatCellResolution(500) != "500m" || FormatCellResolution(8) != "h3:8" {
+		t.Errorf("esperava 500m e h3:8, obteve: %s e %s", FormatCellResolution(500), FormatCellResolution(8))
+	}
+	if _, err := newCellGrid(50, DefaultGridArea); err == nil {
+		t.Errorf("esperava erro para uma grade quadrada de 50m")
+	}
+}
@@ -0,0 +1,452 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// FASE 4: FEATURES TEMPORAIS (HORÁRIAS)
+// ============================================================================
+//
+// As features são calculadas em Go a partir dos incidentes já atribuídos às
+// células, em vez do antigo MERGE com produto cartesiano células x horas:
+//
+//   - só entram células com atividade (algum incidente na janela carregada);
+//   - uma hora é gravada quando há ocorrência, quando a janela de 7 dias ou os
+//     vizinhos têm ocorrências, e fora isso uma linha zerada esparsa por célula
+//     e dia (hourlyZeroRowStride), para o modelo também ver horas sem crime;
+//   - cada dia é um bloco independente (DELETE + INSERT em uma transação),
+//     processado em paralelo por KnowledgeBaseConfig.Workers goroutines.
+//
+// Definições (a hora corrente nunca entra nas janelas):
+//   - lag_1h, lag_24h, lag_7d: ocorrências 1h, 24h e 168h antes;
+//   - roll_3h_sum, roll_24h_sum, roll_7d_sum: soma das 3, 24 e 168 horas anteriores;
+//   - roll_7d_avg, roll_7d_std: média e desvio padrão (populacional) dessas 168 horas;
+//   - dow (0=domingo), hour, is_weekend, is_business_hours (8h às 18h) e os
+//     feriados no fuso de StartDate;
+//   - neighbor_avg_crime: média do roll_24h_sum das células vizinhas (8 na
+//     grade quadrada, 6 na H3).
+
+const (
+	hoursPerWeek = 7 * 24
+
+	// hourlyZeroRowStride é o intervalo (em horas) entre as linhas zeradas
+	// gravadas para uma célula sem ocorrências recentes
+	hourlyZeroRowStride = 24
+
+	// defaultHourlyWorkers é o número padrão de dias processados em paralelo
+	defaultHourlyWorkers = 4
+
+	hourlyColumns = "cell_id, ts, y_count, lag_1h, lag_24h, lag_7d, roll_3h_sum, roll_24h_sum, roll_7d_sum, " +
+		"roll_7d_avg, roll_7d_std, dow, hour, is_weekend, is_business_hours, holiday, day_before_holiday, " +
+		"day_after_holiday, neighbor_avg_crime, created_at, updated_at"
+	hourlyParamsPerRow = 21
+)
+
+// hourlyDay é um bloco de horas [start, end) processado de uma vez
+type hourlyDay struct {
+	start, end time.Time
+}
+
+// hourlyDayResult é o resultado de um bloco, devolvido à goroutine do pipeline
+type hourlyDayResult struct {
+	day  hourlyDay
+	rows int
+	err  error
+}
+
+// hourlyInput são os dados compartilhados (somente leitura) entre os workers
+type hourlyInput struct {
+	grid      cellGrid
+	loc       *time.Location
+	cells     []string           // células com atividade, ordenadas
+	incidents map[string][]int64 // célula → horas (Unix/3600) dos incidentes, ordenadas
+	neighbors map[string][]string
+	holidays  map[string]bool // "2006-01-02"
+}
+
+func (kg *KnowledgeBaseGenerator) generateTemporalFeatures(ctx context.Context, db *sql.DB) (int, error) {
+	loc := kg.config.StartDate.Location()
+	start := kg.config.StartDate.Truncate(time.Hour)
+	end := kg.config.EndDate
+
+	days := splitHourlyDays(start, end, loc)
+	if kg.watermark != nil {
+		days = kg.affectedHourlyDays(days, loc)
+		if len(days) == 0 {
+			kg.logger.Println("✅ Nenhuma célula alterada desde a última execução: features horárias mantidas")
+			return 0, nil
+		}
+	}
+	if len(days) == 0 {
+		return 0, nil
+	}
+
+	input, err := kg.loadHourlyInput(ctx, db, days[0].start, days[len(days)-1].end, loc)
+	if err != nil {
+		return 0, err
+	}
+	kg.logger.Printf("  ➜ %d células com atividade, %d dias a processar", len(input.cells), len(days))
+
+	workers := kg.config.Workers
+	if workers <= 0 {
+		workers = defaultHourlyWorkers
+	}
+	if limit := kg.dialect.MaxWriters(); limit > 0 && workers > limit {
+		workers = limit
+	}
+	if workers > len(days) {
+		workers = len(days)
+	}
+
+	workCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan hourlyDay)
+	results := make(chan hourlyDayResult)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for day := range jobs {
+				rows, err := kg.writeHourlyDay(workCtx, db, input, day)
+				results <- hourlyDayResult{day: day, rows: rows, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, day := range days {
+			select {
+			case jobs <- day:
+			case <-workCtx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Progresso é emitido aqui, na goroutine do pipeline. O primeiro dia com
+	// erro falha a fase, para que a marca d'água não avance e a próxima
+	// execução incremental recalcule o dia.
+	total, daysProcessed := 0, 0
+	var firstErr error
+	for res := range results {
+		if res.err != nil {
+			if ctx.Err() != nil || firstErr != nil {
+				continue
+			}
+			kg.logger.Printf("❌ Erro ao processar dia %s: %v", res.day.start.Format("2006-01-02"), res.err)
+			firstErr = fmt.Errorf("features horárias do dia %s: %v", res.day.start.Format("2006-01-02"), res.err)
+			cancel()
+			continue
+		}
+		total += res.rows
+		daysProcessed++
+		kg.progress.RowsProcessed(res.rows)
+	}
+	if err := ctx.Err(); err != nil {
+		return total, err
+	}
+	if firstErr != nil {
+		return total, firstErr
+	}
+
+	kg.logger.Printf("✅ Features temporais geradas para %d dias (%d registros)", daysProcessed, total)
+	return total, nil
+}
+
+// splitHourlyDays divide [start, end) em blocos que terminam à meia-noite local
+func splitHourlyDays(start, end time.Time, loc *time.Location) []hourlyDay {
+	var days []hourlyDay
+	for day := start; day.Before(end); {
+		local := day.In(loc)
+		next := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, loc)
+		if next.After(end) {
+			next = end.Truncate(time.Hour)
+			if !next.After(day) {
+				break
+			}
+		}
+		days = append(days, hourlyDay{start: day, end: next})
+		day = next
+	}
+	return days
+}
+
+// affectedHourlyDays mantém apenas os dias que podem ter mudado em uma execução
+// incremental: dos meses afetados até 7 dias depois deles (alcance do lag_7d)
+func (kg *KnowledgeBaseGenerator) affectedHourlyDays(days []hourlyDay, loc *time.Location) []hourlyDay {
+	if len(kg.affected) == 0 {
+		return nil
+	}
+
+	var from, to time.Time
+	for _, r := range kg.affected {
+		first := time.Date(r.first.Year(), r.first.Month(), 1, 0, 0, 0, 0, loc)
+		last := time.Date(r.last.Year(), r.last.Month()+1, 1, 0, 0, 0, 0, loc).AddDate(0, 0, 7)
+		if from.IsZero() || first.Before(from) {
+			from = first
+		}
+		if last.After(to) {
+			to = last
+		}
+	}
+
+	var result []hourlyDay
+	for _, day := range days {
+		if day.end.After(from) && day.start.Before(to) {
+			result = append(result, day)
+		}
+	}
+	return result
+}
+
+// loadHourlyInput carrega os incidentes de [start-7d, end) e os feriados
+func (kg *KnowledgeBaseGenerator) loadHourlyInput(ctx context.Context, db *sql.DB, start, end time.Time, loc *time.Location) (*hourlyInput, error) {
+	grid, err := kg.cellGrid()
+	if err != nil {
+		return nil, err
+	}
+	input := &hourlyInput{
+		grid:      grid,
+		loc:       loc,
+		incidents: make(map[string][]int64),
+		neighbors: make(map[string][]string),
+		holidays:  make(map[string]bool),
+	}
+
+	windowStart := start.Add(-hoursPerWeek * time.Hour)
+	query := kg.dialect.Rebind(`
+		SELECT cell_id, occurred_at
+		FROM curated_incidents
+		WHERE cell_id IS NOT NULL AND cell_resolution = ?
+		  AND occurred_at >= ? AND occurred_at < ?
+	`)
+	rows, err := db.QueryContext(ctx, query, kg.config.CellResolution, windowStart, end)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar incidentes: %v", err)
+	}
+	for rows.Next() {
+		var cellID string
+		var occurredAt time.Time
+		if err := rows.Scan(&cellID, &occurredAt); err != nil {
+			continue
+		}
+		if occurredAt.Before(windowStart) || !occurredAt.Before(end) {
+			continue
+		}
+		input.incidents[cellID] = append(input.incidents[cellID], hourIndex(occurredAt))
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler incidentes: %v", err)
+	}
+
+	for cellID, hours := range input.incidents {
+		sort.Slice(hours, func(a, b int) bool { return hours[a] < hours[b] })
+		input.cells = append(input.cells, cellID)
+		input.neighbors[cellID] = input.grid.neighbors(cellID)
+	}
+	sort.Strings(input.cells)
+
+	holidayRows, err := db.QueryContext(ctx, `SELECT date FROM external_holidays`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar feriados: %v", err)
+	}
+	defer func() {
+		if err := holidayRows.Close(); err != nil {
+			kg.logger.Printf("⚠️  Erro ao fechar rows: %v", err)
+		}
+	}()
+	for holidayRows.Next() {
+		var date time.Time
+		if err := holidayRows.Scan(&date); err != nil {
+			continue
+		}
+		input.holidays[date.Format("2006-01-02")] = true
+	}
+
+	return input, holidayRows.Err()
+}
+
+// hourIndex numera as horas absolutas (Unix/3600), independente do fuso
+func hourIndex(t time.Time) int64 {
+	sec := t.Unix()
+	if sec < 0 && sec%3600 != 0 {
+		return sec/3600 - 1
+	}
+	return sec / 3600
+}
+
+// hourlyWindow guarda as contagens por hora de uma célula em [base, end) e as
+// somas acumuladas usadas nas janelas móveis
+type hourlyWindow struct {
+	counts []int
+	sum    []int // sum[x] = counts[0] + ... + counts[x-1]
+	sumSq  []int
+}
+
+func newHourlyWindow(hours []int64, base int64, length int) *hourlyWindow {
+	w := &hourlyWindow{
+		counts: make([]int, length),
+		sum:    make([]int, length+1),
+		sumSq:  make([]int, length+1),
+	}
+
+	first := sort.Search(len(hours), func(i int) bool { return hours[i] >= base })
+	for _, h := range hours[first:] {
+		if h >= base+int64(length) {
+			break
+		}
+		w.counts[h-base]++
+	}
+	for x, c := range w.counts {
+		w.sum[x+1] = w.sum[x] + c
+		w.sumSq[x+1] = w.sumSq[x] + c*c
+	}
+	return w
+}
+
+// rolling soma as n horas anteriores a x (sem incluir x)
+func (w *hourlyWindow) rolling(x, n int) int {
+	return w.sum[x] - w.sum[x-n]
+}
+
+// computeHourlyDay monta os argumentos das linhas de um dia, na ordem de hourlyColumns
+func computeHourlyDay(input *hourlyInput, day hourlyDay, now time.Time) [][]interface{} {
+	h0, h1 := hourIndex(day.start), hourIndex(day.end)
+	base := h0 - hoursPerWeek
+	length := int(h1 - base)
+
+	windows := make(map[string]*hourlyWindow, len(input.cells))
+	for _, cellID := range input.cells {
+		windows[cellID] = newHourlyWindow(input.incidents[cellID], base, length)
+	}
+
+	var rows [][]interface{}
+	for _, cellID := range input.cells {
+		w := windows[cellID]
+		neighbors := input.neighbors[cellID]
+		zeroHour := sparseZeroHour(cellID, day.start)
+
+		for x := hoursPerWeek; x < length; x++ {
+			yCount := w.counts[x]
+			roll7d := w.rolling(x, hoursPerWeek)
+
+			neighborSum := 0
+			for _, n := range neighbors {
+				if nw, ok := windows[n]; ok {
+					neighborSum += nw.rolling(x, 24)
+				}
+			}
+			neighborAvg := 0.0
+			if len(neighbors) > 0 {
+				neighborAvg = float64(neighborSum) / float64(len(neighbors))
+			}
+
+			offset := x - hoursPerWeek
+			if yCount == 0 && roll7d == 0 && neighborSum == 0 && offset%hourlyZeroRowStride != zeroHour {
+				continue
+			}
+
+			avg := float64(roll7d) / hoursPerWeek
+			sq := float64(w.sumSq[x]-w.sumSq[x-hoursPerWeek]) / hoursPerWeek
+			std := math.Sqrt(math.Max(0, sq-avg*avg))
+
+			ts := time.Unix((base+int64(x))*3600, 0).In(input.loc)
+			dow := int(ts.Weekday())
+			hour := ts.Hour()
+
+			rows = append(rows, []interface{}{
+				cellID, ts, yCount,
+				w.counts[x-1], w.counts[x-24], w.counts[x-hoursPerWeek],
+				w.rolling(x, 3), w.rolling(x, 24), roll7d,
+				avg, std,
+				dow, hour,
+				dow == int(time.Sunday) || dow == int(time.Saturday),
+				hour >= 8 && hour <= 18,
+				input.holidays[ts.Format("2006-01-02")],
+				input.holidays[ts.AddDate(0, 0, 1).Format("2006-01-02")],
+				input.holidays[ts.AddDate(0, 0, -1).Format("2006-01-02")],
+				neighborAvg,
+				now, now,
+			})
+		}
+	}
+	return rows
+}
+
+// sparseZeroHour escolhe de forma determinística a hora da linha zerada de uma
+// célula em um dia, variando entre células e dias para não viciar a feature hour
+func sparseZeroHour(cellID string, day time.Time) int {
+	h := fnv.New32a()
+	h.Write([]byte(cellID))
+	h.Write([]byte(day.Format("2006-01-02")))
+	return int(h.Sum32() % hourlyZeroRowStride)
+}
+
+// writeHourlyDay recalcula um dia e substitui suas linhas em uma transação
+func (kg *KnowledgeBaseGenerator) writeHourlyDay(ctx context.Context, db *sql.DB, input *hourlyInput, day hourlyDay) (int, error) {
+	rows := computeHourlyDay(input, day, time.Now())
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	deleteQuery := kg.dialect.Rebind(`
+		DELETE FROM features_cell_hourly
+		WHERE ts >= ? AND ts < ?
+		  AND cell_id IN (SELECT cell_id FROM curated_cells WHERE cell_resolution = ?)
+	`)
+	if _, err := tx.ExecContext(ctx, deleteQuery, day.start, day.end, kg.config.CellResolution); err != nil {
+		return 0, fmt.Errorf("erro ao limpar features: %v", err)
+	}
+
+	rowsPerInsert := kg.dialect.MaxParams() / hourlyParamsPerRow // 100 no SQL Server
+	if rowsPerInsert > 1000 {
+		rowsPerInsert = 1000
+	}
+	rowPlaceholder := "(" + placeholders(hourlyParamsPerRow) + ")"
+
+	for i := 0; i < len(rows); i += rowsPerInsert {
+		batch := rows[i:min(i+rowsPerInsert, len(rows))]
+
+		valueStrings := make([]string, len(batch))
+		args := make([]interface{}, 0, len(batch)*hourlyParamsPerRow)
+		for j, row := range batch {
+			valueStrings[j] = rowPlaceholder
+			args = append(args, row...)
+		}
+
+		query := kg.dialect.Rebind(fmt.Sprintf(`INSERT INTO features_cell_hourly (%s) VALUES %s`,
+			hourlyColumns, strings.Join(valueStrings, ",")))
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return 0, fmt.Errorf("erro ao inserir features (%d linhas): %v", len(batch), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
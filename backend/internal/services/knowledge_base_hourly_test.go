@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+// TestGenerateTemporalFeatures_SQLite confere lags, janelas, calendário e vizinhança
+func TestGenerateTemporalFeatures_SQLite(t *testing.T) {
+	gdb, db := setupSQLiteDB(t)
+	loc := time.FixedZone("BRT", -3*3600)
+
+	grid := newSpatialGrid(1000, DefaultGridArea)
+	col, row := grid.minCol, grid.minRow
+	cellA, cellB, cellC := grid.cellID(col+10, row+10), grid.cellID(col+11, row+10), grid.cellID(col+30, row+30)
+	resolution := 1000
+	for _, id := range []string{cellA, cellB, cellC} {
+		if err := gdb.Create(&models.CuratedCell{CellID: id, CellResolution: resolution}).Error; err != nil {
+			t.Fatalf("falha ao inserir célula: %v", err)
+		}
+	}
+
+	incidents := []struct {
+		id, cell string
+		at       time.Time
+	}{
+		{"a1", cellA, time.Date(2024, 3, 4, 10, 15, 0, 0, loc)},
+		{"a2", cellA, time.Date(2024, 3, 4, 12, 40, 0, 0, loc)},
+		{"b1", cellB, time.Date(2024, 3, 4, 9, 0, 0, 0, loc)},
+		{"c1", cellC, time.Date(2024, 2, 1, 9, 0, 0, 0, loc)}, // antes da janela de 7 dias
+	}
+	for _, inc := range incidents {
+		cell := inc.cell
+		if err := gdb.Create(&models.CuratedIncident{
+			ID: inc.id, OccurredAt: inc.at, Category: "Comum", Severity: 3,
+			Latitude: -22.9, Longitude: -47.0, Confidence: 0.5,
+			CellID: &cell, CellResolution: &resolution,
+		}).Error; err != nil {
+			t.Fatalf("falha ao inserir incidente: %v", err)
+		}
+	}
+	holiday := models.ExternalHoliday{Date: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), Name: "Feriado de teste"}
+	if err := gdb.Create(&holiday).Error; err != nil {
+		t.Fatalf("falha ao inserir feriado: %v", err)
+	}
+
+	dialect, _ := NewKnowledgeBaseDialect("sqlite")
+	kg := NewKnowledgeBaseGenerator(&KnowledgeBaseConfig{
+		SourceDB:       db,
+		TargetDB:       db,
+		Dialect:        dialect,
+		CellResolution: resolution,
+		StartDate:      time.Date(2024, 3, 4, 0, 0, 0, 0, loc),
+		EndDate:        time.Date(2024, 3, 7, 0, 0, 0, 0, loc),
+	})
+
+	total, err := kg.generateTemporalFeatures(context.Background(), db)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+
+	var features []models.FeaturesCellHourly
+	if err := gdb.Find(&features).Error; err != nil {
+		t.Fatalf("falha ao ler features: %v", err)
+	}
+	if len(features) != total {
+		t.Errorf("esperava %d linhas gravadas, obteve: %d", total, len(features))
+	}
+
+	byKey := make(map[string]models.FeaturesCellHourly)
+	for _, f := range features {
+		if f.CellID == cellC {
+			t.Errorf("célula sem atividade na janela não deveria ter linhas: %s", f.Ts)
+		}
+		byKey[f.CellID+f.Ts.In(loc).Format("2006-01-02 15")] = f
+	}
+
+	// Segunda-feira 12h: ocorrência na hora, uma às 10h e o vizinho às 9h
+	f, ok := byKey[cellA+"2024-03-04 12"]
+	if !ok {
+		t.Fatal("esperava linha da célula A em 2024-03-04 12h")
+	}
+	wantStd := math.Sqrt(1.0/168 - 1.0/(168*168))
+	if f.YCount != 1 || f.Lag1h != 0 || f.Lag24h != 0 || f.Roll3hSum != 1 || f.Roll24hSum != 1 || f.Roll7dSum != 1 {
+		t.Errorf("contagens incorretas: %+v", f)
+	}
+	if f.Roll7dAvg == nil || math.Abs(*f.Roll7dAvg-1.0/168) > 1e-9 || f.Roll7dStd == nil || math.Abs(*f.Roll7dStd-wantStd) > 1e-9 {
+		t.Errorf("média/desvio de 7 dias incorretos: %v / %v", f.Roll7dAvg, f.Roll7dStd)
+	}
+	if f.Dow == nil || *f.Dow != 1 || f.Hour == nil || *f.Hour != 12 || *f.IsWeekend || !*f.IsBusinessHours {
+		t.Errorf("calendário incorreto: dow=%v hour=%v", f.Dow, f.Hour)
+	}
+	if f.Holiday || !f.DayBeforeHoliday || f.DayAfterHoliday {
+		t.Errorf("esperava véspera de feriado, obteve: %+v", f)
+	}
+	if f.NeighborAvgCrime == nil || math.Abs(*f.NeighborAvgCrime-1.0/8) > 1e-9 {
+		t.Errorf("esperava neighbor_avg_crime = 1/8, obteve: %v", f.NeighborAvgCrime)
+	}
+
+	// Terça (feriado) 10h: as duas ocorrências de ontem ainda estão nas 24h
+	f = byKey[cellA+"2024-03-05 10"]
+	if !f.Holiday || f.Roll24hSum != 2 || f.Lag24h != 1 || f.Roll3hSum != 0 {
+		t.Errorf("esperava feriado com roll_24h_sum = 2 e lag_24h = 1, obteve: %+v", f)
+	}
+	if f = byKey[cellA+"2024-03-06 10"]; !f.DayAfterHoliday {
+		t.Errorf("esperava dia seguinte ao feriado, obteve: %+v", f)
+	}
+
+	// Uma nova execução substitui as linhas em vez de duplicar
+	again, err := kg.generateTemporalFeatures(context.Background(), db)
+	if err != nil {
+		t.Fatalf("esperava sem erro na segunda execução, obteve: %v", err)
+	}
+	var count int
+	db.QueryRow(`SELECT COUNT(*) FROM features_cell_hourly`).Scan(&count)
+	if again != total || count != total {
+		t.Errorf("esperava %d linhas após reprocessar, obteve: %d (gravadas %d)", total, count, again)
+	}
+}
+
+// TestGenerateKnowledgeBase_HourlyFailure verifica que um dia com erro nas
+// features horárias falha a fase e não avança a marca d'água
+func TestGenerateKnowledgeBase_HourlyFailure(t *testing.T) {
+	gdb, db := setupSQLiteDB(t)
+
+	centro := models.Neighborhood{Name: "Centro", Latitude: -22.9056, Longitude: -47.0608, NeighborhoodWeight: 3}
+	furto := models.Crime{CrimeName: "Furto", CrimeWeight: 3}
+	for _, v := range []interface{}{&centro, &furto} {
+		if err := gdb.Create(v).Error; err != nil {
+			t.Fatalf("falha ao inserir dados base: %v", err)
+		}
+	}
+	at := occurredAt(t, "2024-01-10 10:00:00")
+	report := models.Report{NeighborhoodID: centro.NeighborhoodID, CrimeID: furto.CrimeID, ReportDate: "2024-01-10 10:00:00", OccurredAt: &at}
+	if err := gdb.Create(&report).Error; err != nil {
+		t.Fatalf("falha ao inserir report: %v", err)
+	}
+	// Sem a tabela, a gravação de todo dia falha
+	if err := gdb.Migrator().DropTable(&models.FeaturesCellHourly{}); err != nil {
+		t.Fatalf("falha ao apagar features_cell_hourly: %v", err)
+	}
+
+	dialect, _ := NewKnowledgeBaseDialect("sqlite")
+	kg := NewKnowledgeBaseGenerator(&KnowledgeBaseConfig{
+		SourceDB:       db,
+		TargetDB:       db,
+		Dialect:        dialect,
+		CellResolution: 1000,
+		BatchSize:      100,
+		StartDate:      time.Date(2024, 1, 9, 0, 0, 0, 0, time.Local),
+		EndDate:        time.Date(2024, 1, 12, 0, 0, 0, 0, time.Local),
+		Incremental:    true,
+	})
+	if err := kg.GenerateKnowledgeBase(context.Background()); err == nil {
+		t.Fatal("esperava erro na fase de features horárias")
+	}
+
+	var status string
+	db.QueryRow(`SELECT status FROM analytics_pipeline_logs WHERE execution_id = ? AND phase = ?`,
+		kg.ExecutionID(), PhaseGenerateHourlyFeatures).Scan(&status)
+	if status != JobStatusFailed {
+		t.Errorf("esperava a fase horária com status %s, obteve: %q", JobStatusFailed, status)
+	}
+	var watermarks int
+	db.QueryRow(`SELECT COUNT(*) FROM analytics_watermarks`).Scan(&watermarks)
+	if watermarks != 0 {
+		t.Errorf("esperava a marca d'água mantida, obteve: %d gravadas", watermarks)
+	}
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status possíveis de um job de geração da base de conhecimento
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+var (
+	// ErrJobNotFound indica que o job não existe (ou já foi descartado)
+	ErrJobNotFound = errors.New("job não encontrado")
+	// ErrJobQueueFull indica que a fila de jobs está cheia
+	ErrJobQueueFull = errors.New("fila de jobs cheia")
+	// ErrJobFinished indica que o job já terminou e não pode ser cancelado
+	ErrJobFinished = errors.New("job já finalizado")
+)
+
+// KnowledgeBaseJobFunc executa o trabalho de um job. O contexto é cancelado
+// quando o job é cancelado, e o progresso deve ser reportado no job recebido.
+type KnowledgeBaseJobFunc func(ctx context.Context, job *KnowledgeBaseJob) error
+
+// KnowledgeBasePhaseStatus resume a execução de uma fase dentro de um job
+type KnowledgeBasePhaseStatus struct {
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	Records    int        `json:"records"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// KnowledgeBaseJobSnapshot é a visão imutável de um job, usada nas respostas HTTP
+type KnowledgeBaseJobSnapshot struct {
+	ID            string                     `json:"id"`
+	Status        string                     `json:"status"`
+	ExecutionID   string                     `json:"execution_id,omitempty"`
+	Params        map[string]interface{}     `json:"params"`
+	Phase         string                     `json:"phase,omitempty"`
+	RowsProcessed int                        `json:"rows_processed"`
+	Phases        []KnowledgeBasePhaseStatus `json:"phases"`
+	Errors        []string                   `json:"errors"`
+	CreatedAt     time.Time                  `json:"created_at"`
+	StartedAt     *time.Time                 `json:"started_at,omitempty"`
+	FinishedAt    *time.Time                 `json:"finished_at,omitempty"`
+}
+
+// KnowledgeBaseJob guarda o estado de uma geração em andamento.
+// Implementa KnowledgeBaseProgress para receber o andamento do gerador.
+type KnowledgeBaseJob struct {
+	mu       sync.Mutex
+	snapshot KnowledgeBaseJobSnapshot
+	run      KnowledgeBaseJobFunc
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// Snapshot retorna uma cópia do estado atual do job
+func (j *KnowledgeBaseJob) Snapshot() KnowledgeBaseJobSnapshot {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	snap := j.snapshot
+	snap.Phases = append([]KnowledgeBasePhaseStatus(nil), j.snapshot.Phases...)
+	snap.Errors = append([]string(nil), j.snapshot.Errors...)
+	return snap
+}
+
+// SetExecutionID associa o job ao execution ID do gerador
+func (j *KnowledgeBaseJob) SetExecutionID(id string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.snapshot.ExecutionID = id
+}
+
+// PhaseStarted implementa KnowledgeBaseProgress
+func (j *KnowledgeBaseJob) PhaseStarted(phase string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.snapshot.Phase = phase
+	j.snapshot.Phases = append(j.snapshot.Phases, KnowledgeBasePhaseStatus{
+		Name:      phase,
+		Status:    JobStatusRunning,
+		StartedAt: time.Now(),
+	})
+}
+
+// RowsProcessed implementa KnowledgeBaseProgress
+func (j *KnowledgeBaseJob) RowsProcessed(n int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.snapshot.RowsProcessed += n
+	if last := len(j.snapshot.Phases) - 1; last >= 0 {
+		j.snapshot.Phases[last].Records += n
+	}
+}
+
+// Warning implementa KnowledgeBaseProgress
+func (j *KnowledgeBaseJob) Warning(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.snapshot.Errors = append(j.snapshot.Errors, err.Error())
+}
+
+// PhaseFinished implementa KnowledgeBaseProgress
+func (j *KnowledgeBaseJob) PhaseFinished(phase string, records int, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	last := len(j.snapshot.Phases) - 1
+	if last < 0 || j.snapshot.Phases[last].Name != phase {
+		return
+	}
+
+	now := time.Now()
+	p := &j.snapshot.Phases[last]
+	p.FinishedAt = &now
+	p.Records = records
+	switch {
+	case err == nil:
+		p.Status = JobStatusSucceeded
+	case errors.Is(err, context.Canceled):
+		p.Status = JobStatusCancelled
+	default:
+		p.Status = JobStatusFailed
+		p.Error = err.Error()
+	}
+}
+
+func (j *KnowledgeBaseJob) finish(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	j.snapshot.FinishedAt = &now
+	switch {
+	case j.ctx.Err() != nil:
+		j.snapshot.Status = JobStatusCancelled
+	case err != nil:
+		j.snapshot.Status = JobStatusFailed
+		j.snapshot.Errors = append(j.snapshot.Errors, err.Error())
+	default:
+		j.snapshot.Status = JobStatusSucceeded
+	}
+}
+
+// KnowledgeBaseJobManager enfileira gerações da base de conhecimento e as
+// executa em background, uma de cada vez, já que todas escrevem nas mesmas tabelas.
+type KnowledgeBaseJobManager struct {
+	mu      sync.Mutex
+	jobs    map[string]*KnowledgeBaseJob
+	order   []string
+	queue   chan *KnowledgeBaseJob
+	maxJobs int
+	logger  *log.Logger
+}
+
+// NewKnowledgeBaseJobManager cria o gerenciador e inicia o worker que consome a fila.
+// queueSize limita quantos jobs podem aguardar; maxJobs limita quantos ficam em memória.
+func NewKnowledgeBaseJobManager(queueSize, maxJobs int) *KnowledgeBaseJobManager {
+	if queueSize <= 0 {
+		queueSize = 8
+	}
+	if maxJobs <= 0 {
+		maxJobs = 50
+	}
+
+	m := &KnowledgeBaseJobManager{
+		jobs:    make(map[string]*KnowledgeBaseJob),
+		queue:   make(chan *KnowledgeBaseJob, queueSize),
+		maxJobs: maxJobs,
+		logger:  log.New(os.Stdout, "[KB-JOBS] ", log.LstdFlags|log.Lmsgprefix),
+	}
+	go m.worker()
+
+	return m
+}
+
+// Enqueue registra um novo job e o coloca na fila de execução
+func (m *KnowledgeBaseJobManager) Enqueue(params map[string]interface{}, run KnowledgeBaseJobFunc) (*KnowledgeBaseJob, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &KnowledgeBaseJob{
+		snapshot: KnowledgeBaseJobSnapshot{
+			ID:        uuid.NewString(),
+			Status:    JobStatusQueued,
+			Params:    params,
+			Phases:    []KnowledgeBasePhaseStatus{},
+			Errors:    []string{},
+			CreatedAt: time.Now(),
+		},
+		run:    run,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	// O job é registrado antes de entrar na fila, para que o worker e um
+	// Get/Cancel com o ID retornado sempre o encontrem
+	m.mu.Lock()
+	m.jobs[job.snapshot.ID] = job
+	m.order = append(m.order, job.snapshot.ID)
+	select {
+	case m.queue <- job:
+	default:
+		delete(m.jobs, job.snapshot.ID)
+		m.order = m.order[:len(m.order)-1]
+		m.mu.Unlock()
+		cancel()
+		return nil, ErrJobQueueFull
+	}
+	m.pruneLocked()
+	m.mu.Unlock()
+
+	m.logger.Printf("📥 Job %s enfileirado", job.snapshot.ID)
+	return job, nil
+}
+
+// Get retorna o job pelo ID
+func (m *KnowledgeBaseJobManager) Get(id string) (*KnowledgeBaseJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// List retorna os jobs conhecidos, do mais recente para o mais antigo
+func (m *KnowledgeBaseJobManager) List() []KnowledgeBaseJobSnapshot {
+	m.mu.Lock()
+	jobs := make([]*KnowledgeBaseJob, 0, len(m.order))
+	for i := len(m.order) - 1; i >= 0; i-- {
+		jobs = append(jobs, m.jobs[m.order[i]])
+	}
+	m.mu.Unlock()
+
+	snapshots := make([]KnowledgeBaseJobSnapshot, 0, len(jobs))
+	for _, job := range jobs {
+		snapshots = append(snapshots, job.Snapshot())
+	}
+	return snapshots
+}
+
+// Cancel cancela um job enfileirado ou em execução. O job em execução termina
+// assim que a fase atual observar o cancelamento do contexto.
+func (m *KnowledgeBaseJobManager) Cancel(id string) (*KnowledgeBaseJob, error) {
+	job, err := m.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	switch job.snapshot.Status {
+	case JobStatusQueued:
+		now := time.Now()
+		job.snapshot.Status = JobStatusCancelled
+		job.snapshot.FinishedAt = &now
+	case JobStatusRunning:
+		// o status final é definido pelo worker quando a execução retornar
+	default:
+		return job, ErrJobFinished
+	}
+	job.cancel()
+
+	m.logger.Printf("🛑 Cancelamento solicitado para o job %s", id)
+	return job, nil
+}
+
+func (m *KnowledgeBaseJobManager) worker() {
+	for job := range m.queue {
+		job.mu.Lock()
+		if job.snapshot.Status != JobStatusQueued {
+			job.mu.Unlock()
+			continue
+		}
+		now := time.Now()
+		job.snapshot.Status = JobStatusRunning
+		job.snapshot.StartedAt = &now
+		id := job.snapshot.ID
+		job.mu.Unlock()
+
+		m.logger.Printf("🚀 Executando job %s", id)
+		err := m.execute(job)
+		job.finish(err)
+		job.cancel()
+
+		snap := job.Snapshot()
+		m.logger.Printf("🏁 Job %s finalizado com status %s", id, snap.Status)
+	}
+}
+
+// execute roda o job protegendo o worker contra panics
+func (m *KnowledgeBaseJobManager) execute(job *KnowledgeBaseJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.logger.Printf("❌ Panic no job %s: %v", job.snapshot.ID, r)
+			err = errors.New("panic durante a execução do job")
+		}
+	}()
+	return job.run(job.ctx, job)
+}
+
+// pruneLocked descarta os jobs finalizados mais antigos além do limite maxJobs
+func (m *KnowledgeBaseJobManager) pruneLocked() {
+	for i := 0; len(m.order) > m.maxJobs && i < len(m.order); {
+		id := m.order[i]
+		snap := m.jobs[id].Snapshot()
+		if snap.FinishedAt == nil {
+			i++
+			continue
+		}
+		delete(m.jobs, id)
+		m.order = append(m.order[:i], m.order[i+1:]...)
+	}
+}
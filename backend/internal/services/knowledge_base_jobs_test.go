@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitJob espera o job chegar a um status final
+func waitJob(t *testing.T, job *KnowledgeBaseJob) KnowledgeBaseJobSnapshot {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		snap := job.Snapshot()
+		if snap.FinishedAt != nil {
+			return snap
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s não terminou a tempo", job.Snapshot().ID)
+	return KnowledgeBaseJobSnapshot{}
+}
+
+// TestKnowledgeBaseJob_Progress verifica que fases, registros e avisos
+// reportados pelo gerador aparecem no snapshot do job
+func TestKnowledgeBaseJob_Progress(t *testing.T) {
+	m := NewKnowledgeBaseJobManager(1, 10)
+
+	job, err := m.Enqueue(nil, func(ctx context.Context, job *KnowledgeBaseJob) error {
+		job.PhaseStarted(PhaseMigrateHistoricalData)
+		job.RowsProcessed(10)
+		job.Warning(errors.New("batch ignorado"))
+		job.PhaseFinished(PhaseMigrateHistoricalData, 10, nil)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("esperava sem erro ao enfileirar, obteve: %v", err)
+	}
+
+	snap := waitJob(t, job)
+	if snap.Status != JobStatusSucceeded {
+		t.Errorf("esperava status %s, obteve: %s", JobStatusSucceeded, snap.Status)
+	}
+	if snap.RowsProcessed != 10 || len(snap.Phases) != 1 || snap.Phases[0].Records != 10 {
+		t.Errorf("progresso inesperado: %+v", snap)
+	}
+	if len(snap.Errors) != 1 {
+		t.Errorf("esperava 1 aviso, obteve: %v", snap.Errors)
+	}
+}
+
+// TestKnowledgeBaseJob_Cancel verifica que cancelar um job em execução
+// cancela o contexto recebido pela fase
+func TestKnowledgeBaseJob_Cancel(t *testing.T) {
+	m := NewKnowledgeBaseJobManager(1, 10)
+	started := make(chan struct{})
+
+	job, err := m.Enqueue(nil, func(ctx context.Context, job *KnowledgeBaseJob) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("esperava sem erro ao enfileirar, obteve: %v", err)
+	}
+
+	<-started
+	if _, err := m.Cancel(job.Snapshot().ID); err != nil {
+		t.Fatalf("esperava sem erro ao cancelar, obteve: %v", err)
+	}
+
+	snap := waitJob(t, job)
+	if snap.Status != JobStatusCancelled {
+		t.Errorf("esperava status %s, obteve: %s", JobStatusCancelled, snap.Status)
+	}
+	if _, err := m.Cancel(snap.ID); !errors.Is(err, ErrJobFinished) {
+		t.Errorf("esperava ErrJobFinished, obteve: %v", err)
+	}
+}
+
+// TestKnowledgeBaseJob_QueueFull verifica que o job recusado pela fila cheia
+// não fica registrado e que os aceitos podem ser consultados pelo ID
+func TestKnowledgeBaseJob_QueueFull(t *testing.T) {
+	m := NewKnowledgeBaseJobManager(1, 10)
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	block := func(ctx context.Context, job *KnowledgeBaseJob) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return nil
+	}
+
+	running, err := m.Enqueue(nil, block)
+	if err != nil {
+		t.Fatalf("esperava sem erro ao enfileirar, obteve: %v", err)
+	}
+	<-started
+	queued, err := m.Enqueue(nil, block)
+	if err != nil {
+		t.Fatalf("esperava sem erro ao enfileirar o segundo job, obteve: %v", err)
+	}
+	if _, err := m.Get(queued.Snapshot().ID); err != nil {
+		t.Errorf("esperava o job enfileirado registrado, obteve: %v", err)
+	}
+
+	if _, err := m.Enqueue(nil, block); !errors.Is(err, ErrJobQueueFull) {
+		t.Errorf("esperava ErrJobQueueFull, obteve: %v", err)
+	}
+	if jobs := m.List(); len(jobs) != 2 {
+		t.Errorf("esperava 2 jobs registrados, obteve: %d", len(jobs))
+	}
+
+	close(release)
+	for _, job := range []*KnowledgeBaseJob{running, queued} {
+		if snap := waitJob(t, job); snap.Status != JobStatusSucceeded {
+			t.Errorf("esperava status %s, obteve: %s", JobStatusSucceeded, snap.Status)
+		}
+	}
+}
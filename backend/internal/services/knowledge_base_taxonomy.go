@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+// loadTaxonomy carrega a taxonomia de crime_categories, usada para a
+// categoria e, quando o crime não tem um peso válido, a severidade dos
+// incidentes. Sem a tabela, ou com ela vazia, usa a taxonomia padrão.
+func (kg *KnowledgeBaseGenerator) loadTaxonomy(ctx context.Context, db *sql.DB) {
+	categories, err := scanCrimeCategories(ctx, db)
+	if err != nil {
+		kg.logger.Printf("⚠️  Taxonomia de crimes indisponível, usando a padrão: %v", err)
+		kg.progress.Warning(fmt.Errorf("taxonomia de crimes: %v", err))
+	}
+	if len(categories) == 0 {
+		categories = DefaultCrimeCategories()
+	}
+	kg.taxonomy = NewCrimeTaxonomy(categories)
+	kg.logger.Printf("🏷️  Taxonomia de crimes: %d subcategorias", len(categories))
+}
+
+func scanCrimeCategories(ctx context.Context, db *sql.DB) ([]models.CrimeCategory, error) {
+	rows, err := db.QueryContext(ctx, `
+        SELECT category, subcategory, default_weight, synonyms
+        FROM crime_categories
+        ORDER BY category_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []models.CrimeCategory
+	for rows.Next() {
+		var c models.CrimeCategory
+		if err := rows.Scan(&c.Category, &c.Subcategory, &c.DefaultWeight, &c.Synonyms); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+// crimeSeverity é o peso do crime ou, fora de 1 a 10, o peso padrão da sua
+// subcategoria na taxonomia
+func (kg *KnowledgeBaseGenerator) crimeSeverity(crime Crime) int {
+	if crime.CrimeWeight >= MinCrimeWeight && crime.CrimeWeight <= MaxCrimeWeight {
+		return crime.CrimeWeight
+	}
+	return kg.taxonomy.Weight(crime.CrimeName, 0)
+}
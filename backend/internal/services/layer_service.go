@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"gorm.io/gorm"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/geo"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+// Limits of the incidents layer
+const (
+	DefaultIncidentsLayerLimit = 5000
+	MaxIncidentsLayerLimit     = 50000
+)
+
+// LayerQuery holds the filters of the cells and incidents layers
+type LayerQuery struct {
+	CellResolution int
+	// From and To filter curated_incidents.occurred_at (inclusive)
+	From *time.Time
+	To   *time.Time
+	// Category is the curated category (Hediondo, Violento, Patrimonial...)
+	Category string
+	// BBox filters cells by their center and incidents by their coordinates
+	BBox *BoundingBox
+	// ActiveOnly leaves out the cells without incidents matching the filters
+	ActiveOnly bool
+	// Limit caps the incidents layer (newest first); 0 uses the default
+	Limit int
+}
+
+// PredictionLayerQuery holds the filters of the predictions layer
+type PredictionLayerQuery struct {
+	Year, Month    int
+	CellResolution int
+	ByNeighborhood bool
+	MinRiskLevel   int
+}
+
+// LayerService exports the knowledge base and the predictions as GeoJSON
+// FeatureCollections (lon/lat, WGS84) that GIS tools and the map load directly
+type LayerService interface {
+	// CellsLayer returns the grid cells as polygons with the number of
+	// incidents matching the filters
+	CellsLayer(ctx context.Context, q LayerQuery) (*geojson.FeatureCollection, error)
+	// IncidentsLayer returns the curated incidents as points, newest first.
+	// A layer cut by the limit has "truncated": true.
+	IncidentsLayer(ctx context.Context, q LayerQuery) (*geojson.FeatureCollection, error)
+	// PredictionsLayer returns the predictions of a month as cell polygons or,
+	// per neighborhood, as the neighborhood boundary (or point, without one)
+	PredictionsLayer(ctx context.Context, q PredictionLayerQuery) (*geojson.FeatureCollection, error)
+}
+
+// layerService is the concrete implementation of LayerService
+type layerService struct {
+	db          *gorm.DB
+	predictions PredictionService
+	tables      *tableCache
+}
+
+// NewLayerService creates a new instance of LayerService
+func NewLayerService(db *gorm.DB, predictions PredictionService) LayerService {
+	return &layerService{db: db, predictions: predictions, tables: &tableCache{}}
+}
+
+// CellsLayer builds the cells layer
+func (s *layerService) CellsLayer(ctx context.Context, q LayerQuery) (*geojson.FeatureCollection, error) {
+	cells, err := loadCells(ctx, s.db, s.tables, q.CellResolution, q.BBox)
+	if err != nil {
+		return nil, err
+	}
+
+	var counts []struct {
+		CellID string
+		Total  int
+	}
+	err = s.incidentsQuery(ctx, q).
+		Where("cell_id IS NOT NULL AND cell_resolution = ?", q.CellResolution).
+		Select("cell_id, COUNT(*) AS total").Group("cell_id").Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	incidents := make(map[string]int, len(counts))
+	for _, c := range counts {
+		incidents[c.CellID] = c.Total
+	}
+
+	fc := geojson.NewFeatureCollection()
+	for _, c := range cells {
+		if q.ActiveOnly && incidents[c.CellID] == 0 {
+			continue
+		}
+		f := geojson.NewFeature(cellGeometry(c))
+		f.ID = c.CellID
+		f.Properties = geojson.Properties{
+			"cell_id":         c.CellID,
+			"cell_resolution": q.CellResolution,
+			"center_lat":      c.CenterLat,
+			"center_lng":      c.CenterLng,
+			"neighborhood":    c.Neighborhood,
+			"incidents":       incidents[c.CellID],
+		}
+		fc.Append(f)
+	}
+	return fc, nil
+}
+
+// IncidentsLayer builds the incidents layer
+func (s *layerService) IncidentsLayer(ctx context.Context, q LayerQuery) (*geojson.FeatureCollection, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultIncidentsLayerLimit
+	}
+	if limit > MaxIncidentsLayerLimit {
+		limit = MaxIncidentsLayerLimit
+	}
+
+	db := s.incidentsQuery(ctx, q)
+	if q.BBox != nil {
+		db = db.Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?",
+			q.BBox.MinLat, q.BBox.MaxLat, q.BBox.MinLon, q.BBox.MaxLon)
+	}
+	if q.CellResolution > 0 {
+		db = db.Where("cell_resolution = ?", q.CellResolution)
+	}
+
+	// One extra row tells whether the layer was cut by the limit
+	var incidents []models.CuratedIncident
+	if err := db.Order("occurred_at DESC, id").Limit(limit + 1).Find(&incidents).Error; err != nil {
+		return nil, err
+	}
+
+	fc := geojson.NewFeatureCollection()
+	truncated := len(incidents) > limit
+	if truncated {
+		incidents = incidents[:limit]
+	}
+	for _, inc := range incidents {
+		f := geojson.NewFeature(orb.Point{inc.Longitude, inc.Latitude})
+		f.ID = inc.ID
+		f.Properties = geojson.Properties{
+			"id":           inc.ID,
+			"occurred_at":  inc.OccurredAt.Format(time.RFC3339),
+			"category":     inc.Category,
+			"severity":     inc.Severity,
+			"neighborhood": inc.Neighborhood,
+			"confidence":   inc.Confidence,
+			"cell_id":      inc.CellID,
+		}
+		fc.Append(f)
+	}
+	fc.ExtraMembers = geojson.Properties{"truncated": truncated}
+	return fc, nil
+}
+
+// PredictionsLayer builds the predictions layer
+func (s *layerService) PredictionsLayer(ctx context.Context, q PredictionLayerQuery) (*geojson.FeatureCollection, error) {
+	predictions, err := s.predictions.GetPredictions(ctx, q.Year, q.Month, q.CellResolution, q.ByNeighborhood)
+	if err != nil {
+		return nil, err
+	}
+
+	var geometries map[string]orb.Geometry
+	if q.ByNeighborhood {
+		geometries, err = s.boundaryGeometries(ctx)
+	} else {
+		geometries, err = s.cellGeometries(ctx, q.CellResolution)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return predictionFeatures(predictions, geometries, q.MinRiskLevel), nil
+}
+
+// predictionFeatures builds the features of the predictions with at least
+// minRiskLevel, shaped by the geometry of their cell or neighborhood, or
+// placed at their coordinates when it is missing
+func predictionFeatures(predictions []models.PredictCrime, geometries map[string]orb.Geometry, minRiskLevel int) *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	for _, p := range predictions {
+		if p.Risk_Level < minRiskLevel {
+			continue
+		}
+
+		var geometry orb.Geometry
+		properties := geojson.Properties{
+			"neighborhood":    p.Neighborhood,
+			"risk_level":      p.Risk_Level,
+			"predicted_count": p.PredictedCount,
+			"year":            p.Year,
+			"month":           p.Month,
+			"model_version":   p.ModelVersion,
+		}
+		if p.CellID != nil {
+			geometry = geometries[*p.CellID]
+			properties["cell_id"] = *p.CellID
+		} else {
+			geometry = geometries[geo.NormalizeName(p.Neighborhood)]
+		}
+		if geometry == nil {
+			geometry = orb.Point{p.Longitude, p.Latitude}
+		}
+
+		f := geojson.NewFeature(geometry)
+		f.Properties = properties
+		fc.Append(f)
+	}
+	return fc
+}
+
+// incidentsQuery applies the time window and category filters
+func (s *layerService) incidentsQuery(ctx context.Context, q LayerQuery) *gorm.DB {
+	db := s.db.WithContext(ctx).Model(&models.CuratedIncident{})
+	if q.From != nil {
+		db = db.Where("occurred_at >= ?", *q.From)
+	}
+	if q.To != nil {
+		db = db.Where("occurred_at <= ?", *q.To)
+	}
+	if q.Category != "" {
+		db = db.Where("category = ?", q.Category)
+	}
+	return db
+}
+
+// cellGeometries maps the cells of a resolution to their polygons
+func (s *layerService) cellGeometries(ctx context.Context, cellResolution int) (map[string]orb.Geometry, error) {
+	cells, err := loadCells(ctx, s.db, s.tables, cellResolution, nil)
+	if err != nil {
+		return nil, err
+	}
+	geometries := make(map[string]orb.Geometry, len(cells))
+	for _, c := range cells {
+		geometries[c.CellID] = cellGeometry(c)
+	}
+	return geometries, nil
+}
+
+// boundaryGeometries maps the normalized names of the live neighborhoods to
+// their boundaries
+func (s *layerService) boundaryGeometries(ctx context.Context) (map[string]orb.Geometry, error) {
+	geometries := make(map[string]orb.Geometry)
+	db := s.db.WithContext(ctx)
+	if !db.Migrator().HasTable(&models.NeighborhoodBoundary{}) {
+		return geometries, nil
+	}
+
+	var rows []struct {
+		Name     string
+		Geometry string
+	}
+	err := db.Table("neighborhood_boundaries b").
+		Select("n.name, b.geometry").
+		Joins("JOIN neighborhoods n ON n.neighborhood_id = b.neighborhood_id").
+		Where("n.deleted_at IS NULL").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		if g, err := geo.UnmarshalGeometry(r.Geometry); err == nil {
+			geometries[geo.NormalizeName(r.Name)] = g
+		}
+	}
+	return geometries, nil
+}
+
+// cellGeometry returns the polygon stored in bounds_json or, when it is
+// missing, the cell center
+func cellGeometry(c curatedCell) orb.Geometry {
+	if c.BoundsJSON != nil {
+		if g, err := geojson.UnmarshalGeometry([]byte(*c.BoundsJSON)); err == nil {
+			return g.Geometry()
+		}
+	}
+	return orb.Point{c.CenterLng, c.CenterLat}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/paulmach/orb"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/geo"
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+func TestLayerService(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	ctx := context.Background()
+	svc := NewLayerService(gdb, NewPredictionService(gdb))
+
+	// A primeira célula tem bounds_json gravado; a segunda, sem ele, vira o
+	// ponto do centro
+	grid := newSpatialGrid(1000, DefaultGridArea)
+	col, row := grid.minCol, grid.minRow
+	bounds := polygonJSON(grid.bounds(col, row))
+	cells := []models.CuratedCell{
+		{CellID: grid.cellID(col, row), CellResolution: 1000, CenterLat: -23.0955, CenterLng: -47.2955, BoundsJSON: &bounds},
+		{CellID: grid.cellID(col, row+1), CellResolution: 1000, CenterLat: -23.0865, CenterLng: -47.2955},
+	}
+	for i := range cells {
+		if err := gdb.Create(&cells[i]).Error; err != nil {
+			t.Fatalf("falha ao inserir célula: %v", err)
+		}
+	}
+
+	resolution := 1000
+	incidents := []struct {
+		id, category string
+		day          int
+	}{
+		{"rpt_1", "Patrimonial", 5},
+		{"rpt_2", "Patrimonial", 20},
+		{"rpt_3", "Hediondo", 10},
+	}
+	for _, inc := range incidents {
+		cellID := cells[0].CellID
+		if err := gdb.Create(&models.CuratedIncident{
+			ID: inc.id, OccurredAt: time.Date(2024, 3, inc.day, 12, 0, 0, 0, time.UTC),
+			Category: inc.category, Severity: 3, Latitude: -23.0955, Longitude: -47.2955,
+			Neighborhood: "Centro", Confidence: 0.8, CellID: &cellID, CellResolution: &resolution,
+		}).Error; err != nil {
+			t.Fatalf("falha ao inserir incidente: %v", err)
+		}
+	}
+
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	fc, err := svc.CellsLayer(ctx, LayerQuery{CellResolution: 1000, From: &from, To: &to, Category: "Patrimonial"})
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if len(fc.Features) != 2 {
+		t.Fatalf("esperava 2 células, obteve: %d", len(fc.Features))
+	}
+	if _, ok := fc.Features[0].Geometry.(orb.Polygon); !ok {
+		t.Errorf("esperava o polígono da célula, obteve: %v", fc.Features[0].Geometry)
+	}
+	if p, ok := fc.Features[1].Geometry.(orb.Point); !ok || p.Lat() != -23.0865 {
+		t.Errorf("esperava o centro da célula sem bounds_json, obteve: %v", fc.Features[1].Geometry)
+	}
+	if got := fc.Features[0].Properties["incidents"]; got != 1 {
+		t.Errorf("esperava 1 incidente patrimonial até dia 15, obteve: %v", got)
+	}
+
+	fc, err = svc.CellsLayer(ctx, LayerQuery{CellResolution: 1000, ActiveOnly: true})
+	if err != nil || len(fc.Features) != 1 || fc.Features[0].Properties["incidents"] != 3 {
+		t.Errorf("esperava só a célula ativa com 3 incidentes, obteve: %v (%v)", fc, err)
+	}
+
+	fc, err = svc.IncidentsLayer(ctx, LayerQuery{Limit: 2})
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if len(fc.Features) != 2 || fc.Features[0].ID != "rpt_2" || fc.ExtraMembers["truncated"] != true {
+		t.Errorf("esperava os 2 incidentes mais recentes e truncated, obteve: %d (%v)", len(fc.Features), fc.ExtraMembers)
+	}
+	if p, ok := fc.Features[0].Geometry.(orb.Point); !ok || p.Lon() != -47.2955 {
+		t.Errorf("esperava o ponto do incidente em lon/lat, obteve: %v", fc.Features[0].Geometry)
+	}
+
+	fc, err = svc.IncidentsLayer(ctx, LayerQuery{Category: "Hediondo"})
+	if err != nil || len(fc.Features) != 1 || fc.ExtraMembers["truncated"] != false {
+		t.Errorf("esperava 1 incidente hediondo, obteve: %v (%v)", fc, err)
+	}
+
+	// Previsões: por célula com o polígono e por bairro com o limite importado
+	cellID := cells[0].CellID
+	predictions := []models.PredictCrime{
+		{Neighborhood: "Centro", Risk_Level: 2, Year: 2024, Month: 4, CellResolution: &resolution, CellID: &cellID, PredictedCount: 1.5},
+		{Neighborhood: "Centro", Risk_Level: 2, Year: 2024, Month: 4, CellResolution: &resolution, PredictedCount: 1.5, Latitude: -22.9, Longitude: -47.06},
+		{Neighborhood: "Taquaral", Risk_Level: 0, Year: 2024, Month: 4, CellResolution: &resolution, PredictedCount: 0.1, Latitude: -22.87, Longitude: -47.05},
+	}
+	if err := gdb.Create(&predictions).Error; err != nil {
+		t.Fatalf("falha ao inserir previsões: %v", err)
+	}
+	if err := gdb.Create(&models.Neighborhood{Name: "Centro", Latitude: -22.9, Longitude: -47.06}).Error; err != nil {
+		t.Fatalf("falha ao inserir bairro: %v", err)
+	}
+	if _, err := NewBoundaryService(gdb, DefaultGridArea).ImportBoundaries(ctx, []geo.Boundary{square("centro", -22.92, -47.08, -22.89, -47.04)}, "teste"); err != nil {
+		t.Fatalf("falha ao importar limite: %v", err)
+	}
+
+	fc, err = svc.PredictionsLayer(ctx, PredictionLayerQuery{Year: 2024, Month: 4, CellResolution: 1000})
+	if err != nil || len(fc.Features) != 1 {
+		t.Fatalf("esperava 1 previsão por célula, obteve: %v (%v)", fc, err)
+	}
+	if _, ok := fc.Features[0].Geometry.(orb.Polygon); !ok || fc.Features[0].Properties["cell_id"] != cellID {
+		t.Errorf("esperava o polígono da célula, obteve: %v", fc.Features[0])
+	}
+
+	fc, err = svc.PredictionsLayer(ctx, PredictionLayerQuery{Year: 2024, Month: 4, CellResolution: 1000, ByNeighborhood: true})
+	if err != nil || len(fc.Features) != 2 {
+		t.Fatalf("esperava 2 previsões por bairro, obteve: %v (%v)", fc, err)
+	}
+	if _, ok := fc.Features[0].Geometry.(orb.MultiPolygon); !ok {
+		t.Errorf("esperava o limite do Centro, obteve: %v", fc.Features[0].Geometry)
+	}
+	if _, ok := fc.Features[1].Geometry.(orb.Point); !ok {
+		t.Errorf("esperava o ponto do Taquaral, sem limite, obteve: %v", fc.Features[1].Geometry)
+	}
+
+	fc, _ = svc.PredictionsLayer(ctx, PredictionLayerQuery{Year: 2024, Month: 4, CellResolution: 1000, ByNeighborhood: true, MinRiskLevel: 1})
+	if len(fc.Features) != 1 {
+		t.Errorf("esperava só o bairro de risco alto, obteve: %d", len(fc.Features))
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultListLimit is the page size used when the limit is not informed
+	DefaultListLimit = 100
+	// MaxListLimit is the largest page size accepted by the list endpoints
+	MaxListLimit = 1000
+)
+
+// ErrInvalidListQuery is returned for sort fields or cursor combinations the
+// list endpoints do not support
+var ErrInvalidListQuery = errors.New("invalid list query")
+
+// ListQuery holds the filters, sorting and pagination of a list endpoint.
+// Pagination is either offset based (Limit/Offset) or cursor based (After,
+// the last ID of the previous page), which only works when sorting by id.
+type ListQuery struct {
+	// Name is a case-insensitive substring of the name
+	Name        string
+	MinWeight   *int
+	MaxWeight   *int
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+
+	Limit  int
+	Offset int
+	After  *uint
+
+	// Sort is one of id, name, weight or created_at; Desc reverses it
+	Sort string
+	Desc bool
+}
+
+// ListPage is a page of results with the total matching the filters
+type ListPage[T any] struct {
+	Items []T
+	Total int64
+	// NextCursor is the After of the next page, when sorting by id and the
+	// page is full
+	NextCursor *uint
+}
+
+// listColumns maps the public field names of ListQuery to the table columns
+type listColumns struct {
+	id, name, weight, createdAt string
+}
+
+func (c listColumns) sortColumn(field string) (string, error) {
+	switch field {
+	case "", "id":
+		return c.id, nil
+	case "name":
+		return c.name, nil
+	case "weight":
+		return c.weight, nil
+	case "created_at":
+		return c.createdAt, nil
+	}
+	return "", fmt.Errorf("%w: unknown sort field %q (use id, name, weight or created_at)", ErrInvalidListQuery, field)
+}
+
+// likeEscaper escapes the LIKE wildcards of a search term
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// filter applies the filters of q, without sorting or pagination
+func (q ListQuery) filter(db *gorm.DB, cols listColumns) *gorm.DB {
+	if name := strings.TrimSpace(q.Name); name != "" {
+		db = db.Where("LOWER("+cols.name+`) LIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(strings.ToLower(name))+"%")
+	}
+	if q.MinWeight != nil {
+		db = db.Where(cols.weight+" >= ?", *q.MinWeight)
+	}
+	if q.MaxWeight != nil {
+		db = db.Where(cols.weight+" <= ?", *q.MaxWeight)
+	}
+	if q.CreatedFrom != nil {
+		db = db.Where(cols.createdAt+" >= ?", *q.CreatedFrom)
+	}
+	if q.CreatedTo != nil {
+		db = db.Where(cols.createdAt+" <= ?", *q.CreatedTo)
+	}
+	return db
+}
+
+// listPage counts the rows matching q and loads one page of them. The id is
+// always the last sort key, so pages are stable when the sort field repeats.
+func listPage[T any](db *gorm.DB, model any, q ListQuery, cols listColumns, idOf func(T) uint) (*ListPage[T], error) {
+	sortColumn, err := cols.sortColumn(q.Sort)
+	if err != nil {
+		return nil, err
+	}
+	if q.After != nil && sortColumn != cols.id {
+		return nil, fmt.Errorf("%w: cursor pagination requires sorting by id", ErrInvalidListQuery)
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	limit = min(limit, MaxListLimit)
+
+	page := &ListPage[T]{}
+	if err := q.filter(db.Model(model), cols).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+
+	direction := "ASC"
+	if q.Desc {
+		direction = "DESC"
+	}
+	query := q.filter(db.Model(model), cols).Order(sortColumn + " " + direction)
+	if sortColumn != cols.id {
+		query = query.Order(cols.id + " " + direction)
+	}
+
+	if q.After != nil {
+		op := ">"
+		if q.Desc {
+			op = "<"
+		}
+		query = query.Where(cols.id+" "+op+" ?", *q.After)
+	} else if q.Offset > 0 {
+		query = query.Offset(q.Offset)
+	}
+
+	if err := query.Limit(limit).Find(&page.Items).Error; err != nil {
+		return nil, err
+	}
+
+	if sortColumn == cols.id && len(page.Items) == limit {
+		next := idOf(page.Items[len(page.Items)-1])
+		page.NextCursor = &next
+	}
+	return page, nil
+}
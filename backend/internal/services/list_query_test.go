@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+func TestListCrimes_FiltersAndPagination(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	ctx := context.Background()
+	svc := NewCrimeService(gdb)
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	crimes := []models.Crime{
+		{CrimeName: "Furto", CrimeWeight: 3},
+		{CrimeName: "Furto de Veículo", CrimeWeight: 3},
+		{CrimeName: "Roubo", CrimeWeight: 5},
+		{CrimeName: "Roubo de Carga", CrimeWeight: 5},
+		{CrimeName: "Latrocínio", CrimeWeight: 9},
+		{CrimeName: "100%_Teste", CrimeWeight: 1},
+	}
+	for i := range crimes {
+		crimes[i].CreatedAt = base.AddDate(0, i, 0)
+		if err := gdb.Create(&crimes[i]).Error; err != nil {
+			t.Fatalf("falha ao inserir crime: %v", err)
+		}
+	}
+
+	page, err := svc.ListCrimes(ctx, ListQuery{Name: "roubo"})
+	if err != nil || page.Total != 2 || len(page.Items) != 2 {
+		t.Fatalf("esperava 2 roubos, obteve: %+v (%v)", page, err)
+	}
+
+	// Curingas do LIKE na busca são literais
+	page, _ = svc.ListCrimes(ctx, ListQuery{Name: "%_"})
+	if page.Total != 1 || page.Items[0].CrimeName != "100%_Teste" {
+		t.Errorf("esperava apenas 100%%_Teste, obteve: %+v", page.Items)
+	}
+
+	minWeight, maxWeight := 3, 5
+	from, to := base.AddDate(0, 1, 0), base.AddDate(0, 3, 0)
+	page, _ = svc.ListCrimes(ctx, ListQuery{MinWeight: &minWeight, MaxWeight: &maxWeight, CreatedFrom: &from, CreatedTo: &to})
+	if page.Total != 3 {
+		t.Errorf("esperava 3 crimes entre os pesos e as datas, obteve: %d", page.Total)
+	}
+
+	page, _ = svc.ListCrimes(ctx, ListQuery{Sort: "weight", Desc: true, Limit: 2, Offset: 1})
+	if page.Total != 6 || len(page.Items) != 2 || page.Items[0].CrimeName != "Roubo de Carga" || page.NextCursor != nil {
+		t.Errorf("esperava a segunda página ordenada por peso, obteve: %+v", page)
+	}
+
+	// Paginação por cursor percorre todos os registros sem repetir
+	var names []string
+	q := ListQuery{Limit: 4}
+	for {
+		page, err := svc.ListCrimes(ctx, q)
+		if err != nil {
+			t.Fatalf("esperava sem erro, obteve: %v", err)
+		}
+		for _, c := range page.Items {
+			names = append(names, c.CrimeName)
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		q.After = page.NextCursor
+	}
+	if fmt.Sprint(names) != fmt.Sprint([]string{"Furto", "Furto de Veículo", "Roubo", "Roubo de Carga", "Latrocínio", "100%_Teste"}) {
+		t.Errorf("esperava todos os crimes em ordem de id, obteve: %v", names)
+	}
+
+	if _, err := svc.ListCrimes(ctx, ListQuery{Sort: "weight", After: q.After}); !errors.Is(err, ErrInvalidListQuery) {
+		t.Errorf("esperava ErrInvalidListQuery para cursor fora da ordenação por id, obteve: %v", err)
+	}
+	if _, err := svc.ListCrimes(ctx, ListQuery{Sort: "crime_name; DROP TABLE crimes"}); !errors.Is(err, ErrInvalidListQuery) {
+		t.Errorf("esperava ErrInvalidListQuery para campo de ordenação desconhecido, obteve: %v", err)
+	}
+}
+
+func TestGetAllNeighborhoods_Filters(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	svc := NewNeighborhoodService(gdb, DefaultGridArea)
+
+	for i, name := range []string{"Centro", "Cambuí", "Taquaral"} {
+		n := models.Neighborhood{Name: name, Latitude: models.Coordinate(-22.9 - float64(i)/100), Longitude: -47.06, NeighborhoodWeight: i + 1}
+		if err := gdb.Create(&n).Error; err != nil {
+			t.Fatalf("falha ao inserir bairro: %v", err)
+		}
+	}
+
+	minWeight := 2
+	page, err := svc.GetAllNeighborhoods(context.Background(), ListQuery{MinWeight: &minWeight, Sort: "name"})
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if page.Total != 2 || page.Items[0].Name != "Cambuí" || page.Items[1].Name != "Taquaral" {
+		t.Errorf("esperava Cambuí e Taquaral, obteve: %+v", page.Items)
+	}
+}
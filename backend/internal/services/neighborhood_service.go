@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
 	"gorm.io/gorm"
@@ -11,21 +12,30 @@ import (
 type NeighborhoodService interface {
 	CreateNeighborhood(ctx context.Context, n *models.Neighborhood) error
 	GetNeighborhoodByID(ctx context.Context, id uint) (*models.Neighborhood, error)
-	GetAllNeighborhoods(ctx context.Context) ([]models.Neighborhood, error)
+	GetAllNeighborhoods(ctx context.Context, q ListQuery) (*ListPage[models.Neighborhood], error)
+	UpdateNeighborhood(ctx context.Context, n *models.Neighborhood) error
+	// DeleteNeighborhood soft deletes a neighborhood; it fails with ErrInUse
+	// while the neighborhood still has reports
+	DeleteNeighborhood(ctx context.Context, id uint) error
 }
 
 // neighborhoodService is the concrete implementation of NeighborhoodService
 type neighborhoodService struct {
-	db *gorm.DB
+	db   *gorm.DB
+	area BoundingBox
 }
 
-// NewNeighborhoodService creates a new instance of NeighborhoodService
-func NewNeighborhoodService(db *gorm.DB) NeighborhoodService {
-	return &neighborhoodService{db: db}
+// NewNeighborhoodService creates a new instance of NeighborhoodService that
+// accepts the coordinates inside area (the grid area)
+func NewNeighborhoodService(db *gorm.DB, area BoundingBox) NeighborhoodService {
+	return &neighborhoodService{db: db, area: area}
 }
 
-// CreateNeighborhood creates a new neighborhood in the database
+// CreateNeighborhood validates and creates a new neighborhood in the database
 func (s *neighborhoodService) CreateNeighborhood(ctx context.Context, n *models.Neighborhood) error {
+	if err := ValidateNeighborhood(n, s.area); err != nil {
+		return err
+	}
 	return s.db.WithContext(ctx).Create(n).Error
 }
 
@@ -39,9 +49,33 @@ func (s *neighborhoodService) GetNeighborhoodByID(ctx context.Context, id uint)
 	return &neighborhood, nil
 }
 
-// GetAllNeighborhoods retrieves all neighborhoods from the database
-func (s *neighborhoodService) GetAllNeighborhoods(ctx context.Context) ([]models.Neighborhood, error) {
-	var neighborhoods []models.Neighborhood
-	err := s.db.WithContext(ctx).Find(&neighborhoods).Error
-	return neighborhoods, err
-}
\ No newline at end of file
+// neighborhoodColumns are the Neighborhood columns used to filter and sort
+var neighborhoodColumns = listColumns{id: "neighborhood_id", name: "name", weight: "neighborhood_weight", createdAt: "created_at"}
+
+// GetAllNeighborhoods retrieves a page of the neighborhoods matching the query
+func (s *neighborhoodService) GetAllNeighborhoods(ctx context.Context, q ListQuery) (*ListPage[models.Neighborhood], error) {
+	return listPage(s.db.WithContext(ctx), &models.Neighborhood{}, q, neighborhoodColumns,
+		func(n models.Neighborhood) uint { return n.NeighborhoodID })
+}
+
+// UpdateNeighborhood validates and saves the editable fields of a neighborhood
+func (s *neighborhoodService) UpdateNeighborhood(ctx context.Context, n *models.Neighborhood) error {
+	if err := ValidateNeighborhood(n, s.area); err != nil {
+		return err
+	}
+	return updateColumns(s.db.WithContext(ctx), n, "name", "latitude", "longitude", "neighborhood_weight")
+}
+
+// DeleteNeighborhood soft deletes a neighborhood without reports
+func (s *neighborhoodService) DeleteNeighborhood(ctx context.Context, id uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var reports int64
+		if err := tx.Model(&models.Report{}).Where("neighborhood_id = ?", id).Count(&reports).Error; err != nil {
+			return err
+		}
+		if reports > 0 {
+			return fmt.Errorf("%w: neighborhood has %d reports", ErrInUse, reports)
+		}
+		return softDelete(tx, &models.Neighborhood{}, "neighborhood_id", id)
+	})
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"errors"
+	"math"
+)
+
+// poissonModel is a Poisson regression (log link) over the monthly lag
+// features of a cell:
+//
+//	log(E[y]) = b0 + b1*log(1+lag_1m) + b2*log(1+lag_3m)
+//
+// lag_1m is the count of the previous month and lag_3m the sum of the three
+// previous months, the same definitions used in features_cell_monthly.
+type poissonModel struct {
+	coef []float64
+}
+
+const (
+	// poissonIterations bounds the IRLS (Newton) iterations
+	poissonIterations = 50
+	// poissonTolerance stops IRLS when the largest coefficient change is below it
+	poissonTolerance = 1e-8
+	// poissonRidge is a small L2 penalty on the lag coefficients that keeps the
+	// fit stable when a lag is constant (e.g. all-zero cells)
+	poissonRidge = 1e-4
+	// poissonMaxEta caps the linear predictor to avoid overflowing exp
+	poissonMaxEta = 20.0
+)
+
+var errSingularSystem = errors.New("singular system while fitting the model")
+
+// poissonFeatures builds the design row for a cell-month
+func poissonFeatures(lag1m, lag3m float64) []float64 {
+	return []float64{1, math.Log1p(lag1m), math.Log1p(lag3m)}
+}
+
+// fitPoisson estimates the coefficients by iteratively reweighted least squares
+func fitPoisson(x [][]float64, y []float64) (*poissonModel, error) {
+	if len(x) == 0 || len(x) != len(y) {
+		return nil, errors.New("no rows to fit the model")
+	}
+	p := len(x[0])
+
+	// Start from the mean count so the first iteration is already reasonable
+	mean := 0.0
+	for _, v := range y {
+		mean += v
+	}
+	mean /= float64(len(y))
+	if mean <= 0 {
+		return nil, errors.New("all training counts are zero")
+	}
+
+	coef := make([]float64, p)
+	coef[0] = math.Log(mean)
+
+	for iter := 0; iter < poissonIterations; iter++ {
+		// Normal equations of the Newton step: (X'WX + R) delta = X'(y - mu) - R coef
+		hessian := make([][]float64, p)
+		for i := range hessian {
+			hessian[i] = make([]float64, p)
+		}
+		gradient := make([]float64, p)
+
+		for r, row := range x {
+			mu := math.Exp(math.Min(dot(coef, row), poissonMaxEta))
+			residual := y[r] - mu
+			for i := 0; i < p; i++ {
+				gradient[i] += row[i] * residual
+				for j := 0; j < p; j++ {
+					hessian[i][j] += row[i] * row[j] * mu
+				}
+			}
+		}
+		for i := 1; i < p; i++ {
+			hessian[i][i] += poissonRidge
+			gradient[i] -= poissonRidge * coef[i]
+		}
+
+		delta, err := solveLinear(hessian, gradient)
+		if err != nil {
+			return nil, err
+		}
+
+		maxChange := 0.0
+		for i := range coef {
+			coef[i] += delta[i]
+			maxChange = math.Max(maxChange, math.Abs(delta[i]))
+		}
+		if maxChange < poissonTolerance {
+			break
+		}
+	}
+
+	return &poissonModel{coef: coef}, nil
+}
+
+// predict returns the expected count for a design row
+func (m *poissonModel) predict(row []float64) float64 {
+	return math.Exp(math.Min(dot(m.coef, row), poissonMaxEta))
+}
+
+func dot(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// solveLinear solves a*x = b by Gaussian elimination with partial pivoting
+func solveLinear(a [][]float64, b []float64) ([]float64, error) {
+	n := len(b)
+	m := make([][]float64, n)
+	for i := range a {
+		m[i] = append(append([]float64{}, a[i]...), b[i])
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(m[r][col]) > math.Abs(m[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-12 {
+			return nil, errSingularSystem
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+
+		for r := col + 1; r < n; r++ {
+			factor := m[r][col] / m[col][col]
+			for c := col; c <= n; c++ {
+				m[r][c] -= factor * m[col][c]
+			}
+		}
+	}
+
+	x := make([]float64, n)
+	for r := n - 1; r >= 0; r-- {
+		sum := m[r][n]
+		for c := r + 1; c < n; c++ {
+			sum -= m[r][c] * x[c]
+		}
+		x[r] = sum / m[r][r]
+	}
+	return x, nil
+}
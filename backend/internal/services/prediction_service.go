@@ -0,0 +1,350 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// predictionModelVersion identifies the model stored with each prediction
+	predictionModelVersion = "poisson-lag-v1"
+	// predictionTrainingMonths is how many months before the target are used for training
+	predictionTrainingMonths = 24
+	// predictionHistoryMonths is the history a month needs before it can be a
+	// training row (lag_3m looks three months back)
+	predictionHistoryMonths = 3
+
+	// Risk levels come from the probability of at least one incident in the month
+	riskHighProbability   = 0.5
+	riskMediumProbability = 0.2
+)
+
+// ErrNoTrainingData is returned when the knowledge base has no monthly
+// features to train on (or none before the requested month)
+var ErrNoTrainingData = errors.New("not enough monthly features to train the prediction model")
+
+// PredictionSummary describes a training run, in the shape consumed by the map
+type PredictionSummary struct {
+	Message          string      `json:"message"`
+	TargetYear       int         `json:"target_year"`
+	TargetMonth      int         `json:"target_month"`
+	CellResolution   int         `json:"cell_resolution"`
+	ModelVersion     string      `json:"model_version"`
+	Coefficients     []float64   `json:"coefficients"`
+	RowsTrained      int         `json:"rows_trained"`
+	RowsPredicted    int         `json:"rows_predicted"`
+	Neighborhoods    int         `json:"neighborhoods"`
+	RiskDistribution map[int]int `json:"risk_distribution"`
+}
+
+// PredictionService trains the monthly crime model and serves its predictions
+type PredictionService interface {
+	// TrainMonthly fits the model on features_cell_monthly and replaces the
+	// stored predictions of the target month for the given grid resolution
+	TrainMonthly(ctx context.Context, year, month, cellResolution int) (*PredictionSummary, error)
+	// GetPredictions returns the stored predictions of a month, per cell or
+	// aggregated per neighborhood
+	GetPredictions(ctx context.Context, year, month, cellResolution int, byNeighborhood bool) ([]models.PredictCrime, error)
+}
+
+// predictionService is the concrete implementation of PredictionService
+type predictionService struct {
+	db     *gorm.DB
+	tables *tableCache
+}
+
+// NewPredictionService creates a new instance of PredictionService
+func NewPredictionService(db *gorm.DB) PredictionService {
+	return &predictionService{db: db, tables: &tableCache{}}
+}
+
+// curatedCell is a grid cell with its center, bounds and mapped neighborhood
+type curatedCell struct {
+	CellID       string
+	CenterLat    float64
+	CenterLng    float64
+	BoundsJSON   *string
+	Neighborhood string
+}
+
+// cellSeries holds the monthly counts of each cell, indexed by year*12+month-1
+type cellSeries map[string]map[int]float64
+
+func (s cellSeries) count(cellID string, month int) float64 {
+	return s[cellID][month]
+}
+
+func (s cellSeries) lags(cellID string, month int) (lag1m, lag3m float64) {
+	lag1m = s.count(cellID, month-1)
+	lag3m = lag1m + s.count(cellID, month-2) + s.count(cellID, month-3)
+	return lag1m, lag3m
+}
+
+func monthIndex(year, month int) int {
+	return year*12 + month - 1
+}
+
+// TrainMonthly fits a Poisson regression on the lag features of the months
+// before the target and predicts every cell of the grid. When the target is
+// more than one month after the last month with features, the intermediate
+// months are filled with the model's own predictions.
+func (s *predictionService) TrainMonthly(ctx context.Context, year, month, cellResolution int) (*PredictionSummary, error) {
+	if month < 1 || month > 12 {
+		return nil, fmt.Errorf("invalid month: %d", month)
+	}
+
+	cells, err := loadCells(ctx, s.db, s.tables, cellResolution, nil)
+	if err != nil {
+		return nil, err
+	}
+	series, first, last, err := s.loadSeries(ctx, cellResolution)
+	if err != nil {
+		return nil, err
+	}
+	if len(cells) == 0 || len(series) == 0 {
+		return nil, ErrNoTrainingData
+	}
+
+	target := monthIndex(year, month)
+	trainStart := max(first+predictionHistoryMonths, target-predictionTrainingMonths)
+	trainEnd := min(target-1, last)
+	if trainStart > trainEnd {
+		return nil, ErrNoTrainingData
+	}
+
+	var x [][]float64
+	var y []float64
+	for _, cell := range cells {
+		for m := trainStart; m <= trainEnd; m++ {
+			lag1m, lag3m := series.lags(cell.CellID, m)
+			x = append(x, poissonFeatures(lag1m, lag3m))
+			y = append(y, series.count(cell.CellID, m))
+		}
+	}
+
+	model, err := fitPoisson(x, y)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoTrainingData, err)
+	}
+
+	// Months between the last observed one and the target are forecast recursively
+	for m := last + 1; m < target; m++ {
+		for _, cell := range cells {
+			lag1m, lag3m := series.lags(cell.CellID, m)
+			if series[cell.CellID] == nil {
+				series[cell.CellID] = make(map[int]float64)
+			}
+			series[cell.CellID][m] = model.predict(poissonFeatures(lag1m, lag3m))
+		}
+	}
+
+	records := make([]models.PredictCrime, 0, len(cells))
+	type neighborhoodAgg struct {
+		count, lat, lng float64
+		cells, risk     int
+	}
+	byNeighborhood := make(map[string]*neighborhoodAgg)
+	distribution := map[int]int{0: 0, 1: 0, 2: 0}
+
+	for _, cell := range cells {
+		lag1m, lag3m := series.lags(cell.CellID, target)
+		expected := model.predict(poissonFeatures(lag1m, lag3m))
+		risk := riskLevel(expected)
+		distribution[risk]++
+
+		neighborhood := cell.Neighborhood
+		if neighborhood == "" {
+			neighborhood = cell.CellID
+		} else {
+			agg, ok := byNeighborhood[neighborhood]
+			if !ok {
+				agg = &neighborhoodAgg{}
+				byNeighborhood[neighborhood] = agg
+			}
+			agg.count += expected
+			agg.lat += cell.CenterLat
+			agg.lng += cell.CenterLng
+			agg.cells++
+			agg.risk = max(agg.risk, risk)
+		}
+
+		cellID := cell.CellID
+		records = append(records, s.newPrediction(year, month, cellResolution, neighborhood, risk,
+			&cellID, cell.CenterLat, cell.CenterLng, expected))
+	}
+
+	names := make([]string, 0, len(byNeighborhood))
+	for name := range byNeighborhood {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		agg := byNeighborhood[name]
+		n := float64(agg.cells)
+		records = append(records, s.newPrediction(year, month, cellResolution, name, agg.risk,
+			nil, agg.lat/n, agg.lng/n, agg.count))
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("year = ? AND month = ? AND cell_resolution = ?", year, month, cellResolution).
+			Delete(&models.PredictCrime{}).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(records, 500).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &PredictionSummary{
+		Message:          "Model trained and predictions saved",
+		TargetYear:       year,
+		TargetMonth:      month,
+		CellResolution:   cellResolution,
+		ModelVersion:     predictionModelVersion,
+		Coefficients:     model.coef,
+		RowsTrained:      len(y),
+		RowsPredicted:    len(cells),
+		Neighborhoods:    len(byNeighborhood),
+		RiskDistribution: distribution,
+	}, nil
+}
+
+// GetPredictions returns the stored predictions, highest expected count first
+func (s *predictionService) GetPredictions(ctx context.Context, year, month, cellResolution int, byNeighborhood bool) ([]models.PredictCrime, error) {
+	query := s.db.WithContext(ctx).
+		Where("year = ? AND month = ? AND cell_resolution = ?", year, month, cellResolution)
+	if byNeighborhood {
+		query = query.Where("cell_id IS NULL")
+	} else {
+		query = query.Where("cell_id IS NOT NULL")
+	}
+
+	var predictions []models.PredictCrime
+	err := query.Order("predicted_count DESC").Find(&predictions).Error
+	return predictions, err
+}
+
+func (s *predictionService) newPrediction(year, month, cellResolution int, neighborhood string, risk int,
+	cellID *string, lat, lng, expected float64) models.PredictCrime {
+	resolution := cellResolution
+	return models.PredictCrime{
+		Neighborhood:   neighborhood,
+		Risk_Level:     risk,
+		Year:           year,
+		Month:          month,
+		CellResolution: &resolution,
+		CellID:         cellID,
+		Latitude:       lat,
+		Longitude:      lng,
+		PredictedCount: expected,
+		ModelVersion:   predictionModelVersion,
+	}
+}
+
+// tableCache remembers the tables found by has. A missing table is looked up
+// again on the next call, since the knowledge base creates its tables on its
+// first run.
+type tableCache struct {
+	found sync.Map
+}
+
+// has reports whether the table exists; a nil cache always looks it up
+func (c *tableCache) has(db *gorm.DB, name string) bool {
+	if c != nil {
+		if _, ok := c.found.Load(name); ok {
+			return true
+		}
+	}
+	if !db.Migrator().HasTable(name) {
+		return false
+	}
+	if c != nil {
+		c.found.Store(name, struct{}{})
+	}
+	return true
+}
+
+// loadCells reads the grid cells of a resolution with their neighborhoods,
+// only those centered in bbox when it is not nil. It is shared by the
+// predictions, layers and tiles.
+func loadCells(ctx context.Context, db *gorm.DB, tables *tableCache, cellResolution int, bbox *BoundingBox) ([]curatedCell, error) {
+	db = db.WithContext(ctx)
+	query := db.Table("curated_cells c").Where("c.cell_resolution = ?", cellResolution)
+	if tables.has(db, "cell_neighborhoods") {
+		query = query.Select("c.cell_id, c.center_lat, c.center_lng, c.bounds_json, COALESCE(cn.neighborhood, '') AS neighborhood").
+			Joins("LEFT JOIN cell_neighborhoods cn ON cn.cell_id = c.cell_id")
+	} else {
+		query = query.Select("c.cell_id, c.center_lat, c.center_lng, c.bounds_json, '' AS neighborhood")
+	}
+	if bbox != nil {
+		query = query.Where("c.center_lat BETWEEN ? AND ? AND c.center_lng BETWEEN ? AND ?",
+			bbox.MinLat, bbox.MaxLat, bbox.MinLon, bbox.MaxLon)
+	}
+
+	var cells []curatedCell
+	err := query.Order("c.cell_id").Scan(&cells).Error
+	return cells, err
+}
+
+// cellsInBox selects the IDs of the cells of a resolution centered in bbox,
+// to be used as an IN subquery
+func cellsInBox(db *gorm.DB, cellResolution int, bbox BoundingBox) *gorm.DB {
+	return db.Table("curated_cells").Select("cell_id").
+		Where("cell_resolution = ? AND center_lat BETWEEN ? AND ? AND center_lng BETWEEN ? AND ?",
+			cellResolution, bbox.MinLat, bbox.MaxLat, bbox.MinLon, bbox.MaxLon)
+}
+
+// loadSeries reads the monthly counts of the cells of a resolution and
+// returns the first and last month indexes found
+func (s *predictionService) loadSeries(ctx context.Context, cellResolution int) (cellSeries, int, int, error) {
+	db := s.db.WithContext(ctx)
+	if !db.Migrator().HasTable("features_cell_monthly") {
+		return nil, 0, 0, ErrNoTrainingData
+	}
+
+	rows, err := db.Raw(`SELECT cell_id, year, month, y_count_month FROM features_cell_monthly WHERE cell_id LIKE ?`,
+		cellIDPrefix(cellResolution)+"%").Rows()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer rows.Close()
+
+	series := make(cellSeries)
+	first, last := math.MaxInt, math.MinInt
+	for rows.Next() {
+		var cellID string
+		var year, month, count int
+		if err := rows.Scan(&cellID, &year, &month, &count); err != nil {
+			return nil, 0, 0, err
+		}
+		m := monthIndex(year, month)
+		if series[cellID] == nil {
+			series[cellID] = make(map[int]float64)
+		}
+		series[cellID][m] = float64(count)
+		first, last = min(first, m), max(last, m)
+	}
+	return series, first, last, rows.Err()
+}
+
+// riskLevel maps the expected count to 0 (low), 1 (medium) or 2 (high)
+// using the Poisson probability of at least one incident
+func riskLevel(expected float64) int {
+	p := 1 - math.Exp(-expected)
+	switch {
+	case p >= riskHighProbability:
+		return 2
+	case p >= riskMediumProbability:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+func TestFitPoisson_RecoversCoefficients(t *testing.T) {
+	// y = exp(0.5 + 0.8*log(1+lag1m)) sem ruído: o ajuste deve recuperar os coeficientes
+	var x [][]float64
+	var y []float64
+	for lag1 := 0.0; lag1 <= 10; lag1++ {
+		for lag3 := lag1; lag3 <= lag1+6; lag3 += 2 {
+			row := poissonFeatures(lag1, lag3)
+			x = append(x, row)
+			y = append(y, math.Exp(0.5+0.8*row[1]))
+		}
+	}
+
+	model, err := fitPoisson(x, y)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	want := []float64{0.5, 0.8, 0}
+	for i, w := range want {
+		if math.Abs(model.coef[i]-w) > 1e-3 {
+			t.Errorf("coef[%d]: esperava %.3f, obteve: %.5f", i, w, model.coef[i])
+		}
+	}
+
+	if _, err := fitPoisson([][]float64{{1, 0, 0}}, []float64{0}); err == nil {
+		t.Error("esperava erro quando todas as contagens são zero")
+	}
+}
+
+func TestRiskLevel(t *testing.T) {
+	cases := map[float64]int{0: 0, 0.1: 0, 0.25: 1, 0.6: 1, 0.7: 2, 4: 2}
+	for expected, want := range cases {
+		if got := riskLevel(expected); got != want {
+			t.Errorf("riskLevel(%.2f): esperava %d, obteve: %d", expected, want, got)
+		}
+	}
+}
+
+func TestPredictionService_TrainMonthly(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	ctx := context.Background()
+	svc := NewPredictionService(gdb)
+
+	if _, err := svc.TrainMonthly(ctx, 2025, 1, 1000); !errors.Is(err, ErrNoTrainingData) {
+		t.Fatalf("esperava ErrNoTrainingData sem features, obteve: %v", err)
+	}
+
+	for _, stmt := range (sqliteDialect{}).MonthlyFeaturesTableStatements() {
+		if err := gdb.Exec(stmt).Error; err != nil {
+			t.Fatalf("falha ao criar features_cell_monthly: %v", err)
+		}
+	}
+	if err := gdb.Exec(`CREATE TABLE cell_neighborhoods (cell_id TEXT PRIMARY KEY, neighborhood TEXT NOT NULL, distance REAL)`).Error; err != nil {
+		t.Fatalf("falha ao criar cell_neighborhoods: %v", err)
+	}
+
+	// Célula quente com 4 ocorrências por mês, outra com 1 e uma sem nenhuma
+	cells := []struct {
+		id, neighborhood string
+		perMonth         int
+	}{
+		{"UTM23S-1000-E288-N7465", "Centro", 4},
+		{"UTM23S-1000-E288-N7466", "Centro", 1},
+		{"UTM23S-1000-E288-N7467", "Taquaral", 0},
+	}
+	for i, c := range cells {
+		if err := gdb.Create(&models.CuratedCell{CellID: c.id, CellResolution: 1000, CenterLat: -22.9 + float64(i)*0.01, CenterLng: -47.06}).Error; err != nil {
+			t.Fatalf("falha ao inserir célula: %v", err)
+		}
+		gdb.Exec(`INSERT INTO cell_neighborhoods (cell_id, neighborhood, distance) VALUES (?, ?, 0)`, c.id, c.neighborhood)
+		for month := 1; month <= 12; month++ {
+			gdb.Exec(`INSERT INTO features_cell_monthly (cell_id, year, month, y_count_month, lag_1m, lag_3m) VALUES (?, 2024, ?, ?, 0, 0)`,
+				c.id, month, c.perMonth)
+		}
+	}
+
+	// Fevereiro de 2025 fica dois meses após o último mês com dados
+	summary, err := svc.TrainMonthly(ctx, 2025, 2, 1000)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if summary.RowsPredicted != 3 || summary.Neighborhoods != 2 || summary.RowsTrained != 3*9 {
+		t.Errorf("resumo inesperado: %+v", summary)
+	}
+	if summary.RiskDistribution[2] != 2 || summary.RiskDistribution[0] != 1 {
+		t.Errorf("esperava duas células de risco alto e uma de risco baixo, obteve: %v", summary.RiskDistribution)
+	}
+
+	predictions, err := svc.GetPredictions(ctx, 2025, 2, 1000, false)
+	if err != nil || len(predictions) != 3 {
+		t.Fatalf("esperava 3 previsões por célula, obteve: %d (%v)", len(predictions), err)
+	}
+	hot := predictions[0]
+	if hot.CellID == nil || *hot.CellID != "UTM23S-1000-E288-N7465" || hot.Risk_Level != 2 || hot.Neighborhood != "Centro" {
+		t.Errorf("esperava a célula quente primeiro com risco alto, obteve: %+v", hot)
+	}
+	if math.Abs(hot.PredictedCount-4) > 0.5 {
+		t.Errorf("esperava cerca de 4 incidentes na célula quente, obteve: %.3f", hot.PredictedCount)
+	}
+
+	neighborhoods, _ := svc.GetPredictions(ctx, 2025, 2, 1000, true)
+	if len(neighborhoods) != 2 || neighborhoods[0].Neighborhood != "Centro" || neighborhoods[0].Risk_Level != 2 {
+		t.Errorf("esperava o Centro agregado com risco alto, obteve: %+v", neighborhoods)
+	}
+
+	// Treinar de novo substitui as previsões do mês
+	if _, err := svc.TrainMonthly(ctx, 2025, 2, 1000); err != nil {
+		t.Fatalf("esperava sem erro ao treinar de novo, obteve: %v", err)
+	}
+	var count int64
+	gdb.Model(&models.PredictCrime{}).Where("year = ? AND month = ?", 2025, 2).Count(&count)
+	if count != 5 {
+		t.Errorf("esperava 5 previsões gravadas (3 células + 2 bairros), obteve: %d", count)
+	}
+}
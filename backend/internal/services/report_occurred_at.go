@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	// Embeds the timezone database, so DB_TIMEZONE also loads in images
+	// without /usr/share/zoneinfo
+	_ "time/tzdata"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// DefaultReportTimezone is the timezone of the report dates when DB_TIMEZONE
+// is not set
+const DefaultReportTimezone = "America/Sao_Paulo"
+
+// LoadReportLocation loads the timezone in which report dates without an
+// offset are read; an empty name loads DefaultReportTimezone
+func LoadReportLocation(name string) (*time.Location, error) {
+	if name == "" {
+		name = DefaultReportTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// occurredAtBackfillBatch is the number of reports read, and updated in one
+// transaction, at a time by BackfillOccurredAt
+const occurredAtBackfillBatch = 500
+
+// OccurredAtBackfillResult is the outcome of BackfillOccurredAt
+type OccurredAtBackfillResult struct {
+	DryRun bool `json:"dry_run"`
+	// Scanned is the number of reports without occurred_at
+	Scanned int `json:"scanned"`
+	// Converted is the number of them whose date was parsed (and, unless
+	// DryRun, saved)
+	Converted int `json:"converted"`
+	// Failed lists the reports whose date is in none of ReportDateLayouts
+	Failed []OccurredAtBackfillFailure `json:"failed"`
+}
+
+// OccurredAtBackfillFailure is a report whose date could not be parsed
+type OccurredAtBackfillFailure struct {
+	ReportID   uint   `json:"report_id"`
+	ReportDate string `json:"report_date"`
+	Error      string `json:"error"`
+}
+
+// occurredAtBackfillRow is a report read by BackfillOccurredAt.
+// ReportDateFormated is the legacy column of the databases created by the
+// migrations, once read by the knowledge base pipeline.
+type occurredAtBackfillRow struct {
+	ReportID           uint
+	ReportDate         string
+	ReportDateFormated *string
+}
+
+// BackfillOccurredAt parses report_date of the reports without occurred_at,
+// soft-deleted ones included, in the timezone of the service. When
+// report_date does not parse, report_date_formated is tried if the column
+// exists. Saving occurred_at also moves updated_at, so an incremental run of
+// the knowledge base picks the reports up. With dryRun nothing is saved.
+func (s *reportService) BackfillOccurredAt(ctx context.Context, dryRun bool) (*OccurredAtBackfillResult, error) {
+	db := s.db.WithContext(ctx)
+	result := &OccurredAtBackfillResult{DryRun: dryRun, Failed: []OccurredAtBackfillFailure{}}
+
+	columns := []string{"report_id", "report_date"}
+	if db.Migrator().HasColumn(&models.Report{}, "report_date_formated") {
+		columns = append(columns, "report_date_formated")
+	}
+
+	// Reports that fail keep occurred_at NULL, so the pages go by report_id
+	var lastID uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		var rows []occurredAtBackfillRow
+		err := db.Unscoped().Model(&models.Report{}).Select(columns).
+			Where("occurred_at IS NULL AND report_id > ?", lastID).
+			Order("report_id").Limit(occurredAtBackfillBatch).
+			Scan(&rows).Error
+		if err != nil {
+			return result, err
+		}
+		if len(rows) == 0 {
+			return result, nil
+		}
+		lastID = rows[len(rows)-1].ReportID
+
+		parsed := make(map[uint]time.Time, len(rows))
+		for _, row := range rows {
+			result.Scanned++
+			t, err := ParseReportDate(row.ReportDate, s.loc)
+			if err != nil && row.ReportDateFormated != nil {
+				if legacy, legacyErr := ParseReportDate(*row.ReportDateFormated, s.loc); legacyErr == nil {
+					t, err = legacy, nil
+				}
+			}
+			if err != nil {
+				result.Failed = append(result.Failed, OccurredAtBackfillFailure{
+					ReportID: row.ReportID, ReportDate: row.ReportDate, Error: err.Error(),
+				})
+				continue
+			}
+			parsed[row.ReportID] = t
+		}
+
+		if !dryRun && len(parsed) > 0 {
+			err := db.Transaction(func(tx *gorm.DB) error {
+				for id, t := range parsed {
+					if err := tx.Unscoped().Model(&models.Report{ReportID: id}).Update("occurred_at", t).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return result, err
+			}
+		}
+		result.Converted += len(parsed)
+	}
+}
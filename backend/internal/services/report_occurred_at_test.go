@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+func TestProcessReportText_OccurredAt(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	svc := NewReportService(gdb, saoPaulo, DefaultGridArea)
+	ctx := context.Background()
+
+	for date, want := range map[string]*time.Time{
+		"05/01/2024 22:30": ptrTime(time.Date(2024, 1, 5, 22, 30, 0, 0, saoPaulo)),
+		"ontem à noite":    nil,
+	} {
+		report, err := svc.ProcessReportText(ctx, &models.ReportRequest{
+			Name: "Centro", Latitude: -22.9056, Longitude: -47.0608, CrimeName: "Furto", ReportDate: date,
+		})
+		if err != nil {
+			t.Fatalf("esperava sem erro, obteve: %v", err)
+		}
+
+		var saved models.Report
+		gdb.First(&saved, report.ReportID)
+		switch {
+		case want == nil && saved.OccurredAt != nil:
+			t.Errorf("%q: esperava occurred_at vazio, obteve: %s", date, saved.OccurredAt)
+		case want != nil && (saved.OccurredAt == nil || !saved.OccurredAt.Equal(*want)):
+			t.Errorf("%q: esperava occurred_at %s, obteve: %v", date, want, saved.OccurredAt)
+		}
+	}
+}
+
+// TestBackfillOccurredAt converte as datas dos reports antigos, inclusive
+// pela coluna legada report_date_formated, e lista as que não reconhece
+func TestBackfillOccurredAt(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	svc := NewReportService(gdb, saoPaulo, DefaultGridArea)
+	ctx := context.Background()
+
+	if err := gdb.Exec(`ALTER TABLE reports ADD COLUMN report_date_formated TEXT`).Error; err != nil {
+		t.Fatalf("falha ao criar report_date_formated: %v", err)
+	}
+	legacy := []struct {
+		date, formated string
+		deleted        bool
+	}{
+		{"05/01/2024", "", false},
+		{"2024-02-10 08:00:00", "", true},
+		{"10 de março", "2024-03-10", false},
+		{"ontem", "", false},
+	}
+	ids := make([]uint, len(legacy))
+	for i, r := range legacy {
+		report := models.Report{NeighborhoodID: 1, CrimeID: 1, ReportDate: r.date}
+		if err := gdb.Create(&report).Error; err != nil {
+			t.Fatalf("falha ao inserir report: %v", err)
+		}
+		if r.formated != "" {
+			gdb.Exec(`UPDATE reports SET report_date_formated = ? WHERE report_id = ?`, r.formated, report.ReportID)
+		}
+		if r.deleted {
+			gdb.Delete(&report)
+		}
+		ids[i] = report.ReportID
+	}
+
+	result, err := svc.BackfillOccurredAt(ctx, true)
+	if err != nil || result.Scanned != 4 || result.Converted != 3 || len(result.Failed) != 1 {
+		t.Fatalf("esperava 4 reports lidos e 3 convertidos no dry-run, obteve: %+v (%v)", result, err)
+	}
+	var pending int64
+	gdb.Unscoped().Model(&models.Report{}).Where("occurred_at IS NULL").Count(&pending)
+	if pending != 4 {
+		t.Errorf("o dry-run não deveria gravar, obteve: %d reports sem occurred_at", pending)
+	}
+
+	result, err = svc.BackfillOccurredAt(ctx, false)
+	if err != nil || result.Converted != 3 || len(result.Failed) != 1 {
+		t.Fatalf("esperava 3 reports convertidos, obteve: %+v (%v)", result, err)
+	}
+	if f := result.Failed[0]; f.ReportID != ids[3] || f.ReportDate != "ontem" || f.Error == "" {
+		t.Errorf("esperava a falha do report %d, obteve: %+v", ids[3], f)
+	}
+
+	for i, want := range []time.Time{
+		time.Date(2024, 1, 5, 0, 0, 0, 0, saoPaulo),
+		time.Date(2024, 2, 10, 8, 0, 0, 0, saoPaulo),
+		time.Date(2024, 3, 10, 0, 0, 0, 0, saoPaulo),
+	} {
+		var report models.Report
+		gdb.Unscoped().First(&report, ids[i])
+		if report.OccurredAt == nil || !report.OccurredAt.Equal(want) {
+			t.Errorf("report %d: esperava occurred_at %s, obteve: %v", ids[i], want, report.OccurredAt)
+		}
+	}
+
+	// Uma nova execução só relê os reports que falharam
+	result, err = svc.BackfillOccurredAt(ctx, false)
+	if err != nil || result.Scanned != 1 || result.Converted != 0 {
+		t.Errorf("esperava apenas o report com data não reconhecida, obteve: %+v (%v)", result, err)
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// earthRadiusMeters is the mean Earth radius used by haversineMeters
+const earthRadiusMeters = 6371000.0
+
+// BoundingBox is a latitude/longitude rectangle
+type BoundingBox struct {
+	MinLat, MinLon, MaxLat, MaxLon float64
+}
+
+// Contains reports whether the point is inside the box (borders included)
+func (b BoundingBox) Contains(lat, lon float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lon >= b.MinLon && lon <= b.MaxLon
+}
+
+// ParseBoundingBox parses a box written as min_lon,min_lat,max_lon,max_lat
+func ParseBoundingBox(value string) (BoundingBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return BoundingBox{}, errors.New("bounding box must be min_lon,min_lat,max_lon,max_lat")
+	}
+	var v [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BoundingBox{}, fmt.Errorf("invalid bounding box coordinate %q", part)
+		}
+		v[i] = f
+	}
+	if v[0] > v[2] || v[1] > v[3] {
+		return BoundingBox{}, errors.New("bounding box minimum is greater than its maximum")
+	}
+	return BoundingBox{MinLon: v[0], MinLat: v[1], MaxLon: v[2], MaxLat: v[3]}, nil
+}
+
+// GeoRadius is a circle of RadiusMeters around a point
+type GeoRadius struct {
+	Lat, Lon     float64
+	RadiusMeters float64
+}
+
+// Contains reports whether the point is within the radius
+func (r GeoRadius) Contains(lat, lon float64) bool {
+	return haversineMeters(r.Lat, r.Lon, lat, lon) <= r.RadiusMeters
+}
+
+// BoundingBox returns the smallest latitude/longitude box around the circle,
+// so the database can filter by it before the exact haversineMeters. A circle
+// reaching a pole spans every longitude.
+func (r GeoRadius) BoundingBox() BoundingBox {
+	toRad := math.Pi / 180
+	angle := r.RadiusMeters / earthRadiusMeters // radians
+	box := BoundingBox{
+		MinLat: max(r.Lat-angle/toRad, -90), MaxLat: min(r.Lat+angle/toRad, 90),
+		MinLon: -180, MaxLon: 180,
+	}
+	if sin, cos := math.Sin(angle), math.Cos(r.Lat*toRad); sin < cos {
+		dLon := math.Asin(sin/cos) / toRad
+		box.MinLon, box.MaxLon = max(r.Lon-dLon, -180), min(r.Lon+dLon, 180)
+	}
+	return box
+}
+
+// ReportQuery holds the filters, sorting and pagination of ListReports.
+// Reports are located by the coordinates of their neighborhood.
+type ReportQuery struct {
+	// DateFrom and DateTo filter occurred_at by day (inclusive), the days
+	// being taken in the timezone of the reports
+	DateFrom *time.Time
+	DateTo   *time.Time
+
+	CrimeID   *uint
+	CrimeName string // case-insensitive substring
+	MinWeight *int   // crime_weight
+	MaxWeight *int
+
+	NeighborhoodID   *uint
+	NeighborhoodName string // case-insensitive substring
+
+	BBox *BoundingBox
+	Near *GeoRadius
+
+	Limit  int
+	Offset int
+	// Sort is date or id, ascending unless Desc; empty sorts by date, newest first
+	Sort string
+	Desc bool
+}
+
+// startOfDay is midnight of the day of t in loc
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// neighborhoodCount is the number of reports of a neighborhood
+type neighborhoodCount struct {
+	NeighborhoodID uint
+	Latitude       models.Coordinate
+	Longitude      models.Coordinate
+	Count          int64
+}
+
+// ListReports returns a page of reports with their neighborhood and crime.
+// The bounding box and the box around the radius filter the coordinates of
+// the joined neighborhoods in the database; the exact radius is then checked
+// with haversineMeters on the neighborhoods of the box only, and the page is
+// read from the neighborhoods inside it.
+func (s *reportService) ListReports(ctx context.Context, q ReportQuery) (*ListPage[models.Report], error) {
+	db := s.db.WithContext(ctx)
+	dialect, err := NewKnowledgeBaseDialect(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var sortColumn string
+	switch q.Sort {
+	case "", "date":
+		sortColumn = dialect.Instant("reports.occurred_at")
+	case "id":
+		sortColumn = "reports.report_id"
+	default:
+		return nil, fmt.Errorf("%w: unknown sort field %q (use date or id)", ErrInvalidListQuery, q.Sort)
+	}
+
+	var boxes []BoundingBox
+	if q.BBox != nil {
+		boxes = append(boxes, *q.BBox)
+	}
+	if q.Near != nil {
+		boxes = append(boxes, q.Near.BoundingBox())
+	}
+
+	filter := func() *gorm.DB {
+		tx := db.Model(&models.Report{}).
+			Joins("JOIN crimes ON crimes.crime_id = reports.crime_id").
+			Joins("JOIN neighborhoods ON neighborhoods.neighborhood_id = reports.neighborhood_id")
+		column, param := dialect.Instant("reports.occurred_at"), dialect.Instant("?")
+		if q.DateFrom != nil {
+			tx = tx.Where(column+" >= "+param, startOfDay(*q.DateFrom, s.loc).UTC())
+		}
+		if q.DateTo != nil {
+			tx = tx.Where(column+" < "+param, startOfDay(*q.DateTo, s.loc).AddDate(0, 0, 1).UTC())
+		}
+		if q.CrimeID != nil {
+			tx = tx.Where("reports.crime_id = ?", *q.CrimeID)
+		}
+		if name := strings.TrimSpace(q.CrimeName); name != "" {
+			tx = tx.Where(`LOWER(crimes.crime_name) LIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(strings.ToLower(name))+"%")
+		}
+		if q.MinWeight != nil {
+			tx = tx.Where("crimes.crime_weight >= ?", *q.MinWeight)
+		}
+		if q.MaxWeight != nil {
+			tx = tx.Where("crimes.crime_weight <= ?", *q.MaxWeight)
+		}
+		if q.NeighborhoodID != nil {
+			tx = tx.Where("reports.neighborhood_id = ?", *q.NeighborhoodID)
+		}
+		if name := strings.TrimSpace(q.NeighborhoodName); name != "" {
+			tx = tx.Where(`LOWER(neighborhoods.name) LIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(strings.ToLower(name))+"%")
+		}
+		for _, box := range boxes {
+			tx = tx.Where("neighborhoods.latitude BETWEEN ? AND ?", box.MinLat, box.MaxLat).
+				Where("neighborhoods.longitude BETWEEN ? AND ?", box.MinLon, box.MaxLon)
+		}
+		return tx
+	}
+
+	page := &ListPage[models.Report]{}
+	if q.Near == nil {
+		if err := filter().Count(&page.Total).Error; err != nil {
+			return nil, err
+		}
+	} else {
+		// Reports share the coordinates of their neighborhood, so the total
+		// inside the radius comes from one count per neighborhood of the box,
+		// and the page is read from the neighborhoods inside the radius
+		var counts []neighborhoodCount
+		err := filter().
+			Select("neighborhoods.neighborhood_id, neighborhoods.latitude, neighborhoods.longitude, COUNT(*) AS count").
+			Group("neighborhoods.neighborhood_id, neighborhoods.latitude, neighborhoods.longitude").
+			Scan(&counts).Error
+		if err != nil {
+			return nil, err
+		}
+		var ids []string
+		for _, c := range counts {
+			if q.Near.Contains(c.Latitude.Float64(), c.Longitude.Float64()) {
+				page.Total += c.Count
+				ids = append(ids, strconv.FormatUint(uint64(c.NeighborhoodID), 10))
+			}
+		}
+		if len(ids) == 0 {
+			page.Items = []models.Report{}
+			return page, nil
+		}
+		// The IDs are written inline: they are integers, and a radius can
+		// hold more neighborhoods than the parameters SQL Server accepts
+		inRadius := filter
+		filter = func() *gorm.DB {
+			return inRadius().Where("reports.neighborhood_id IN (" + strings.Join(ids, ", ") + ")")
+		}
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	limit = min(limit, MaxListLimit)
+
+	// Without a sort field the newest reports come first
+	direction := "ASC"
+	if q.Desc || q.Sort == "" {
+		direction = "DESC"
+	}
+	query := func() *gorm.DB {
+		tx := filter().Preload("Neighborhood").Preload("Crime").
+			Order(sortColumn + " " + direction)
+		if sortColumn != "reports.report_id" {
+			tx = tx.Order("reports.report_id " + direction)
+		}
+		return tx
+	}
+
+	tx := query()
+	if q.Offset > 0 {
+		tx = tx.Offset(q.Offset)
+	}
+	if err := tx.Limit(limit).Find(&page.Items).Error; err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// GetReportByID returns a report with its neighborhood and crime
+func (s *reportService) GetReportByID(ctx context.Context, id uint) (*models.Report, error) {
+	var report models.Report
+	err := s.db.WithContext(ctx).Preload("Neighborhood").Preload("Crime").First(&report, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// haversineMeters is the great-circle distance between two points
+func haversineMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
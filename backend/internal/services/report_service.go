@@ -3,9 +3,13 @@ package services
 import (
 	"context"
 	"errors"
+	"math/rand/v2"
+	"strings"
+	"time"
 
 	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ReportService defines business operations
@@ -17,43 +21,160 @@ type ReportService interface {
 	ProcessReportText(ctx context.Context, req *models.ReportRequest) (*models.Report, error)
 	FindOrCreateNeighborhood(ctx context.Context, n *models.Neighborhood) (uint, error)
 	FindOrCreateCrime(ctx context.Context, c *models.Crime) (uint, error)
+	// ProcessReportsBulk processes many report requests, one transaction per
+	// chunk, and returns one result per request in the same order.
+	ProcessReportsBulk(ctx context.Context, reqs []models.ReportRequest) ([]BulkReportResult, error)
+	// ListReports returns a page of reports, with neighborhood and crime,
+	// matching the filters of the query.
+	ListReports(ctx context.Context, q ReportQuery) (*ListPage[models.Report], error)
+	GetReportByID(ctx context.Context, id uint) (*models.Report, error)
+	UpdateReport(ctx context.Context, r *models.Report) error
+	DeleteReport(ctx context.Context, id uint) error
+	// ReconcileImportedReports makes the imported reports under the scopes
+	// match counts, the number of occurrences per source key.
+	ReconcileImportedReports(ctx context.Context, scopes []string, counts map[string]int) (*ImportReconcileResult, error)
+	// BackfillOccurredAt fills occurred_at of the reports saved before it
+	// from their report_date, listing the dates it could not parse.
+	BackfillOccurredAt(ctx context.Context, dryRun bool) (*OccurredAtBackfillResult, error)
+}
+
+// BulkReportChunkSize is the number of requests committed per transaction
+// by ProcessReportsBulk
+const BulkReportChunkSize = 500
+
+// Status values of a BulkReportResult
+const (
+	BulkReportAccepted = "accepted"
+	BulkReportRejected = "rejected"
+	// BulkReportSkipped is a request whose SourceKey and SourceSeq were
+	// already imported
+	BulkReportSkipped = "skipped"
+)
+
+// BulkReportResult is the outcome of a single request of a bulk ingestion
+type BulkReportResult struct {
+	Index    int    `json:"index"`
+	Status   string `json:"status"`
+	ReportID uint   `json:"report_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ErrMissingReportFields is returned when a report request lacks required fields
+var ErrMissingReportFields = errors.New("Missing required fields: name, latitude, longitude, crime_name")
+
+// ErrReportAlreadyImported is returned when a report with the SourceKey and
+// SourceSeq of the request already exists, deleted ones included
+var ErrReportAlreadyImported = errors.New("report already imported")
+
+// ValidateReportRequest checks the fields required to process a report
+// request and returns FieldErrors when the coordinates are outside area
+func ValidateReportRequest(req *models.ReportRequest, area BoundingBox) error {
+	if req.Name == "" || req.Latitude == 0 || req.Longitude == 0 || req.CrimeName == "" {
+		return ErrMissingReportFields
+	}
+	errs := FieldErrors{}
+	validateArea(errs, req.Latitude, req.Longitude, area)
+	return errs.orNil()
 }
 
 // reportService is the concrete implementation of ReportService.
-// It has the GORM instance to persist data in the database.
+// It has the GORM instance to persist data in the database, the
+// timezone in which report dates are read and the area of the grid, which
+// the coordinates of the report requests must be inside.
 type reportService struct {
-	db *gorm.DB
+	db   *gorm.DB
+	loc  *time.Location
+	area BoundingBox
+	// taxonomy is loaded once per chunk by ProcessReportsBulk; nil loads it
+	// on every new crime
+	taxonomy *CrimeTaxonomy
+}
+
+// NewReportService injects the *gorm.DB dependency, the timezone of the
+// report dates (see LoadReportLocation) and the grid area, and returns a
+// ReportService instance ready for use.
+func NewReportService(db *gorm.DB, loc *time.Location, area BoundingBox) ReportService {
+	return &reportService{db: db, loc: loc, area: area}
 }
 
-// NewReportService injects the *gorm.DB dependency and returns
-// a ReportService instance ready for use.
-func NewReportService(db *gorm.DB) ReportService {
-	return &reportService{db: db}
+// occurredAt parses a report date in the timezone of the service; nil when
+// the date is in none of ReportDateLayouts
+func (s *reportService) occurredAt(reportDate string) *time.Time {
+	t, err := ParseReportDate(reportDate, s.loc)
+	if err != nil {
+		return nil
+	}
+	return &t
 }
 
 // CreateReport inserts a new report record in the database.
 // - Receives the request context for timeout control.
 // - r is the pointer to models.Report containing the data to save.
-// - Returns an error if the Create operation fails.
+// - Returns FieldErrors when the report is invalid or references a
+//   neighborhood or crime that does not exist, or an error if the Create
+//   operation fails.
 func (s *reportService) CreateReport(ctx context.Context, r *models.Report) error {
-	// db.WithContext(ctx) associates the context with GORM
-	// and Create(r) performs the INSERT in the "Reports" table.
-	return s.db.WithContext(ctx).Create(r).Error
+	db := s.db.WithContext(ctx)
+	if err := validateReportRefs(db, r); err != nil {
+		return err
+	}
+	r.OccurredAt = s.occurredAt(r.ReportDate)
+	// Create(r) performs the INSERT in the "Reports" table; the nested
+	// neighborhood and crime are references, never created from here
+	return db.Omit(clause.Associations).Create(r).Error
+}
+
+// UpdateReport validates and saves the neighborhood, crime and date of a report
+func (s *reportService) UpdateReport(ctx context.Context, r *models.Report) error {
+	db := s.db.WithContext(ctx)
+	if err := validateReportRefs(db, r); err != nil {
+		return err
+	}
+	r.OccurredAt = s.occurredAt(r.ReportDate)
+	return updateColumns(db.Omit(clause.Associations), r, "neighborhood_id", "crime_id", "report_date", "occurred_at")
 }
 
+// DeleteReport soft deletes a report
+func (s *reportService) DeleteReport(ctx context.Context, id uint) error {
+	return softDelete(s.db.WithContext(ctx), &models.Report{}, "report_id", id)
+}
+
+// validateReportRefs runs ValidateReport and checks that the neighborhood and
+// the crime exist
+func validateReportRefs(db *gorm.DB, r *models.Report) error {
+	if err := ValidateReport(r); err != nil {
+		return err
+	}
+
+	errs := FieldErrors{}
+	var count int64
+	if err := db.Model(&models.Neighborhood{}).Where("neighborhood_id = ?", r.NeighborhoodID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		errs["neighborhood_id"] = "neighborhood not found"
+	}
+	if err := db.Model(&models.Crime{}).Where("crime_id = ?", r.CrimeID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		errs["crime_id"] = "crime not found"
+	}
+	return errs.orNil()
+}
+
+// FindOrCreateNeighborhood matches neighborhoods by their numeric coordinates,
+// so "-22.9056" and "-22.90560" are the same neighborhood
 func (s *reportService) FindOrCreateNeighborhood(ctx context.Context, n *models.Neighborhood) (uint, error) {
+	db := s.db.WithContext(ctx)
 	var existingNeighborhood models.Neighborhood
-	result := s.db.Where("latitude = ? AND longitude = ?", n.Latitude, n.Longitude).First(&existingNeighborhood)
+	result := db.Where("latitude = ? AND longitude = ?", n.Latitude, n.Longitude).First(&existingNeighborhood)
 
 	if result.Error == nil {
-		// Bairro encontrado → SOMAR o peso
-		existingNeighborhood.NeighborhoodWeight += n.NeighborhoodWeight
-
-		// Atualizar no banco
-		if err := s.db.WithContext(ctx).
-			Model(&existingNeighborhood).
-			Update("neighborhood_weight", existingNeighborhood.NeighborhoodWeight).Error; err != nil {
-
+		// Bairro encontrado → SOMAR o peso no próprio UPDATE, para que envios
+		// simultâneos não sobrescrevam o incremento um do outro
+		if err := db.Model(&existingNeighborhood).
+			Update("neighborhood_weight", gorm.Expr("neighborhood_weight + ?", n.NeighborhoodWeight)).Error; err != nil {
 			return 0, err
 		}
 
@@ -64,8 +185,10 @@ func (s *reportService) FindOrCreateNeighborhood(ctx context.Context, n *models.
 		return 0, result.Error
 	}
 
-	// Bairro não encontrado → criar um novo
-	if err := s.db.WithContext(ctx).Create(n).Error; err != nil {
+	// Bairro não encontrado → criar um novo. Se outro envio criou o mesmo
+	// bairro antes, o índice único recusa com gorm.ErrDuplicatedKey e
+	// processReportRow refaz o report, que então o encontra
+	if err := db.Create(n).Error; err != nil {
 		return 0, err
 	}
 
@@ -73,9 +196,21 @@ func (s *reportService) FindOrCreateNeighborhood(ctx context.Context, n *models.
 }
 
 func (s *reportService) FindOrCreateCrime(ctx context.Context, c *models.Crime) (uint, error) {
-	// Check if a crime with this name already exists
+	taxonomy := s.taxonomy
+	if taxonomy == nil {
+		var err error
+		if taxonomy, err = loadCrimeTaxonomy(s.db.WithContext(ctx)); err != nil {
+			return 0, err
+		}
+	}
+
+	// Check if a crime with the same key already exists; crimes saved before
+	// crime_key and not merged yet are still matched by the exact name
+	key := taxonomy.Key(c.CrimeName)
 	var existingCrime models.Crime
-	result := s.db.Where("crime_name = ?", c.CrimeName).First(&existingCrime)
+	result := s.db.WithContext(ctx).
+		Where("crime_key = ? OR (crime_key IS NULL AND crime_name = ?)", key, c.CrimeName).
+		First(&existingCrime)
 
 	if result.Error == nil {
 		// Crime found, return the ID
@@ -87,10 +222,12 @@ func (s *reportService) FindOrCreateCrime(ctx context.Context, c *models.Crime)
 		return 0, result.Error
 	}
 
-	// Crime not found, create a new one
+	// Crime not found, create a new one weighted by the taxonomy; the
+	// request weight is only used for names outside of it
 	newCrime := models.Crime{
 		CrimeName:   c.CrimeName,
-		CrimeWeight: c.CrimeWeight,
+		CrimeWeight: taxonomy.Weight(c.CrimeName, c.CrimeWeight),
+		CrimeKey:    key,
 	}
 
 	if err := s.db.WithContext(ctx).Create(&newCrime).Error; err != nil {
@@ -100,7 +237,83 @@ func (s *reportService) FindOrCreateCrime(ctx context.Context, c *models.Crime)
 	return newCrime.CrimeID, nil
 }
 
+// ProcessReportText finds or creates the neighborhood and the crime of the
+// request and creates its report, all in one transaction. A duplicate key
+// from a concurrent request (the same new neighborhood or crime) redoes the
+// report like processReportRow, and a transaction that conflicts with a
+// concurrent one (a deadlock) is retried, each up to processReportAttempts
+// times. An invalid request fails with ValidateReportRequest's error.
 func (s *reportService) ProcessReportText(ctx context.Context, req *models.ReportRequest) (*models.Report, error) {
+	if err := ValidateReportRequest(req, s.area); err != nil {
+		return nil, err
+	}
+	var report *models.Report
+	err := retryOnConflict(ctx, processReportAttempts, func() error {
+		var err error
+		report, err = s.processReportRow(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// processReportRow runs processReportTx again, up to processReportAttempts
+// times, while it fails with gorm.ErrDuplicatedKey: another transaction
+// committed the same new neighborhood, crime or imported occurrence, which
+// the next attempt finds. Only the transaction (or savepoint) of this report
+// is redone.
+func (s *reportService) processReportRow(ctx context.Context, req *models.ReportRequest) (*models.Report, error) {
+	var report *models.Report
+	var err error
+	for attempt := 1; attempt <= processReportAttempts; attempt++ {
+		report, err = s.processReportTx(ctx, req)
+		if !errors.Is(err, gorm.ErrDuplicatedKey) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// processReportTx runs processReport in a transaction of s.db, which is a
+// savepoint when s.db is already in a transaction
+func (s *reportService) processReportTx(ctx context.Context, req *models.ReportRequest) (*models.Report, error) {
+	var report *models.Report
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		report, err = (&reportService{db: tx, loc: s.loc, area: s.area, taxonomy: s.taxonomy}).processReport(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// processReport runs the steps of ProcessReportText with s.db
+func (s *reportService) processReport(ctx context.Context, req *models.ReportRequest) (*models.Report, error) {
+	var sourceKey *string
+	var sourceSeq *int
+	if req.SourceKey != "" {
+		// The unique index on (source_key, source_seq) makes a concurrent
+		// import of the same occurrence fail with a duplicate key, redone by
+		// processReportRow until this check sees it
+		var count int64
+		err := s.db.WithContext(ctx).Unscoped().Model(&models.Report{}).
+			Where("source_key = ? AND source_seq = ?", req.SourceKey, req.SourceSeq).Count(&count).Error
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, ErrReportAlreadyImported
+		}
+		sourceKey, sourceSeq = &req.SourceKey, &req.SourceSeq
+	}
+
 	// Create or find neighborhood
 	neighborhood := &models.Neighborhood{
 		Name:               req.Name,
@@ -130,11 +343,146 @@ func (s *reportService) ProcessReportText(ctx context.Context, req *models.Repor
 		NeighborhoodID: neighborhoodID,
 		CrimeID:        crimeID,
 		ReportDate:     req.ReportDate,
+		OccurredAt:     s.occurredAt(req.ReportDate),
+		SourceKey:      sourceKey,
+		SourceSeq:      sourceSeq,
 	}
 
-	if err := s.CreateReport(ctx, report); err != nil {
+	// The date comes as typed in the chat, so it is not validated like
+	// CreateReport: a date that does not parse is kept without occurred_at
+	// and listed by BackfillOccurredAt
+	if err := s.db.WithContext(ctx).Create(report).Error; err != nil {
 		return nil, err
 	}
 
 	return report, nil
-}
\ No newline at end of file
+}
+
+// ProcessReportsBulk processes every request like ProcessReportText. Each
+// chunk of BulkReportChunkSize requests shares a transaction, and each request
+// runs in a savepoint of it: a failing row is rolled back and rejected without
+// affecting the other rows of the chunk, and a duplicate key only redoes that
+// savepoint (processReportRow). A deadlock or serialization failure aborts
+// the whole transaction instead, so the chunk is rolled back and retried up to
+// processReportAttempts times, with no lock held while waiting to retry; if
+// it keeps failing, every row of the chunk is rejected. The returned error is only set when the context is cancelled;
+// results of the chunks already committed are still returned.
+func (s *reportService) ProcessReportsBulk(ctx context.Context, reqs []models.ReportRequest) ([]BulkReportResult, error) {
+	results := make([]BulkReportResult, 0, len(reqs))
+
+	for start := 0; start < len(reqs); start += BulkReportChunkSize {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		end := min(start+BulkReportChunkSize, len(reqs))
+		var chunk []BulkReportResult
+		err := retryOnConflict(ctx, processReportAttempts, func() error {
+			var err error
+			chunk, err = s.processReportsChunk(ctx, reqs, start, end)
+			return err
+		})
+
+		if err != nil {
+			// The whole chunk was rolled back: every row of it is rejected
+			chunk = make([]BulkReportResult, 0, end-start)
+			for i := start; i < end; i++ {
+				chunk = append(chunk, BulkReportResult{Index: i, Status: BulkReportRejected, Error: err.Error()})
+			}
+		}
+		results = append(results, chunk...)
+	}
+
+	return results, nil
+}
+
+// processReportsChunk processes reqs[start:end] in one transaction and
+// returns one result per request. A conflict (isConflict) of any row is
+// returned, which rolls the transaction back, so the caller can retry the
+// chunk.
+func (s *reportService) processReportsChunk(ctx context.Context, reqs []models.ReportRequest, start, end int) ([]BulkReportResult, error) {
+	chunk := make([]BulkReportResult, 0, end-start)
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		taxonomy, err := loadCrimeTaxonomy(tx)
+		if err != nil {
+			return err
+		}
+		txSvc := &reportService{db: tx, loc: s.loc, area: s.area, taxonomy: taxonomy}
+
+		for i := start; i < end; i++ {
+			result := BulkReportResult{Index: i, Status: BulkReportRejected}
+
+			if err := ValidateReportRequest(&reqs[i], s.area); err != nil {
+				result.Error = err.Error()
+				chunk = append(chunk, result)
+				continue
+			}
+
+			report, err := txSvc.processReportRow(ctx, &reqs[i])
+			switch {
+			case errors.Is(err, ErrReportAlreadyImported):
+				result.Status = BulkReportSkipped
+				result.Error = err.Error()
+			case err != nil && isConflict(err):
+				return err
+			case err != nil:
+				result.Error = err.Error()
+			default:
+				result.Status = BulkReportAccepted
+				result.ReportID = report.ReportID
+			}
+			chunk = append(chunk, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chunk, nil
+}
+
+// processReportAttempts is how many times a report is redone after a
+// duplicate key, and how many times ProcessReportText, or a chunk of
+// ProcessReportsBulk, runs its transaction while it conflicts with
+// concurrent ones
+const processReportAttempts = 5
+
+// conflictMessages are the errors of a transaction that lost a race with a
+// concurrent one and was aborted: deadlocks (SQL Server and PostgreSQL),
+// serialization failures and a busy SQLite database
+var conflictMessages = []string{"deadlock", "could not serialize", "database is locked", "sqlite_busy"}
+
+// isConflict reports whether err is transient and may go away by retrying
+// the whole transaction. A duplicate key is not: it is retried by
+// processReportRow, without redoing the other rows of a chunk.
+func isConflict(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, conflict := range conflictMessages {
+		if strings.Contains(msg, conflict) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryOnConflict runs fn up to attempts times while it fails with a conflict,
+// waiting a growing, randomized delay between the attempts
+func retryOnConflict(ctx context.Context, attempts int, fn func() error) error {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = fn(); err == nil || !isConflict(err) {
+			return err
+		}
+		if attempt == attempts {
+			break
+		}
+		delay := time.Duration(attempt)*10*time.Millisecond + rand.N(10*time.Millisecond)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	return err
+}
@@ -0,0 +1,533 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// saoPaulo é o fuso das datas dos reports nos testes
+var saoPaulo, _ = LoadReportLocation(DefaultReportTimezone)
+
+// TestProcessReportsBulk envia mais de um chunk com linhas inválidas no meio
+func TestProcessReportsBulk(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	svc := NewReportService(gdb, saoPaulo, DefaultGridArea)
+
+	total := BulkReportChunkSize + 10
+	reqs := make([]models.ReportRequest, total)
+	for i := range reqs {
+		reqs[i] = models.ReportRequest{
+			Name:        fmt.Sprintf("Bairro %d", i%3),
+			Latitude:    models.Coordinate(-22.900 - float64(i%3)/1000),
+			Longitude:   -47.06,
+			CrimeName:   "Furto",
+			ReportDate:  "2024-01-10 10:00:00",
+			CrimeWeight: 3,
+		}
+	}
+	reqs[7].CrimeName = ""
+	reqs[9].Longitude = -46.5 // fora da área da grade
+	reqs[BulkReportChunkSize+1].Latitude = 0
+
+	results, err := svc.ProcessReportsBulk(context.Background(), reqs)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if len(results) != total {
+		t.Fatalf("esperava %d resultados, obteve: %d", total, len(results))
+	}
+
+	accepted := 0
+	for i, r := range results {
+		if r.Index != i {
+			t.Fatalf("esperava resultado %d na posição %d, obteve: %d", i, i, r.Index)
+		}
+		switch {
+		case i == 7 || i == BulkReportChunkSize+1:
+			if r.Status != BulkReportRejected || r.Error != ErrMissingReportFields.Error() {
+				t.Errorf("esperava linha %d rejeitada por campos faltando, obteve: %+v", i, r)
+			}
+		case i == 9:
+			if r.Status != BulkReportRejected || r.Error != "invalid fields: longitude" {
+				t.Errorf("esperava linha %d rejeitada fora da área, obteve: %+v", i, r)
+			}
+		case r.Status != BulkReportAccepted || r.ReportID == 0:
+			t.Errorf("esperava linha %d aceita, obteve: %+v", i, r)
+		default:
+			accepted++
+		}
+	}
+
+	var reports, neighborhoods, crimes int64
+	gdb.Model(&models.Report{}).Count(&reports)
+	gdb.Model(&models.Neighborhood{}).Count(&neighborhoods)
+	gdb.Model(&models.Crime{}).Count(&crimes)
+	if reports != int64(accepted) || neighborhoods != 3 || crimes != 1 {
+		t.Errorf("esperava %d reports, 3 bairros e 1 crime, obteve: %d, %d, %d", accepted, reports, neighborhoods, crimes)
+	}
+}
+
+// TestProcessReportsBulk_SourceKey reimporta as mesmas linhas: as já
+// importadas, inclusive as apagadas, são ignoradas
+func TestProcessReportsBulk_SourceKey(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	svc := NewReportService(gdb, saoPaulo, DefaultGridArea)
+
+	reqs := make([]models.ReportRequest, 3)
+	for i := range reqs {
+		reqs[i] = models.ReportRequest{
+			Name:       "Cambuí",
+			Latitude:   -22.8989,
+			Longitude:  -47.0523,
+			CrimeName:  "Furto",
+			ReportDate: "10/01/2025",
+			SourceKey:  "ssp:campinas:13 DP:2025-01:FURTO - OUTROS",
+			SourceSeq:  i,
+		}
+	}
+
+	first, err := svc.ProcessReportsBulk(context.Background(), reqs[:2])
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if err := svc.DeleteReport(context.Background(), first[0].ReportID); err != nil {
+		t.Fatalf("esperava sem erro ao apagar report, obteve: %v", err)
+	}
+
+	results, err := svc.ProcessReportsBulk(context.Background(), reqs)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	want := []string{BulkReportSkipped, BulkReportSkipped, BulkReportAccepted}
+	for i, r := range results {
+		if r.Status != want[i] {
+			t.Errorf("esperava linha %d com status %s, obteve: %+v", i, want[i], r)
+		}
+	}
+
+	var reports int64
+	gdb.Unscoped().Model(&models.Report{}).Count(&reports)
+	if reports != 3 {
+		t.Errorf("esperava 3 reports, obteve: %d", reports)
+	}
+}
+
+// TestReconcileImportedReports reimporta um mês com contagens diferentes: a
+// contagem menor apaga as ocorrências a mais, a maior restaura as apagadas e a
+// natureza que saiu da planilha é apagada inteira
+func TestReconcileImportedReports(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	svc := NewReportService(gdb, saoPaulo, DefaultGridArea)
+	ctx := context.Background()
+
+	const (
+		scope  = "ssp:campinas:13 DP:2025-01:"
+		furto  = scope + "FURTO - OUTROS"
+		roubo  = scope + "ROUBO - OUTROS"
+		pichac = scope + "PICHAÇÃO"
+		other  = "ssp:campinas:13 DP:2025-02:FURTO - OUTROS"
+	)
+	var reqs []models.ReportRequest
+	for key, n := range map[string]int{furto: 3, roubo: 2, pichac: 1, other: 1} {
+		for i := 0; i < n; i++ {
+			reqs = append(reqs, models.ReportRequest{
+				Name:       "Cambuí",
+				Latitude:   -22.8989,
+				Longitude:  -47.0523,
+				CrimeName:  "Furto",
+				ReportDate: "10/01/2025",
+				SourceKey:  key,
+				SourceSeq:  i,
+			})
+		}
+	}
+	results, err := svc.ProcessReportsBulk(ctx, reqs)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	for i, r := range results {
+		if reqs[i].SourceKey == roubo && reqs[i].SourceSeq == 1 {
+			if err := svc.DeleteReport(ctx, r.ReportID); err != nil {
+				t.Fatalf("esperava sem erro ao apagar report, obteve: %v", err)
+			}
+		}
+	}
+
+	got, err := svc.ReconcileImportedReports(ctx, []string{scope}, map[string]int{furto: 1, roubo: 2})
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if got.Restored != 1 || got.Removed != 3 {
+		t.Errorf("esperava 1 restaurado e 3 removidos, obteve: %+v", got)
+	}
+
+	live := make(map[string]int64)
+	for _, key := range []string{furto, roubo, pichac, other} {
+		var n int64
+		gdb.Model(&models.Report{}).Where("source_key = ?", key).Count(&n)
+		live[key] = n
+	}
+	want := map[string]int64{furto: 1, roubo: 2, pichac: 0, other: 1}
+	for key, n := range want {
+		if live[key] != n {
+			t.Errorf("esperava %d reports de %s, obteve: %d", n, key, live[key])
+		}
+	}
+}
+
+// TestProcessReportText_ConcurrentSerialized envia os mesmos bairros e
+// crimes de muitas goroutines ao mesmo tempo: não pode haver bairro ou crime
+// duplicado nem incremento de peso perdido. Com _txlock=immediate o SQLite
+// serializa as transações, então só o caminho sem conflito é coberto; as
+// novas tentativas são cobertas por TestProcessReportText_RetriesConflict.
+func TestProcessReportText_ConcurrentSerialized(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	svc := NewReportService(gdb, saoPaulo, DefaultGridArea)
+	// Mais threads que CPUs, para que as goroutines se intercalem mesmo em
+	// máquinas com um só núcleo
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+
+	const workers, perWorker = 50, 8
+	crimeNames := []string{"Furto", "FURTO", "Roubo - Outros", "roubo outros"}
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make(chan error, workers*perWorker)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			<-start
+			for i := 0; i < perWorker; i++ {
+				n := (w + i) % 3
+				_, err := svc.ProcessReportText(context.Background(), &models.ReportRequest{
+					Name:       fmt.Sprintf("Bairro %d", n),
+					Latitude:   models.Coordinate(-22.900 - float64(n)/1000),
+					Longitude:  -47.06,
+					CrimeName:  crimeNames[(w*perWorker+i)%len(crimeNames)],
+					ReportDate: "2024-01-10 10:00:00",
+				})
+				if err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("esperava sem erro, obteve: %v", err)
+	}
+
+	var neighborhoods []models.Neighborhood
+	gdb.Find(&neighborhoods)
+	if len(neighborhoods) != 3 {
+		t.Fatalf("esperava 3 bairros, obteve: %d", len(neighborhoods))
+	}
+	for _, n := range neighborhoods {
+		var reports int64
+		gdb.Model(&models.Report{}).Where("neighborhood_id = ?", n.NeighborhoodID).Count(&reports)
+		if int64(n.NeighborhoodWeight) != reports {
+			t.Errorf("%s: esperava peso %d (um por report), obteve: %d", n.Name, reports, n.NeighborhoodWeight)
+		}
+	}
+
+	var reports, crimes int64
+	gdb.Model(&models.Report{}).Count(&reports)
+	gdb.Model(&models.Crime{}).Count(&crimes)
+	if reports != workers*perWorker || crimes != 2 {
+		t.Errorf("esperava %d reports e 2 crimes, obteve: %d e %d", workers*perWorker, reports, crimes)
+	}
+}
+
+// failCreate faz o n-ésimo INSERT em table falhar com err, como uma
+// transação que perdeu a corrida para outra, e conta os INSERTs em table
+func failCreate(t *testing.T, gdb *gorm.DB, table string, n int, err error) *int {
+	t.Helper()
+	calls := 0
+	hook := func(db *gorm.DB) {
+		if db.Statement.Table != table {
+			return
+		}
+		calls++
+		if calls == n {
+			db.AddError(err)
+		}
+	}
+	if err := gdb.Callback().Create().Before("gorm:create").Register("test:fail_create", hook); err != nil {
+		t.Fatalf("falha ao registrar callback: %v", err)
+	}
+	return &calls
+}
+
+// TestProcessReportText_RetriesConflict força um conflito na primeira
+// tentativa: a transação é refeita sem duplicar o bairro criado antes do erro
+func TestProcessReportText_RetriesConflict(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	svc := NewReportService(gdb, saoPaulo, DefaultGridArea)
+	calls := failCreate(t, gdb, "crimes", 1, errors.New("database is locked (5) (SQLITE_BUSY)"))
+
+	report, err := svc.ProcessReportText(context.Background(), &models.ReportRequest{
+		Name: "Centro", Latitude: -22.9056, Longitude: -47.0608, CrimeName: "Furto", ReportDate: "2024-01-10",
+	})
+	if err != nil || report.ReportID == 0 {
+		t.Fatalf("esperava o report criado na segunda tentativa, obteve: %+v (%v)", report, err)
+	}
+	if *calls != 2 {
+		t.Errorf("esperava 2 tentativas de criar o crime, obteve: %d", *calls)
+	}
+
+	var neighborhoods, crimes, reports int64
+	gdb.Model(&models.Neighborhood{}).Count(&neighborhoods)
+	gdb.Model(&models.Crime{}).Count(&crimes)
+	gdb.Model(&models.Report{}).Count(&reports)
+	if neighborhoods != 1 || crimes != 1 || reports != 1 {
+		t.Errorf("esperava 1 bairro, 1 crime e 1 report, obteve: %d, %d, %d", neighborhoods, crimes, reports)
+	}
+}
+
+// TestProcessReportText_OutsideArea envia coordenadas fora da área da
+// grade: o report é recusado sem criar o bairro
+func TestProcessReportText_OutsideArea(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	svc := NewReportService(gdb, saoPaulo, DefaultGridArea)
+
+	_, err := svc.ProcessReportText(context.Background(), &models.ReportRequest{
+		Name: "Sé", Latitude: -23.5505, Longitude: -46.6333, CrimeName: "Furto", ReportDate: "2024-01-10",
+	})
+	var fields FieldErrors
+	if !errors.As(err, &fields) || fields["latitude"] == "" || fields["longitude"] == "" {
+		t.Errorf("esperava erros em latitude e longitude, obteve: %v", err)
+	}
+	var neighborhoods int64
+	gdb.Model(&models.Neighborhood{}).Count(&neighborhoods)
+	if neighborhoods != 0 {
+		t.Errorf("esperava nenhum bairro, obteve: %d", neighborhoods)
+	}
+}
+
+// TestProcessReportsBulk_RetriesRow força uma chave duplicada na segunda
+// linha: só o savepoint dela é refeito, sem refazer a primeira linha
+func TestProcessReportsBulk_RetriesRow(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	svc := NewReportService(gdb, saoPaulo, DefaultGridArea)
+
+	reqs := []models.ReportRequest{
+		{Name: "Centro", Latitude: -22.9056, Longitude: -47.0608, CrimeName: "Furto", ReportDate: "2024-01-10"},
+		{Name: "Cambuí", Latitude: -22.8989, Longitude: -47.0523, CrimeName: "Roubo", ReportDate: "2024-01-11"},
+	}
+	// O primeiro INSERT em reports é da linha 0; a chave duplicada vai para a linha 1
+	calls := failCreate(t, gdb, "reports", 2, fmt.Errorf("criar report: %w", gorm.ErrDuplicatedKey))
+
+	results, err := svc.ProcessReportsBulk(context.Background(), reqs)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	for i, r := range results {
+		if r.Status != BulkReportAccepted {
+			t.Errorf("esperava linha %d aceita, obteve: %+v", i, r)
+		}
+	}
+	// 1 INSERT da linha 0 e 2 da linha 1
+	if *calls != 3 {
+		t.Errorf("esperava só a linha 1 refeita, obteve: %d INSERTs", *calls)
+	}
+
+	var neighborhoods, crimes, reports int64
+	gdb.Model(&models.Neighborhood{}).Count(&neighborhoods)
+	gdb.Model(&models.Crime{}).Count(&crimes)
+	gdb.Model(&models.Report{}).Count(&reports)
+	if neighborhoods != 2 || crimes != 2 || reports != 2 {
+		t.Errorf("esperava 2 bairros, 2 crimes e 2 reports, obteve: %d, %d, %d", neighborhoods, crimes, reports)
+	}
+}
+
+// TestProcessReportsBulk_RetriesChunk força um conflito na segunda linha: o
+// chunk inteiro é desfeito e refeito, sem duplicar o que a primeira linha
+// gravou na tentativa anterior
+func TestProcessReportsBulk_RetriesChunk(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	svc := NewReportService(gdb, saoPaulo, DefaultGridArea)
+
+	reqs := []models.ReportRequest{
+		{Name: "Centro", Latitude: -22.9056, Longitude: -47.0608, CrimeName: "Furto", ReportDate: "2024-01-10"},
+		{Name: "Cambuí", Latitude: -22.8989, Longitude: -47.0523, CrimeName: "Roubo", ReportDate: "2024-01-11"},
+	}
+	// O primeiro INSERT em reports é da linha 0; o conflito vai para a linha 1
+	calls := failCreate(t, gdb, "reports", 2, errors.New("database is locked (5) (SQLITE_BUSY)"))
+
+	results, err := svc.ProcessReportsBulk(context.Background(), reqs)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	for i, r := range results {
+		if r.Status != BulkReportAccepted {
+			t.Errorf("esperava linha %d aceita, obteve: %+v", i, r)
+		}
+	}
+	// 2 INSERTs na tentativa desfeita e 2 na que foi gravada
+	if *calls != 4 {
+		t.Errorf("esperava o chunk refeito desde a primeira linha, obteve: %d INSERTs", *calls)
+	}
+
+	var neighborhoods, crimes, reports int64
+	gdb.Model(&models.Neighborhood{}).Count(&neighborhoods)
+	gdb.Model(&models.Crime{}).Count(&crimes)
+	gdb.Model(&models.Report{}).Count(&reports)
+	if neighborhoods != 2 || crimes != 2 || reports != 2 {
+		t.Errorf("esperava 2 bairros, 2 crimes e 2 reports, obteve: %d, %d, %d", neighborhoods, crimes, reports)
+	}
+}
+
+func TestListReports(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	ctx := context.Background()
+	svc := NewReportService(gdb, saoPaulo, DefaultGridArea)
+
+	// Centro e Cambuí ficam a ~1km; Sousas a ~11km do Centro
+	reqs := []models.ReportRequest{
+		{Name: "Centro", Latitude: -22.9056, Longitude: -47.0608, CrimeName: "Furto", CrimeWeight: 3, ReportDate: "05/01/2024"},
+		{Name: "Centro", Latitude: -22.9056, Longitude: -47.0608, CrimeName: "Roubo", CrimeWeight: 5, ReportDate: "2024-02-10 08:00:00"},
+		{Name: "Cambuí", Latitude: -22.8989, Longitude: -47.0523, CrimeName: "Latrocínio", CrimeWeight: 9, ReportDate: "2024-03-01"},
+		{Name: "Sousas", Latitude: -22.8856, Longitude: -46.9567, CrimeName: "Furto", CrimeWeight: 3, ReportDate: "20/03/2024"},
+	}
+	if _, err := svc.ProcessReportsBulk(ctx, reqs); err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+
+	page, err := svc.ListReports(ctx, ReportQuery{})
+	if err != nil || page.Total != 4 {
+		t.Fatalf("esperava 4 reports, obteve: %+v (%v)", page, err)
+	}
+	if page.Items[0].ReportDate != "20/03/2024" || page.Items[0].Neighborhood.Name != "Sousas" || page.Items[0].Crime.CrimeName != "Furto" {
+		t.Errorf("esperava o report mais recente primeiro com bairro e crime, obteve: %+v", page.Items[0])
+	}
+
+	from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	page, _ = svc.ListReports(ctx, ReportQuery{DateFrom: &from, DateTo: &to})
+	if page.Total != 2 {
+		t.Errorf("esperava 2 reports entre fevereiro e 1º de março, obteve: %d", page.Total)
+	}
+
+	page, _ = svc.ListReports(ctx, ReportQuery{CrimeName: "furto", NeighborhoodName: "sou"})
+	if page.Total != 1 || page.Items[0].Neighborhood.Name != "Sousas" {
+		t.Errorf("esperava o furto de Sousas, obteve: %+v", page.Items)
+	}
+
+	near := &GeoRadius{Lat: -22.9056, Lon: -47.0608, RadiusMeters: 2000}
+	minWeight := 5
+	page, _ = svc.ListReports(ctx, ReportQuery{Near: near, MinWeight: &minWeight, Sort: "date"})
+	if page.Total != 2 || page.Items[0].Crime.CrimeName != "Roubo" || page.Items[1].Crime.CrimeName != "Latrocínio" {
+		t.Errorf("esperava roubo e latrocínio perto do Centro em ordem de data, obteve: %+v", page.Items)
+	}
+
+	// O Cambuí (~1,1km) fica dentro da caixa do raio de 1km, mas fora do raio:
+	// o total e o offset contam só os reports dentro do raio
+	near = &GeoRadius{Lat: -22.9056, Lon: -47.0608, RadiusMeters: 1000}
+	if box := near.BoundingBox(); !box.Contains(-22.8989, -47.0523) {
+		t.Errorf("esperava o Cambuí dentro da caixa do raio, obteve: %+v", box)
+	}
+	page, _ = svc.ListReports(ctx, ReportQuery{Near: near, Sort: "id", Limit: 1, Offset: 1})
+	if page.Total != 2 || len(page.Items) != 1 || page.Items[0].Crime.CrimeName != "Roubo" {
+		t.Errorf("esperava o segundo dos 2 reports do Centro, obteve: %d %+v", page.Total, page.Items)
+	}
+
+	bbox := &BoundingBox{MinLat: -23, MinLon: -47, MaxLat: -22.8, MaxLon: -46.9}
+	page, _ = svc.ListReports(ctx, ReportQuery{BBox: bbox, Limit: 1})
+	if page.Total != 1 || len(page.Items) != 1 {
+		t.Errorf("esperava apenas Sousas dentro do bbox, obteve: %+v", page)
+	}
+
+	report, err := svc.GetReportByID(ctx, page.Items[0].ReportID)
+	if err != nil || report.Neighborhood.Name != "Sousas" {
+		t.Errorf("esperava o report de Sousas pelo ID, obteve: %+v (%v)", report, err)
+	}
+	if _, err := svc.GetReportByID(ctx, 9999); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("esperava ErrRecordNotFound, obteve: %v", err)
+	}
+}
+
+// TestListReports_NearDeepOffset pede uma página funda de um raio com
+// reports de fora do raio (Cambuí) intercalados: a página vem direto do
+// offset, numa única leitura dos reports
+func TestListReports_NearDeepOffset(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	ctx := context.Background()
+	svc := NewReportService(gdb, saoPaulo, DefaultGridArea)
+
+	reqs := make([]models.ReportRequest, 1200)
+	for i := range reqs {
+		reqs[i] = models.ReportRequest{Name: "Centro", Latitude: -22.9056, Longitude: -47.0608, CrimeName: "Furto", ReportDate: "05/01/2024"}
+		if i%2 == 1 {
+			reqs[i].Name, reqs[i].Latitude, reqs[i].Longitude = "Cambuí", -22.8989, -47.0523
+		}
+	}
+	results, err := svc.ProcessReportsBulk(ctx, reqs)
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+
+	reads := 0
+	gdb.Callback().Query().After("gorm:query").Register("test:count_reads", func(tx *gorm.DB) {
+		if tx.Statement.Table == "reports" {
+			reads++
+		}
+	})
+
+	near := &GeoRadius{Lat: -22.9056, Lon: -47.0608, RadiusMeters: 1000}
+	page, err := svc.ListReports(ctx, ReportQuery{Near: near, Sort: "id", Offset: 550, Limit: 10})
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if page.Total != 600 || len(page.Items) != 10 {
+		t.Fatalf("esperava 10 dos 600 reports do Centro, obteve: %d %d", page.Total, len(page.Items))
+	}
+	for i, report := range page.Items {
+		if want := results[2*(550+i)].ReportID; report.ReportID != want {
+			t.Errorf("esperava o report %d na posição %d, obteve: %d", want, i, report.ReportID)
+		}
+	}
+	if reads != 1 {
+		t.Errorf("esperava uma única leitura dos reports, obteve: %d", reads)
+	}
+}
+
+func TestRetryOnConflict(t *testing.T) {
+	calls := 0
+	err := retryOnConflict(context.Background(), 3, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("Transaction (Process ID 52) was deadlocked on lock resources")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("esperava sucesso na terceira tentativa, obteve: %v após %d", err, calls)
+	}
+
+	calls = 0
+	err = retryOnConflict(context.Background(), 3, func() error {
+		calls++
+		return gorm.ErrRecordNotFound
+	})
+	if !errors.Is(err, gorm.ErrRecordNotFound) || calls != 1 {
+		t.Errorf("esperava parar no primeiro erro que não é conflito, obteve: %v após %d", err, calls)
+	}
+
+	if !isConflict(errors.New("database is locked (5) (SQLITE_BUSY)")) || !isConflict(errors.New("Transaction (Process ID 52) was deadlocked on lock resources")) {
+		t.Error("esperava SQLITE_BUSY e deadlock como conflitos")
+	}
+	if isConflict(fmt.Errorf("criar bairro: %w", gorm.ErrDuplicatedKey)) {
+		t.Error("esperava chave duplicada fora dos conflitos, refeita só por processReportRow")
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+// importReconcileBatch is the number of reports restored or deleted per
+// statement by ReconcileImportedReports
+const importReconcileBatch = 500
+
+// ImportReconcileResult is the outcome of ReconcileImportedReports
+type ImportReconcileResult struct {
+	// Restored is the number of deleted reports brought back because the
+	// count of their source key went up again
+	Restored int `json:"restored"`
+	// Removed is the number of reports soft deleted because the count of
+	// their source key went down, or the key left the scope
+	Removed int `json:"removed"`
+}
+
+// importedReportRow is a report read by ReconcileImportedReports
+type importedReportRow struct {
+	ReportID  uint
+	SourceKey string
+	SourceSeq int
+	DeletedAt gorm.DeletedAt
+}
+
+// ReconcileImportedReports runs after an import: for every source key under
+// the scopes (prefixes of source_key), the reports with source_seq below its
+// count in counts are kept, or restored if deleted, and the others are soft
+// deleted, keys missing from counts included. Both move updated_at, so an
+// incremental run of the knowledge base picks the changes up.
+func (s *reportService) ReconcileImportedReports(ctx context.Context, scopes []string, counts map[string]int) (*ImportReconcileResult, error) {
+	result := &ImportReconcileResult{}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var restore, remove []uint
+		for _, scope := range scopes {
+			var rows []importedReportRow
+			err := tx.Unscoped().Model(&models.Report{}).
+				Select("report_id", "source_key", "source_seq", "deleted_at").
+				Where(`source_key LIKE ? ESCAPE '\'`, likeEscaper.Replace(scope)+"%").
+				Scan(&rows).Error
+			if err != nil {
+				return err
+			}
+			for _, row := range rows {
+				keep := row.SourceSeq < counts[row.SourceKey]
+				switch {
+				case keep && row.DeletedAt.Valid:
+					restore = append(restore, row.ReportID)
+				case !keep && !row.DeletedAt.Valid:
+					remove = append(remove, row.ReportID)
+				}
+			}
+		}
+
+		now := time.Now()
+		for _, batch := range []struct {
+			ids       []uint
+			deletedAt any
+		}{{restore, nil}, {remove, now}} {
+			for start := 0; start < len(batch.ids); start += importReconcileBatch {
+				ids := batch.ids[start:min(start+importReconcileBatch, len(batch.ids))]
+				err := tx.Unscoped().Model(&models.Report{}).Where("report_id IN ?", ids).
+					Updates(map[string]any{"deleted_at": batch.deletedAt, "updated_at": now}).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+		result.Restored, result.Removed = len(restore), len(remove)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
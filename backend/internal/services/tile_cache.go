@@ -0,0 +1,84 @@
+package services
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// tileCache is an in-memory LRU of encoded tiles. Entries expire after ttl,
+// so tiles pick up a new knowledge base run or training without an explicit
+// invalidation.
+type tileCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List // most recently used first
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+type tileCacheEntry struct {
+	key       string
+	data      []byte
+	expiresAt time.Time
+}
+
+func newTileCache(size int, ttl time.Duration) *tileCache {
+	return &tileCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// get returns a cached tile that has not expired
+func (c *tileCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*tileCacheEntry)
+	if c.now().After(entry.expiresAt) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return entry.data, true
+}
+
+// put stores a tile, evicting the least recently used ones above size
+func (c *tileCache) put(key string, data []byte) {
+	if c.size <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*tileCacheEntry)
+		entry.data, entry.expiresAt = data, expiresAt
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&tileCacheEntry{key: key, data: data, expiresAt: expiresAt})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*tileCacheEntry).key)
+	}
+}
+
+// len returns the number of cached tiles, expired ones included
+func (c *tileCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/maptile"
+	"gorm.io/gorm"
+)
+
+// Tile layers served by TileService
+const (
+	TileLayerIncidents   = "incidents"
+	TileLayerCells       = "cells"
+	TileLayerPredictions = "predictions"
+)
+
+const (
+	// MaxTileZoom is the deepest zoom level served
+	MaxTileZoom = 20
+	// DefaultTileCacheSize is how many encoded tiles are kept in memory
+	DefaultTileCacheSize = 4096
+	// DefaultTileCacheTTL is how long a cached tile is served
+	DefaultTileCacheTTL = 10 * time.Minute
+
+	// tileBins is the number of bins per tile side used to aggregate incidents
+	tileBins = 256
+)
+
+var (
+	// ErrUnknownTileLayer is returned for a layer other than incidents, cells
+	// or predictions
+	ErrUnknownTileLayer = errors.New("unknown tile layer")
+	// ErrInvalidTile is returned for tile coordinates outside the zoom level
+	ErrInvalidTile = errors.New("invalid tile coordinates")
+	// ErrMissingPeriod is returned when the predictions layer has no year and month
+	ErrMissingPeriod = errors.New("year and month are required")
+)
+
+// TileQuery holds the filters of a vector tile
+type TileQuery struct {
+	// CellResolution selects the grid of the cells and predictions layers
+	CellResolution int
+	// From, To and Category filter the incidents layer
+	From     *time.Time
+	To       *time.Time
+	Category string
+	// Year and Month select the month of the predictions layer and, when
+	// informed, of the cells layer (Month 0 sums the whole year; Year 0 sums
+	// every month)
+	Year  int
+	Month int
+}
+
+// cacheKey identifies a tile and its filters in the cache
+func (q TileQuery) cacheKey(layer string, tile maptile.Tile) string {
+	key := fmt.Sprintf("%s/%d/%d/%d?res=%d&y=%d&m=%d&cat=%s", layer, tile.Z, tile.X, tile.Y,
+		q.CellResolution, q.Year, q.Month, q.Category)
+	if q.From != nil {
+		key += "&from=" + q.From.Format(time.RFC3339)
+	}
+	if q.To != nil {
+		key += "&to=" + q.To.Format(time.RFC3339)
+	}
+	return key
+}
+
+// TileService renders the knowledge base and the predictions as Mapbox
+// Vector Tiles (one layer per tile, named after it)
+type TileService interface {
+	// Tile returns the encoded (uncompressed) tile z/x/y of a layer:
+	//   - incidents: points aggregated in a 256x256 grid per tile, with count
+	//     and max_severity, ready for a heatmap weighted by count;
+	//   - cells: cell polygons with the incidents of features_cell_monthly;
+	//   - predictions: cell polygons with risk_level and predicted_count.
+	Tile(ctx context.Context, layer string, z, x, y uint32, q TileQuery) ([]byte, error)
+}
+
+// tileService is the concrete implementation of TileService
+type tileService struct {
+	db     *gorm.DB
+	layers *layerService
+	cache  *tileCache
+	area   BoundingBox
+	// tables is shared with layers, so each table is looked up until found
+	// instead of once per tile
+	tables *tableCache
+}
+
+// NewTileService creates a new instance of TileService for the grid area,
+// with an LRU cache of cacheSize tiles kept for ttl (cacheSize 0 disables the
+// cache). Tiles outside the area are empty.
+func NewTileService(db *gorm.DB, predictions PredictionService, area BoundingBox, cacheSize int, ttl time.Duration) TileService {
+	tables := &tableCache{}
+	return &tileService{
+		db:     db,
+		layers: &layerService{db: db, predictions: predictions, tables: tables},
+		tables: tables,
+		cache:  newTileCache(cacheSize, ttl),
+		area:   area,
+	}
+}
+
+// Tile renders a tile or serves it from the cache
+func (s *tileService) Tile(ctx context.Context, layer string, z, x, y uint32, q TileQuery) ([]byte, error) {
+	if layer != TileLayerIncidents && layer != TileLayerCells && layer != TileLayerPredictions {
+		return nil, ErrUnknownTileLayer
+	}
+	tile := maptile.New(x, y, maptile.Zoom(z))
+	if z > MaxTileZoom || !tile.Valid() {
+		return nil, ErrInvalidTile
+	}
+	if layer == TileLayerPredictions && (q.Year == 0 || q.Month == 0) {
+		return nil, ErrMissingPeriod
+	}
+
+	key := q.cacheKey(layer, tile)
+	if data, ok := s.cache.get(key); ok {
+		return data, nil
+	}
+
+	fc := geojson.NewFeatureCollection()
+	bound := tile.Bound()
+	area := orb.Bound{Min: orb.Point{s.area.MinLon, s.area.MinLat}, Max: orb.Point{s.area.MaxLon, s.area.MaxLat}}
+	if bound.Intersects(area) {
+		var err error
+		switch layer {
+		case TileLayerIncidents:
+			fc, err = s.incidentBins(ctx, tile, q)
+		case TileLayerCells:
+			fc, err = s.cellCounts(ctx, bound, q)
+		case TileLayerPredictions:
+			fc, err = s.predictionCells(ctx, bound, q)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	layers := mvt.Layers{mvt.NewLayer(layer, fc)}
+	layers.ProjectToTile(tile)
+	layers.Clip(mvt.MapboxGLDefaultExtentBound)
+	data, err := mvt.Marshal(layers)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.put(key, data)
+	return data, nil
+}
+
+// incidentBins aggregates the incidents of the tile in tileBins x tileBins
+// bins; each bin becomes a point at the mean position of its incidents
+func (s *tileService) incidentBins(ctx context.Context, tile maptile.Tile, q TileQuery) (*geojson.FeatureCollection, error) {
+	bound := tile.Bound()
+	db := s.layers.incidentsQuery(ctx, LayerQuery{From: q.From, To: q.To, Category: q.Category}).
+		Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?",
+			bound.Min.Lat(), bound.Max.Lat(), bound.Min.Lon(), bound.Max.Lon())
+	rows, err := db.Select("latitude, longitude, severity").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type bin struct {
+		lat, lon    float64
+		count       int
+		maxSeverity int
+	}
+	bins := make(map[int]*bin)
+	var order []int
+	for rows.Next() {
+		var lat, lon float64
+		var severity int
+		if err := rows.Scan(&lat, &lon, &severity); err != nil {
+			return nil, err
+		}
+		f := maptile.Fraction(orb.Point{lon, lat}, tile.Z)
+		bx := clampBin(int(math.Floor((f.X() - float64(tile.X)) * tileBins)))
+		by := clampBin(int(math.Floor((f.Y() - float64(tile.Y)) * tileBins)))
+		key := by*tileBins + bx
+
+		b, ok := bins[key]
+		if !ok {
+			b = &bin{}
+			bins[key] = b
+			order = append(order, key)
+		}
+		b.lat += lat
+		b.lon += lon
+		b.count++
+		b.maxSeverity = max(b.maxSeverity, severity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	fc := geojson.NewFeatureCollection()
+	for _, key := range order {
+		b := bins[key]
+		f := geojson.NewFeature(orb.Point{b.lon / float64(b.count), b.lat / float64(b.count)})
+		f.Properties = geojson.Properties{"count": b.count, "max_severity": b.maxSeverity}
+		fc.Append(f)
+	}
+	return fc, nil
+}
+
+func clampBin(b int) int {
+	return min(max(b, 0), tileBins-1)
+}
+
+// cellCounts returns the cells of the tile with incidents in
+// features_cell_monthly for the period. Only the cells centered around the
+// tile are read and summed.
+func (s *tileService) cellCounts(ctx context.Context, bound orb.Bound, q TileQuery) (*geojson.FeatureCollection, error) {
+	fc := geojson.NewFeatureCollection()
+	db := s.db.WithContext(ctx)
+	if !s.tables.has(db, "features_cell_monthly") {
+		return fc, nil
+	}
+	grid, err := newCellGrid(q.CellResolution, s.area)
+	if err != nil {
+		return nil, err
+	}
+	box := tileCellBox(bound, grid)
+
+	query := db.Table("features_cell_monthly").
+		Select("cell_id, SUM(y_count_month) AS total").
+		Where("cell_id IN (?)", cellsInBox(db, q.CellResolution, *box)).
+		Group("cell_id")
+	if q.Year > 0 {
+		query = query.Where("year = ?", q.Year)
+	}
+	if q.Month > 0 {
+		query = query.Where("month = ?", q.Month)
+	}
+	var counts []struct {
+		CellID string
+		Total  int
+	}
+	if err := query.Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	if len(counts) == 0 {
+		return fc, nil
+	}
+	totals := make(map[string]int, len(counts))
+	for _, c := range counts {
+		totals[c.CellID] = c.Total
+	}
+
+	cells, err := loadCells(ctx, db, s.tables, q.CellResolution, box)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range cells {
+		total := totals[c.CellID]
+		if total == 0 {
+			continue
+		}
+		f := geojson.NewFeature(cellGeometry(c))
+		f.Properties = geojson.Properties{"cell_id": c.CellID, "neighborhood": c.Neighborhood, "count": total}
+		fc.Append(f)
+	}
+	return fc, nil
+}
+
+// predictionCells returns the cell predictions of the month that touch the
+// tile, reading only the predictions of the cells centered around it
+func (s *tileService) predictionCells(ctx context.Context, bound orb.Bound, q TileQuery) (*geojson.FeatureCollection, error) {
+	db := s.db.WithContext(ctx)
+	grid, err := newCellGrid(q.CellResolution, s.area)
+	if err != nil {
+		return nil, err
+	}
+	box := tileCellBox(bound, grid)
+
+	var predictions []models.PredictCrime
+	err = db.Where("year = ? AND month = ? AND cell_resolution = ?", q.Year, q.Month, q.CellResolution).
+		Where("cell_id IN (?)", cellsInBox(db, q.CellResolution, *box)).
+		Order("predicted_count DESC").
+		Find(&predictions).Error
+	if err != nil {
+		return nil, err
+	}
+	fc := geojson.NewFeatureCollection()
+	if len(predictions) == 0 {
+		return fc, nil
+	}
+
+	cells, err := loadCells(ctx, db, s.tables, q.CellResolution, box)
+	if err != nil {
+		return nil, err
+	}
+	geometries := make(map[string]orb.Geometry, len(cells))
+	for _, c := range cells {
+		geometries[c.CellID] = cellGeometry(c)
+	}
+	for _, f := range predictionFeatures(predictions, geometries, 0).Features {
+		if f.Geometry.Bound().Intersects(bound) {
+			fc.Append(f)
+		}
+	}
+	return fc, nil
+}
+
+// tileCellBox expands the tile by the grid margin, so cells whose center falls just
+// outside it but whose polygon overlaps it are included
+func tileCellBox(bound orb.Bound, grid cellGrid) *BoundingBox {
+	margin := grid.margin()
+	return &BoundingBox{
+		MinLat: bound.Min.Lat() - margin, MaxLat: bound.Max.Lat() + margin,
+		MinLon: bound.Min.Lon() - margin, MaxLon: bound.Max.Lon() + margin,
+	}
+}
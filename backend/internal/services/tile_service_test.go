@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/maptile"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+)
+
+func decodeTile(t *testing.T, data []byte, name string) *mvt.Layer {
+	t.Helper()
+	layers, err := mvt.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("falha ao decodificar tile: %v", err)
+	}
+	if len(layers) != 1 || layers[0].Name != name {
+		t.Fatalf("esperava só a camada %s, obteve: %v", name, layers)
+	}
+	return layers[0]
+}
+
+func TestTileService(t *testing.T) {
+	gdb, sqlDB := setupSQLiteDB(t)
+	ctx := context.Background()
+	svc := NewTileService(gdb, NewPredictionService(gdb), DefaultGridArea, 16, time.Minute)
+
+	grid := newSpatialGrid(1000, DefaultGridArea)
+	lat, lon := grid.center(grid.minCol+10, grid.minRow+10)
+	center := orb.Point{lon, lat}
+	cellID, _ := grid.locate(lat, lon)
+	bounds := polygonJSON(grid.bounds(grid.minCol+10, grid.minRow+10))
+	if err := gdb.Create(&models.CuratedCell{
+		CellID: cellID, CellResolution: 1000, CenterLat: center.Lat(), CenterLng: center.Lon(), BoundsJSON: &bounds,
+	}).Error; err != nil {
+		t.Fatalf("falha ao inserir célula: %v", err)
+	}
+
+	// Dois incidentes no mesmo ponto caem no mesmo bin; o terceiro fica longe
+	resolution := 1000
+	incidents := []struct {
+		id       string
+		severity int
+		lat, lon float64
+	}{
+		{"rpt_1", 2, center.Lat(), center.Lon()},
+		{"rpt_2", 5, center.Lat(), center.Lon()},
+		{"rpt_3", 3, center.Lat() + 0.003, center.Lon() + 0.003},
+	}
+	for _, inc := range incidents {
+		if err := gdb.Create(&models.CuratedIncident{
+			ID: inc.id, OccurredAt: time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+			Category: "Patrimonial", Severity: inc.severity, Latitude: inc.lat, Longitude: inc.lon,
+			Neighborhood: "Centro", Confidence: 0.8, CellID: &cellID, CellResolution: &resolution,
+		}).Error; err != nil {
+			t.Fatalf("falha ao inserir incidente: %v", err)
+		}
+	}
+
+	tile := maptile.At(center, 14)
+	data, err := svc.Tile(ctx, TileLayerIncidents, uint32(tile.Z), tile.X, tile.Y, TileQuery{CellResolution: 1000})
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	layer := decodeTile(t, data, TileLayerIncidents)
+	if len(layer.Features) != 2 {
+		t.Fatalf("esperava 2 bins, obteve: %d", len(layer.Features))
+	}
+	if got := layer.Features[0].Properties["count"]; got != float64(2) {
+		t.Errorf("esperava 2 incidentes no primeiro bin, obteve: %v", got)
+	}
+	if got := layer.Features[0].Properties["max_severity"]; got != float64(5) {
+		t.Errorf("esperava severidade máxima 5, obteve: %v", got)
+	}
+
+	// Mesma consulta vem do cache, mesmo depois de apagar os incidentes
+	if err := gdb.Where("1 = 1").Delete(&models.CuratedIncident{}).Error; err != nil {
+		t.Fatalf("falha ao apagar incidentes: %v", err)
+	}
+	cached, _ := svc.Tile(ctx, TileLayerIncidents, uint32(tile.Z), tile.X, tile.Y, TileQuery{CellResolution: 1000})
+	if string(cached) != string(data) {
+		t.Errorf("esperava o tile do cache")
+	}
+
+	// Células: contagem do mês em features_cell_monthly
+	for _, stmt := range (sqliteDialect{}).MonthlyFeaturesTableStatements() {
+		if _, err := sqlDB.Exec(stmt); err != nil {
+			t.Fatalf("falha ao criar features_cell_monthly: %v", err)
+		}
+	}
+	if _, err := sqlDB.Exec(`INSERT INTO features_cell_monthly (cell_id, year, month, y_count_month)
+		VALUES (?, 2024, 3, 4), (?, 2024, 4, 1)`, cellID, cellID); err != nil {
+		t.Fatalf("falha ao inserir features: %v", err)
+	}
+	data, err = svc.Tile(ctx, TileLayerCells, uint32(tile.Z), tile.X, tile.Y, TileQuery{CellResolution: 1000, Year: 2024, Month: 3})
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	layer = decodeTile(t, data, TileLayerCells)
+	if len(layer.Features) != 1 || layer.Features[0].Properties["count"] != float64(4) {
+		t.Fatalf("esperava a célula com 4 incidentes em março, obteve: %v", layer.Features)
+	}
+	if _, ok := layer.Features[0].Geometry.(orb.Polygon); !ok {
+		t.Errorf("esperava o polígono da célula, obteve: %T", layer.Features[0].Geometry)
+	}
+
+	// Previsões exigem ano e mês
+	if _, err := svc.Tile(ctx, TileLayerPredictions, uint32(tile.Z), tile.X, tile.Y, TileQuery{CellResolution: 1000}); !errors.Is(err, ErrMissingPeriod) {
+		t.Errorf("esperava ErrMissingPeriod, obteve: %v", err)
+	}
+	if err := gdb.Create(&models.PredictCrime{
+		Neighborhood: "Centro", Risk_Level: 2, Year: 2024, Month: 4, CellResolution: &resolution, CellID: &cellID, PredictedCount: 1.5,
+	}).Error; err != nil {
+		t.Fatalf("falha ao inserir previsão: %v", err)
+	}
+	data, err = svc.Tile(ctx, TileLayerPredictions, uint32(tile.Z), tile.X, tile.Y, TileQuery{CellResolution: 1000, Year: 2024, Month: 4})
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if layer = decodeTile(t, data, TileLayerPredictions); len(layer.Features) != 1 {
+		t.Errorf("esperava 1 previsão no tile, obteve: %d", len(layer.Features))
+	}
+
+	// Fora de Campinas o tile é vazio
+	far := maptile.At(orb.Point{-43.2, -22.9}, 14)
+	data, err = svc.Tile(ctx, TileLayerCells, uint32(far.Z), far.X, far.Y, TileQuery{CellResolution: 1000})
+	if err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if layer = decodeTile(t, data, TileLayerCells); len(layer.Features) != 0 {
+		t.Errorf("esperava tile vazio fora de Campinas, obteve: %d", len(layer.Features))
+	}
+
+	if _, err := svc.Tile(ctx, "bairros", 14, 0, 0, TileQuery{}); !errors.Is(err, ErrUnknownTileLayer) {
+		t.Errorf("esperava ErrUnknownTileLayer, obteve: %v", err)
+	}
+	if _, err := svc.Tile(ctx, TileLayerCells, 2, 4, 0, TileQuery{}); !errors.Is(err, ErrInvalidTile) {
+		t.Errorf("esperava ErrInvalidTile, obteve: %v", err)
+	}
+}
+
+func TestTileCache(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	cache := newTileCache(2, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.put("a", []byte("a"))
+	cache.put("b", []byte("b"))
+	cache.get("a")
+	cache.put("c", []byte("c"))
+	if _, ok := cache.get("b"); ok {
+		t.Errorf("esperava que b, o menos usado, fosse removido")
+	}
+	if _, ok := cache.get("a"); !ok || cache.len() != 2 {
+		t.Errorf("esperava a e c no cache, obteve: %d tiles", cache.len())
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := cache.get("a"); ok {
+		t.Errorf("esperava que a expirasse")
+	}
+
+	disabled := newTileCache(0, time.Minute)
+	disabled.put("a", []byte("a"))
+	if disabled.len() != 0 {
+		t.Errorf("esperava cache desligado com tamanho 0")
+	}
+}
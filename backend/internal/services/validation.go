@@ -0,0 +1,180 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Valid range of crime_weight (heinous crimes weigh 9)
+const (
+	MinCrimeWeight = 1
+	MaxCrimeWeight = 10
+)
+
+// maxCrimeCategoryName is the size of the category and subcategory columns
+const maxCrimeCategoryName = 100
+
+// ReportDateLayouts are the accepted formats of report_date: dd/mm/yyyy, as
+// imported from the SSP spreadsheets, and ISO dates, both with an optional
+// time, and RFC 3339. Fractional seconds are accepted after the seconds.
+var ReportDateLayouts = []string{
+	"02/01/2006", "02/01/2006 15:04", "02/01/2006 15:04:05",
+	"2006-01-02", "2006-01-02 15:04", "2006-01-02 15:04:05", "2006-01-02T15:04:05",
+	time.RFC3339,
+}
+
+// ErrInUse is returned when deleting a neighborhood or crime that still has reports
+var ErrInUse = errors.New("resource still has reports")
+
+// FieldErrors maps each invalid JSON field to the reason it was rejected
+type FieldErrors map[string]string
+
+func (e FieldErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return "invalid fields: " + strings.Join(fields, ", ")
+}
+
+// orNil returns nil when there are no field errors, so the result can be
+// returned directly as an error
+func (e FieldErrors) orNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// ValidateNeighborhood checks the name and that the coordinates are inside
+// the area of the grid (GRID_BBOX, or DefaultGridArea)
+func ValidateNeighborhood(n *models.Neighborhood, area BoundingBox) error {
+	errs := FieldErrors{}
+	if strings.TrimSpace(n.Name) == "" {
+		errs["name"] = "is required"
+	}
+	validateArea(errs, n.Latitude, n.Longitude, area)
+	if n.NeighborhoodWeight < 0 {
+		errs["neighborhood_weight"] = "must not be negative"
+	}
+	return errs.orNil()
+}
+
+// ValidateCrime checks the name and the weight range
+func ValidateCrime(c *models.Crime) error {
+	errs := FieldErrors{}
+	if strings.TrimSpace(c.CrimeName) == "" {
+		errs["crime_name"] = "is required"
+	}
+	if c.CrimeWeight < MinCrimeWeight || c.CrimeWeight > MaxCrimeWeight {
+		errs["crime_weight"] = fmt.Sprintf("must be between %d and %d", MinCrimeWeight, MaxCrimeWeight)
+	}
+	return errs.orNil()
+}
+
+// ValidateCrimeCategory checks the names, the weight range and that no
+// synonym is blank
+func ValidateCrimeCategory(c *models.CrimeCategory) error {
+	errs := FieldErrors{}
+	fields := []struct{ name, value string }{{"category", c.Category}, {"subcategory", c.Subcategory}}
+	for _, f := range fields {
+		switch {
+		case strings.TrimSpace(f.value) == "":
+			errs[f.name] = "is required"
+		case len([]rune(f.value)) > maxCrimeCategoryName:
+			errs[f.name] = fmt.Sprintf("must have at most %d characters", maxCrimeCategoryName)
+		}
+	}
+	if c.DefaultWeight < MinCrimeWeight || c.DefaultWeight > MaxCrimeWeight {
+		errs["default_weight"] = fmt.Sprintf("must be between %d and %d", MinCrimeWeight, MaxCrimeWeight)
+	}
+	for _, synonym := range c.Synonyms {
+		if models.NormalizeCrimeName(synonym) == "" {
+			errs["synonyms"] = "must not have blank synonyms"
+			break
+		}
+	}
+	return errs.orNil()
+}
+
+// ValidateReport checks the references and the format of report_date. It does
+// not check that the neighborhood and crime exist.
+func ValidateReport(r *models.Report) error {
+	errs := FieldErrors{}
+	if r.NeighborhoodID == 0 {
+		errs["neighborhood_id"] = "is required"
+	}
+	if r.CrimeID == 0 {
+		errs["crime_id"] = "is required"
+	}
+	if _, err := ParseReportDate(r.ReportDate, time.UTC); err != nil {
+		errs["report_date"] = "must be dd/mm/yyyy or yyyy-mm-dd, with an optional hh:mm[:ss], or RFC 3339"
+	}
+	return errs.orNil()
+}
+
+// ParseReportDate parses report_date in any of ReportDateLayouts. Dates and
+// times without an offset are read in loc, dates without a time at midnight;
+// RFC 3339 times keep their instant and are returned in loc.
+func ParseReportDate(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range ReportDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t.In(loc), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid report_date: %q", value)
+}
+
+// validateArea adds to errs the latitude and longitude outside the area
+func validateArea(errs FieldErrors, lat, lon models.Coordinate, area BoundingBox) {
+	if msg := validateCoordinate(lat, area.MinLat, area.MaxLat); msg != "" {
+		errs["latitude"] = msg
+	}
+	if msg := validateCoordinate(lon, area.MinLon, area.MaxLon); msg != "" {
+		errs["longitude"] = msg
+	}
+}
+
+// validateCoordinate returns why a coordinate is invalid, or ""
+func validateCoordinate(value models.Coordinate, minValue, maxValue float64) string {
+	if v := value.Float64(); v < minValue || v > maxValue {
+		return fmt.Sprintf("must be between %g and %g (grid area)", minValue, maxValue)
+	}
+	return ""
+}
+
+// updateColumns saves the given columns of a record (and updated_at), failing
+// with gorm.ErrRecordNotFound when it does not exist or was deleted
+func updateColumns(db *gorm.DB, record any, columns ...string) error {
+	result := db.Model(record).Select(columns).Updates(record)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// softDelete sets deleted_at and updated_at of a record, so incremental
+// knowledge base runs see the deletion
+func softDelete(db *gorm.DB, model any, idColumn string, id uint) error {
+	now := time.Now()
+	result := db.Model(model).Where(idColumn+" = ?", id).
+		Updates(map[string]any{"deleted_at": now, "updated_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
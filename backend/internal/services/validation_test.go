@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AloysioLvy/TccRadarCampinas/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestValidation_FieldErrors(t *testing.T) {
+	err := ValidateNeighborhood(&models.Neighborhood{Name: " ", Latitude: -23.5}, DefaultGridArea)
+	var fields FieldErrors
+	if !errors.As(err, &fields) || len(fields) != 3 || fields["name"] == "" || fields["latitude"] == "" || fields["longitude"] == "" {
+		t.Errorf("esperava erros em name, latitude e longitude, obteve: %v", err)
+	}
+	centro := &models.Neighborhood{Name: "Centro", Latitude: -22.9056, Longitude: -47.0608}
+	if err := ValidateNeighborhood(centro, DefaultGridArea); err != nil {
+		t.Errorf("esperava bairro válido, obteve: %v", err)
+	}
+	// A área configurada (GRID_BBOX) substitui a de Campinas
+	saoPauloArea := BoundingBox{MinLat: -23.8, MinLon: -46.9, MaxLat: -23.3, MaxLon: -46.3}
+	if err := ValidateNeighborhood(centro, saoPauloArea); !errors.As(err, &fields) || fields["latitude"] == "" || fields["longitude"] == "" {
+		t.Errorf("esperava o Centro de Campinas fora da área de São Paulo, obteve: %v", err)
+	}
+
+	req := &models.ReportRequest{Name: "Centro", Latitude: -22.9056, Longitude: -47.0608, CrimeName: "Furto"}
+	if err := ValidateReportRequest(req, DefaultGridArea); err != nil {
+		t.Errorf("esperava request válido, obteve: %v", err)
+	}
+	if err := ValidateReportRequest(req, saoPauloArea); !errors.As(err, &fields) || len(fields) != 2 {
+		t.Errorf("esperava erros em latitude e longitude, obteve: %v", err)
+	}
+	if err := ValidateReportRequest(&models.ReportRequest{Name: "Centro"}, DefaultGridArea); !errors.Is(err, ErrMissingReportFields) {
+		t.Errorf("esperava ErrMissingReportFields, obteve: %v", err)
+	}
+
+	if err := ValidateCrime(&models.Crime{CrimeName: "Furto", CrimeWeight: 11}); !errors.As(err, &fields) || fields["crime_weight"] == "" {
+		t.Errorf("esperava erro em crime_weight, obteve: %v", err)
+	}
+
+	for date, valid := range map[string]bool{
+		"05/01/2024": true, "2024-01-05": true, "2024-01-05 10:00:00": true, "2024-01-05T10:00:00-03:00": true,
+		"ontem": false, "2024-13-01": false, "": false,
+	} {
+		err := ValidateReport(&models.Report{NeighborhoodID: 1, CrimeID: 1, ReportDate: date})
+		if (err == nil) != valid {
+			t.Errorf("report_date %q: esperava válido=%v, obteve: %v", date, valid, err)
+		}
+	}
+}
+
+func TestParseReportDate(t *testing.T) {
+	for value, want := range map[string]time.Time{
+		"05/01/2024":                time.Date(2024, 1, 5, 0, 0, 0, 0, saoPaulo),
+		"05/01/2024 14:30":          time.Date(2024, 1, 5, 14, 30, 0, 0, saoPaulo),
+		" 2024-01-05 14:30:15 ":     time.Date(2024, 1, 5, 14, 30, 15, 0, saoPaulo),
+		"2024-01-05T14:30:15":       time.Date(2024, 1, 5, 14, 30, 15, 0, saoPaulo),
+		"2024-01-05 14:30:15.250":   time.Date(2024, 1, 5, 14, 30, 15, 250e6, saoPaulo),
+		"2024-01-05T17:30:00Z":      time.Date(2024, 1, 5, 14, 30, 0, 0, saoPaulo),
+		"2024-01-05T14:30:00-03:00": time.Date(2024, 1, 5, 14, 30, 0, 0, saoPaulo),
+	} {
+		got, err := ParseReportDate(value, saoPaulo)
+		if err != nil || !got.Equal(want) || got.Location() != saoPaulo {
+			t.Errorf("%q: esperava %s, obteve: %s (%v)", value, want, got, err)
+		}
+	}
+
+	// Meia-noite em São Paulo são 3h em UTC
+	got, _ := ParseReportDate("2024-01-05", saoPaulo)
+	if got.UTC() != time.Date(2024, 1, 5, 3, 0, 0, 0, time.UTC) {
+		t.Errorf("esperava 2024-01-05 03:00 UTC, obteve: %s", got.UTC())
+	}
+
+	for _, value := range []string{"ontem", "31/02/2024", "2024-01-05 25:00", "5 de janeiro"} {
+		if _, err := ParseReportDate(value, saoPaulo); err == nil {
+			t.Errorf("%q: esperava erro", value)
+		}
+	}
+}
+
+func TestCoordinate_JSON(t *testing.T) {
+	var req models.ReportRequest
+	if err := json.Unmarshal([]byte(`{"latitude": " -22,90560", "longitude": -47.0608}`), &req); err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if req.Latitude != -22.9056 || req.Longitude != -47.0608 {
+		t.Errorf("esperava as coordenadas como número, obteve: %v, %v", req.Latitude, req.Longitude)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	err := json.Unmarshal([]byte(`{"latitude": "abc"}`), &req)
+	if !errors.As(err, &typeErr) {
+		t.Errorf("esperava erro de tipo na latitude, obteve: %v", err)
+	}
+
+	out, _ := json.Marshal(models.Neighborhood{Latitude: -22.9056})
+	var decoded map[string]any
+	json.Unmarshal(out, &decoded)
+	if decoded["latitude"] != -22.9056 {
+		t.Errorf("esperava latitude como número no JSON, obteve: %s", out)
+	}
+}
+
+func TestCRUD_SoftDelete(t *testing.T) {
+	gdb, _ := setupSQLiteDB(t)
+	ctx := context.Background()
+	neighborhoods := NewNeighborhoodService(gdb, DefaultGridArea)
+	crimes := NewCrimeService(gdb)
+	reports := NewReportService(gdb, saoPaulo, DefaultGridArea)
+
+	centro := &models.Neighborhood{Name: "Centro", Latitude: -22.9056, Longitude: -47.0608, NeighborhoodWeight: 1}
+	furto := &models.Crime{CrimeName: "Furto", CrimeWeight: 3}
+	if err := neighborhoods.CreateNeighborhood(ctx, centro); err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+	if err := crimes.CreateCrime(ctx, furto); err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+
+	var fields FieldErrors
+	err := reports.CreateReport(ctx, &models.Report{NeighborhoodID: centro.NeighborhoodID, CrimeID: 999, ReportDate: "2024-01-05"})
+	if !errors.As(err, &fields) || fields["crime_id"] == "" {
+		t.Errorf("esperava erro em crime_id inexistente, obteve: %v", err)
+	}
+
+	report := &models.Report{NeighborhoodID: centro.NeighborhoodID, CrimeID: furto.CrimeID, ReportDate: "2024-01-05"}
+	if err := reports.CreateReport(ctx, report); err != nil {
+		t.Fatalf("esperava sem erro, obteve: %v", err)
+	}
+
+	report.ReportDate = "15/02/2024"
+	if err := reports.UpdateReport(ctx, report); err != nil {
+		t.Fatalf("esperava sem erro ao atualizar report, obteve: %v", err)
+	}
+	furto.CrimeWeight = 4
+	if err := crimes.UpdateCrime(ctx, furto); err != nil {
+		t.Fatalf("esperava sem erro ao atualizar crime, obteve: %v", err)
+	}
+	if err := crimes.UpdateCrime(ctx, &models.Crime{CrimeID: 999, CrimeName: "Roubo", CrimeWeight: 5}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("esperava ErrRecordNotFound ao atualizar crime inexistente, obteve: %v", err)
+	}
+
+	// Bairro e crime com reports não podem ser apagados
+	if err := neighborhoods.DeleteNeighborhood(ctx, centro.NeighborhoodID); !errors.Is(err, ErrInUse) {
+		t.Errorf("esperava ErrInUse ao apagar bairro com reports, obteve: %v", err)
+	}
+	if err := crimes.DeleteCrime(ctx, furto.CrimeID); !errors.Is(err, ErrInUse) {
+		t.Errorf("esperava ErrInUse ao apagar crime com reports, obteve: %v", err)
+	}
+
+	if err := reports.DeleteReport(ctx, report.ReportID); err != nil {
+		t.Fatalf("esperava sem erro ao apagar report, obteve: %v", err)
+	}
+	if err := reports.DeleteReport(ctx, report.ReportID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("esperava ErrRecordNotFound ao apagar de novo, obteve: %v", err)
+	}
+	page, _ := reports.ListReports(ctx, ReportQuery{})
+	if page.Total != 0 {
+		t.Errorf("esperava o report apagado fora da listagem, obteve: %d", page.Total)
+	}
+
+	if err := neighborhoods.DeleteNeighborhood(ctx, centro.NeighborhoodID); err != nil {
+		t.Errorf("esperava sem erro ao apagar bairro sem reports, obteve: %v", err)
+	}
+	if _, err := neighborhoods.GetNeighborhoodByID(ctx, centro.NeighborhoodID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("esperava bairro apagado, obteve: %v", err)
+	}
+
+	// O registro continua no banco com deleted_at preenchido
+	var stored int64
+	gdb.Unscoped().Model(&models.Report{}).Where("deleted_at IS NOT NULL").Count(&stored)
+	if stored != 1 {
+		t.Errorf("esperava o report mantido com deleted_at, obteve: %d", stored)
+	}
+}
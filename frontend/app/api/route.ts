@@ -1,6 +1,7 @@
 import { NextResponse } from "next/server";
 import axios from "axios";
 import { checkRateLimit, rateLimit } from "./rateLimting";
+import { pesoCrime } from "../../utils/pesoCrime";
 
 
 const SYSTEM_PROMPT = `
@@ -32,36 +33,6 @@ Pergunte: "Está correto? (responda sim ou não)"
 
 Se o usuário responder "não", reinicie o processo de coleta.
 `;
-const HeinousCrimes = [
-      "latrocínio",
-      "homicídio qualificado",
-      "homicídio praticado por grupo de extermínio",
-      "feminicídio",
-      "genocídio",
-      "estupro",
-      "estupro de vulnerável",
-      "atentado violento ao pudor",
-      "favorecimento à prostituição",
-      "exploração sexual",
-      "tráfico de pessoas",
-      "tráfico de drogas",
-      "organização criminosa",
-      "comércio ilegal de armas",
-      "extorsão qualificada",
-      "sequestro e cárcere privado",
-      "extorsão mediante seqüestro",
-      "envenenamento de alimentos",
-      "epidemia com resultado morte",
-      "falsificação de medicamentos",
-      "tráfico internacional de armas",
-      'Sequestro e extorsão qualificada'
-];
-function calculateWeightCrime(typeOfCrime:string):number {
-  const crimeNormalized = typeOfCrime.trim().toLowerCase();
-  const isHeinous = HeinousCrimes.some(crime => crime.toLowerCase().includes(crimeNormalized));
-  return isHeinous ? 9 : 3;
-}
-
 async function geocodeAddress(address: string) {
   const correctAdderss = address.trim();
   try { 
@@ -142,7 +113,7 @@ export async function POST(req: Request) {
     }
 
     if (finalData && finalData.localizacao != null) {
-      const crime_weight = calculateWeightCrime(finalData.tipo_de_crime);
+      const crime_weight = await pesoCrime(finalData.tipo_de_crime);
       const locationInfo = await geocodeAddress(finalData.localizacao);
       
 
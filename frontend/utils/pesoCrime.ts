@@ -1,28 +1,20 @@
-const crimesHediondos = [
-    "tortura",
-    "tráfico de drogas",
-    "terrorismo",
-    "homicídio em atividade típica de grupo de extermínio",
-    "homicídio qualificado",
-    "latrocínio",
-    "extorsão qualificada pela morte",
-    "extorsão mediante sequestro (inclusive na forma qualificada)",
-    "estupro",
-    "atentado violento ao pudor",
-    "epidemia com resultado morte",
-    "genocídio",
-    "falsificação de produto terapêutico ou medicinal",
-    "corrupção de produto terapêutico ou medicinal",
-    "alteração de produto terapêutico ou medicinal"
-  ];
-  
-export function pesoCrime(lista: any, tipoCrime: string) {
-    var cont= 0;
-    while(true){
-        if(crimesHediondos[cont] == tipoCrime){
-            return 9
-        }
-        cont ++
-    }
+import axios from "axios";
+
+// Peso de crimes fora da taxonomia, o mesmo DefaultCrimeWeight do backend
+const PESO_PADRAO = 3;
+
+// O peso vem da taxonomia de crimes do backend (crime_categories), a mesma
+// usada na ingestão e na Knowledge Base, em vez de uma lista própria do frontend
+export async function pesoCrime(tipoCrime: string): Promise<number> {
+  try {
+    const { data } = await axios.get(
+      `${process.env.NEXT_PUBLIC_MACHINE_LEARNING_ROUTE_URL}/crime-categories/classify`,
+      { params: { name: tipoCrime } }
+    );
+    return data.weight;
+  } catch (error) {
+    // Sem a API o backend ainda aplica a taxonomia ao gravar um crime novo
+    console.error("Error classifying crime:", error);
+    return PESO_PADRAO;
   }
-  
\ No newline at end of file
+}